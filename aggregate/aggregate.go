@@ -0,0 +1,194 @@
+// Package aggregate computes portfolio totals and group means of API results
+// with an explicit rounding policy, so reconciled totals are identical
+// across runs, machines and input orders.
+//
+// Every value is first rounded to the policy's precision (centavos with
+// banker's rounding by default) and then summed as an integer number of
+// units, so floating-point summation order never changes a total:
+//
+//	valorVenal := func(r iptuapi.ConsultaSQLResult) float64 { return r.ValorVenalTotal }
+//	bairro := func(r iptuapi.ConsultaSQLResult) string { return r.Bairro }
+//
+//	carteira := aggregate.Portfolio(results, valorVenal, aggregate.Centavos)
+//	porBairro := aggregate.Totals(results, bairro, valorVenal, aggregate.Centavos)
+//	media := aggregate.WeightedMean(porBairro, aggregate.Centavos)
+package aggregate
+
+import (
+	"math"
+	"math/big"
+	"sort"
+	"strconv"
+)
+
+// Rounding selects how a value is rounded to the policy's precision.
+type Rounding int
+
+const (
+	// HalfEven rounds ties to the even neighbor (banker's rounding), so
+	// rounding errors do not accumulate in one direction.
+	HalfEven Rounding = iota
+	// HalfUp rounds ties away from zero.
+	HalfUp
+	// Truncate drops the digits beyond the precision.
+	Truncate
+)
+
+// Policy is the precision and rounding applied to every value and result.
+type Policy struct {
+	// Decimals is the number of decimal places kept, from 0 to 6.
+	Decimals int
+	Rounding Rounding
+}
+
+// Centavos rounds to two decimal places with banker's rounding.
+var Centavos = Policy{Decimals: 2, Rounding: HalfEven}
+
+// Group is the aggregate of a set of properties.
+type Group struct {
+	Key string `json:"key,omitempty"`
+	// Count is the number of properties with a finite value.
+	Count int     `json:"count"`
+	Total float64 `json:"total"`
+	// Mean is Total / Count under the policy, or zero if Count is zero.
+	Mean float64 `json:"mean"`
+	// Skipped counts properties whose value was NaN or infinite.
+	Skipped int `json:"skipped,omitempty"`
+
+	units int64
+}
+
+// Round rounds v to the policy's precision. Rounding works on the shortest
+// decimal representation of v, so 2.675 rounds as the decimal 2.675 rather
+// than as its binary approximation 2.67499….
+func (p Policy) Round(v float64) float64 {
+	if math.IsNaN(v) || math.IsInf(v, 0) {
+		return v
+	}
+	return p.value(p.units(v))
+}
+
+func (p Policy) scale() int64 {
+	d := p.Decimals
+	if d < 0 {
+		d = 0
+	}
+	if d > 6 {
+		d = 6
+	}
+	s := int64(1)
+	for i := 0; i < d; i++ {
+		s *= 10
+	}
+	return s
+}
+
+// units returns v as an integer number of policy units.
+func (p Policy) units(v float64) int64 {
+	r, ok := new(big.Rat).SetString(strconv.FormatFloat(v, 'g', -1, 64))
+	if !ok {
+		return 0
+	}
+	r.Mul(r, new(big.Rat).SetInt64(p.scale()))
+	return p.div(r.Num(), r.Denom())
+}
+
+// div returns num / den rounded to an integer under the policy; den is
+// positive.
+func (p Policy) div(num, den *big.Int) int64 {
+	q, m := new(big.Int).QuoRem(num, den, new(big.Int))
+	if m.Sign() == 0 || p.Rounding == Truncate {
+		return q.Int64()
+	}
+	// Compare twice the remainder's magnitude with the divisor.
+	cmp := new(big.Int).Abs(m)
+	cmp.Lsh(cmp, 1)
+	step := int64(num.Sign())
+	switch c := cmp.Cmp(den); {
+	case c > 0:
+		q.Add(q, big.NewInt(step))
+	case c == 0 && (p.Rounding == HalfUp || q.Bit(0) == 1):
+		q.Add(q, big.NewInt(step))
+	}
+	return q.Int64()
+}
+
+func (p Policy) value(units int64) float64 {
+	return float64(units) / float64(p.scale())
+}
+
+// add accumulates one value into g.
+func (p Policy) add(g *Group, v float64) {
+	if math.IsNaN(v) || math.IsInf(v, 0) {
+		g.Skipped++
+		return
+	}
+	g.Count++
+	g.units += p.units(v)
+}
+
+// finish sets Total and Mean from the accumulated units.
+func (p Policy) finish(g *Group) {
+	g.Total = p.value(g.units)
+	if g.Count > 0 {
+		g.Mean = p.value(p.div(big.NewInt(g.units), big.NewInt(int64(g.Count))))
+	}
+}
+
+// Sum returns the total of values, each rounded to the policy's precision.
+// NaN and infinite values are ignored.
+func (p Policy) Sum(values ...float64) float64 {
+	var g Group
+	for _, v := range values {
+		p.add(&g, v)
+	}
+	p.finish(&g)
+	return g.Total
+}
+
+// Portfolio aggregates value over all items.
+func Portfolio[T any](items []T, value func(T) float64, p Policy) Group {
+	var g Group
+	for _, item := range items {
+		p.add(&g, value(item))
+	}
+	p.finish(&g)
+	return g
+}
+
+// Totals aggregates value over items grouped by key, e.g. per bairro. Groups
+// are sorted by key.
+func Totals[T any](items []T, key func(T) string, value func(T) float64, p Policy) []Group {
+	byKey := make(map[string]*Group)
+	for _, item := range items {
+		k := key(item)
+		g, ok := byKey[k]
+		if !ok {
+			g = &Group{Key: k}
+			byKey[k] = g
+		}
+		p.add(g, value(item))
+	}
+	groups := make([]Group, 0, len(byKey))
+	for _, g := range byKey {
+		p.finish(g)
+		groups = append(groups, *g)
+	}
+	sort.Slice(groups, func(i, j int) bool { return groups[i].Key < groups[j].Key })
+	return groups
+}
+
+// WeightedMean returns the mean of the groups' means weighted by their
+// property counts, which equals the mean over all their properties; a plain
+// mean of neighborhood means would overweight small neighborhoods.
+func WeightedMean(groups []Group, p Policy) float64 {
+	var units, count int64
+	for _, g := range groups {
+		units += p.units(g.Total)
+		count += int64(g.Count)
+	}
+	if count == 0 {
+		return 0
+	}
+	return p.value(p.div(big.NewInt(units), big.NewInt(count)))
+}
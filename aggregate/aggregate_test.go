@@ -0,0 +1,84 @@
+package aggregate
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRound(t *testing.T) {
+	tests := []struct {
+		policy Policy
+		in     float64
+		want   float64
+	}{
+		{Centavos, 2.675, 2.68},
+		{Centavos, 2.665, 2.66},
+		{Centavos, 0.125, 0.12},
+		{Centavos, 0.135, 0.14},
+		{Centavos, -0.125, -0.12},
+		{Centavos, 1.005, 1.0},
+		{Centavos, 10, 10},
+		{Policy{Decimals: 2, Rounding: HalfUp}, 0.125, 0.13},
+		{Policy{Decimals: 2, Rounding: HalfUp}, -0.125, -0.13},
+		{Policy{Decimals: 2, Rounding: Truncate}, 0.129, 0.12},
+		{Policy{Decimals: 0}, 2.5, 2},
+		{Policy{Decimals: 0}, 3.5, 4},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, tt.policy.Round(tt.in), "%v %v", tt.policy, tt.in)
+	}
+	assert.True(t, math.IsNaN(Centavos.Round(math.NaN())))
+}
+
+func TestSumIsOrderIndependent(t *testing.T) {
+	values := make([]float64, 1000)
+	for i := range values {
+		values[i] = float64(i)*0.1 + 0.005
+	}
+	want := Centavos.Sum(values...)
+	rng := rand.New(rand.NewSource(1))
+	for i := 0; i < 10; i++ {
+		rng.Shuffle(len(values), func(a, b int) { values[a], values[b] = values[b], values[a] })
+		assert.Equal(t, want, Centavos.Sum(values...))
+	}
+}
+
+type imovel struct {
+	bairro string
+	valor  float64
+}
+
+func TestTotalsAndWeightedMean(t *testing.T) {
+	items := []imovel{
+		{"Pinheiros", 100.005},
+		{"Pinheiros", 200},
+		{"Pinheiros", 300},
+		{"Moema", 1000},
+		{"Moema", math.NaN()},
+	}
+	valor := func(i imovel) float64 { return i.valor }
+	bairro := func(i imovel) string { return i.bairro }
+
+	groups := Totals(items, bairro, valor, Centavos)
+	assert.Len(t, groups, 2)
+	assert.Equal(t, "Moema", groups[0].Key, "groups are sorted by key")
+	assert.Equal(t, 1, groups[0].Count)
+	assert.Equal(t, 1, groups[0].Skipped)
+	assert.Equal(t, 1000.0, groups[0].Mean)
+	assert.Equal(t, 600.0, groups[1].Total, "100.005 rounds to the even centavo")
+	assert.Equal(t, 200.0, groups[1].Mean)
+
+	assert.Equal(t, 400.0, WeightedMean(groups, Centavos), "weighted by property count, not the mean of means (600)")
+
+	all := Portfolio(items, valor, Centavos)
+	assert.Equal(t, 4, all.Count)
+	assert.Equal(t, 1600.0, all.Total)
+	assert.Equal(t, 400.0, all.Mean)
+}
+
+func TestWeightedMeanEmpty(t *testing.T) {
+	assert.Zero(t, WeightedMean(nil, Centavos))
+}
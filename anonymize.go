@@ -0,0 +1,211 @@
+package iptuapi
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// AnonymizeAction is what an Anonymizer does with one field.
+type AnonymizeAction int
+
+const (
+	// AnonKeep leaves the field as is.
+	AnonKeep AnonymizeAction = iota
+	// AnonDrop clears the field.
+	AnonDrop
+	// AnonHash replaces a text field with a keyed hash, so records can
+	// still be joined or deduplicated without revealing the value.
+	AnonHash
+	// AnonGeneralize coarsens the field: numero becomes a range of 100
+	// ("1000-1099"), cep its 5-digit prefix, sql its sector, and numbers
+	// are rounded to two significant digits. Other text is cleared.
+	AnonGeneralize
+)
+
+// Anonymizer strips direct identifiers from results before they are shared,
+// e.g. with analytics teams under LGPD data minimization. Fields are named
+// by their JSON name and the rules apply at every depth, so the sql of
+// nested comparables is treated like the top-level one. Fields not listed
+// are kept.
+type Anonymizer struct {
+	Fields map[string]AnonymizeAction
+	// Key keys AnonHash. Keep it secret and stable: hashes are only
+	// comparable across exports made with the same key. AnonHash clears
+	// the field when Key is empty.
+	Key []byte
+}
+
+// NewAnonymizer returns an Anonymizer with the default rules: sql is
+// hashed, numero and cep are generalized and complemento is dropped, while
+// bairro, areas and values are kept. Adjust Fields to change them.
+func NewAnonymizer(key []byte) *Anonymizer {
+	return &Anonymizer{
+		Key: key,
+		Fields: map[string]AnonymizeAction{
+			"sql":         AnonHash,
+			"numero":      AnonGeneralize,
+			"complemento": AnonDrop,
+			"cep":         AnonGeneralize,
+		},
+	}
+}
+
+// Apply anonymizes v in place. v must be a pointer to a result, a slice of
+// results or a map decoded from JSON.
+func (a *Anonymizer) Apply(v interface{}) {
+	a.walk(reflect.ValueOf(v), "")
+}
+
+// PostProcessor returns a PostProcessor applying a to every response, for
+// clients dedicated to exports.
+func (a *Anonymizer) PostProcessor() PostProcessor {
+	return func(_ string, v interface{}) error {
+		a.Apply(v)
+		return nil
+	}
+}
+
+func (a *Anonymizer) walk(v reflect.Value, name string) {
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if !v.IsNil() {
+			a.walk(v.Elem(), name)
+		}
+	case reflect.Struct:
+		t := v.Type()
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			if !f.IsExported() {
+				continue
+			}
+			if f.Anonymous {
+				a.walk(v.Field(i), "")
+				continue
+			}
+			a.field(v.Field(i), jsonName(f))
+		}
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			a.walk(v.Index(i), name)
+		}
+	case reflect.Map:
+		if v.Type().Key().Kind() != reflect.String {
+			return
+		}
+		for _, k := range v.MapKeys() {
+			// Map values are not addressable; anonymize a copy and store it.
+			elem := reflect.New(v.Type().Elem()).Elem()
+			elem.Set(v.MapIndex(k))
+			a.field(elem, k.String())
+			if elem.IsZero() && a.Fields[k.String()] == AnonDrop {
+				v.SetMapIndex(k, reflect.Value{})
+				continue
+			}
+			v.SetMapIndex(k, elem)
+		}
+	}
+}
+
+// field applies the rule for name to v, or walks into it if there is none.
+func (a *Anonymizer) field(v reflect.Value, name string) {
+	action := a.Fields[name]
+	if action == AnonKeep {
+		a.walk(v, name)
+		return
+	}
+	if v.Kind() == reflect.Interface && !v.IsNil() {
+		// Values decoded into interface{} hold a string or float64.
+		inner := reflect.New(v.Elem().Type()).Elem()
+		inner.Set(v.Elem())
+		a.transform(inner, name, action)
+		if inner.IsZero() {
+			v.Set(reflect.Zero(v.Type()))
+		} else {
+			v.Set(inner)
+		}
+		return
+	}
+	a.transform(v, name, action)
+}
+
+func (a *Anonymizer) transform(v reflect.Value, name string, action AnonymizeAction) {
+	if !v.CanSet() {
+		return
+	}
+	switch {
+	case action == AnonDrop:
+		v.Set(reflect.Zero(v.Type()))
+	case v.Kind() == reflect.String && action == AnonHash:
+		v.SetString(a.hash(v.String()))
+	case v.Kind() == reflect.String && action == AnonGeneralize:
+		v.SetString(generalizeText(name, v.String()))
+	case v.CanFloat() && action == AnonGeneralize:
+		v.SetFloat(roundSignificant(v.Float(), 2))
+	case v.CanInt() && action == AnonGeneralize:
+		v.SetInt(int64(roundSignificant(float64(v.Int()), 2)))
+	default:
+		v.Set(reflect.Zero(v.Type()))
+	}
+}
+
+func (a *Anonymizer) hash(s string) string {
+	if s == "" || len(a.Key) == 0 {
+		return ""
+	}
+	mac := hmac.New(sha256.New, a.Key)
+	mac.Write([]byte(s))
+	return hex.EncodeToString(mac.Sum(nil))[:16]
+}
+
+func generalizeText(name, s string) string {
+	switch name {
+	case "numero":
+		digits := s
+		if i := strings.IndexFunc(s, func(r rune) bool { return r < '0' || r > '9' }); i >= 0 {
+			digits = s[:i]
+		}
+		n, err := strconv.Atoi(digits)
+		if err != nil {
+			return ""
+		}
+		lo := n / 100 * 100
+		return strconv.Itoa(lo) + "-" + strconv.Itoa(lo+99)
+	case "cep":
+		digits := strings.Map(func(r rune) rune {
+			if r < '0' || r > '9' {
+				return -1
+			}
+			return r
+		}, s)
+		if len(digits) < 5 {
+			return ""
+		}
+		return digits[:5]
+	case "sql":
+		sector, _, _ := strings.Cut(s, ".")
+		return sector
+	}
+	return ""
+}
+
+func roundSignificant(x float64, digits int) float64 {
+	if x == 0 || math.IsNaN(x) || math.IsInf(x, 0) {
+		return x
+	}
+	scale := math.Pow(10, float64(digits)-math.Ceil(math.Log10(math.Abs(x))))
+	return math.Round(x*scale) / scale
+}
+
+// jsonName returns the JSON name of a struct field.
+func jsonName(f reflect.StructField) string {
+	name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+	if name == "" {
+		return f.Name
+	}
+	return name
+}
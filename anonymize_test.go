@@ -0,0 +1,44 @@
+package iptuapi
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAnonymizer(t *testing.T) {
+	r := sampleIPTUResponse
+	r.Complemento = "apto 12"
+	r.Comparaveis = []ComparavelItem{{SQL: "111.222.3333-4", Numero: "57"}}
+	a := NewAnonymizer([]byte("segredo"))
+
+	a.Apply(&r)
+	assert.Len(t, r.SQL, 16)
+	assert.NotEqual(t, sampleIPTUResponse.SQL, r.SQL)
+	assert.Equal(t, "1000-1099", r.Numero)
+	assert.Empty(t, r.Complemento)
+	assert.Equal(t, sampleIPTUResponse.Bairro, r.Bairro)
+	assert.Equal(t, sampleIPTUResponse.ValorVenalTotal, r.ValorVenalTotal)
+	assert.Equal(t, "0-99", r.Comparaveis[0].Numero, "rules apply to nested results")
+
+	again := sampleIPTUResponse
+	a.Apply(&again)
+	assert.Equal(t, r.SQL, again.SQL, "hashes are stable for joins")
+}
+
+func TestAnonymizerConfigurable(t *testing.T) {
+	a := &Anonymizer{Fields: map[string]AnonymizeAction{
+		"sql":               AnonGeneralize,
+		"valor_venal_total": AnonGeneralize,
+		"logradouro":        AnonDrop,
+	}}
+	results := []ConsultaEnderecoResult{{SQL: "012.345.6789-0", Logradouro: "Rua A", ValorVenalTotal: 523456.78}}
+	a.Apply(&results)
+	assert.Equal(t, "012", results[0].SQL)
+	assert.Empty(t, results[0].Logradouro)
+	assert.Equal(t, 520000.0, results[0].ValorVenalTotal)
+
+	m := map[string]interface{}{"cnpj": "12.345.678/0001-90", "cep": "01310-100", "porte": "ME"}
+	(&Anonymizer{Fields: map[string]AnonymizeAction{"cnpj": AnonDrop, "cep": AnonGeneralize}}).Apply(&m)
+	assert.Equal(t, map[string]interface{}{"cep": "01310", "porte": "ME"}, m)
+}
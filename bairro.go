@@ -0,0 +1,224 @@
+package iptuapi
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// bairroAbbreviations expands the abbreviations common in cadastral
+// neighborhood names. Keys are lowercase, without accents or dots.
+var bairroAbbreviations = map[string]string{
+	"jd":    "jardim",
+	"jdm":   "jardim",
+	"jar":   "jardim",
+	"vl":    "vila",
+	"v":     "vila",
+	"pq":    "parque",
+	"pqe":   "parque",
+	"prq":   "parque",
+	"cj":    "conjunto",
+	"conj":  "conjunto",
+	"res":   "residencial",
+	"resid": "residencial",
+	"sta":   "santa",
+	"sto":   "santo",
+	"cid":   "cidade",
+	"chac":  "chácara",
+	"ch":    "chácara",
+	"lot":   "loteamento",
+	"bal":   "balneário",
+	"hab":   "habitacional",
+	"nsa":   "nossa",
+	"ns":    "nossa",
+	"n":     "nossa",
+	"sra":   "senhora",
+	"sr":    "senhor",
+	"pres":  "presidente",
+	"gov":   "governador",
+	"dr":    "doutor",
+	"prof":  "professor",
+	"mal":   "marechal",
+	"gal":   "general",
+}
+
+// bairroConnectors stay lowercase when a name is title-cased.
+var bairroConnectors = map[string]bool{
+	"da": true, "de": true, "do": true, "das": true, "dos": true, "e": true,
+}
+
+// bairrosCanonicos lists, per city, the canonical spelling of well-known
+// neighborhoods and the variants that map to them. Names not listed are expanded and title-cased only.
+var bairrosCanonicos = map[Cidade]map[string][]string{
+	CidadeSaoPaulo: {
+		"Aclimação":           nil,
+		"Alto de Pinheiros":   {"alto pinheiros"},
+		"Bela Vista":          {"bixiga", "bexiga"},
+		"Brás":                nil,
+		"Brooklin":            {"brooklin paulista", "brooklin novo", "brooklyn"},
+		"Butantã":             nil,
+		"Cambuci":             nil,
+		"Campo Belo":          nil,
+		"Cerqueira César":     nil,
+		"Consolação":          nil,
+		"Higienópolis":        nil,
+		"Ipiranga":            nil,
+		"Itaim Bibi":          {"itaim"},
+		"Jabaquara":           nil,
+		"Jardim América":      nil,
+		"Jardim Europa":       nil,
+		"Jardim Paulista":     {"jardim paulistano"},
+		"Lapa":                nil,
+		"Liberdade":           nil,
+		"Moema":               nil,
+		"Mooca":               {"moca"},
+		"Morumbi":             nil,
+		"Paraíso":             nil,
+		"Penha":               {"penha de franca"},
+		"Perdizes":            nil,
+		"Pinheiros":           nil,
+		"República":           nil,
+		"Santa Cecília":       nil,
+		"Santana":             nil,
+		"Santo Amaro":         nil,
+		"Saúde":               nil,
+		"Sé":                  nil,
+		"Tatuapé":             nil,
+		"Vila Madalena":       nil,
+		"Vila Mariana":        nil,
+		"Vila Nova Conceição": nil,
+		"Vila Olímpia":        nil,
+	},
+	CidadeRioDeJaneiro: {
+		"Barra da Tijuca":          {"barra"},
+		"Botafogo":                 nil,
+		"Centro":                   nil,
+		"Copacabana":               nil,
+		"Flamengo":                 nil,
+		"Gávea":                    nil,
+		"Ipanema":                  nil,
+		"Jardim Botânico":          nil,
+		"Lagoa":                    nil,
+		"Laranjeiras":              nil,
+		"Leblon":                   nil,
+		"Méier":                    nil,
+		"Recreio dos Bandeirantes": {"recreio"},
+		"Tijuca":                   nil,
+	},
+	CidadeBeloHorizonte: {
+		"Belvedere":       nil,
+		"Buritis":         nil,
+		"Centro":          nil,
+		"Funcionários":    nil,
+		"Lourdes":         nil,
+		"Santo Agostinho": nil,
+		"Savassi":         nil,
+		"Serra":           nil,
+		"Sion":            nil,
+	},
+	CidadeCuritiba: {
+		"Água Verde":    nil,
+		"Batel":         nil,
+		"Bigorrilho":    {"champagnat"},
+		"Cabral":        nil,
+		"Centro Cívico": nil,
+		"Juvevê":        nil,
+		"Mossunguê":     {"ecoville"},
+	},
+	CidadePortoAlegre: {
+		"Bom Fim":          nil,
+		"Cidade Baixa":     nil,
+		"Menino Deus":      nil,
+		"Moinhos de Vento": {"moinhos"},
+		"Petrópolis":       nil,
+	},
+	CidadeRecife: {
+		"Aflitos":    nil,
+		"Boa Viagem": nil,
+		"Casa Forte": nil,
+		"Espinheiro": nil,
+		"Graças":     {"gracas", "das gracas"},
+		"Parnamirim": nil,
+	},
+	CidadeFortaleza: {
+		"Aldeota":  nil,
+		"Cocó":     nil,
+		"Meireles": nil,
+		"Mucuripe": nil,
+		"Varjota":  nil,
+	},
+	CidadeBrasilia: {
+		"Asa Norte":  nil,
+		"Asa Sul":    nil,
+		"Lago Norte": nil,
+		"Lago Sul":   nil,
+		"Noroeste":   nil,
+		"Sudoeste":   {"sudoeste octogonal"},
+	},
+}
+
+// bairroIndex maps a city and a folded, expanded name to its canonical
+// spelling.
+var bairroIndex = buildBairroIndex()
+
+func buildBairroIndex() map[Cidade]map[string]string {
+	index := make(map[Cidade]map[string]string, len(bairrosCanonicos))
+	for cidade, bairros := range bairrosCanonicos {
+		m := make(map[string]string)
+		for canonical, variants := range bairros {
+			m[bairroKey(canonical)] = canonical
+			for _, v := range variants {
+				m[bairroKey(v)] = canonical
+			}
+		}
+		index[cidade] = m
+	}
+	return index
+}
+
+// bairroWords splits s into lowercase words with abbreviations expanded,
+// keeping accents.
+func bairroWords(s string) []string {
+	words := strings.FieldsFunc(strings.ToLower(SanitizeText(s)), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	for i, w := range words {
+		if full, ok := bairroAbbreviations[FoldAccents(w)]; ok {
+			words[i] = full
+		}
+	}
+	return words
+}
+
+// bairroKey is the comparison key of a neighborhood name: expanded,
+// lowercase and without accents.
+func bairroKey(s string) string {
+	return FoldAccents(strings.Join(bairroWords(s), " "))
+}
+
+// NormalizeBairro returns a consistent spelling for a neighborhood name as
+// found in cadastral data: abbreviations are expanded ("Jd. Paulista"
+// becomes "Jardim Paulista"), known variants of the city's neighborhoods
+// map to their canonical name with accents ("Bixiga" becomes "Bela Vista"
+// in São Paulo), and other names are title-cased. An empty cidade applies
+// only the generic rules.
+func NormalizeBairro(cidade Cidade, s string) string {
+	words := bairroWords(s)
+	if canonical, ok := bairroIndex[cidade][FoldAccents(strings.Join(words, " "))]; ok {
+		return canonical
+	}
+	for i, w := range words {
+		if i > 0 && bairroConnectors[w] {
+			continue
+		}
+		r, size := utf8.DecodeRuneInString(w)
+		words[i] = string(unicode.ToUpper(r)) + w[size:]
+	}
+	return strings.Join(words, " ")
+}
+
+// SameBairro reports whether a and b name the same neighborhood of cidade
+// once normalized.
+func SameBairro(cidade Cidade, a, b string) bool {
+	return NormalizeBairro(cidade, a) == NormalizeBairro(cidade, b)
+}
@@ -0,0 +1,63 @@
+package iptuapi
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNormalizeBairro(t *testing.T) {
+	tests := []struct {
+		cidade Cidade
+		in     string
+		want   string
+	}{
+		{CidadeSaoPaulo, "Jd. Paulista", "Jardim Paulista"},
+		{CidadeSaoPaulo, "JARDIM PAULISTA", "Jardim Paulista"},
+		{CidadeSaoPaulo, "VL OLIMPIA", "Vila Olímpia"},
+		{CidadeSaoPaulo, "Bixiga", "Bela Vista"},
+		{CidadeSaoPaulo, "SE", "Sé"},
+		{CidadeSaoPaulo, "cerqueira cesar", "Cerqueira César"},
+		{CidadeRioDeJaneiro, "Barra", "Barra da Tijuca"},
+		{CidadeCuritiba, "Champagnat", "Bigorrilho"},
+		// Unknown names are expanded and title-cased, keeping accents.
+		{CidadeSaoPaulo, "PQ. SÃO RAFAEL", "Parque São Rafael"},
+		{CidadeSaoPaulo, "conj res. josé bonifácio", "Conjunto Residencial José Bonifácio"},
+		{CidadeSaoPaulo, "vila n. sra. da penha", "Vila Nossa Senhora da Penha"},
+		// Variants are per city.
+		{CidadeSaoPaulo, "Barra", "Barra"},
+		{"", "Bixiga", "Bixiga"},
+		{"", "  ", ""},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, NormalizeBairro(tt.cidade, tt.in), "%s %q", tt.cidade, tt.in)
+	}
+
+	assert.True(t, SameBairro(CidadeSaoPaulo, "Jd Europa", "JARDIM EUROPA"))
+	assert.False(t, SameBairro(CidadeSaoPaulo, "Jardim Europa", "Jardim America"))
+}
+
+func TestValuationNormalizesBairro(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/valuation/comparables":
+			assert.Equal(t, "Jardim Paulista", r.URL.Query().Get("bairro"))
+			json.NewEncoder(w).Encode([]ComparavelItem{})
+		default:
+			assert.Equal(t, "/valuation/statistics/Vila Mariana", r.URL.Path)
+			json.NewEncoder(w).Encode(ValuationStatisticsResult{})
+		}
+	}))
+	defer server.Close()
+	client := NewClient("test_key", WithBaseURL(server.URL))
+
+	_, err := client.ValuationComparables(context.Background(), "JD. PAULISTA", 50, 100, CidadeSaoPaulo, 10)
+	require.NoError(t, err)
+	_, err = client.ValuationStatistics(context.Background(), "V. Mariana", "")
+	require.NoError(t, err)
+}
@@ -0,0 +1,219 @@
+package iptuapi
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrorClass tells whether a failed call may succeed if sent again later.
+type ErrorClass string
+
+const (
+	// ErrorRetryable covers rate limits, 5xx responses, unavailable
+	// municipal sources, an open circuit breaker, timeouts and temporary
+	// network failures.
+	ErrorRetryable ErrorClass = "retryable"
+	// ErrorPermanent covers everything else, such as not found, validation
+	// and authentication errors.
+	ErrorPermanent ErrorClass = "permanent"
+)
+
+// ClassifyError returns the class of a failed call's error, or "" for nil.
+func ClassifyError(err error) ErrorClass {
+	var (
+		rateLimit *RateLimitError
+		server    *ServerError
+		fonte     *FonteIndisponivelError
+		deadline  *RetryDeadlineError
+	)
+	switch {
+	case err == nil:
+		return ""
+	case errors.As(err, &rateLimit), errors.As(err, &server), errors.As(err, &fonte),
+		errors.As(err, &deadline), IsCircuitOpen(err), isTemporaryNetErr(err),
+		errors.Is(err, context.DeadlineExceeded):
+		return ErrorRetryable
+	default:
+		return ErrorPermanent
+	}
+}
+
+// BatchOptions configures RunBatch.
+type BatchOptions struct {
+	// Concurrency is the number of items processed at once in the main
+	// pass (default 1). Retry passes run one item at a time.
+	Concurrency int
+	// RetryPasses is the number of passes over the items that failed with
+	// a retryable error (default 2; negative disables retries).
+	RetryPasses int
+	// RetryBackoff is the wait before the first retry pass, doubled on
+	// every pass (default 2s). A longer Retry-After from a rate limit or
+	// the reopening of the circuit breaker takes precedence.
+	RetryBackoff time.Duration
+	// Client, if set, is the client fn calls, whose requests and quota are
+	// included in the summary's Report and whose clock times the retry
+	// passes.
+	Client *Client
+}
+
+// BatchItem is the outcome of one item of RunBatch.
+type BatchItem[T any] struct {
+	Value T
+	Err   error
+	// Class is the class of Err, or "" on success.
+	Class ErrorClass
+	// Attempts is the number of times the item was processed; zero means
+	// the run was cancelled before reaching it.
+	Attempts int
+}
+
+// BatchFailure describes an item that still failed at the end of the run.
+type BatchFailure struct {
+	Index    int        `json:"index"`
+	Class    ErrorClass `json:"class"`
+	Attempts int        `json:"attempts"`
+	Error    string     `json:"error"`
+}
+
+// BatchSummary reports a RunBatch run, listing failures by class rather
+// than interleaved with the results.
+type BatchSummary struct {
+	Total     int `json:"total"`
+	Succeeded int `json:"succeeded"`
+	// Recovered counts items that failed in the main pass and succeeded in
+	// a retry pass.
+	Recovered int `json:"recovered"`
+	// Retryable lists items whose retryable errors outlasted every retry
+	// pass; Permanent lists those that failed with permanent errors.
+	Retryable []BatchFailure `json:"retryable,omitempty"`
+	Permanent []BatchFailure `json:"permanent,omitempty"`
+	// Report has the run's duration, latency percentiles, quota consumed
+	// and most frequent errors.
+	Report *RunReport `json:"report"`
+}
+
+// RunBatch calls fn for every item. Items failing with a retryable error
+// (see ClassifyError) are queued and retried after the main pass, one at a
+// time and with an increasing backoff, instead of being retried in place
+// while the API is throttling or failing. Items are returned in input
+// order. The error is non-nil only if ctx ends, in which case the items
+// not processed have zero Attempts.
+func RunBatch[In, Out any](ctx context.Context, items []In, fn func(context.Context, In) (Out, error), opts *BatchOptions) ([]BatchItem[Out], *BatchSummary, error) {
+	o := BatchOptions{}
+	if opts != nil {
+		o = *opts
+	}
+	if o.Concurrency <= 0 {
+		o.Concurrency = 1
+	}
+	if o.RetryPasses == 0 {
+		o.RetryPasses = 2
+	}
+	if o.RetryBackoff <= 0 {
+		o.RetryBackoff = 2 * time.Second
+	}
+
+	clock := SystemClock
+	if o.Client != nil {
+		clock = o.Client.clock
+	}
+	recorder := NewRunRecorder(o.Client)
+	results := make([]BatchItem[Out], len(items))
+	run := func(i int) {
+		start := time.Now()
+		v, err := fn(ctx, items[i])
+		if err != nil && ctx.Err() != nil {
+			return // cancelled: keep the previous outcome
+		}
+		recorder.Latency(time.Since(start))
+		r := &results[i]
+		r.Value, r.Err, r.Class = v, err, ClassifyError(err)
+		r.Attempts++
+	}
+
+	// Main pass.
+	sem := make(chan struct{}, o.Concurrency)
+	var wg sync.WaitGroup
+	for i := range items {
+		sem <- struct{}{}
+		if ctx.Err() != nil {
+			<-sem
+			break
+		}
+		wg.Add(1)
+		go func(i int) {
+			defer func() { <-sem; wg.Done() }()
+			run(i)
+		}(i)
+	}
+	wg.Wait()
+
+	// Retry queue.
+	var queue []int
+	for i := range results {
+		if results[i].Class == ErrorRetryable {
+			queue = append(queue, i)
+		}
+	}
+	backoff := o.RetryBackoff
+	for pass := 0; pass < o.RetryPasses && len(queue) > 0 && ctx.Err() == nil; pass++ {
+		clock.Sleep(ctx, retryQueueWait(clock.Now(), backoff, results, queue))
+		var next []int
+		for _, i := range queue {
+			if ctx.Err() != nil {
+				break
+			}
+			run(i)
+			if results[i].Class == ErrorRetryable {
+				next = append(next, i)
+			}
+		}
+		queue = next
+		backoff *= 2
+	}
+
+	summary := &BatchSummary{Total: len(items)}
+	for i, r := range results {
+		switch {
+		case r.Attempts == 0:
+			recorder.Record(OutcomeNotRun, nil)
+		case r.Err == nil && r.Attempts > 1:
+			summary.Succeeded++
+			summary.Recovered++
+			recorder.Record(OutcomeRecovered, nil)
+		case r.Err == nil:
+			summary.Succeeded++
+			recorder.Record(OutcomeSucceeded, nil)
+		case r.Class == ErrorRetryable:
+			summary.Retryable = append(summary.Retryable, BatchFailure{Index: i, Class: r.Class, Attempts: r.Attempts, Error: r.Err.Error()})
+			recorder.Record(OutcomeRetryable, r.Err)
+		default:
+			summary.Permanent = append(summary.Permanent, BatchFailure{Index: i, Class: r.Class, Attempts: r.Attempts, Error: r.Err.Error()})
+			recorder.Record(OutcomePermanent, r.Err)
+		}
+	}
+	summary.Report = recorder.Report()
+	return results, summary, ctx.Err()
+}
+
+// retryQueueWait returns how long to wait before a retry pass: backoff, or
+// longer if a queued item was told to wait by a rate limit or an open
+// circuit breaker.
+func retryQueueWait[T any](now time.Time, backoff time.Duration, results []BatchItem[T], queue []int) time.Duration {
+	wait := backoff
+	for _, i := range queue {
+		var (
+			rateLimit *RateLimitError
+			open      *CircuitOpenError
+		)
+		switch {
+		case errors.As(results[i].Err, &rateLimit):
+			wait = max(wait, time.Duration(rateLimit.RetryAfter)*time.Second)
+		case errors.As(results[i].Err, &open) && !open.RetryAt.IsZero():
+			wait = max(wait, open.RetryAt.Sub(now))
+		}
+	}
+	return wait
+}
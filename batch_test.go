@@ -0,0 +1,111 @@
+package iptuapi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClassifyError(t *testing.T) {
+	tests := []struct {
+		err  error
+		want ErrorClass
+	}{
+		{nil, ""},
+		{&RateLimitError{APIError: &APIError{StatusCode: 429}}, ErrorRetryable},
+		{&ServerError{APIError: &APIError{StatusCode: 503}}, ErrorRetryable},
+		{&FonteIndisponivelError{APIError: &APIError{StatusCode: 503}}, ErrorRetryable},
+		{&CircuitOpenError{State: BreakerOpen}, ErrorRetryable},
+		{&url.Error{Op: "Get", URL: "x", Err: io.ErrUnexpectedEOF}, ErrorRetryable},
+		{fmt.Errorf("lookup: %w", context.DeadlineExceeded), ErrorRetryable},
+		{&NotFoundError{APIError: &APIError{StatusCode: 404}}, ErrorPermanent},
+		{&ValidationError{APIError: &APIError{StatusCode: 422}}, ErrorPermanent},
+		{context.Canceled, ErrorPermanent},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, ClassifyError(tt.err), "%v", tt.err)
+	}
+}
+
+func TestRunBatchRetryQueue(t *testing.T) {
+	calls := map[string]int{}
+	var order []string
+	fn := func(ctx context.Context, item string) (string, error) {
+		calls[item]++
+		order = append(order, item)
+		switch item {
+		case "flaky":
+			if calls[item] == 1 {
+				return "", &ServerError{APIError: &APIError{StatusCode: 502, Message: "bad gateway"}}
+			}
+		case "missing":
+			return "", &NotFoundError{APIError: &APIError{StatusCode: 404, Message: "nao encontrado"}}
+		case "throttled":
+			return "", &RateLimitError{APIError: &APIError{StatusCode: 429, Message: "rate limit"}}
+		}
+		return "ok:" + item, nil
+	}
+
+	items := []string{"a", "flaky", "missing", "throttled", "b"}
+	results, summary, err := RunBatch(context.Background(), items, fn, &BatchOptions{RetryBackoff: time.Millisecond})
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"a", "flaky", "missing", "throttled", "b", "flaky", "throttled", "throttled"}, order,
+		"failed items are retried after the main pass")
+	assert.Equal(t, "ok:flaky", results[1].Value)
+	assert.Equal(t, 2, results[1].Attempts)
+	assert.Equal(t, ErrorPermanent, results[2].Class)
+	assert.Equal(t, 1, results[2].Attempts, "permanent errors are not retried")
+	assert.Equal(t, 3, results[3].Attempts)
+
+	assert.Equal(t, 5, summary.Total)
+	assert.Equal(t, 3, summary.Succeeded)
+	assert.Equal(t, 1, summary.Recovered)
+	require.Len(t, summary.Permanent, 1)
+	assert.Equal(t, 2, summary.Permanent[0].Index)
+	require.Len(t, summary.Retryable, 1)
+	assert.Equal(t, BatchFailure{Index: 3, Class: ErrorRetryable, Attempts: 3, Error: summary.Retryable[0].Error}, summary.Retryable[0])
+
+	require.NotNil(t, summary.Report)
+	assert.Equal(t, map[string]int{OutcomeSucceeded: 2, OutcomeRecovered: 1, OutcomePermanent: 1, OutcomeRetryable: 1}, summary.Report.Outcomes)
+	assert.Equal(t, 0.4, summary.Report.FailureRate(OutcomeSucceeded, OutcomeRecovered))
+	require.Len(t, summary.Report.TopErrors, 2)
+}
+
+func TestRunBatchCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	fn := func(ctx context.Context, i int) (int, error) {
+		if i == 1 {
+			cancel()
+			return 0, ctx.Err()
+		}
+		return i, nil
+	}
+
+	results, summary, err := RunBatch(ctx, []int{0, 1, 2}, fn, nil)
+	assert.True(t, errors.Is(err, context.Canceled))
+	assert.Equal(t, 1, results[0].Attempts)
+	assert.Zero(t, results[1].Attempts, "a call ended by the cancellation is not counted")
+	assert.Zero(t, results[2].Attempts)
+	assert.Equal(t, 1, summary.Succeeded)
+	assert.Empty(t, summary.Permanent)
+	assert.Equal(t, 2, summary.Report.Outcomes[OutcomeNotRun])
+}
+
+func TestRetryQueueWaitHonorsRetryAfter(t *testing.T) {
+	results := []BatchItem[int]{{Err: &RateLimitError{APIError: &APIError{StatusCode: 429}, RetryAfter: 7}}}
+	now := time.Now()
+	assert.Equal(t, 7*time.Second, retryQueueWait(now, time.Second, results, []int{0}))
+	assert.Equal(t, 10*time.Second, retryQueueWait(now, 10*time.Second, results, []int{0}))
+
+	results = []BatchItem[int]{{Err: &CircuitOpenError{RetryAt: now.Add(time.Minute)}}}
+	assert.Equal(t, time.Minute, retryQueueWait(now, time.Second, results, []int{0}))
+}
@@ -0,0 +1,40 @@
+package iptuapi
+
+import (
+	"context"
+	"errors"
+	"net/url"
+)
+
+// ErrBetaDisabled is returned by beta calls on clients created without
+// WithBetaFeatures(true).
+var ErrBetaDisabled = errors.New("iptuapi: beta features disabled; see WithBetaFeatures")
+
+// WithBetaFeatures enables the calls and types marked "Beta:" in their
+// documentation. They give early access to experimental API features, but
+// unlike the rest of the SDK they may change or disappear in any release,
+// patch releases included. Disabled by default.
+func WithBetaFeatures(enabled bool) ClientOption {
+	return func(c *Client) {
+		c.beta = enabled
+	}
+}
+
+// BetaEnabled reports whether the client was created with
+// WithBetaFeatures(true).
+func (c *Client) BetaEnabled() bool {
+	return c.beta
+}
+
+// BetaCall calls an experimental endpoint the SDK has no method for yet,
+// decoding the JSON response into result. Retries, rate limiting, the
+// circuit breaker and stats apply as for any other call. It returns
+// ErrBetaDisabled unless beta features are enabled.
+//
+// Beta: the signature may change in any release.
+func (c *Client) BetaCall(ctx context.Context, method, endpoint string, params url.Values, body, result interface{}, opts ...RequestOption) error {
+	if !c.beta {
+		return ErrBetaDisabled
+	}
+	return c.doRequest(ctx, method, endpoint, params, body, result, opts...)
+}
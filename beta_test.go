@@ -0,0 +1,36 @@
+package iptuapi
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBetaCall(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/beta/geometria", r.URL.Path)
+		assert.Equal(t, "000.000.0000-0", r.URL.Query().Get("sql"))
+		w.Write([]byte(`{"tipo":"Polygon"}`))
+	}))
+	defer server.Close()
+
+	ctx := context.Background()
+	params := url.Values{"sql": {"000.000.0000-0"}}
+	var result struct {
+		Tipo string `json:"tipo"`
+	}
+
+	client := NewClient("test_key", WithBaseURL(server.URL))
+	assert.False(t, client.BetaEnabled())
+	assert.ErrorIs(t, client.BetaCall(ctx, "GET", "/beta/geometria", params, nil, &result), ErrBetaDisabled)
+
+	client = NewClient("test_key", WithBaseURL(server.URL), WithBetaFeatures(true))
+	require.NoError(t, client.BetaCall(ctx, "GET", "/beta/geometria", params, nil, &result))
+	assert.Equal(t, "Polygon", result.Tipo)
+	assert.Equal(t, int64(1), client.Stats().Requests["GET /beta/geometria"]["200"])
+}
@@ -0,0 +1,191 @@
+package iptuapi
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// BreakerState is the state of the circuit breaker.
+type BreakerState string
+
+const (
+	// BreakerClosed lets every request through.
+	BreakerClosed BreakerState = "closed"
+	// BreakerOpen fails requests fast until the cooldown ends.
+	BreakerOpen BreakerState = "open"
+	// BreakerHalfOpen lets one trial request through; its outcome closes
+	// or reopens the breaker.
+	BreakerHalfOpen BreakerState = "half_open"
+	// BreakerMaintenance is set during an incident declared by the API
+	// (see WatchIncidents): one request at a time is let through and
+	// failures do not trip the breaker.
+	BreakerMaintenance BreakerState = "maintenance"
+)
+
+// BreakerConfig configures WithCircuitBreaker.
+type BreakerConfig struct {
+	// FailureThreshold is the number of consecutive failed attempts (no
+	// response or 5xx) that opens the breaker (default 5).
+	FailureThreshold int
+	// Cooldown is how long the breaker stays open before a trial request
+	// (default 30s).
+	Cooldown time.Duration
+}
+
+// WithCircuitBreaker stops sending requests after repeated failures, so an
+// API outage fails calls fast instead of tying up workers in retries.
+// Rejected calls return a *CircuitOpenError. Trips are counted in Stats.
+func WithCircuitBreaker(cfg BreakerConfig) ClientOption {
+	return func(c *Client) {
+		if cfg.FailureThreshold <= 0 {
+			cfg.FailureThreshold = 5
+		}
+		if cfg.Cooldown <= 0 {
+			cfg.Cooldown = 30 * time.Second
+		}
+		c.breaker = &breaker{cfg: cfg, state: BreakerClosed, now: time.Now}
+	}
+}
+
+// ErrCircuitOpen is matched by errors.Is for every *CircuitOpenError.
+var ErrCircuitOpen = errors.New("iptuapi: circuit breaker open")
+
+// CircuitOpenError is returned for calls rejected by the circuit breaker.
+type CircuitOpenError struct {
+	State BreakerState
+	// RetryAt is when the breaker lets a trial request through, or zero in
+	// maintenance mode.
+	RetryAt time.Time
+	// Incident is the declared incident behind maintenance mode, if any.
+	Incident *Incident
+}
+
+func (e *CircuitOpenError) Error() string {
+	if e.Incident != nil {
+		return fmt.Sprintf("iptuapi: circuit breaker in maintenance during incident %s: %s", e.Incident.ID, e.Incident.Titulo)
+	}
+	return "iptuapi: circuit breaker open until " + e.RetryAt.Format(time.RFC3339)
+}
+
+func (e *CircuitOpenError) Is(target error) bool {
+	return target == ErrCircuitOpen
+}
+
+// IsCircuitOpen checks if a call was rejected by the circuit breaker.
+func IsCircuitOpen(err error) bool {
+	return errors.Is(err, ErrCircuitOpen)
+}
+
+// BreakerState returns the state of the circuit breaker, or BreakerClosed
+// if WithCircuitBreaker was not used.
+func (c *Client) BreakerState() BreakerState {
+	if c.breaker == nil {
+		return BreakerClosed
+	}
+	return c.breaker.current()
+}
+
+// BreakerRetryAt returns when the open circuit breaker lets a trial request
+// through, or the zero time if it is not open. In maintenance mode the time
+// is unknown and zero too.
+func (c *Client) BreakerRetryAt() time.Time {
+	if c.breaker == nil {
+		return time.Time{}
+	}
+	b := c.breaker
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.state != BreakerOpen {
+		return time.Time{}
+	}
+	return b.openedAt.Add(b.cfg.Cooldown)
+}
+
+type breaker struct {
+	cfg BreakerConfig
+	now func() time.Time
+
+	mu       sync.Mutex
+	state    BreakerState
+	failures int
+	openedAt time.Time
+	// trial is set while the single half-open or maintenance request is in
+	// flight.
+	trial    bool
+	incident *Incident
+}
+
+func (b *breaker) current() BreakerState {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.state == BreakerOpen && !b.now().Before(b.openedAt.Add(b.cfg.Cooldown)) {
+		return BreakerHalfOpen
+	}
+	return b.state
+}
+
+// allow reports whether an attempt may be sent, returning the rejection
+// error otherwise.
+func (b *breaker) allow() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	switch b.state {
+	case BreakerOpen:
+		retryAt := b.openedAt.Add(b.cfg.Cooldown)
+		if b.now().Before(retryAt) {
+			return &CircuitOpenError{State: BreakerOpen, RetryAt: retryAt}
+		}
+		b.state = BreakerHalfOpen
+		fallthrough
+	case BreakerHalfOpen, BreakerMaintenance:
+		if b.trial {
+			return &CircuitOpenError{State: b.state, RetryAt: b.openedAt.Add(b.cfg.Cooldown), Incident: b.incident}
+		}
+		b.trial = true
+	}
+	return nil
+}
+
+// record reports the outcome of an allowed attempt and whether it tripped
+// the breaker.
+func (b *breaker) record(failed bool) (tripped bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.trial = false
+	switch {
+	case b.state == BreakerMaintenance:
+		return false
+	case !failed:
+		b.state, b.failures = BreakerClosed, 0
+		return false
+	}
+	b.failures++
+	if b.state == BreakerHalfOpen || b.failures >= b.cfg.FailureThreshold {
+		b.state, b.openedAt = BreakerOpen, b.now()
+		return true
+	}
+	return false
+}
+
+// release ends an allowed attempt whose outcome says nothing about the
+// API, e.g. one canceled by the caller.
+func (b *breaker) release() {
+	b.mu.Lock()
+	b.trial = false
+	b.mu.Unlock()
+}
+
+// maintenance enters maintenance mode for inc, or leaves it if inc is nil.
+func (b *breaker) maintenance(inc *Incident) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.incident = inc
+	switch {
+	case inc != nil:
+		b.state = BreakerMaintenance
+	case b.state == BreakerMaintenance:
+		b.state, b.failures = BreakerClosed, 0
+	}
+}
@@ -0,0 +1,139 @@
+package iptuapi
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCircuitBreaker(t *testing.T) {
+	var failing atomic.Bool
+	var hits atomic.Int32
+	failing.Store(true)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		if failing.Load() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte(`{"cidades":[]}`))
+	}))
+	defer server.Close()
+
+	client := NewClient("test_key", WithBaseURL(server.URL), WithRetry(&RetryConfig{MaxRetries: 0}),
+		WithCircuitBreaker(BreakerConfig{FailureThreshold: 2, Cooldown: time.Minute}))
+	now := time.Unix(1_700_000_000, 0)
+	client.breaker.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	for i := 0; i < 2; i++ {
+		_, err := client.IPTUToolsCidades(ctx)
+		require.Error(t, err)
+		assert.False(t, IsCircuitOpen(err))
+	}
+	assert.Equal(t, BreakerOpen, client.BreakerState())
+	assert.Equal(t, int64(1), client.Stats().BreakerTrips)
+
+	_, err := client.IPTUToolsCidades(ctx)
+	var open *CircuitOpenError
+	require.ErrorAs(t, err, &open)
+	assert.Equal(t, now.Add(time.Minute), open.RetryAt)
+	assert.Equal(t, int32(2), hits.Load(), "rejected calls are not sent")
+
+	now = now.Add(time.Minute)
+	assert.Equal(t, BreakerHalfOpen, client.BreakerState())
+	failing.Store(false)
+	_, err = client.IPTUToolsCidades(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, BreakerClosed, client.BreakerState(), "a successful trial closes the breaker")
+}
+
+func TestCircuitBreakerHalfOpenFailure(t *testing.T) {
+	b := &breaker{cfg: BreakerConfig{FailureThreshold: 3, Cooldown: time.Second}, state: BreakerOpen, now: time.Now}
+	b.openedAt = time.Now().Add(-time.Hour)
+
+	require.NoError(t, b.allow())
+	assert.ErrorIs(t, b.allow(), ErrCircuitOpen, "only one trial at a time")
+	assert.True(t, b.record(true), "a failed trial reopens the breaker")
+	assert.Equal(t, BreakerOpen, b.current())
+}
+
+func TestWatchIncidentsMaintenance(t *testing.T) {
+	incidents := []Incident{{ID: "inc_1", Titulo: "Lentidao na consulta", Status: IncidentInvestigando}}
+	var feed atomic.Value
+	feed.Store(incidents)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/status/incidents" {
+			json.NewEncoder(w).Encode(feed.Load())
+			return
+		}
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	client := NewClient("test_key", WithBaseURL(server.URL), WithRetry(&RetryConfig{MaxRetries: 0}),
+		WithCircuitBreaker(BreakerConfig{FailureThreshold: 1}))
+	ctx := context.Background()
+
+	client.checkIncidents(ctx)
+	assert.Equal(t, BreakerMaintenance, client.BreakerState())
+
+	_, err := client.IPTUToolsCidades(ctx)
+	require.Error(t, err)
+	assert.False(t, IsCircuitOpen(err), "one request at a time still goes through")
+	assert.Equal(t, BreakerMaintenance, client.BreakerState(), "failures do not trip the breaker")
+	assert.Zero(t, client.Stats().BreakerTrips)
+
+	client.breaker.allow()
+	_, err = client.IPTUToolsCidades(ctx)
+	var open *CircuitOpenError
+	require.ErrorAs(t, err, &open)
+	require.NotNil(t, open.Incident)
+	assert.Equal(t, "inc_1", open.Incident.ID)
+	client.breaker.release()
+
+	fim := time.Now()
+	feed.Store([]Incident{{ID: "inc_1", Status: IncidentResolvido, FimEm: &fim}})
+	client.checkIncidents(ctx)
+	assert.Equal(t, BreakerClosed, client.BreakerState())
+}
+
+func TestIncidents(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/status/incidents", r.URL.Path)
+		w.Write([]byte(`[{"id":"inc_1","titulo":"Manutencao","status":"monitorando","inicio_em":"2024-01-01T00:00:00Z"}]`))
+	}))
+	defer server.Close()
+
+	client := NewClient("test_key", WithBaseURL(server.URL))
+	incidents, err := client.Incidents(context.Background())
+	require.NoError(t, err)
+	require.Len(t, incidents, 1)
+	assert.True(t, incidents[0].Ativo())
+}
+
+func TestIncidentsBypassCache(t *testing.T) {
+	var status atomic.Value
+	status.Store("investigando")
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`[{"id":"inc_1","status":"` + status.Load().(string) + `"}]`))
+	}))
+	defer server.Close()
+
+	client := NewClient("test_key", WithBaseURL(server.URL), WithCache(NewLRUCache(10), time.Hour))
+	incidents, err := client.Incidents(context.Background())
+	require.NoError(t, err)
+	assert.True(t, incidents[0].Ativo())
+
+	status.Store(string(IncidentResolvido))
+	incidents, err = client.Incidents(context.Background())
+	require.NoError(t, err)
+	assert.False(t, incidents[0].Ativo())
+}
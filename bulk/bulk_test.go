@@ -0,0 +1,113 @@
+package bulk
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	iptuapi "github.com/raphaeltorquat0/iptuapi-go"
+)
+
+func newTestClient(t *testing.T) *iptuapi.Client {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/consulta/endereco":
+			if r.URL.Query().Get("logradouro") == "Rua Inexistente" {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			assert.Equal(t, "Rua São Bento", r.URL.Query().Get("logradouro"))
+			assert.Equal(t, "sp", r.URL.Query().Get("cidade"))
+			json.NewEncoder(w).Encode(iptuapi.ConsultaEnderecoResult{SQL: "1", Bairro: "Sé", ValorVenalTotal: 500000})
+		case strings.HasPrefix(r.URL.Path, "/consulta/sql/"):
+			json.NewEncoder(w).Encode(iptuapi.ConsultaSQLResult{SQL: "2", Bairro: "Moema"})
+		}
+	}))
+	t.Cleanup(server.Close)
+	return iptuapi.NewClient("test_key",
+		iptuapi.WithBaseURL(server.URL),
+		iptuapi.WithRetry(&iptuapi.RetryConfig{MaxRetries: 0}),
+	)
+}
+
+func TestEnrichLatin1WithMapping(t *testing.T) {
+	// "Endereço;Nº;Inscrição" in ISO-8859-1.
+	input := []byte("Endere\xe7o;N\xba;Inscri\xe7\xe3o\nRua S\xe3o Bento;100;\nRua Inexistente;1;\n;;2\n;;\n")
+
+	e := &Enricher{
+		Client: newTestClient(t),
+		Mapping: Mapping{
+			Delimiter:    ";",
+			Encoding:     "ISO-8859-1",
+			Header:       true,
+			Columns:      map[string]string{"logradouro": "endereço", "numero": "2", "sql": "Inscrição"},
+			CidadePadrao: iptuapi.CidadeSaoPaulo,
+		},
+	}
+	var out bytes.Buffer
+	stats, err := e.Enrich(context.Background(), bytes.NewReader(input), &out)
+	require.NoError(t, err)
+	require.NotNil(t, stats.Report)
+	assert.Equal(t, map[string]int{StatusOK: 2, StatusNaoEncontrado: 1, StatusSemEntrada: 1}, stats.Report.Outcomes)
+	assert.Equal(t, int64(3), stats.Report.QuotaConsumed)
+	stats.Report = nil
+	assert.Equal(t, Stats{Rows: 4, OK: 2, NotFound: 1, Skipped: 1}, stats)
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	require.Len(t, lines, 5)
+	assert.True(t, strings.HasPrefix(lines[0], "Endere\xe7o;N\xba;Inscri\xe7\xe3o;iptu_sql"), "output keeps Latin-1")
+	assert.Equal(t, "Rua S\xe3o Bento;100;;1;S\xe9;;;500000;;ok;", lines[1])
+	assert.Contains(t, lines[2], ";nao_encontrado;")
+	assert.Contains(t, lines[3], ";2;Moema;")
+	assert.Contains(t, lines[4], ";sem_entrada;")
+}
+
+func TestEnrichDetectsEncoding(t *testing.T) {
+	mapping := Mapping{
+		Delimiter:    ";",
+		Header:       true,
+		Columns:      map[string]string{"logradouro": "endereço"},
+		CidadePadrao: iptuapi.CidadeSaoPaulo,
+	}
+	inputs := map[string]string{
+		"latin1":   "Endere\xe7o\nRua S\xe3o Bento\n",
+		"mojibake": "EndereÃ§o\nRua SÃ£o  Bento\n",
+		"nfd":      "Endereço\nRua Sa\u0303o Bento\n",
+	}
+	for name, input := range inputs {
+		t.Run(name, func(t *testing.T) {
+			e := &Enricher{Client: newTestClient(t), Mapping: mapping}
+			var out bytes.Buffer
+			stats, err := e.Enrich(context.Background(), strings.NewReader(input), &out)
+			require.NoError(t, err)
+			assert.Equal(t, 1, stats.OK)
+		})
+	}
+
+	var out bytes.Buffer
+	e := &Enricher{Client: newTestClient(t), Mapping: mapping}
+	_, err := e.Enrich(context.Background(), strings.NewReader(inputs["latin1"]), &out)
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(out.String(), "Endere\xe7o;iptu_sql"), "output keeps detected Latin-1")
+}
+
+func TestMappingErrors(t *testing.T) {
+	e := &Enricher{Mapping: Mapping{Header: true, Columns: map[string]string{"numero": "n"}}}
+	_, err := e.Enrich(context.Background(), strings.NewReader("n\n1\n"), &bytes.Buffer{})
+	assert.ErrorContains(t, err, "needs a")
+
+	e.Mapping.Columns = map[string]string{"sql": "inscricao"}
+	_, err = e.Enrich(context.Background(), strings.NewReader("n\n1\n"), &bytes.Buffer{})
+	assert.ErrorContains(t, err, `column "inscricao" not found`)
+
+	e.Mapping.Encoding = "ebcdic"
+	_, err = e.Enrich(context.Background(), strings.NewReader("n\n1\n"), &bytes.Buffer{})
+	assert.ErrorContains(t, err, "unsupported encoding")
+}
@@ -0,0 +1,117 @@
+package bulk
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+	"unicode/utf8"
+
+	iptuapi "github.com/raphaeltorquat0/iptuapi-go"
+)
+
+// Encoding names accepted in Mapping.Encoding.
+const (
+	EncodingAuto   = "auto"
+	EncodingUTF8   = iptuapi.EncodingUTF8
+	EncodingLatin1 = iptuapi.EncodingLatin1
+)
+
+// detectSize is how much of the input is inspected to detect its encoding.
+const detectSize = 64 << 10
+
+// normalizeEncoding maps common aliases to EncodingAuto, EncodingUTF8 or
+// EncodingLatin1.
+func normalizeEncoding(name string) (string, error) {
+	switch strings.ToLower(strings.ReplaceAll(name, "_", "-")) {
+	case "", "auto":
+		return EncodingAuto, nil
+	case "utf-8", "utf8":
+		return EncodingUTF8, nil
+	case "latin1", "latin-1", "iso-8859-1", "iso8859-1", "windows-1252", "cp1252":
+		return EncodingLatin1, nil
+	}
+	return "", fmt.Errorf("bulk: unsupported encoding %q", name)
+}
+
+// detectEncoding peeks at the start of r and reports whether it is UTF-8
+// or Latin-1. The returned reader yields the full input.
+func detectEncoding(r io.Reader) (io.Reader, string, error) {
+	br := bufio.NewReaderSize(r, detectSize)
+	head, err := br.Peek(detectSize)
+	if err != nil && err != io.EOF && err != bufio.ErrBufferFull {
+		return nil, "", err
+	}
+	return br, iptuapi.DetectEncoding(head), nil
+}
+
+// latin1Reader decodes ISO-8859-1 bytes into UTF-8.
+type latin1Reader struct {
+	r       *bufio.Reader
+	pending []byte
+}
+
+func newLatin1Reader(r io.Reader) io.Reader {
+	return &latin1Reader{r: bufio.NewReader(r)}
+}
+
+func (l *latin1Reader) Read(p []byte) (int, error) {
+	n := 0
+	for n < len(p) {
+		if len(l.pending) > 0 {
+			c := copy(p[n:], l.pending)
+			l.pending = l.pending[c:]
+			n += c
+			continue
+		}
+		b, err := l.r.ReadByte()
+		if err != nil {
+			if n > 0 {
+				return n, nil
+			}
+			return 0, err
+		}
+		if b < utf8.RuneSelf {
+			p[n] = b
+			n++
+			continue
+		}
+		var buf [2]byte
+		utf8.EncodeRune(buf[:], rune(b))
+		l.pending = append(l.pending[:0], buf[:]...)
+	}
+	return n, nil
+}
+
+// latin1Writer encodes UTF-8 text as ISO-8859-1, replacing characters
+// outside Latin-1 with '?'.
+type latin1Writer struct {
+	w   io.Writer
+	buf []byte
+}
+
+func newLatin1Writer(w io.Writer) io.Writer {
+	return &latin1Writer{w: w}
+}
+
+func (l *latin1Writer) Write(p []byte) (int, error) {
+	// Keep an incomplete trailing rune for the next call.
+	data := append(l.buf, p...)
+	out := make([]byte, 0, len(data))
+	for len(data) > 0 {
+		if !utf8.FullRune(data) {
+			break
+		}
+		r, size := utf8.DecodeRune(data)
+		if r > 0xff {
+			r = '?'
+		}
+		out = append(out, byte(r))
+		data = data[size:]
+	}
+	l.buf = append(l.buf[:0], data...)
+	if _, err := l.w.Write(out); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
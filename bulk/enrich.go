@@ -0,0 +1,206 @@
+package bulk
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	iptuapi "github.com/raphaeltorquat0/iptuapi-go"
+)
+
+// Row status values written to the iptu_status column.
+const (
+	StatusOK            = "ok"
+	StatusNaoEncontrado = "nao_encontrado"
+	StatusErro          = "erro"
+	StatusSemEntrada    = "sem_entrada"
+)
+
+// OutputColumns are appended to every input row.
+var OutputColumns = []string{
+	"iptu_sql", "iptu_bairro", "iptu_area_terreno", "iptu_area_construida",
+	"iptu_valor_venal_total", "iptu_valor", "iptu_status", "iptu_erro",
+}
+
+// Stats counts enriched rows by outcome.
+type Stats struct {
+	Rows     int `json:"rows"`
+	OK       int `json:"ok"`
+	NotFound int `json:"not_found"`
+	Errors   int `json:"errors"`
+	Skipped  int `json:"skipped"`
+	// Report counts rows by status column value, with the lookups'
+	// latency, the quota consumed and the most frequent errors.
+	Report *iptuapi.RunReport `json:"report,omitempty"`
+}
+
+// Enricher appends IPTU data to each row of a CSV file laid out as described
+// by Mapping.
+type Enricher struct {
+	Client  *iptuapi.Client
+	Mapping Mapping
+}
+
+// Enrich reads CSV rows from r and writes them to w with OutputColumns
+// appended. Rows are looked up by SQL when the SQL column is mapped and
+// filled, otherwise by address. Lookup failures are recorded per row; only
+// I/O, mapping and context errors stop the run.
+func (e *Enricher) Enrich(ctx context.Context, r io.Reader, w io.Writer) (stats Stats, err error) {
+	rec := iptuapi.NewRunRecorder(e.Client)
+	defer func() { stats.Report = rec.Report() }()
+
+	enc, err := normalizeEncoding(e.Mapping.Encoding)
+	if err != nil {
+		return stats, err
+	}
+	delim, err := e.Mapping.delimiter()
+	if err != nil {
+		return stats, err
+	}
+	if enc == EncodingAuto {
+		if r, enc, err = detectEncoding(r); err != nil {
+			return stats, err
+		}
+	}
+	if enc == EncodingLatin1 {
+		r = newLatin1Reader(r)
+		w = newLatin1Writer(w)
+	}
+
+	cr := csv.NewReader(r)
+	cr.Comma = delim
+	cr.FieldsPerRecord = -1
+	cr.LazyQuotes = true
+	cw := csv.NewWriter(w)
+	cw.Comma = delim
+
+	var header []string
+	if e.Mapping.Header {
+		header, err = cr.Read()
+		if err == io.EOF {
+			return stats, errors.New("bulk: input is empty")
+		}
+		if err != nil {
+			return stats, err
+		}
+		if len(header) > 0 {
+			header[0] = strings.TrimPrefix(header[0], "\ufeff")
+		}
+		if err := cw.Write(append(append([]string(nil), header...), OutputColumns...)); err != nil {
+			return stats, err
+		}
+	}
+	idx, err := e.Mapping.resolve(header)
+	if err != nil {
+		return stats, err
+	}
+
+	for {
+		if err := ctx.Err(); err != nil {
+			cw.Flush()
+			return stats, err
+		}
+		row, err := cr.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return stats, err
+		}
+		stats.Rows++
+
+		out, err := e.enrichRow(ctx, row, idx, &stats, rec)
+		if err != nil {
+			cw.Flush()
+			return stats, err
+		}
+		if err := cw.Write(append(row, out...)); err != nil {
+			return stats, err
+		}
+	}
+	cw.Flush()
+	return stats, cw.Error()
+}
+
+func (e *Enricher) enrichRow(ctx context.Context, row []string, idx map[string]int, stats *Stats, rec *iptuapi.RunRecorder) ([]string, error) {
+	get := func(field string) string {
+		if i, ok := idx[field]; ok && i < len(row) {
+			return iptuapi.SanitizeText(row[i])
+		}
+		return ""
+	}
+	cidade := iptuapi.Cidade(strings.ToLower(get(FieldCidade)))
+	if cidade == "" {
+		cidade = e.Mapping.CidadePadrao
+	}
+
+	var (
+		sql, bairro                string
+		terreno, construida, venal float64
+		iptu                       float64
+		err                        error
+	)
+	start := time.Now()
+	switch {
+	case get(FieldSQL) != "":
+		var r *iptuapi.ConsultaSQLResult
+		r, err = e.Client.ConsultaSQL(ctx, get(FieldSQL), cidade)
+		if err == nil {
+			sql, bairro, terreno, construida, venal, iptu = r.SQL, r.Bairro, r.AreaTerreno, r.AreaConstruida, r.ValorVenalTotal, r.IPTUValor
+		}
+	case get(FieldLogradouro) != "":
+		var r *iptuapi.ConsultaEnderecoResult
+		r, err = e.Client.ConsultaEndereco(ctx, &iptuapi.ConsultaEnderecoParams{
+			Logradouro:  get(FieldLogradouro),
+			Numero:      get(FieldNumero),
+			Complemento: get(FieldComplemento),
+			Cidade:      cidade,
+		})
+		if err == nil {
+			sql, bairro, terreno, construida, venal, iptu = r.SQL, r.Bairro, r.AreaTerreno, r.AreaConstruida, r.ValorVenalTotal, r.IPTUValor
+		}
+	default:
+		stats.Skipped++
+		rec.Record(StatusSemEntrada, nil)
+		return statusColumns(StatusSemEntrada, ""), nil
+	}
+	rec.Latency(time.Since(start))
+
+	switch {
+	case err == nil:
+		stats.OK++
+		rec.Record(StatusOK, nil)
+	case errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded):
+		return nil, err
+	case iptuapi.IsNotFound(err):
+		stats.NotFound++
+		rec.Record(StatusNaoEncontrado, nil)
+		return statusColumns(StatusNaoEncontrado, ""), nil
+	default:
+		stats.Errors++
+		rec.Record(StatusErro, err)
+		return statusColumns(StatusErro, err.Error()), nil
+	}
+
+	return []string{
+		sql, bairro, formatFloat(terreno), formatFloat(construida),
+		formatFloat(venal), formatFloat(iptu), StatusOK, "",
+	}, nil
+}
+
+func statusColumns(status, msg string) []string {
+	out := make([]string, len(OutputColumns))
+	out[len(out)-2], out[len(out)-1] = status, msg
+	return out
+}
+
+func formatFloat(f float64) string {
+	if f == 0 {
+		return ""
+	}
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
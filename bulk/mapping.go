@@ -0,0 +1,120 @@
+// Package bulk enriches customer CSV files with IPTU API data.
+package bulk
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	iptuapi "github.com/raphaeltorquat0/iptuapi-go"
+)
+
+// Mapping describes the layout of an input CSV file. Column references are
+// header names (matched case-insensitively) or 1-based column numbers.
+//
+// A mapping file looks like:
+//
+//	{
+//	  "delimiter": ";",
+//	  "encoding": "latin1",
+//	  "header": true,
+//	  "columns": {"logradouro": "Endereço", "numero": "Nº", "cidade": "Município", "sql": "Inscrição"},
+//	  "cidade_padrao": "sp"
+//	}
+type Mapping struct {
+	// Delimiter is the field separator (default ",").
+	Delimiter string `json:"delimiter,omitempty"`
+	// Encoding is "auto" (default), "utf-8" or "latin1". Auto inspects the
+	// start of the input and falls back to Latin-1 when it is not valid
+	// UTF-8. Output uses the same encoding as the input.
+	Encoding string `json:"encoding,omitempty"`
+	// Header reports whether the first row holds column names.
+	Header bool `json:"header"`
+	// Columns maps the fields logradouro, numero, complemento, cidade and
+	// sql to input columns. Either sql or logradouro is required.
+	Columns map[string]string `json:"columns"`
+	// CidadePadrao is used for rows without a city column or value.
+	CidadePadrao iptuapi.Cidade `json:"cidade_padrao,omitempty"`
+}
+
+// Mapped field names.
+const (
+	FieldLogradouro  = "logradouro"
+	FieldNumero      = "numero"
+	FieldComplemento = "complemento"
+	FieldCidade      = "cidade"
+	FieldSQL         = "sql"
+)
+
+// LoadMapping reads a JSON mapping file.
+func LoadMapping(path string) (*Mapping, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var m Mapping
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("bulk: parsing mapping %s: %w", path, err)
+	}
+	return &m, nil
+}
+
+func (m *Mapping) delimiter() (rune, error) {
+	if m.Delimiter == "" {
+		return ',', nil
+	}
+	d := m.Delimiter
+	if d == `\t` || strings.EqualFold(d, "tab") {
+		d = "\t"
+	}
+	r, size := utf8.DecodeRuneInString(d)
+	if size != len(d) {
+		return 0, fmt.Errorf("bulk: delimiter must be a single character, got %q", m.Delimiter)
+	}
+	return r, nil
+}
+
+// resolve returns the column index of each mapped field.
+func (m *Mapping) resolve(header []string) (map[string]int, error) {
+	idx := make(map[string]int, len(m.Columns))
+	for field, ref := range m.Columns {
+		switch field {
+		case FieldLogradouro, FieldNumero, FieldComplemento, FieldCidade, FieldSQL:
+		default:
+			return nil, fmt.Errorf("bulk: unknown mapped field %q", field)
+		}
+		i, err := columnIndex(ref, header)
+		if err != nil {
+			return nil, fmt.Errorf("bulk: field %s: %w", field, err)
+		}
+		idx[field] = i
+	}
+	_, hasSQL := idx[FieldSQL]
+	_, hasLogradouro := idx[FieldLogradouro]
+	if !hasSQL && !hasLogradouro {
+		return nil, fmt.Errorf("bulk: mapping needs a %q or %q column", FieldSQL, FieldLogradouro)
+	}
+	return idx, nil
+}
+
+// columnIndex resolves ref to a header column, comparing names without
+// case or accents, or to a 1-based column number.
+func columnIndex(ref string, header []string) (int, error) {
+	want := strings.TrimSpace(ref)
+	for i, h := range header {
+		if strings.EqualFold(columnKey(h), columnKey(want)) {
+			return i, nil
+		}
+	}
+	if n, err := strconv.Atoi(want); err == nil && n >= 1 {
+		return n - 1, nil
+	}
+	return 0, fmt.Errorf("column %q not found", ref)
+}
+
+func columnKey(name string) string {
+	return iptuapi.FoldAccents(iptuapi.SanitizeText(name))
+}
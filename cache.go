@@ -0,0 +1,181 @@
+package iptuapi
+
+import (
+	"container/list"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"log/slog"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Cache stores raw API responses. Implementations must be safe for
+// concurrent use. Errors are logged and treated as misses, so a cache
+// outage never fails a call.
+type Cache interface {
+	// Get returns the value stored under key, if present and not expired.
+	Get(ctx context.Context, key string) ([]byte, bool, error)
+	// Set stores value under key for ttl.
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
+}
+
+// WithCache caches successful GET responses in cache for ttl, keyed by
+// endpoint and parameters, so repeated lookups of the same property do not
+// spend quota. Keys are scoped to the API key.
+func WithCache(cache Cache, ttl time.Duration) ClientOption {
+	return func(c *Client) {
+		c.cache = cache
+		c.cacheTTL = ttl
+	}
+}
+
+// CacheKey returns the key under which the response to a GET of endpoint
+// with params is cached, e.g. to invalidate one entry.
+func (c *Client) CacheKey(endpoint string, params url.Values) string {
+	return cacheKey(c.apiKey, endpoint, params)
+}
+
+func cacheKey(apiKey, endpoint string, params url.Values) string {
+	sum := sha256.Sum256([]byte(apiKey))
+	key := "iptuapi:" + hex.EncodeToString(sum[:]) + ":" + endpoint
+	if len(params) > 0 {
+		key += "?" + params.Encode()
+	}
+	return key
+}
+
+// CacheKeyEndpoint returns the endpoint path of a key built by CacheKey,
+// e.g. to pick a TTL with ClassifyEndpoint, or "" for other keys.
+func CacheKeyEndpoint(key string) string {
+	rest, ok := strings.CutPrefix(key, "iptuapi:")
+	if !ok {
+		return ""
+	}
+	_, endpoint, ok := strings.Cut(rest, ":")
+	if !ok {
+		return ""
+	}
+	endpoint, _, _ = strings.Cut(endpoint, "?")
+	return endpoint
+}
+
+func (c *Client) cacheGet(ctx context.Context, key string) ([]byte, bool) {
+	if c.cache == nil || key == "" {
+		return nil, false
+	}
+	b, ok, err := c.cache.Get(ctx, key)
+	if err != nil {
+		c.log(ctx, SubsystemCache, slog.LevelWarn, "Cache get failed", "key", key, "error", err)
+		ok = false
+	}
+	if ok {
+		c.stats.cacheHit()
+		c.log(ctx, SubsystemCache, slog.LevelDebug, "Cache hit", "key", key)
+		return b, true
+	}
+	c.stats.cacheMiss()
+	return nil, false
+}
+
+func (c *Client) cacheSet(ctx context.Context, key string, value []byte) {
+	if c.cache == nil || key == "" {
+		return
+	}
+	if err := c.cache.Set(ctx, key, value, c.cacheTTL); err != nil {
+		c.log(ctx, SubsystemCache, slog.LevelWarn, "Cache set failed", "key", key, "error", err)
+	}
+}
+
+// LRUCache is an in-memory Cache holding at most a fixed number of entries,
+// evicting the least recently used.
+type LRUCache struct {
+	mu      sync.Mutex
+	size    int
+	ll      *list.List
+	entries map[string]*list.Element
+
+	now func() time.Time
+}
+
+type lruEntry struct {
+	key     string
+	value   []byte
+	expires time.Time
+}
+
+// NewLRUCache returns a cache holding up to size entries (1000 if size is
+// not positive).
+func NewLRUCache(size int) *LRUCache {
+	if size <= 0 {
+		size = 1000
+	}
+	return &LRUCache{
+		size:    size,
+		ll:      list.New(),
+		entries: make(map[string]*list.Element),
+		now:     time.Now,
+	}
+}
+
+// Get implements Cache.
+func (l *LRUCache) Get(_ context.Context, key string) ([]byte, bool, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	el, ok := l.entries[key]
+	if !ok {
+		return nil, false, nil
+	}
+	e := el.Value.(*lruEntry)
+	if !e.expires.IsZero() && !l.now().Before(e.expires) {
+		l.remove(el)
+		return nil, false, nil
+	}
+	l.ll.MoveToFront(el)
+	return e.value, true, nil
+}
+
+// Set implements Cache. A non-positive ttl keeps the entry until evicted.
+func (l *LRUCache) Set(_ context.Context, key string, value []byte, ttl time.Duration) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	var expires time.Time
+	if ttl > 0 {
+		expires = l.now().Add(ttl)
+	}
+	if el, ok := l.entries[key]; ok {
+		e := el.Value.(*lruEntry)
+		e.value, e.expires = value, expires
+		l.ll.MoveToFront(el)
+		return nil
+	}
+	l.entries[key] = l.ll.PushFront(&lruEntry{key: key, value: value, expires: expires})
+	for l.ll.Len() > l.size {
+		l.remove(l.ll.Back())
+	}
+	return nil
+}
+
+// Delete removes key from the cache.
+func (l *LRUCache) Delete(key string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if el, ok := l.entries[key]; ok {
+		l.remove(el)
+	}
+}
+
+// Len returns the number of entries, including expired ones not yet
+// evicted.
+func (l *LRUCache) Len() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.ll.Len()
+}
+
+func (l *LRUCache) remove(el *list.Element) {
+	l.ll.Remove(el)
+	delete(l.entries, el.Value.(*lruEntry).key)
+}
@@ -0,0 +1,103 @@
+package iptuapi
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLRUCache(t *testing.T) {
+	ctx := context.Background()
+	now := time.Unix(1_700_000_000, 0)
+	l := NewLRUCache(2)
+	l.now = func() time.Time { return now }
+
+	require.NoError(t, l.Set(ctx, "a", []byte("1"), time.Minute))
+	require.NoError(t, l.Set(ctx, "b", []byte("2"), 0))
+	_, ok, _ := l.Get(ctx, "a")
+	assert.True(t, ok)
+
+	require.NoError(t, l.Set(ctx, "c", []byte("3"), time.Minute))
+	_, ok, _ = l.Get(ctx, "b")
+	assert.False(t, ok, "least recently used entry evicted")
+	assert.Equal(t, 2, l.Len())
+
+	now = now.Add(time.Minute)
+	_, ok, _ = l.Get(ctx, "a")
+	assert.False(t, ok, "expired")
+
+	l.Delete("c")
+	assert.Equal(t, 0, l.Len())
+}
+
+func TestWithCache(t *testing.T) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		if r.URL.Query().Get("cidade") == "bh" {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"detail":"nao encontrado"}`))
+			return
+		}
+		w.Write([]byte(`{"sql":"000.000.0000-0"}`))
+	}))
+	defer server.Close()
+
+	client := NewClient("test_key", WithBaseURL(server.URL),
+		WithRetry(&RetryConfig{MaxRetries: 0}), WithCache(NewLRUCache(10), time.Minute))
+	ctx := context.Background()
+
+	for i := 0; i < 3; i++ {
+		r, err := client.ConsultaSQL(ctx, "000.000.0000-0", CidadeSaoPaulo)
+		require.NoError(t, err)
+		assert.Equal(t, "000.000.0000-0", r.SQL)
+	}
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+
+	for i := 0; i < 2; i++ {
+		_, err := client.ConsultaSQL(ctx, "000.000.0000-0", CidadeBeloHorizonte)
+		assert.True(t, IsNotFound(err))
+	}
+	assert.Equal(t, int32(3), atomic.LoadInt32(&calls), "errors are not cached")
+
+	s := client.Stats()
+	assert.Equal(t, int64(2), s.CacheHits)
+	assert.Equal(t, int64(3), s.CacheMisses)
+
+	key := client.CacheKey("/consulta/sql/000.000.0000-0", url.Values{"cidade": {"sp"}})
+	assert.Equal(t, "/consulta/sql/000.000.0000-0", CacheKeyEndpoint(key))
+	assert.Equal(t, "", CacheKeyEndpoint("other"))
+	assert.Regexp(t, `^iptuapi:[0-9a-f]{64}:/`, key, "keys are scoped by the full key digest")
+
+	other := NewClient("other_key")
+	assert.NotEqual(t, client.CacheKey("/consulta/sql/x", nil), other.CacheKey("/consulta/sql/x", nil))
+}
+
+type failingCache struct{}
+
+func (failingCache) Get(context.Context, string) ([]byte, bool, error) {
+	return nil, false, errors.New("cache down")
+}
+
+func (failingCache) Set(context.Context, string, []byte, time.Duration) error {
+	return errors.New("cache down")
+}
+
+func TestCacheErrorsAreMisses(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"sql":"000.000.0000-0"}`))
+	}))
+	defer server.Close()
+
+	client := NewClient("test_key", WithBaseURL(server.URL), WithCache(failingCache{}, time.Minute))
+	_, err := client.ConsultaSQL(context.Background(), "000.000.0000-0", CidadeSaoPaulo)
+	assert.NoError(t, err)
+}
@@ -0,0 +1,73 @@
+package iptuapi
+
+import (
+	"context"
+	"sync/atomic"
+)
+
+// CallGroup cancels a set of SDK calls at once, e.g. every lookup started
+// by a screen the user navigated away from. Calls join the group by using
+// its Context; calls made under a group are counted in Client.Stats.
+type CallGroup struct {
+	ctx       context.Context
+	cancel    context.CancelFunc
+	cancelled atomic.Bool
+	inFlight  atomic.Int64
+}
+
+type callGroupKey struct{}
+
+// NewCallGroup returns a group whose context is derived from parent.
+// Cancelling parent also cancels the group's calls.
+func NewCallGroup(parent context.Context) *CallGroup {
+	ctx, cancel := context.WithCancel(parent)
+	g := &CallGroup{cancel: cancel}
+	g.ctx = context.WithValue(ctx, callGroupKey{}, g)
+	return g
+}
+
+// Context returns the context to pass to SDK calls that belong to the
+// group. Contexts derived from it belong to the group too.
+func (g *CallGroup) Context() context.Context {
+	return g.ctx
+}
+
+// Cancel aborts every in-flight call of the group and makes later calls
+// fail with context.Canceled. It is safe to call more than once.
+func (g *CallGroup) Cancel() {
+	g.cancelled.Store(true)
+	g.cancel()
+}
+
+// Cancelled reports whether Cancel was called.
+func (g *CallGroup) Cancelled() bool {
+	return g.cancelled.Load()
+}
+
+// InFlight reports the number of group calls currently running.
+func (g *CallGroup) InFlight() int {
+	return int(g.inFlight.Load())
+}
+
+// callGroupFrom returns the innermost CallGroup ctx belongs to, or nil.
+func callGroupFrom(ctx context.Context) *CallGroup {
+	g, _ := ctx.Value(callGroupKey{}).(*CallGroup)
+	return g
+}
+
+// trackCallGroup registers a call made with ctx in its group, if any. The
+// returned func must be called when the call finishes.
+func (c *Client) trackCallGroup(ctx context.Context) func() {
+	g := callGroupFrom(ctx)
+	if g == nil {
+		return func() {}
+	}
+	g.inFlight.Add(1)
+	c.stats.add(&c.stats.groupCalls)
+	return func() {
+		g.inFlight.Add(-1)
+		if g.Cancelled() && ctx.Err() != nil {
+			c.stats.add(&c.stats.groupCancelled)
+		}
+	}
+}
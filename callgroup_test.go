@@ -0,0 +1,69 @@
+package iptuapi
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCallGroupCancel(t *testing.T) {
+	started := make(chan struct{}, 3)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/iptu-tools/cidades" {
+			json.NewEncoder(w).Encode(CidadesResult{})
+			return
+		}
+		started <- struct{}{}
+		select {
+		case <-r.Context().Done():
+		case <-time.After(5 * time.Second):
+		}
+	}))
+	defer server.Close()
+	client := NewClient("test_key", WithBaseURL(server.URL), WithRetry(&RetryConfig{MaxRetries: 0}))
+
+	g := NewCallGroup(context.Background())
+	_, err := client.IPTUToolsCidades(g.Context())
+	require.NoError(t, err)
+
+	var wg sync.WaitGroup
+	errs := make([]error, 3)
+	for i := range errs {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			_, errs[i] = client.ConsultaSQL(g.Context(), "1", CidadeSaoPaulo)
+		}(i)
+	}
+	for range errs {
+		<-started
+	}
+	assert.Equal(t, 3, g.InFlight())
+
+	// Calls outside the group are unaffected.
+	_, err = client.IPTUToolsCidades(context.Background())
+	require.NoError(t, err)
+
+	g.Cancel()
+	wg.Wait()
+	for _, err := range errs {
+		assert.True(t, errors.Is(err, context.Canceled), "got %v", err)
+	}
+	assert.True(t, g.Cancelled())
+	assert.Zero(t, g.InFlight())
+
+	_, err = client.IPTUToolsCidades(g.Context())
+	assert.True(t, errors.Is(err, context.Canceled))
+
+	stats := client.Stats()
+	assert.Equal(t, int64(5), stats.GroupCalls)
+	assert.Equal(t, int64(4), stats.GroupCancelled)
+}
@@ -0,0 +1,189 @@
+package iptuapi
+
+import (
+	"context"
+	"sort"
+)
+
+// Plano is an API subscription plan.
+type Plano string
+
+// Plans in ascending order.
+const (
+	PlanoFree       Plano = "free"
+	PlanoStarter    Plano = "starter"
+	PlanoPro        Plano = "pro"
+	PlanoEnterprise Plano = "enterprise"
+)
+
+var planoRank = map[Plano]int{
+	PlanoFree:       0,
+	PlanoStarter:    1,
+	PlanoPro:        2,
+	PlanoEnterprise: 3,
+}
+
+// Inclui reports whether plan p grants access to features requiring other.
+// Unknown plans include nothing.
+func (p Plano) Inclui(other Plano) bool {
+	a, ok := planoRank[p]
+	if !ok {
+		return false
+	}
+	b, ok := planoRank[other]
+	return ok && a >= b
+}
+
+// Capability describes one endpoint for one city.
+type Capability struct {
+	// Cidade is the city code. Empty means the endpoint is not city-scoped.
+	Cidade Cidade `json:"cidade,omitempty"`
+	// Endpoint is the route template, e.g. "/consulta/sql/{sql}".
+	Endpoint string `json:"endpoint"`
+	// Disponivel reports whether the endpoint serves data for the city.
+	Disponivel bool `json:"disponivel"`
+	// AnoInicial is the earliest year with data, or 0 when unknown.
+	AnoInicial int `json:"ano_inicial,omitempty"`
+	// FormatoIdentificador describes the property identifier the endpoint
+	// takes, e.g. "000.000.0000-0" for the São Paulo SQL.
+	FormatoIdentificador string `json:"formato_identificador,omitempty"`
+	// Plano is the minimum plan required.
+	Plano Plano `json:"plano"`
+}
+
+// CapabilityMatrix lists the capabilities of every endpoint per city.
+type CapabilityMatrix struct {
+	// Versao identifies the matrix revision.
+	Versao       string       `json:"versao"`
+	Capabilities []Capability `json:"capabilities"`
+}
+
+// Lookup returns the capability of endpoint for cidade. Endpoints that are
+// not city-scoped match any cidade.
+func (m *CapabilityMatrix) Lookup(cidade Cidade, endpoint string) (Capability, bool) {
+	for _, c := range m.Capabilities {
+		if c.Endpoint == endpoint && (c.Cidade == cidade || c.Cidade == "") {
+			return c, true
+		}
+	}
+	return Capability{}, false
+}
+
+// Disponivel reports whether endpoint serves data for cidade.
+func (m *CapabilityMatrix) Disponivel(cidade Cidade, endpoint string) bool {
+	c, ok := m.Lookup(cidade, endpoint)
+	return ok && c.Disponivel
+}
+
+// Permitido reports whether endpoint serves data for cidade and is included
+// in plano.
+func (m *CapabilityMatrix) Permitido(cidade Cidade, endpoint string, plano Plano) bool {
+	c, ok := m.Lookup(cidade, endpoint)
+	return ok && c.Disponivel && plano.Inclui(c.Plano)
+}
+
+// Cidades returns the cities with at least one available endpoint, sorted.
+func (m *CapabilityMatrix) Cidades() []Cidade {
+	seen := make(map[Cidade]bool)
+	var out []Cidade
+	for _, c := range m.Capabilities {
+		if c.Cidade != "" && c.Disponivel && !seen[c.Cidade] {
+			seen[c.Cidade] = true
+			out = append(out, c.Cidade)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i] < out[j] })
+	return out
+}
+
+// CapabilitiesVersao is the revision of the matrix shipped with the SDK.
+const CapabilitiesVersao = "2026-10"
+
+type endpointSpec struct {
+	route string
+	plano Plano
+	// identificador marks endpoints keyed by the city property identifier.
+	identificador bool
+}
+
+var (
+	// cadastroEndpoints are served for cities with cadastral data.
+	cadastroEndpoints = []endpointSpec{
+		{route: "/consulta/endereco", plano: PlanoFree},
+		{route: "/consulta/cep/{cep}", plano: PlanoFree},
+		{route: "/consulta/sql/{sql}", plano: PlanoStarter, identificador: true},
+		{route: "/dados/iptu/historico/{sql}", plano: PlanoStarter, identificador: true},
+		{route: "/dados/itbi/transacoes", plano: PlanoPro},
+		{route: "/valuation/estimate", plano: PlanoPro},
+		{route: "/valuation/evaluate", plano: PlanoPro, identificador: true},
+		{route: "/valuation/comparables", plano: PlanoPro},
+		{route: "/valuation/statistics/{bairro}", plano: PlanoPro},
+		{route: "/valuation/estimate/batch", plano: PlanoEnterprise},
+	}
+	// toolsEndpoints are served for every city with an IPTU calendar.
+	toolsEndpoints = []endpointSpec{
+		{route: "/iptu-tools/calendario", plano: PlanoFree},
+		{route: "/iptu-tools/simulador", plano: PlanoFree},
+		{route: "/iptu-tools/isencao", plano: PlanoFree},
+		{route: "/iptu-tools/proximo-vencimento", plano: PlanoFree},
+	}
+	// globalEndpoints are not city-scoped.
+	globalEndpoints = []endpointSpec{
+		{route: "/consulta/zoneamento", plano: PlanoFree},
+		{route: "/iptu-tools/cidades", plano: PlanoFree},
+		{route: "/dicionario", plano: PlanoFree},
+		{route: "/dados/cnpj/{cnpj}", plano: PlanoStarter},
+		{route: "/dados/ipca", plano: PlanoStarter},
+		{route: "/dados/ipca/corrigir", plano: PlanoStarter},
+	}
+
+	// formatoIdentificador maps cities with cadastral data to the format of
+	// their property identifier.
+	formatoIdentificador = map[Cidade]string{
+		CidadeSaoPaulo:      "000.000.0000-0",
+		CidadeBeloHorizonte: "indice cadastral",
+		CidadeRecife:        "sequencial do imovel",
+	}
+
+	capabilityCidades = []Cidade{
+		CidadeSaoPaulo, CidadeBeloHorizonte, CidadeRecife, CidadePortoAlegre,
+		CidadeFortaleza, CidadeCuritiba, CidadeRioDeJaneiro, CidadeBrasilia,
+	}
+)
+
+// Capabilities returns the capability matrix shipped with the SDK: for each
+// city and endpoint, whether data is available, the identifier format and
+// the minimum plan. The result is a fresh copy the caller may modify. Use
+// Client.Capabilities for the live matrix.
+func Capabilities() *CapabilityMatrix {
+	m := &CapabilityMatrix{Versao: CapabilitiesVersao}
+	for _, e := range globalEndpoints {
+		m.Capabilities = append(m.Capabilities, Capability{Endpoint: e.route, Disponivel: true, Plano: e.plano})
+	}
+	for _, cidade := range capabilityCidades {
+		formato, cadastro := formatoIdentificador[cidade]
+		for _, e := range cadastroEndpoints {
+			c := Capability{Cidade: cidade, Endpoint: e.route, Disponivel: cadastro, Plano: e.plano}
+			if cadastro && e.identificador {
+				c.FormatoIdentificador = formato
+			}
+			m.Capabilities = append(m.Capabilities, c)
+		}
+		for _, e := range toolsEndpoints {
+			m.Capabilities = append(m.Capabilities, Capability{Cidade: cidade, Endpoint: e.route, Disponivel: true, Plano: e.plano})
+		}
+	}
+	return m
+}
+
+// Capabilities fetches the live capability matrix from the API, which
+// includes earliest data years and reflects cities added after this SDK
+// release.
+func (c *Client) Capabilities(ctx context.Context, opts ...RequestOption) (*CapabilityMatrix, error) {
+	var result CapabilityMatrix
+	err := c.doRequest(ctx, "GET", "/capabilities", nil, nil, &result, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
@@ -0,0 +1,57 @@
+package iptuapi
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCapabilities(t *testing.T) {
+	m := Capabilities()
+	assert.Equal(t, CapabilitiesVersao, m.Versao)
+
+	sql, ok := m.Lookup(CidadeSaoPaulo, "/consulta/sql/{sql}")
+	require.True(t, ok)
+	assert.True(t, sql.Disponivel)
+	assert.Equal(t, "000.000.0000-0", sql.FormatoIdentificador)
+	assert.Equal(t, PlanoStarter, sql.Plano)
+
+	assert.False(t, m.Disponivel(CidadeCuritiba, "/consulta/sql/{sql}"))
+	assert.True(t, m.Disponivel(CidadeCuritiba, "/iptu-tools/calendario"))
+	assert.True(t, m.Disponivel(CidadeCuritiba, "/dados/ipca"), "global endpoints match any city")
+	assert.False(t, m.Disponivel(CidadeSaoPaulo, "/inexistente"))
+
+	assert.True(t, m.Permitido(CidadeSaoPaulo, "/valuation/estimate", PlanoPro))
+	assert.True(t, m.Permitido(CidadeSaoPaulo, "/valuation/estimate", PlanoEnterprise))
+	assert.False(t, m.Permitido(CidadeSaoPaulo, "/valuation/estimate", PlanoStarter))
+	assert.False(t, m.Permitido(CidadeSaoPaulo, "/valuation/estimate", Plano("gold")))
+
+	assert.Contains(t, m.Cidades(), CidadeBrasilia)
+
+	m.Capabilities[0].Disponivel = false
+	assert.True(t, Capabilities().Capabilities[0].Disponivel, "each call returns a fresh copy")
+}
+
+func TestClientCapabilities(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/capabilities", r.URL.Path)
+		w.Write([]byte(`{"versao":"2026-11","capabilities":[
+			{"cidade":"sp","endpoint":"/dados/iptu/historico/{sql}","disponivel":true,
+			 "ano_inicial":2010,"formato_identificador":"000.000.0000-0","plano":"starter"}
+		]}`))
+	}))
+	defer server.Close()
+
+	client := NewClient("test_key", WithBaseURL(server.URL), WithRetry(&RetryConfig{MaxRetries: 0}))
+	m, err := client.Capabilities(context.Background())
+	require.NoError(t, err)
+
+	c, ok := m.Lookup(CidadeSaoPaulo, "/dados/iptu/historico/{sql}")
+	require.True(t, ok)
+	assert.Equal(t, 2010, c.AnoInicial)
+	assert.True(t, m.Permitido(CidadeSaoPaulo, "/dados/iptu/historico/{sql}", PlanoPro))
+}
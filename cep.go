@@ -0,0 +1,38 @@
+package iptuapi
+
+import (
+	"context"
+	"strings"
+)
+
+var cepSeparators = strings.NewReplacer("-", "", ".", "", " ", "")
+
+// cepDigits removes the separators of a CEP.
+func cepDigits(cep string) string {
+	return cepSeparators.Replace(cep)
+}
+
+// ConsultaCEPNumero returns the properties of cep at numero, e.g. the
+// units of a building, for leads that come with only a CEP and a number.
+// Numbers match ignoring leading zeros, spaces and case, so "0100" and
+// "100" are the same, but "100A" is not. It costs one ConsultaCEP call
+// and returns an empty slice when no property has that number.
+func (c *Client) ConsultaCEPNumero(ctx context.Context, cep, numero string, cidade Cidade, opts ...RequestOption) ([]ConsultaEnderecoResult, error) {
+	results, err := c.ConsultaCEP(ctx, cep, cidade, opts...)
+	if err != nil {
+		return nil, err
+	}
+	out := []ConsultaEnderecoResult{}
+	for _, r := range results {
+		if sameNumero(r.Numero, numero) {
+			out = append(out, r)
+		}
+	}
+	return out, nil
+}
+
+func sameNumero(a, b string) bool {
+	na, ra, oka := splitNumero(a)
+	nb, rb, okb := splitNumero(b)
+	return oka == okb && na == nb && ra == rb
+}
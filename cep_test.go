@@ -0,0 +1,41 @@
+package iptuapi
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConsultaCEPNumero(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/consulta/cep/01310-100", r.URL.Path)
+		w.Write([]byte(`[
+			{"sql":"1","numero":"100","complemento":"Apto 11"},
+			{"sql":"2","numero":"0100","complemento":"Apto 12"},
+			{"sql":"3","numero":"100A"},
+			{"sql":"4","numero":"102"}
+		]`))
+	}))
+	defer server.Close()
+
+	client := NewClient("test_key", WithBaseURL(server.URL))
+	ctx := context.Background()
+
+	results, err := client.ConsultaCEPNumero(ctx, "01310-100", "100", CidadeSaoPaulo)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"1", "2"}, []string{results[0].SQL, results[1].SQL})
+
+	results, err = client.ConsultaCEPNumero(ctx, "01310-100", "100 a", CidadeSaoPaulo)
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	assert.Equal(t, "3", results[0].SQL)
+
+	results, err = client.ConsultaCEPNumero(ctx, "01310-100", "999", CidadeSaoPaulo)
+	require.NoError(t, err)
+	assert.NotNil(t, results)
+	assert.Empty(t, results)
+}
@@ -0,0 +1,65 @@
+package iptuapi
+
+import (
+	"context"
+	"time"
+)
+
+// Clock is the time source and sleeper of the retry, rate-limit and
+// circuit breaker subsystems. Tests can inject a fake one with WithClock to
+// run backoffs and throttling without real sleeps.
+type Clock interface {
+	Now() time.Time
+	// Sleep waits for d, or returns ctx.Err() if ctx is done first.
+	Sleep(ctx context.Context, d time.Duration) error
+}
+
+// SystemClock is the real clock, used by default.
+var SystemClock Clock = systemClock{}
+
+type systemClock struct{}
+
+func (systemClock) Now() time.Time { return time.Now() }
+
+func (systemClock) Sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// WithClock replaces the clock of retry backoffs and Retry-After dates,
+// rate limit resets, API key rotation, base URL failover, the SelfCheck
+// probe TTL, RunBatch and ITBIPorMes retries, the ITBIPorMes default end
+// date and the circuit breaker. Context deadlines and latency measurements
+// still use real time. A RateLimiter set with WithRateLimiter and an
+// LRUCache set with WithCache keep their own clocks, since they may be
+// shared between clients; set them with RateLimiter.SetClock and
+// LRUCache.SetClock. A nil clock keeps the default.
+func WithClock(clock Clock) ClientOption {
+	return func(c *Client) {
+		if clock != nil {
+			c.clock = clock
+		}
+	}
+}
+
+// SetClock replaces the cache's clock, which expires entries, e.g. with a
+// fake in tests.
+func (l *LRUCache) SetClock(clock Clock) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.now = clock.Now
+}
+
+// SetClock replaces the limiter's clock, e.g. with a fake in tests.
+func (l *RateLimiter) SetClock(clock Clock) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.clock = clock
+	l.now = clock.Now
+}
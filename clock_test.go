@@ -0,0 +1,186 @@
+package iptuapi
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeClock advances instantly on Sleep and records the durations slept.
+type fakeClock struct {
+	mu     sync.Mutex
+	now    time.Time
+	sleeps []time.Duration
+}
+
+func newFakeClock() *fakeClock {
+	return &fakeClock{now: time.Unix(1_700_000_000, 0)}
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) Sleep(ctx context.Context, d time.Duration) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+	c.sleeps = append(c.sleeps, d)
+	return nil
+}
+
+func (c *fakeClock) slept() []time.Duration {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]time.Duration(nil), c.sleeps...)
+}
+
+func TestWithClockRetryBackoff(t *testing.T) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) < 4 {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		w.Write([]byte(`{"sql":"1"}`))
+	}))
+	defer server.Close()
+
+	clock := newFakeClock()
+	client := NewClient("test_key", WithBaseURL(server.URL), WithClock(clock), WithRetry(&RetryConfig{
+		MaxRetries: 3, InitialDelay: 10 * time.Second, MaxDelay: 30 * time.Second, BackoffFactor: 2,
+		RetryableStatus: []int{502},
+	}))
+
+	start := time.Now()
+	_, err := client.ConsultaSQL(context.Background(), "1", CidadeSaoPaulo)
+	require.NoError(t, err)
+	assert.Less(t, time.Since(start), time.Second)
+	assert.Equal(t, []time.Duration{10 * time.Second, 20 * time.Second, 30 * time.Second}, clock.slept())
+}
+
+func TestWithClockRateLimiter(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"sql":"1"}`))
+	}))
+	defer server.Close()
+
+	clock := newFakeClock()
+	limiter := NewRateLimiter(1, time.Minute)
+	limiter.SetClock(clock)
+	// The limiter may be shared, so WithClock leaves its clock alone.
+	client := NewClient("test_key", WithBaseURL(server.URL), WithClock(newFakeClock()),
+		WithRateLimiter(limiter))
+
+	for i := 0; i < 3; i++ {
+		_, err := client.ConsultaSQL(context.Background(), "1", CidadeSaoPaulo)
+		require.NoError(t, err)
+	}
+	assert.Equal(t, []time.Duration{time.Minute, time.Minute}, clock.slept())
+}
+
+func TestWithClockProbeTTLAndCache(t *testing.T) {
+	var probes atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		probes.Add(1)
+		w.Write([]byte(`{"cidades":[]}`))
+	}))
+	defer server.Close()
+
+	clock := newFakeClock()
+	cache := NewLRUCache(10)
+	cache.SetClock(clock)
+	client := NewClient("test_key", WithBaseURL(server.URL), WithClock(clock), WithCache(cache, time.Minute))
+	ctx := context.Background()
+
+	client.SelfCheck(ctx)
+	client.SelfCheck(ctx)
+	assert.Equal(t, int32(1), probes.Load(), "probe result cached within its TTL")
+	clock.Sleep(ctx, client.health.probeTTL)
+	result := client.SelfCheck(ctx)
+	assert.Equal(t, int32(2), probes.Load(), "probe TTL follows the injected clock")
+	assert.Equal(t, clock.Now(), result.CheckedAt)
+
+	require.NoError(t, cache.Set(ctx, "k", []byte("v"), time.Minute))
+	clock.Sleep(ctx, time.Minute)
+	_, ok, _ := cache.Get(ctx, "k")
+	assert.False(t, ok, "LRUCache entries expire on its clock")
+}
+
+func TestWithClockFailover(t *testing.T) {
+	down := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer down.Close()
+	up := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"sql":"1"}`))
+	}))
+	defer up.Close()
+
+	clock := newFakeClock()
+	client := NewClient("test_key", WithBaseURLs(down.URL, up.URL), WithClock(clock), WithRetry(&RetryConfig{MaxRetries: 0}))
+	_, err := client.ConsultaSQL(context.Background(), "1", CidadeSaoPaulo)
+	require.NoError(t, err)
+
+	status := client.BaseURLStatus()
+	require.Len(t, status, 2)
+	assert.Equal(t, down.URL, status[0].URL)
+	assert.False(t, status[0].Healthy)
+	assert.Equal(t, clock.Now().Add(defaultFailoverCooldown), status[0].DownUntil)
+
+	clock.Sleep(context.Background(), defaultFailoverCooldown)
+	assert.True(t, client.BaseURLStatus()[0].Healthy, "the cooldown runs on the injected clock")
+}
+
+func TestWithClockRateLimitReset(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-RateLimit-Limit", "100")
+		w.Header().Set("X-RateLimit-Remaining", "10")
+		w.Header().Set("X-RateLimit-Reset", "60")
+		w.Write([]byte(`{"sql":"1"}`))
+	}))
+	defer server.Close()
+
+	clock := newFakeClock()
+	client := NewClient("test_key", WithBaseURL(server.URL), WithClock(clock))
+	_, err := client.ConsultaSQL(context.Background(), "1", CidadeSaoPaulo)
+	require.NoError(t, err)
+	assert.Equal(t, clock.Now().Add(time.Minute), client.RateLimitState().ResetTime)
+}
+
+func TestWithClockITBIWindowRetry(t *testing.T) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte(`[]`))
+	}))
+	defer server.Close()
+
+	clock := newFakeClock()
+	client := NewClient("test_key", WithBaseURL(server.URL), WithClock(clock), WithRetry(&RetryConfig{MaxRetries: 0}))
+	it := client.ITBIPorMes(&ITBIParams{
+		DataInicio: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+		DataFim:    time.Date(2024, 1, 31, 0, 0, 0, 0, time.UTC),
+	}, &ITBIWindowOptions{Backoff: 5 * time.Second})
+
+	start := time.Now()
+	require.True(t, it.Next(context.Background()))
+	require.NoError(t, it.Err())
+	assert.Less(t, time.Since(start), time.Second)
+	assert.Equal(t, []time.Duration{5 * time.Second}, clock.slept())
+}
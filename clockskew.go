@@ -0,0 +1,44 @@
+package iptuapi
+
+import (
+	"net/http"
+	"time"
+)
+
+// resetDeltaThreshold separates X-RateLimit-Reset values sent as seconds
+// until the reset from Unix timestamps.
+const resetDeltaThreshold = 1_000_000_000
+
+// ClockSkew returns the local clock minus the API clock, measured from the
+// Date header of the last response that had one; positive means the local
+// clock is ahead. Its resolution is one second.
+func (c *Client) ClockSkew() time.Duration {
+	return time.Duration(c.clockSkew.Load())
+}
+
+// observeClock records the skew between received and the Date header, if
+// present, and returns the current skew.
+func (c *Client) observeClock(date string, received time.Time) time.Duration {
+	if t, err := http.ParseTime(date); err == nil {
+		// Date is truncated to the second, so only whole seconds are
+		// meaningful.
+		c.clockSkew.Store(int64(received.Sub(t).Truncate(time.Second)))
+	}
+	return c.ClockSkew()
+}
+
+// localResetTime converts an X-RateLimit-Reset value to the local clock.
+// Small values are taken as seconds from now; timestamps are shifted by the
+// measured skew. Resets in the past are clamped to received.
+func (c *Client) localResetTime(reset int64, skew time.Duration, received time.Time) time.Time {
+	var t time.Time
+	if reset < resetDeltaThreshold {
+		t = received.Add(time.Duration(reset) * time.Second)
+	} else {
+		t = time.Unix(reset, 0).Add(skew)
+	}
+	if t.Before(received) {
+		return received
+	}
+	return t
+}
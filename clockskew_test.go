@@ -0,0 +1,48 @@
+package iptuapi
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResetTimeCorrectedForClockSkew(t *testing.T) {
+	// The API clock is an hour behind the local one.
+	serverNow := time.Now().Add(-time.Hour)
+	var reset string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Date", serverNow.UTC().Format(http.TimeFormat))
+		w.Header().Set("X-RateLimit-Limit", "100")
+		w.Header().Set("X-RateLimit-Remaining", "0")
+		w.Header().Set("X-RateLimit-Reset", reset)
+		w.Write([]byte(`{"sql":"000.000.0000-0"}`))
+	}))
+	defer server.Close()
+	client := NewClient("test_key", WithBaseURL(server.URL))
+	ctx := context.Background()
+
+	reset = strconv.FormatInt(serverNow.Add(time.Minute).Unix(), 10)
+	_, err := client.ConsultaSQL(ctx, "000.000.0000-0", CidadeSaoPaulo)
+	require.NoError(t, err)
+	assert.InDelta(t, time.Hour.Seconds(), client.ClockSkew().Seconds(), 2)
+	assert.InDelta(t, time.Minute.Seconds(), time.Until(client.RateLimit.ResetTime).Seconds(), 2,
+		"reset is a minute away, not an hour in the past")
+
+	reset = "30"
+	_, err = client.ConsultaSQL(ctx, "000.000.0000-0", CidadeSaoPaulo)
+	require.NoError(t, err)
+	assert.InDelta(t, 30, time.Until(client.RateLimit.ResetTime).Seconds(), 2, "small values are seconds from now")
+}
+
+func TestLocalResetTimeClampsPast(t *testing.T) {
+	c := NewClient("test_key")
+	now := time.Unix(1_700_000_000, 0)
+	assert.Equal(t, now, c.localResetTime(now.Add(-time.Hour).Unix(), 0, now))
+	assert.Equal(t, now.Add(time.Minute), c.localResetTime(now.Unix(), time.Minute, now))
+}
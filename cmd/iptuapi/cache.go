@@ -0,0 +1,57 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+
+	"github.com/raphaeltorquat0/iptuapi-go/iptuapicache/diskcache"
+)
+
+func runCacheMigrate(ctx context.Context, args []string, stdout, stderr io.Writer) error {
+	fs := flag.NewFlagSet("cache migrate", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	dir := fs.String("dir", "", "disk cache directory")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	cache, err := openCache(*dir)
+	if err != nil {
+		return err
+	}
+
+	report, err := cache.Migrate(ctx)
+	enc := json.NewEncoder(stdout)
+	enc.SetIndent("", "  ")
+	if encErr := enc.Encode(report); encErr != nil && err == nil {
+		err = encErr
+	}
+	return err
+}
+
+func runCachePurge(ctx context.Context, args []string, stdout, stderr io.Writer) error {
+	fs := flag.NewFlagSet("cache purge", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	dir := fs.String("dir", "", "disk cache directory")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	cache, err := openCache(*dir)
+	if err != nil {
+		return err
+	}
+
+	n, err := cache.Purge(ctx)
+	fmt.Fprintf(stdout, "removed %d entries\n", n)
+	return err
+}
+
+func openCache(dir string) (*diskcache.Cache, error) {
+	if dir == "" {
+		return nil, errors.New("--dir is required")
+	}
+	return diskcache.New(dir, diskcache.Options{})
+}
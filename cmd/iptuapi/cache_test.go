@@ -0,0 +1,50 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/raphaeltorquat0/iptuapi-go/iptuapicache/diskcache"
+)
+
+func TestCacheMigrateAndPurge(t *testing.T) {
+	dir := t.TempDir()
+	cache, err := diskcache.New(dir, diskcache.Options{})
+	require.NoError(t, err)
+	ctx := context.Background()
+	require.NoError(t, cache.Set(ctx, "iptuapi:k:/consulta/sql/1", []byte(`{"sql":"1"}`), time.Hour))
+	require.NoError(t, cache.Set(ctx, "iptuapi:k:/consulta/sql/2", []byte(`{"sql":"2"}`), time.Hour))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "corrompido.entry"), []byte("lixo"), 0o600))
+
+	code, stdout, stderr := runCLI(t, "cache", "migrate", "--dir", dir)
+	require.Equal(t, 0, code, stderr)
+	var report diskcache.MigrateReport
+	require.NoError(t, json.Unmarshal([]byte(stdout), &report), stdout)
+	assert.Equal(t, diskcache.MigrateReport{Kept: 2, Removed: 1}, report)
+
+	_, ok, err := cache.Get(ctx, "iptuapi:k:/consulta/sql/1")
+	require.NoError(t, err)
+	assert.True(t, ok, "migrate keeps current entries")
+
+	code, stdout, stderr = runCLI(t, "cache", "purge", "--dir", dir)
+	require.Equal(t, 0, code, stderr)
+	assert.Equal(t, "removed 2 entries\n", stdout)
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	assert.Empty(t, entries)
+}
+
+func TestCacheRequiresDir(t *testing.T) {
+	for _, verb := range []string{"migrate", "purge"} {
+		code, _, stderr := runCLI(t, "cache", verb)
+		assert.Equal(t, 1, code, verb)
+		assert.Contains(t, stderr, "--dir is required", verb)
+	}
+}
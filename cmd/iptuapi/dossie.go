@@ -0,0 +1,201 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	iptuapi "github.com/raphaeltorquat0/iptuapi-go"
+)
+
+// dossieStats summarizes a dossie run.
+type dossieStats struct {
+	Total     int               `json:"total"`
+	Generated int               `json:"generated"`
+	Skipped   int               `json:"skipped"`
+	Failed    int               `json:"failed"`
+	Errors    map[string]string `json:"errors,omitempty"`
+}
+
+func runDossie(ctx context.Context, args []string, stdout, stderr io.Writer) error {
+	fs := flag.NewFlagSet("dossie", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	var cf clientFlags
+	cf.register(fs)
+	input := fs.String("input", "-", "file with one SQL per line (- for stdin)")
+	outDir := fs.String("out", "dossies", "output directory")
+	format := fs.String("format", "pdf", "output format: pdf, json or txt")
+	cidade := fs.String("cidade", string(iptuapi.CidadeSaoPaulo), "city code")
+	minInterval := fs.Duration("min-interval", 0, "minimum delay between dossiers")
+	reserve := fs.Int("reserve", 5, "pause until the quota resets when this many requests remain")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	switch *format {
+	case "pdf", "json", "txt":
+	default:
+		return fmt.Errorf("unknown format %q (want pdf, json or txt)", *format)
+	}
+
+	client, err := cf.client()
+	if err != nil {
+		return err
+	}
+	sqls, err := readLines(*input)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(*outDir, 0o755); err != nil {
+		return err
+	}
+
+	stats := dossieStats{Total: len(sqls), Errors: map[string]string{}}
+	var last time.Time
+	for _, sql := range sqls {
+		path := filepath.Join(*outDir, safeFileName(sql)+"."+*format)
+		// Files are renamed into place only when complete, so an existing
+		// file means the SQL was done by an earlier, interrupted run.
+		if _, err := os.Stat(path); err == nil {
+			stats.Skipped++
+			continue
+		}
+
+		if err := pace(ctx, client, last, *minInterval, *reserve); err != nil {
+			return finishDossie(stdout, stats, err)
+		}
+		last = time.Now()
+
+		d, err := client.Dossie(ctx, sql, iptuapi.Cidade(*cidade))
+		if err != nil {
+			if ctx.Err() != nil {
+				return finishDossie(stdout, stats, ctx.Err())
+			}
+			stats.Failed++
+			stats.Errors[sql] = err.Error()
+			continue
+		}
+		if err := writeDossie(path, *format, d); err != nil {
+			return finishDossie(stdout, stats, err)
+		}
+		stats.Generated++
+	}
+	return finishDossie(stdout, stats, nil)
+}
+
+func finishDossie(stdout io.Writer, stats dossieStats, err error) error {
+	enc := json.NewEncoder(stdout)
+	enc.SetIndent("", "  ")
+	if encErr := enc.Encode(stats); encErr != nil && err == nil {
+		err = encErr
+	}
+	return err
+}
+
+// pace waits for minInterval since last and, when the last response showed
+// reserve or fewer requests left in the quota window, until the window resets.
+func pace(ctx context.Context, client *iptuapi.Client, last time.Time, minInterval time.Duration, reserve int) error {
+	wait := time.Until(last.Add(minInterval))
+	if rl := client.RateLimitState(); rl.Limit > 0 && rl.Remaining <= reserve {
+		if untilReset := time.Until(rl.ResetTime); untilReset > wait {
+			wait = untilReset
+		}
+	}
+	if wait <= 0 {
+		return nil
+	}
+	select {
+	case <-time.After(wait):
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// writeDossie renders d and moves it into place atomically.
+func writeDossie(path, format string, d *iptuapi.Dossie) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".dossie-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	switch format {
+	case "json":
+		enc := json.NewEncoder(tmp)
+		enc.SetIndent("", "  ")
+		err = enc.Encode(d)
+	case "txt":
+		_, err = io.WriteString(tmp, strings.Join(dossieLines(d), "\n")+"\n")
+	default:
+		err = writePDF(tmp, "Dossiê "+d.SQL, dossieLines(d))
+	}
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// dossieLines renders a dossier as plain text lines.
+func dossieLines(d *iptuapi.Dossie) []string {
+	im := d.Imovel
+	lines := []string{
+		"Dossiê do imóvel " + d.SQL,
+		fmt.Sprintf("Cidade: %s    Gerado em: %s", d.Cidade, d.GeradoEm.Format("02/01/2006 15:04 MST")),
+		"",
+		"CADASTRO",
+		fmt.Sprintf("Endereço: %s %s, %s", im.Logradouro, im.Numero, im.Bairro),
+		fmt.Sprintf("Área do terreno: %.2f m²    Área construída: %.2f m²", im.AreaTerreno, im.AreaConstruida),
+		fmt.Sprintf("Valor venal: R$ %.2f    IPTU: R$ %.2f", im.ValorVenalTotal, im.IPTUValor),
+	}
+
+	if len(d.Historico) > 0 {
+		lines = append(lines, "", "HISTÓRICO IPTU")
+		for _, h := range d.Historico {
+			lines = append(lines, fmt.Sprintf("%d  valor venal R$ %.2f  IPTU R$ %.2f", h.Ano, h.ValorVenalTotal, h.IPTUValor))
+		}
+	}
+	if len(d.Transacoes) > 0 {
+		lines = append(lines, "", "TRANSAÇÕES ITBI")
+		for _, t := range d.Transacoes {
+			lines = append(lines, fmt.Sprintf("%s  %s  R$ %.2f", t.DataTransacao, t.NaturezaTransacao, t.ValorTransacao))
+		}
+	}
+	if s := d.Estatisticas; s != nil {
+		lines = append(lines, "", "ESTATÍSTICAS DO BAIRRO "+strings.ToUpper(s.Bairro),
+			fmt.Sprintf("Imóveis: %d  Mediana: R$ %.2f  Média: R$ %.2f  Mín: R$ %.2f  Máx: R$ %.2f",
+				s.TotalImoveis, s.Mediana, s.Media, s.Min, s.Max))
+	}
+	if len(d.Avisos) > 0 {
+		lines = append(lines, "", "AVISOS")
+		for _, a := range d.Avisos {
+			lines = append(lines, fmt.Sprintf("%s (%s): %s", a.Codigo, a.Fonte, a.Mensagem))
+		}
+	}
+	return lines
+}
+
+// safeFileName maps a SQL to a file name, keeping digits, letters and dashes.
+func safeFileName(s string) string {
+	name := strings.Map(func(r rune) rune {
+		switch {
+		case r >= '0' && r <= '9', r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r == '-':
+			return r
+		case r == '.':
+			return '-'
+		}
+		return -1
+	}, s)
+	if name == "" {
+		return "sem-sql"
+	}
+	return name
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	iptuapi "github.com/raphaeltorquat0/iptuapi-go"
+)
+
+func TestSafeFileName(t *testing.T) {
+	tests := []struct{ in, want string }{
+		{"000.000.0001-1", "000-000-0001-1"},
+		{"../../etc/passwd", "----etcpasswd"},
+		{`..\..\windows`, "----windows"},
+		{"a/b\\c", "abc"},
+		{"", "sem-sql"},
+		{"/", "sem-sql"},
+	}
+	for _, tt := range tests {
+		got := safeFileName(tt.in)
+		assert.Equal(t, tt.want, got, tt.in)
+		assert.NotContains(t, got, "..", tt.in)
+		assert.Equal(t, got, filepath.Base(got), tt.in)
+	}
+}
+
+func TestDossieResumeSkipsExistingFiles(t *testing.T) {
+	var mu sync.Mutex
+	var paths []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		paths = append(paths, r.URL.Path)
+		mu.Unlock()
+		if sql, ok := strings.CutPrefix(r.URL.Path, "/consulta/sql/"); ok {
+			json.NewEncoder(w).Encode(map[string]interface{}{"sql": sql, "logradouro": "Rua Augusta", "bairro": "Consolacao"})
+			return
+		}
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(`{"detail":"sem dados"}`))
+	}))
+	defer server.Close()
+
+	out := t.TempDir()
+	done := filepath.Join(out, "000-000-0001-1.txt")
+	require.NoError(t, os.WriteFile(done, []byte("gerado antes\n"), 0o600))
+	input := writeFile(t, "sqls.txt", "000.000.0001-1\n000.000.0002-2\n")
+
+	code, stdout, stderr := runCLI(t, "dossie", "--input", input, "--out", out, "--format", "txt", "--base-url", server.URL)
+	require.Equal(t, 0, code, stderr)
+	var stats dossieStats
+	require.NoError(t, json.Unmarshal([]byte(stdout), &stats), stdout)
+	assert.Equal(t, dossieStats{Total: 2, Generated: 1, Skipped: 1}, stats)
+
+	data, err := os.ReadFile(done)
+	require.NoError(t, err)
+	assert.Equal(t, "gerado antes\n", string(data), "existing outputs are left alone")
+	data, err = os.ReadFile(filepath.Join(out, "000-000-0002-2.txt"))
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "Rua Augusta")
+	for _, p := range paths {
+		assert.NotContains(t, p, "000.000.0001-1", "skipped SQLs spend no quota")
+	}
+
+	entries, err := os.ReadDir(out)
+	require.NoError(t, err)
+	assert.Len(t, entries, 2, "no temporary files are left behind")
+}
+
+func TestPace(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-RateLimit-Limit", "100")
+		w.Header().Set("X-RateLimit-Remaining", "3")
+		w.Header().Set("X-RateLimit-Reset", "3600")
+		w.Write([]byte(`{"sql":"1"}`))
+	}))
+	defer server.Close()
+	client := iptuapi.NewClient("test_key", iptuapi.WithBaseURL(server.URL))
+	ctx := context.Background()
+
+	start := time.Now()
+	require.NoError(t, pace(ctx, client, start, 50*time.Millisecond, 5))
+	assert.GreaterOrEqual(t, time.Since(start), 50*time.Millisecond, "waits for the minimum interval")
+
+	_, err := client.ConsultaSQL(ctx, "1", iptuapi.CidadeSaoPaulo)
+	require.NoError(t, err)
+	require.NoError(t, pace(ctx, client, time.Time{}, 0, 2), "above the reserve there is no wait")
+
+	short, cancel := context.WithTimeout(ctx, 20*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, pace(short, client, time.Time{}, 0, 5), context.DeadlineExceeded,
+		"within the reserve it waits for the quota reset")
+}
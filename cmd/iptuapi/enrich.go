@@ -0,0 +1,69 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"io"
+	"os"
+
+	"github.com/raphaeltorquat0/iptuapi-go/bulk"
+)
+
+func runEnrich(ctx context.Context, args []string, stdout, stderr io.Writer) error {
+	fs := flag.NewFlagSet("enrich", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	var cf clientFlags
+	cf.register(fs)
+	input := fs.String("input", "-", "CSV file to enrich (- for stdin)")
+	output := fs.String("output", "-", "enriched CSV file (- for stdout)")
+	mappingPath := fs.String("mapping", "", "JSON mapping of input columns, delimiter and encoding")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	mapping := &bulk.Mapping{Header: true, Columns: map[string]string{
+		bulk.FieldLogradouro: bulk.FieldLogradouro,
+		bulk.FieldNumero:     bulk.FieldNumero,
+		bulk.FieldCidade:     bulk.FieldCidade,
+	}}
+	if *mappingPath != "" {
+		var err error
+		if mapping, err = bulk.LoadMapping(*mappingPath); err != nil {
+			return err
+		}
+	}
+	client, err := cf.client()
+	if err != nil {
+		return err
+	}
+
+	var in io.Reader = os.Stdin
+	if *input != "-" {
+		f, err := os.Open(*input)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		in = f
+	}
+	out, summary := io.Writer(stdout), stderr
+	if *output != "-" {
+		f, err := os.Create(*output)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		out, summary = f, stdout
+	}
+
+	e := &bulk.Enricher{Client: client, Mapping: *mapping}
+	stats, err := e.Enrich(ctx, in, out)
+
+	enc := json.NewEncoder(summary)
+	enc.SetIndent("", "  ")
+	if encErr := enc.Encode(stats); encErr != nil && err == nil {
+		err = encErr
+	}
+	return err
+}
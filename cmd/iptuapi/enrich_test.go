@@ -0,0 +1,93 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/raphaeltorquat0/iptuapi-go/bulk"
+)
+
+func newEnrichAPI(t *testing.T) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/consulta/endereco" && r.URL.Query().Get("logradouro") == "Rua Inexistente":
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"detail":"Imovel nao encontrado"}`))
+		case r.URL.Path == "/consulta/endereco":
+			json.NewEncoder(w).Encode(map[string]interface{}{"sql": "000.000.0001-1", "bairro": "Se", "valor_venal_total": 500000})
+		case strings.HasPrefix(r.URL.Path, "/consulta/sql/"):
+			json.NewEncoder(w).Encode(map[string]interface{}{"sql": "000.000.0002-2", "bairro": "Moema"})
+		default:
+			t.Errorf("unexpected path %s", r.URL.Path)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func readCSV(t *testing.T, data string, delim rune) [][]string {
+	t.Helper()
+	r := csv.NewReader(strings.NewReader(data))
+	r.Comma = delim
+	rows, err := r.ReadAll()
+	require.NoError(t, err)
+	return rows
+}
+
+func TestEnrichDefaultMapping(t *testing.T) {
+	server := newEnrichAPI(t)
+	input := writeFile(t, "clientes.csv", "logradouro,numero,cidade\nRua Sao Bento,100,sp\nRua Inexistente,1,sp\n")
+	output := filepath.Join(t.TempDir(), "enriquecido.csv")
+
+	code, stdout, stderr := runCLI(t, "enrich", "--input", input, "--output", output, "--base-url", server.URL)
+	require.Equal(t, 0, code, stderr)
+
+	var stats bulk.Stats
+	require.NoError(t, json.Unmarshal([]byte(stdout), &stats), "with --output the summary goes to stdout")
+	assert.Equal(t, 2, stats.Rows)
+	assert.Equal(t, 1, stats.OK)
+	assert.Equal(t, 1, stats.NotFound)
+
+	data, err := os.ReadFile(output)
+	require.NoError(t, err)
+	rows := readCSV(t, string(data), ',')
+	require.Len(t, rows, 3)
+	assert.Equal(t, append([]string{"logradouro", "numero", "cidade"}, bulk.OutputColumns...), rows[0])
+	assert.Equal(t, "000.000.0001-1", rows[1][3])
+	assert.Equal(t, bulk.StatusOK, rows[1][9])
+	assert.Equal(t, bulk.StatusNaoEncontrado, rows[2][9])
+}
+
+func TestEnrichMappingToStdout(t *testing.T) {
+	server := newEnrichAPI(t)
+	mapping := writeFile(t, "mapping.json", `{"delimiter":";","header":true,"columns":{"sql":"Inscricao"},"cidade_padrao":"sp"}`)
+	input := writeFile(t, "clientes.csv", "Nome;Inscricao\nAna;000.000.0002-2\nBia;\n")
+
+	code, stdout, stderr := runCLI(t, "enrich", "--input", input, "--mapping", mapping, "--base-url", server.URL)
+	require.Equal(t, 0, code, stderr)
+
+	rows := readCSV(t, stdout, ';')
+	require.Len(t, rows, 3)
+	assert.Equal(t, "Moema", rows[1][3])
+	assert.Equal(t, bulk.StatusSemEntrada, rows[2][8])
+
+	var stats bulk.Stats
+	require.NoError(t, json.Unmarshal([]byte(stderr), &stats), "with stdout output the summary goes to stderr")
+	assert.Equal(t, 1, stats.OK)
+	assert.Equal(t, 1, stats.Skipped)
+}
+
+func TestEnrichBadMapping(t *testing.T) {
+	code, _, stderr := runCLI(t, "enrich", "--mapping", filepath.Join(t.TempDir(), "missing.json"))
+	assert.Equal(t, 1, code)
+	assert.Contains(t, stderr, "missing.json")
+}
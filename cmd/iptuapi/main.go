@@ -0,0 +1,136 @@
+// Command iptuapi is a command-line client for the IPTU API.
+//
+// Usage:
+//
+//	iptuapi mirror sync --db mirror.db --input sqls.txt [--cidade sp]
+//	iptuapi dossie --input sqls.txt --out dir/ [--format pdf]
+//	iptuapi enrich --input clientes.csv --output enriquecido.csv [--mapping mapping.json]
+//	iptuapi valuation batch --input props.csv --out valued.csv [--cidade sp]
+//	iptuapi cache migrate --dir /var/cache/iptuapi
+//	iptuapi cache purge --dir /var/cache/iptuapi
+//
+// The API key is read from the IPTU_API_KEY environment variable.
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+
+	iptuapi "github.com/raphaeltorquat0/iptuapi-go"
+)
+
+const usage = `usage: iptuapi <command> [flags]
+
+commands:
+  mirror sync   download records into a local SQLite mirror
+  dossie        generate per-property dossiers (pdf, json or txt)
+  enrich        append IPTU data to the rows of a CSV file
+  valuation batch
+                estimate the value of each row of a CSV file, with audit columns
+  cache migrate upgrade disk cache entries after an SDK upgrade
+  cache purge   remove every disk cache entry
+
+Run "iptuapi <command> -h" for command flags.
+The API key is read from the IPTU_API_KEY environment variable.
+`
+
+type command func(ctx context.Context, args []string, stdout, stderr io.Writer) error
+
+var commands = map[string]command{
+	"mirror sync":     runMirrorSync,
+	"dossie":          runDossie,
+	"enrich":          runEnrich,
+	"valuation batch": runValuationBatch,
+	"cache migrate":   runCacheMigrate,
+	"cache purge":     runCachePurge,
+}
+
+func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	os.Exit(run(ctx, os.Args[1:], os.Stdout, os.Stderr))
+}
+
+func run(ctx context.Context, args []string, stdout, stderr io.Writer) int {
+	cmd, rest := lookup(args)
+	if cmd == nil {
+		fmt.Fprint(stderr, usage)
+		return 2
+	}
+	if err := cmd(ctx, rest, stdout, stderr); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return 2
+		}
+		fmt.Fprintf(stderr, "iptuapi: %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+// lookup resolves the longest command name (one or two words) in args.
+func lookup(args []string) (command, []string) {
+	if len(args) >= 2 {
+		if cmd, ok := commands[args[0]+" "+args[1]]; ok {
+			return cmd, args[2:]
+		}
+	}
+	if len(args) >= 1 {
+		if cmd, ok := commands[args[0]]; ok {
+			return cmd, args[1:]
+		}
+	}
+	return nil, nil
+}
+
+// clientFlags registers the flags shared by every command that calls the API.
+type clientFlags struct {
+	baseURL string
+}
+
+func (f *clientFlags) register(fs *flag.FlagSet) {
+	fs.StringVar(&f.baseURL, "base-url", "", "override the API base URL")
+}
+
+func (f *clientFlags) client() (*iptuapi.Client, error) {
+	key := os.Getenv("IPTU_API_KEY")
+	if key == "" {
+		return nil, errors.New("IPTU_API_KEY environment variable is required")
+	}
+	var opts []iptuapi.ClientOption
+	if f.baseURL != "" {
+		opts = append(opts, iptuapi.WithBaseURL(f.baseURL))
+	}
+	return iptuapi.NewClient(key, opts...), nil
+}
+
+// readLines reads non-empty, non-comment lines from path ("-" for stdin).
+func readLines(path string) ([]string, error) {
+	var r io.Reader = os.Stdin
+	if path != "-" {
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		r = f
+	}
+
+	var lines []string
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		lines = append(lines, line)
+	}
+	return lines, scanner.Err()
+}
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// runCLI runs the command line with an API key set and returns the exit
+// code, stdout and stderr.
+func runCLI(t *testing.T, args ...string) (int, string, string) {
+	t.Helper()
+	t.Setenv("IPTU_API_KEY", "test_key")
+	var stdout, stderr bytes.Buffer
+	code := run(context.Background(), args, &stdout, &stderr)
+	return code, stdout.String(), stderr.String()
+}
+
+// writeFile writes content to name in a temporary directory and returns
+// its path.
+func writeFile(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestRunUsage(t *testing.T) {
+	code, _, stderr := runCLI(t, "unknown")
+	assert.Equal(t, 2, code)
+	assert.Contains(t, stderr, "usage: iptuapi")
+
+	code, _, stderr = runCLI(t, "mirror", "sync", "--no-such-flag")
+	assert.Equal(t, 1, code)
+	assert.Contains(t, stderr, "no-such-flag")
+}
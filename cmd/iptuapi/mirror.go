@@ -0,0 +1,88 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+
+	iptuapi "github.com/raphaeltorquat0/iptuapi-go"
+	"github.com/raphaeltorquat0/iptuapi-go/mirror"
+)
+
+func runMirrorSync(ctx context.Context, args []string, stdout, stderr io.Writer) error {
+	fs := flag.NewFlagSet("mirror sync", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	var cf clientFlags
+	cf.register(fs)
+	dbPath := fs.String("db", "iptuapi-mirror.db", "SQLite database file")
+	driver := fs.String("driver", "sqlite", "database/sql driver name")
+	input := fs.String("input", "-", "file with one SQL or CEP per line (- for stdin)")
+	byCEP := fs.Bool("cep", false, "input lists CEPs; refresh them differentially using updated-since")
+	cidade := fs.String("cidade", string(iptuapi.CidadeSaoPaulo), "city code")
+	maxAge := fs.Duration("max-age", 0, "refetch records older than this (0 keeps stored records)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	db, err := openDB(*driver, *dbPath)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	store, err := mirror.NewSQLStore(ctx, db)
+	if err != nil {
+		return err
+	}
+	client, err := cf.client()
+	if err != nil {
+		return err
+	}
+	lines, err := readLines(*input)
+	if err != nil {
+		return err
+	}
+
+	m := mirror.New(client, store, mirror.WithMaxAge(*maxAge))
+	var stats mirror.SyncStats
+	if *byCEP {
+		stats, err = m.SyncCEP(ctx, iptuapi.Cidade(*cidade), lines)
+	} else {
+		stats, err = m.Sync(ctx, iptuapi.Cidade(*cidade), lines)
+	}
+
+	enc := json.NewEncoder(stdout)
+	enc.SetIndent("", "  ")
+	if encErr := enc.Encode(stats); encErr != nil && err == nil {
+		err = encErr
+	}
+	return err
+}
+
+// openDB opens a database with a driver that must be linked into the binary.
+func openDB(driver, dsn string) (*sql.DB, error) {
+	registered := false
+	for _, d := range sql.Drivers() {
+		if d == driver {
+			registered = true
+			break
+		}
+	}
+	if !registered {
+		return nil, fmt.Errorf("database driver %q is not linked into this binary; "+
+			"build iptuapi with a SQLite driver import (e.g. _ \"modernc.org/sqlite\")", driver)
+	}
+	db, err := sql.Open(driver, dsn)
+	if err != nil {
+		return nil, err
+	}
+	if err := db.Ping(); err != nil {
+		db.Close()
+		return nil, errors.Join(fmt.Errorf("opening %s", dsn), err)
+	}
+	return db, nil
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/raphaeltorquat0/iptuapi-go/mirror"
+)
+
+func TestMirrorSync(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		sql := strings.TrimPrefix(r.URL.Path, "/consulta/sql/")
+		if sql == "999.999.9999-9" {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"detail":"Imovel nao encontrado"}`))
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]string{"sql": sql, "bairro": "Pinheiros"})
+	}))
+	defer server.Close()
+
+	db := filepath.Join(t.TempDir(), "mirror.db")
+	input := writeFile(t, "sqls.txt", "# carteira\n000.000.0001-1\n000.000.0002-2\n\n999.999.9999-9\n")
+	sync := func() mirror.SyncStats {
+		t.Helper()
+		code, stdout, stderr := runCLI(t, "mirror", "sync", "--db", db, "--input", input, "--base-url", server.URL)
+		require.Equal(t, 0, code, stderr)
+		var stats mirror.SyncStats
+		require.NoError(t, json.Unmarshal([]byte(stdout), &stats), stdout)
+		return stats
+	}
+
+	stats := sync()
+	assert.Equal(t, 3, stats.Total)
+	assert.Equal(t, 2, stats.Fetched)
+	assert.Equal(t, 1, stats.Failed)
+	assert.Contains(t, stats.Errors, "999.999.9999-9")
+
+	// The second run serves the stored records from the SQLite file.
+	stats = sync()
+	assert.Equal(t, 2, stats.Skipped)
+	assert.Equal(t, 1, stats.Failed)
+	assert.Equal(t, int32(4), calls.Load())
+}
+
+func TestMirrorSyncUnknownDriver(t *testing.T) {
+	code, _, stderr := runCLI(t, "mirror", "sync", "--driver", "nosuchdb", "--db", filepath.Join(t.TempDir(), "x.db"))
+	assert.Equal(t, 1, code)
+	assert.Contains(t, stderr, `database driver "nosuchdb" is not linked`)
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWritePDF(t *testing.T) {
+	lines := []string{"Dossiê do imóvel (teste)", `C:\caminho`, "emoji 🏠"}
+	for i := 0; i < 100; i++ {
+		lines = append(lines, fmt.Sprintf("linha %d", i))
+	}
+	var buf bytes.Buffer
+	require.NoError(t, writePDF(&buf, "Dossiê 1", lines))
+	pdf := buf.Bytes()
+
+	require.True(t, bytes.HasPrefix(pdf, []byte("%PDF-1.4\n")))
+	require.True(t, bytes.HasSuffix(pdf, []byte("%%EOF\n")))
+
+	m := regexp.MustCompile(`startxref\n(\d+)\n%%EOF\n$`).FindSubmatch(pdf)
+	require.NotNil(t, m)
+	xref, err := strconv.Atoi(string(m[1]))
+	require.NoError(t, err)
+	require.True(t, bytes.HasPrefix(pdf[xref:], []byte("xref\n")), "startxref points at the xref table")
+
+	table := strings.Split(string(pdf[xref:]), "\n")
+	var size int
+	_, err = fmt.Sscanf(table[1], "0 %d", &size)
+	require.NoError(t, err)
+	assert.Equal(t, "0000000000 65535 f ", table[2])
+	// Two pages of content: catalog, pages, font, info, then two objects
+	// per page.
+	require.Equal(t, 4+2*2+1, size)
+	for n := 1; n < size; n++ {
+		entry := table[2+n]
+		require.Len(t, entry, 19, "xref entries are 20 bytes with the newline")
+		off, err := strconv.Atoi(entry[:10])
+		require.NoError(t, err)
+		assert.True(t, bytes.HasPrefix(pdf[off:], []byte(fmt.Sprintf("%d 0 obj\n", n))),
+			"xref entry %d points at offset %d", n, off)
+	}
+	assert.Contains(t, string(pdf), fmt.Sprintf("/Size %d", size))
+
+	for _, s := range regexp.MustCompile(`(?s)<< /Length (\d+) >>\nstream\n(.*?)\nendstream`).FindAllSubmatch(pdf, -1) {
+		n, _ := strconv.Atoi(string(s[1]))
+		assert.Equal(t, n, len(s[2]), "stream lengths match their content")
+	}
+
+	assert.Contains(t, string(pdf), `(Dossi\352 do im\363vel \(teste\)) '`)
+	assert.Contains(t, string(pdf), `(C:\\caminho) '`)
+	assert.Contains(t, string(pdf), `(emoji ?) '`)
+}
@@ -0,0 +1,5 @@
+package main
+
+// The mirror commands store records in SQLite; link a pure Go driver so the
+// binary needs no cgo.
+import _ "modernc.org/sqlite"
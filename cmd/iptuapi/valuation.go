@@ -0,0 +1,225 @@
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	iptuapi "github.com/raphaeltorquat0/iptuapi-go"
+)
+
+// auditColumns are appended to each row by "valuation batch", so pricing
+// teams can trace every value back to the model and request behind it.
+var auditColumns = []string{
+	"valor_estimado", "valor_minimo", "valor_maximo", "confianca", "metodo",
+	"modelo_versao", "avaliado_em", "request_id", "erro",
+}
+
+// valuationSummary is printed after a batch run.
+type valuationSummary struct {
+	Total  int `json:"total"`
+	Valued int `json:"valued"`
+	Failed int `json:"failed"`
+}
+
+func runValuationBatch(ctx context.Context, args []string, stdout, stderr io.Writer) error {
+	fs := flag.NewFlagSet("valuation batch", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	var cf clientFlags
+	cf.register(fs)
+	input := fs.String("input", "-", "CSV file with one property per row (- for stdin)")
+	output := fs.String("out", "-", "valued CSV file (- for stdout)")
+	cidade := fs.String("cidade", "", "city of rows without a cidade column")
+	delimiter := fs.String("delimiter", ",", "field delimiter of the input and output files")
+	decimal := fs.String("decimal", "auto", `decimal separator of the areas: ".", "," or auto`)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if len(*delimiter) != 1 {
+		return errors.New("--delimiter must be a single character")
+	}
+	if *decimal != "auto" && *decimal != "." && *decimal != "," {
+		return errors.New(`--decimal must be ".", "," or auto`)
+	}
+	client, err := cf.client()
+	if err != nil {
+		return err
+	}
+
+	var in io.Reader = os.Stdin
+	if *input != "-" {
+		f, err := os.Open(*input)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		in = f
+	}
+	out, summary := io.Writer(stdout), stderr
+	if *output != "-" {
+		f, err := os.Create(*output)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		out, summary = f, stdout
+	}
+
+	r := csv.NewReader(in)
+	r.Comma = rune((*delimiter)[0])
+	r.FieldsPerRecord = -1
+	w := csv.NewWriter(out)
+	w.Comma = r.Comma
+
+	header, err := r.Read()
+	if err != nil {
+		return fmt.Errorf("reading header: %w", err)
+	}
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	if err := w.Write(append(append([]string(nil), header...), auditColumns...)); err != nil {
+		return err
+	}
+
+	var s valuationSummary
+	for {
+		row, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		s.Total++
+		audit := valueRow(ctx, client, columns, row, iptuapi.Cidade(*cidade), *decimal)
+		if audit[len(audit)-1] == "" {
+			s.Valued++
+		} else {
+			s.Failed++
+		}
+		if err := w.Write(append(row, audit...)); err != nil {
+			return err
+		}
+		if ctx.Err() != nil {
+			break
+		}
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return err
+	}
+
+	enc := json.NewEncoder(summary)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(s); err != nil {
+		return err
+	}
+	return ctx.Err()
+}
+
+// valueRow estimates the property of row and returns its audit columns.
+// Failures are reported in the erro column rather than stopping the run.
+func valueRow(ctx context.Context, client *iptuapi.Client, columns map[string]int, row []string, cidade iptuapi.Cidade, decimal string) []string {
+	audit := make([]string, len(auditColumns))
+	audit[6] = time.Now().UTC().Format(time.RFC3339)
+
+	p, err := valuationParams(columns, row, cidade, decimal)
+	if err != nil {
+		audit[8] = err.Error()
+		return audit
+	}
+	var raw iptuapi.RawResponse
+	result, err := client.ValuationEstimate(ctx, p, iptuapi.WithRawCapture(&raw))
+	audit[7] = raw.Header.Get("X-Request-ID")
+	if err != nil {
+		var apiErr *iptuapi.APIError
+		if errors.As(err, &apiErr) && apiErr.RequestID != "" {
+			audit[7] = apiErr.RequestID
+		}
+		audit[8] = err.Error()
+		return audit
+	}
+	audit[0] = formatValue(result.ValorEstimado)
+	audit[1] = formatValue(result.ValorMinimo)
+	audit[2] = formatValue(result.ValorMaximo)
+	audit[3] = formatValue(result.Confianca)
+	audit[4] = result.Metodo
+	audit[5] = result.ModeloVersao
+	return audit
+}
+
+// valuationParams reads the ValuationParams of a row, by the JSON names of
+// its fields.
+func valuationParams(columns map[string]int, row []string, cidade iptuapi.Cidade, decimal string) (*iptuapi.ValuationParams, error) {
+	get := func(name string) string {
+		if i, ok := columns[name]; ok && i < len(row) {
+			return strings.TrimSpace(row[i])
+		}
+		return ""
+	}
+	p := &iptuapi.ValuationParams{
+		Bairro:     get("bairro"),
+		Zona:       get("zona"),
+		TipoUso:    get("tipo_uso"),
+		TipoPadrao: get("tipo_padrao"),
+		Cidade:     cidade,
+	}
+	if c := get("cidade"); c != "" {
+		p.Cidade = iptuapi.Cidade(strings.ToLower(c))
+	}
+	var err error
+	if p.AreaTerreno, err = parseNumber(get("area_terreno"), decimal); err != nil {
+		return nil, fmt.Errorf("area_terreno: %w", err)
+	}
+	if p.AreaConstruida, err = parseNumber(get("area_construida"), decimal); err != nil {
+		return nil, fmt.Errorf("area_construida: %w", err)
+	}
+	if ano := get("ano_construcao"); ano != "" {
+		if p.AnoConstrucao, err = strconv.Atoi(ano); err != nil {
+			return nil, fmt.Errorf("ano_construcao: %w", err)
+		}
+	}
+	return p, nil
+}
+
+// parseNumber parses numbers as spreadsheets export them, with decimal
+// separator decimal. With "auto", a comma means the Brazilian format
+// ("1.234,5") and a point a decimal point ("1234.5"), except that a single
+// point followed by three digits ("1.234") could be either and is rejected.
+// Empty is zero.
+func parseNumber(s, decimal string) (float64, error) {
+	if s == "" {
+		return 0, nil
+	}
+	if decimal == "auto" {
+		decimal = "."
+		if strings.Contains(s, ",") {
+			decimal = ","
+		} else if i := strings.Index(s, "."); i >= 0 && strings.Count(s, ".") == 1 && len(s)-i-1 == 3 {
+			return 0, fmt.Errorf("ambiguous number %q: set --decimal to \".\" or \",\"", s)
+		}
+	}
+	if decimal == "," {
+		s = strings.ReplaceAll(strings.ReplaceAll(s, ".", ""), ",", ".")
+	} else {
+		s = strings.ReplaceAll(s, ",", "")
+	}
+	return strconv.ParseFloat(s, 64)
+}
+
+func formatValue(v float64) string {
+	if v == 0 {
+		return ""
+	}
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
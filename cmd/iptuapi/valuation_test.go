@@ -0,0 +1,168 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	iptuapi "github.com/raphaeltorquat0/iptuapi-go"
+)
+
+func newValuationAPI(t *testing.T) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/valuation/estimate" {
+			t.Errorf("unexpected path %s", r.URL.Path)
+			return
+		}
+		var p iptuapi.ValuationParams
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&p))
+		if p.Bairro == "Inexistente" {
+			w.Header().Set("X-Request-ID", "req-erro")
+			w.WriteHeader(http.StatusUnprocessableEntity)
+			w.Write([]byte(`{"detail":"bairro sem comparaveis"}`))
+			return
+		}
+		w.Header().Set("X-Request-ID", "req-"+p.Bairro)
+		w.Header().Set("X-Model-Version", "2024.06")
+		json.NewEncoder(w).Encode(iptuapi.ValuationResult{
+			ValorEstimado: p.AreaConstruida * 10000,
+			ValorMinimo:   p.AreaConstruida * 9000,
+			ValorMaximo:   p.AreaConstruida * 11000,
+			Confianca:     0.85,
+			Metodo:        "comparativo",
+		})
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestValuationBatch(t *testing.T) {
+	server := newValuationAPI(t)
+	input := writeFile(t, "imoveis.csv", "id;bairro;area_terreno;area_construida\n"+
+		"1;Pinheiros;200;80\n"+
+		"2;Moema;1.500,5;120,5\n"+
+		"3;Inexistente;100;50\n"+
+		"4;Pinheiros;100;abc\n")
+	output := filepath.Join(t.TempDir(), "avaliados.csv")
+
+	code, stdout, stderr := runCLI(t, "valuation", "batch", "--input", input, "--out", output,
+		"--cidade", "sp", "--delimiter", ";", "--base-url", server.URL)
+	require.Equal(t, 0, code, stderr)
+
+	var s valuationSummary
+	require.NoError(t, json.Unmarshal([]byte(stdout), &s), "with --out the summary goes to stdout")
+	assert.Equal(t, valuationSummary{Total: 4, Valued: 2, Failed: 2}, s)
+
+	data, err := os.ReadFile(output)
+	require.NoError(t, err)
+	rows := readCSV(t, string(data), ';')
+	require.Len(t, rows, 5)
+	assert.Equal(t, append([]string{"id", "bairro", "area_terreno", "area_construida"}, auditColumns...), rows[0])
+
+	audit := func(row []string) map[string]string {
+		m := make(map[string]string, len(auditColumns))
+		for i, name := range auditColumns {
+			m[name] = row[4+i]
+		}
+		return m
+	}
+
+	first := audit(rows[1])
+	assert.Equal(t, "800000", first["valor_estimado"])
+	assert.Equal(t, "720000", first["valor_minimo"])
+	assert.Equal(t, "880000", first["valor_maximo"])
+	assert.Equal(t, "0.85", first["confianca"])
+	assert.Equal(t, "comparativo", first["metodo"])
+	assert.Equal(t, "2024.06", first["modelo_versao"], "taken from X-Model-Version")
+	assert.Equal(t, "req-Pinheiros", first["request_id"])
+	assert.Empty(t, first["erro"])
+	_, err = time.Parse(time.RFC3339, first["avaliado_em"])
+	assert.NoError(t, err)
+
+	assert.Equal(t, "1205000", audit(rows[2])["valor_estimado"], "Brazilian number format")
+	assert.Equal(t, "req-Moema", audit(rows[2])["request_id"])
+
+	apiErr := audit(rows[3])
+	assert.Empty(t, apiErr["valor_estimado"])
+	assert.Equal(t, "req-erro", apiErr["request_id"], "taken from the error response")
+	assert.Contains(t, apiErr["erro"], "bairro sem comparaveis")
+
+	parseErr := audit(rows[4])
+	assert.Empty(t, parseErr["request_id"], "no request is made for an unparsable row")
+	assert.Contains(t, parseErr["erro"], "area_construida")
+	assert.NotEmpty(t, parseErr["avaliado_em"])
+}
+
+func TestValuationBatchToStdout(t *testing.T) {
+	server := newValuationAPI(t)
+	input := writeFile(t, "imoveis.csv", "bairro,area_construida\nPinheiros,80\n")
+
+	code, stdout, stderr := runCLI(t, "valuation", "batch", "--input", input, "--cidade", "sp", "--base-url", server.URL)
+	require.Equal(t, 0, code, stderr)
+
+	rows := readCSV(t, stdout, ',')
+	require.Len(t, rows, 2)
+	assert.Equal(t, "800000", rows[1][2])
+
+	var s valuationSummary
+	require.NoError(t, json.Unmarshal([]byte(stderr), &s), "without --out the summary goes to stderr")
+	assert.Equal(t, 1, s.Valued)
+}
+
+func TestParseNumber(t *testing.T) {
+	tests := []struct {
+		in      string
+		decimal string
+		want    float64
+		wantErr bool
+	}{
+		{"", "auto", 0, false},
+		{"1234.5", "auto", 1234.5, false},
+		{"1.234,5", "auto", 1234.5, false},
+		{"1234,5", "auto", 1234.5, false},
+		{"12.5", "auto", 12.5, false},
+		{"1.234", "auto", 0, true},
+		{"1.234", ".", 1.234, false},
+		{"1.234", ",", 1234, false},
+		{"1,234.5", ".", 1234.5, false},
+		{"1.234,5", ",", 1234.5, false},
+		{"abc", "auto", 0, true},
+	}
+	for _, tt := range tests {
+		got, err := parseNumber(tt.in, tt.decimal)
+		if tt.wantErr {
+			assert.Error(t, err, "%q with %s", tt.in, tt.decimal)
+			continue
+		}
+		require.NoError(t, err, "%q with %s", tt.in, tt.decimal)
+		assert.Equal(t, tt.want, got, "%q with %s", tt.in, tt.decimal)
+	}
+}
+
+func TestValuationBatchAmbiguousNumber(t *testing.T) {
+	server := newValuationAPI(t)
+	input := writeFile(t, "imoveis.csv", "bairro,area_construida\nPinheiros,1.234\n")
+
+	code, stdout, stderr := runCLI(t, "valuation", "batch", "--input", input, "--cidade", "sp", "--base-url", server.URL)
+	require.Equal(t, 0, code, stderr)
+	rows := readCSV(t, stdout, ',')
+	assert.Contains(t, rows[1][len(rows[1])-1], "ambiguous number")
+
+	code, stdout, stderr = runCLI(t, "valuation", "batch", "--input", input, "--cidade", "sp", "--decimal", ",", "--base-url", server.URL)
+	require.Equal(t, 0, code, stderr)
+	rows = readCSV(t, stdout, ',')
+	assert.Equal(t, "12340000", rows[1][2])
+}
+
+func TestValuationBatchBadDelimiter(t *testing.T) {
+	code, _, stderr := runCLI(t, "valuation", "batch", "--delimiter", ";;")
+	assert.Equal(t, 1, code)
+	assert.Contains(t, stderr, "--delimiter must be a single character")
+}
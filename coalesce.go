@@ -0,0 +1,221 @@
+package iptuapi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// maxCoalescedBatch caps the estimates sent in one coalesced batch call.
+const maxCoalescedBatch = 100
+
+// batchUnavailableCooldown is how long coalesced estimates skip the batch
+// endpoint after the plan or deployment turned it down, so that a plan
+// upgrade is eventually noticed.
+const batchUnavailableCooldown = 10 * time.Minute
+
+// WithCoalescing holds each lookup for window (e.g. 50ms) so that calls
+// arriving nearly simultaneously share one request:
+//
+//   - identical GET calls, including those arriving while the shared call
+//     is in flight, receive the same response;
+//   - ValuationEstimate calls without request options are sent together
+//     through the batch endpoint, falling back to individual calls when
+//     the plan does not include it (and skipping the endpoint for a while
+//     after that).
+//
+// Coalescing trades up to window of added latency for fewer requests, and
+// suits high fan-in backends serving many users the same properties.
+func WithCoalescing(window time.Duration) ClientOption {
+	return func(c *Client) {
+		if window <= 0 {
+			c.coalesce = nil
+			return
+		}
+		c.coalesce = &coalescer{window: window, calls: make(map[string]*coalescedCall)}
+	}
+}
+
+type coalescer struct {
+	window time.Duration
+
+	mu    sync.Mutex
+	calls map[string]*coalescedCall
+	batch *estimateBatch
+
+	// batchUnavailableUntil is the UnixNano time until which the batch
+	// endpoint is skipped.
+	batchUnavailableUntil atomic.Int64
+}
+
+type coalescedCall struct {
+	done chan struct{}
+	body []byte
+	err  error
+}
+
+// do runs fetch once for all callers sharing key. The first caller waits
+// for the window, then fetches with its own context; if that context ends
+// the call, the others fetch again with theirs.
+func (co *coalescer) do(ctx context.Context, key string, fetch func(context.Context) ([]byte, error)) ([]byte, error) {
+	co.mu.Lock()
+	if call, ok := co.calls[key]; ok {
+		co.mu.Unlock()
+		select {
+		case <-call.done:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+		if isContextErr(call.err) && ctx.Err() == nil {
+			return fetch(ctx)
+		}
+		return call.body, call.err
+	}
+	call := &coalescedCall{done: make(chan struct{})}
+	co.calls[key] = call
+	co.mu.Unlock()
+
+	defer func() {
+		co.mu.Lock()
+		delete(co.calls, key)
+		co.mu.Unlock()
+		close(call.done)
+	}()
+
+	timer := time.NewTimer(co.window)
+	select {
+	case <-timer.C:
+	case <-ctx.Done():
+		timer.Stop()
+		call.err = ctx.Err()
+		return nil, call.err
+	}
+	call.body, call.err = fetch(ctx)
+	return call.body, call.err
+}
+
+func isContextErr(err error) bool {
+	return errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded)
+}
+
+type estimateBatch struct {
+	once    sync.Once
+	waiters []*estimateWaiter
+}
+
+type estimateWaiter struct {
+	params *ValuationParams
+	done   chan struct{}
+	result *ValuationResult
+	err    error
+}
+
+func (w *estimateWaiter) finish(r *ValuationResult, err error) {
+	w.result, w.err = r, err
+	close(w.done)
+}
+
+// estimate adds p to the pending batch, sent when the window ends or the
+// batch is full.
+func (co *coalescer) estimate(ctx context.Context, c *Client, p *ValuationParams) (*ValuationResult, error) {
+	w := &estimateWaiter{params: p, done: make(chan struct{})}
+
+	co.mu.Lock()
+	b := co.batch
+	if b == nil {
+		b = &estimateBatch{}
+		co.batch = b
+		time.AfterFunc(co.window, func() { co.flush(c, b) })
+	}
+	b.waiters = append(b.waiters, w)
+	if len(b.waiters) >= maxCoalescedBatch {
+		co.batch = nil
+		go co.flush(c, b)
+	}
+	co.mu.Unlock()
+
+	select {
+	case <-w.done:
+		return w.result, w.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// flush sends a batch. It runs detached from the callers' contexts, so one
+// caller giving up does not fail the others.
+func (co *coalescer) flush(c *Client, b *estimateBatch) {
+	b.once.Do(func() {
+		co.mu.Lock()
+		if co.batch == b {
+			co.batch = nil
+		}
+		waiters := b.waiters
+		co.mu.Unlock()
+
+		ctx := context.Background()
+		individually := func() {
+			var wg sync.WaitGroup
+			for _, w := range waiters {
+				wg.Add(1)
+				go func(w *estimateWaiter) {
+					defer wg.Done()
+					w.finish(c.valuationEstimate(ctx, w.params, nil))
+				}(w)
+			}
+			wg.Wait()
+		}
+		if len(waiters) == 1 || c.clock.Now().UnixNano() < co.batchUnavailableUntil.Load() {
+			individually()
+			return
+		}
+
+		params := make([]ValuationParams, len(waiters))
+		for i, w := range waiters {
+			params[i] = *w.params
+		}
+		c.log(ctx, SubsystemBatch, slog.LevelDebug, "Coalesced valuation batch", "size", len(waiters))
+		res, err := c.ValuationBatch(ctx, params)
+		switch {
+		case IsForbidden(err) || IsNotFound(err):
+			// The plan or deployment has no batch endpoint.
+			co.batchUnavailableUntil.Store(c.clock.Now().Add(batchUnavailableCooldown).UnixNano())
+			c.log(ctx, SubsystemBatch, slog.LevelInfo, "Valuation batch unavailable, sending estimates individually",
+				"cooldown", batchUnavailableCooldown, "error", err)
+			individually()
+		case err != nil:
+			for _, w := range waiters {
+				w.finish(nil, err)
+			}
+		default:
+			distributeBatch(waiters, res)
+		}
+	})
+}
+
+// distributeBatch hands each waiter its result. Resultados holds the
+// successful items in input order; failed items are listed in Erros.
+func distributeBatch(waiters []*estimateWaiter, res *BatchValuationResult) {
+	failed := make(map[int]string, len(res.Erros))
+	for _, e := range res.Erros {
+		failed[e.Index] = e.Error
+	}
+	next := 0
+	for i, w := range waiters {
+		if msg, ok := failed[i]; ok {
+			w.finish(nil, fmt.Errorf("iptuapi: batch item %d: %s", i, msg))
+			continue
+		}
+		if next >= len(res.Resultados) {
+			w.finish(nil, fmt.Errorf("iptuapi: batch item %d: missing result", i))
+			continue
+		}
+		r := res.Resultados[next]
+		next++
+		w.finish(&r, nil)
+	}
+}
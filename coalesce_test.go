@@ -0,0 +1,189 @@
+package iptuapi
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCoalescingIdenticalGETs(t *testing.T) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.Write([]byte(`{"sql":"000.000.0000-0"}`))
+	}))
+	defer server.Close()
+
+	client := NewClient("test_key", WithBaseURL(server.URL), WithCoalescing(50*time.Millisecond))
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			r, err := client.ConsultaSQL(context.Background(), "000.000.0000-0", CidadeSaoPaulo)
+			assert.NoError(t, err)
+			assert.Equal(t, "000.000.0000-0", r.SQL)
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+}
+
+func TestCoalescingLeaderCancelled(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"sql":"000.000.0000-0"}`))
+	}))
+	defer server.Close()
+
+	client := NewClient("test_key", WithBaseURL(server.URL), WithCoalescing(50*time.Millisecond))
+	leaderCtx, cancel := context.WithCancel(context.Background())
+	leaderErr := make(chan error, 1)
+	go func() {
+		_, err := client.ConsultaSQL(leaderCtx, "000.000.0000-0", CidadeSaoPaulo)
+		leaderErr <- err
+	}()
+	time.Sleep(10 * time.Millisecond)
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		cancel()
+	}()
+
+	_, err := client.ConsultaSQL(context.Background(), "000.000.0000-0", CidadeSaoPaulo)
+	assert.NoError(t, err, "follower fetches again when the leader is cancelled")
+	assert.ErrorIs(t, <-leaderErr, context.Canceled)
+}
+
+func TestCoalescingValuationBatch(t *testing.T) {
+	var batches, singles int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/valuation/estimate/batch":
+			atomic.AddInt32(&batches, 1)
+			var body struct {
+				Imoveis []ValuationParams `json:"imoveis"`
+			}
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+			res := BatchValuationResult{}
+			for i, p := range body.Imoveis {
+				if p.AreaTerreno == 0 {
+					res.Erros = append(res.Erros, BatchError{Index: i, Error: "area_terreno obrigatoria"})
+					continue
+				}
+				r := ValuationResult{ValorEstimado: p.AreaTerreno * 1000}
+				if p.AreaTerreno == 100 {
+					r.Reproducao = &Reproducao{InputsHash: "abc", Seed: 42}
+				}
+				res.Resultados = append(res.Resultados, r)
+			}
+			w.Header().Set("X-Model-Version", "2024.06")
+			json.NewEncoder(w).Encode(res)
+		default:
+			atomic.AddInt32(&singles, 1)
+			w.Write([]byte(`{"valor_estimado":1}`))
+		}
+	}))
+	defer server.Close()
+
+	client := NewClient("test_key", WithBaseURL(server.URL), WithCoalescing(50*time.Millisecond))
+	areas := []float64{100, 0, 300}
+	results := make([]*ValuationResult, len(areas))
+	errs := make([]error, len(areas))
+	var wg sync.WaitGroup
+	for i, a := range areas {
+		wg.Add(1)
+		go func(i int, a float64) {
+			defer wg.Done()
+			results[i], errs[i] = client.ValuationEstimate(context.Background(), &ValuationParams{AreaTerreno: a, AreaConstruida: 80, Bairro: "Pinheiros"})
+		}(i, a)
+	}
+	wg.Wait()
+
+	assert.Equal(t, int32(1), atomic.LoadInt32(&batches))
+	assert.Equal(t, int32(0), atomic.LoadInt32(&singles))
+	require.NoError(t, errs[0])
+	require.NoError(t, errs[2])
+	assert.Equal(t, 100000.0, results[0].ValorEstimado)
+	assert.Equal(t, 300000.0, results[2].ValorEstimado)
+	assert.ErrorContains(t, errs[1], "area_terreno obrigatoria")
+
+	// Each fanned-out result carries the batch's model version.
+	assert.Equal(t, "2024.06", results[0].ModeloVersao)
+	assert.Equal(t, "2024.06", results[2].ModeloVersao)
+	assert.Equal(t, &Reproducao{InputsHash: "abc", Seed: 42, ModeloVersao: "2024.06"}, results[0].Reproducao)
+	assert.Nil(t, results[2].Reproducao)
+}
+
+func TestCoalescingValuationWithoutBatchPlan(t *testing.T) {
+	var singles int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/valuation/estimate/batch" {
+			w.WriteHeader(http.StatusForbidden)
+			w.Write([]byte(`{"detail":"plano","required_plan":"enterprise"}`))
+			return
+		}
+		atomic.AddInt32(&singles, 1)
+		w.Write([]byte(`{"valor_estimado":1}`))
+	}))
+	defer server.Close()
+
+	client := NewClient("test_key", WithBaseURL(server.URL), WithCoalescing(50*time.Millisecond))
+	var wg sync.WaitGroup
+	for i := 0; i < 3; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			r, err := client.ValuationEstimate(context.Background(), &ValuationParams{AreaTerreno: 100, AreaConstruida: 80, Bairro: "Pinheiros"})
+			assert.NoError(t, err)
+			assert.Equal(t, 1.0, r.ValorEstimado)
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, int32(3), atomic.LoadInt32(&singles))
+}
+
+func TestCoalescingRemembersBatchUnavailable(t *testing.T) {
+	var batches, singles int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/valuation/estimate/batch" {
+			atomic.AddInt32(&batches, 1)
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"detail":"Not Found"}`))
+			return
+		}
+		atomic.AddInt32(&singles, 1)
+		w.Write([]byte(`{"valor_estimado":1}`))
+	}))
+	defer server.Close()
+
+	clock := newFakeClock()
+	client := NewClient("test_key", WithBaseURL(server.URL), WithCoalescing(50*time.Millisecond), WithClock(clock))
+	window := func() {
+		var wg sync.WaitGroup
+		for i := 0; i < 2; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				_, err := client.ValuationEstimate(context.Background(), &ValuationParams{AreaTerreno: 100, AreaConstruida: 80, Bairro: "Pinheiros"})
+				assert.NoError(t, err)
+			}()
+		}
+		wg.Wait()
+	}
+
+	window()
+	window()
+	assert.Equal(t, int32(1), atomic.LoadInt32(&batches), "the batch endpoint is not retried every window")
+	assert.Equal(t, int32(4), atomic.LoadInt32(&singles))
+
+	clock.Sleep(context.Background(), batchUnavailableCooldown)
+	window()
+	assert.Equal(t, int32(2), atomic.LoadInt32(&batches), "the batch endpoint is tried again after the cooldown")
+}
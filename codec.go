@@ -0,0 +1,19 @@
+package iptuapi
+
+// WithJSONCodec replaces encoding/json for request bodies and responses,
+// e.g. with jsoniter or sonic in high-throughput pipelines:
+//
+//	iptuapi.WithJSONCodec(sonic.Marshal, sonic.Unmarshal)
+//
+// The codec must honor encoding/json struct tags and the json.Unmarshaler
+// interface. A nil function keeps the encoding/json default.
+func WithJSONCodec(marshal func(v interface{}) ([]byte, error), unmarshal func(data []byte, v interface{}) error) ClientOption {
+	return func(c *Client) {
+		if marshal != nil {
+			c.marshal = marshal
+		}
+		if unmarshal != nil {
+			c.unmarshal = unmarshal
+		}
+	}
+}
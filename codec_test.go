@@ -0,0 +1,43 @@
+package iptuapi
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithJSONCodec(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"valor_estimado":500000}`))
+	}))
+	defer server.Close()
+
+	var marshaled, unmarshaled int
+	client := NewClient("test_key", WithBaseURL(server.URL), WithJSONCodec(
+		func(v interface{}) ([]byte, error) {
+			marshaled++
+			return json.Marshal(v)
+		},
+		func(data []byte, v interface{}) error {
+			unmarshaled++
+			return json.Unmarshal(data, v)
+		},
+	))
+
+	result, err := client.ValuationEstimate(context.Background(), &ValuationParams{AreaTerreno: 100, AreaConstruida: 80, Bairro: "Pinheiros"})
+	require.NoError(t, err)
+	assert.Equal(t, 500000.0, result.ValorEstimado)
+	assert.Equal(t, 1, marshaled)
+	assert.Equal(t, 1, unmarshaled)
+}
+
+func TestWithJSONCodecNilKeepsDefault(t *testing.T) {
+	client := NewClient("test_key", WithJSONCodec(nil, nil))
+	require.NotNil(t, client.marshal)
+	require.NotNil(t, client.unmarshal)
+}
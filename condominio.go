@@ -0,0 +1,241 @@
+package iptuapi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+)
+
+// Codes of the inconsistencies found by AggregateCondominio.
+const (
+	// CondominioSQLDuplicado is a unit listed more than once.
+	CondominioSQLDuplicado = "SQL_DUPLICADO"
+	// CondominioEnderecoDivergente is a unit at another address than most
+	// of the building, likely from a neighboring lot.
+	CondominioEnderecoDivergente = "ENDERECO_DIVERGENTE"
+	// CondominioSemFracaoIdeal is a unit without fração ideal in a
+	// building whose other units have one.
+	CondominioSemFracaoIdeal = "SEM_FRACAO_IDEAL"
+	// CondominioFracaoIdealSoma is a building whose fractions do not add
+	// up to the whole lot.
+	CondominioFracaoIdealSoma = "FRACAO_IDEAL_SOMA"
+	// CondominioFracaoDesproporcional is a unit whose fração ideal is
+	// less than half or more than twice its share of the built area.
+	CondominioFracaoDesproporcional = "FRACAO_DESPROPORCIONAL"
+	// CondominioSemValor is a unit without venal value or built area.
+	CondominioSemValor = "SEM_VALOR"
+)
+
+// fracaoTolerancia is how far the sum of the fractions may be from 1.
+const fracaoTolerancia = 0.005
+
+// CondominioInconsistencia is a problem found in the units of a building.
+// SQL is empty for problems of the building as a whole.
+type CondominioInconsistencia struct {
+	SQL      string `json:"sql,omitempty"`
+	Codigo   string `json:"codigo"`
+	Mensagem string `json:"mensagem"`
+}
+
+// CondominioAggregate sums the units of a vertical condominium.
+type CondominioAggregate struct {
+	// Logradouro and Numero are the address of most units.
+	Logradouro string `json:"logradouro"`
+	Numero     string `json:"numero,omitempty"`
+	Unidades   int    `json:"unidades"`
+	// ValorVenalTotal and IPTUTotal are summed in centavos, so they do not
+	// depend on the order of the units.
+	ValorVenalTotal     float64 `json:"valor_venal_total"`
+	IPTUTotal           float64 `json:"iptu_total"`
+	AreaConstruidaTotal float64 `json:"area_construida_total"`
+	// FracaoIdealTotal is the sum of the units' fractions, normalized to
+	// 1 when the cadastre reports percentages. It should be 1.
+	FracaoIdealTotal float64                    `json:"fracao_ideal_total"`
+	Inconsistencias  []CondominioInconsistencia `json:"inconsistencias,omitempty"`
+}
+
+// Consistente reports whether no inconsistency was found.
+func (a *CondominioAggregate) Consistente() bool {
+	return len(a.Inconsistencias) == 0
+}
+
+// AggregateCondominio computes the totals of a building from its units, as
+// returned by ConsultaCEP for the building's CEP, and flags inconsistencies
+// such as duplicated units, units from another address and fractions that
+// do not add up. Duplicated units are counted once.
+func AggregateCondominio(units []ConsultaEnderecoResult) *CondominioAggregate {
+	a := &CondominioAggregate{}
+	a.Logradouro, a.Numero = enderecoPredominante(units)
+
+	var (
+		venal, iptu int64
+		seen        = make(map[string]bool, len(units))
+		kept        []ConsultaEnderecoResult
+		semFracao   []string
+	)
+	for _, u := range units {
+		if seen[u.SQL] {
+			a.add(u.SQL, CondominioSQLDuplicado, "unidade listada mais de uma vez")
+			continue
+		}
+		seen[u.SQL] = true
+		kept = append(kept, u)
+
+		if bairroKey(u.Logradouro) != bairroKey(a.Logradouro) || u.Numero != a.Numero {
+			a.add(u.SQL, CondominioEnderecoDivergente,
+				fmt.Sprintf("endereço %s, %s difere do prédio", u.Logradouro, u.Numero))
+		}
+		if u.ValorVenalTotal <= 0 || u.AreaConstruida <= 0 {
+			a.add(u.SQL, CondominioSemValor, "unidade sem valor venal ou área construída")
+		}
+		if u.FracaoIdeal <= 0 {
+			semFracao = append(semFracao, u.SQL)
+		}
+		a.Unidades++
+		venal += int64(math.Round(u.ValorVenalTotal * 100))
+		iptu += int64(math.Round(u.IPTUValor * 100))
+		a.AreaConstruidaTotal += u.AreaConstruida
+		a.FracaoIdealTotal += u.FracaoIdeal
+	}
+	a.ValorVenalTotal = float64(venal) / 100
+	a.IPTUTotal = float64(iptu) / 100
+	a.AreaConstruidaTotal = math.Round(a.AreaConstruidaTotal*100) / 100
+
+	// Without any fraction the cadastre does not report them; otherwise
+	// every unit should have one.
+	if len(semFracao) == len(kept) {
+		return a
+	}
+	for _, sql := range semFracao {
+		a.add(sql, CondominioSemFracaoIdeal, "unidade sem fração ideal")
+	}
+	escala := 1.0
+	if a.FracaoIdealTotal > 1.5 {
+		escala = 100 // percentages
+	}
+	a.FracaoIdealTotal = math.Round(a.FracaoIdealTotal/escala*1e6) / 1e6
+	if len(semFracao) == 0 && math.Abs(a.FracaoIdealTotal-1) > fracaoTolerancia {
+		a.add("", CondominioFracaoIdealSoma,
+			fmt.Sprintf("frações ideais somam %.4f em vez de 1", a.FracaoIdealTotal))
+	}
+	if a.AreaConstruidaTotal > 0 {
+		for _, u := range kept {
+			if u.FracaoIdeal <= 0 || u.AreaConstruida <= 0 {
+				continue
+			}
+			ratio := (u.FracaoIdeal / escala) / (u.AreaConstruida / a.AreaConstruidaTotal)
+			if ratio < 0.5 || ratio > 2 {
+				a.add(u.SQL, CondominioFracaoDesproporcional,
+					fmt.Sprintf("fração ideal %.4f incompatível com %.2f m² construídos", u.FracaoIdeal/escala, u.AreaConstruida))
+			}
+		}
+	}
+	return a
+}
+
+// ConsultaCondominioParams identifies a building by the SQL of any of its
+// units or by its address. The address takes precedence when both are set.
+type ConsultaCondominioParams struct {
+	SQL        string
+	Logradouro string
+	Numero     string
+	// Cidade defaults to CidadeSaoPaulo.
+	Cidade Cidade
+}
+
+// Validate checks the parameters as ConsultaCondominio does before
+// sending any call. It returns a *ValidationError listing every invalid
+// field.
+func (p *ConsultaCondominioParams) Validate() error {
+	var v validation
+	if p.Logradouro == "" || p.Numero == "" {
+		v.required("sql", p.SQL)
+	}
+	v.cidade("cidade", p.Cidade)
+	return v.err()
+}
+
+// Condominio is a building with every unit registered at its address.
+type Condominio struct {
+	Logradouro string `json:"logradouro"`
+	Numero     string `json:"numero"`
+	CEP        string `json:"cep"`
+	// Unidades are the apartments, garage boxes, storage and commercial
+	// units, sorted by SQL; see ClassificarUnidade to tell them apart.
+	Unidades []ConsultaEnderecoResult `json:"unidades"`
+}
+
+// Aggregate returns the totals and inconsistencies of the building; see
+// AggregateCondominio.
+func (c *Condominio) Aggregate() *CondominioAggregate {
+	return AggregateCondominio(c.Unidades)
+}
+
+// ErrCondominioSemCEP is returned by ConsultaCondominio when the
+// building's address has no CEP to list its units by.
+var ErrCondominioSemCEP = errors.New("iptuapi: endereço sem CEP; não é possível listar as unidades")
+
+// ConsultaCondominio returns every unit of a building, with SQLs, areas and
+// venal values, for reconciling whole buildings. As the API has no
+// building endpoint, it resolves the address (ConsultaSQL, when given a
+// SQL), finds its CEP with ConsultaEndereco and lists the units at the
+// building's number with ConsultaCEPNumero: two or three requests.
+func (c *Client) ConsultaCondominio(ctx context.Context, p *ConsultaCondominioParams, opts ...RequestOption) (*Condominio, error) {
+	cidade := newRequestOptions(opts).cidadeOr(p.Cidade)
+	if err := c.check(opts, p.Validate); err != nil {
+		return nil, err
+	}
+
+	logradouro, numero := p.Logradouro, p.Numero
+	if logradouro == "" || numero == "" {
+		unidade, err := c.ConsultaSQL(ctx, p.SQL, cidade, opts...)
+		if err != nil {
+			return nil, err
+		}
+		logradouro, numero = unidade.Logradouro, unidade.Numero
+	}
+	endereco, err := c.ConsultaEndereco(ctx, &ConsultaEnderecoParams{Logradouro: logradouro, Numero: numero, Cidade: cidade}, opts...)
+	if err != nil {
+		return nil, err
+	}
+	if endereco.CEP == "" {
+		return nil, ErrCondominioSemCEP
+	}
+	unidades, err := c.ConsultaCEPNumero(ctx, endereco.CEP, numero, cidade, opts...)
+	if err != nil {
+		return nil, err
+	}
+	SortBy(unidades)
+	return &Condominio{
+		Logradouro: endereco.Logradouro,
+		Numero:     numero,
+		CEP:        endereco.CEP,
+		Unidades:   unidades,
+	}, nil
+}
+
+func (a *CondominioAggregate) add(sql, codigo, mensagem string) {
+	a.Inconsistencias = append(a.Inconsistencias, CondominioInconsistencia{SQL: sql, Codigo: codigo, Mensagem: mensagem})
+}
+
+// enderecoPredominante returns the most frequent address of units, the
+// first one seen on ties.
+func enderecoPredominante(units []ConsultaEnderecoResult) (string, string) {
+	type endereco struct{ logradouro, numero string }
+	counts := make(map[endereco]int)
+	var best endereco
+	for _, u := range units {
+		e := endereco{bairroKey(u.Logradouro), u.Numero}
+		counts[e]++
+		if counts[e] > counts[best] {
+			best = e
+		}
+	}
+	for _, u := range units {
+		if bairroKey(u.Logradouro) == best.logradouro && u.Numero == best.numero {
+			return u.Logradouro, u.Numero
+		}
+	}
+	return "", ""
+}
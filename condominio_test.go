@@ -0,0 +1,99 @@
+package iptuapi
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func unidade(sql string, area, fracao, valor float64) ConsultaEnderecoResult {
+	return ConsultaEnderecoResult{SQL: sql, Logradouro: "Rua Augusta", Numero: "100",
+		AreaConstruida: area, FracaoIdeal: fracao, ValorVenalTotal: valor, IPTUValor: valor / 100}
+}
+
+func TestAggregateCondominio(t *testing.T) {
+	a := AggregateCondominio([]ConsultaEnderecoResult{
+		unidade("1", 50, 0.25, 300000.10),
+		unidade("2", 50, 0.25, 300000.10),
+		unidade("3", 100, 0.5, 600000.10),
+	})
+	assert.True(t, a.Consistente(), "%+v", a.Inconsistencias)
+	assert.Equal(t, "Rua Augusta", a.Logradouro)
+	assert.Equal(t, 3, a.Unidades)
+	assert.Equal(t, 1200000.30, a.ValorVenalTotal)
+	assert.Equal(t, 200.0, a.AreaConstruidaTotal)
+	assert.Equal(t, 1.0, a.FracaoIdealTotal)
+
+	// Percentages are normalized.
+	a = AggregateCondominio([]ConsultaEnderecoResult{unidade("1", 50, 50, 1), unidade("2", 50, 50, 1)})
+	assert.True(t, a.Consistente(), "%+v", a.Inconsistencias)
+	assert.Equal(t, 1.0, a.FracaoIdealTotal)
+
+	// Cadastres without fractions are not flagged.
+	a = AggregateCondominio([]ConsultaEnderecoResult{unidade("1", 50, 0, 1), unidade("2", 50, 0, 1)})
+	assert.True(t, a.Consistente())
+}
+
+func TestAggregateCondominioInconsistencies(t *testing.T) {
+	vizinho := unidade("9", 50, 0.2, 1)
+	vizinho.Numero = "120"
+	a := AggregateCondominio([]ConsultaEnderecoResult{
+		unidade("1", 50, 0.2, 1),
+		unidade("1", 50, 0.2, 1),
+		unidade("2", 50, 0.6, 1),
+		unidade("3", 50, 0.2, 0),
+		vizinho,
+	})
+	codigos := make(map[string][]string)
+	for _, inc := range a.Inconsistencias {
+		codigos[inc.Codigo] = append(codigos[inc.Codigo], inc.SQL)
+	}
+	assert.Equal(t, []string{"1"}, codigos[CondominioSQLDuplicado])
+	assert.Equal(t, []string{"9"}, codigos[CondominioEnderecoDivergente])
+	assert.Equal(t, []string{"3"}, codigos[CondominioSemValor])
+	assert.Equal(t, []string{""}, codigos[CondominioFracaoIdealSoma])
+	assert.Equal(t, []string{"2"}, codigos[CondominioFracaoDesproporcional])
+	assert.Equal(t, 4, a.Unidades)
+	assert.Equal(t, 1.2, a.FracaoIdealTotal)
+
+	a = AggregateCondominio([]ConsultaEnderecoResult{unidade("1", 50, 0.5, 1), unidade("2", 50, 0, 1)})
+	assert.Equal(t, CondominioSemFracaoIdeal, a.Inconsistencias[0].Codigo)
+	assert.Len(t, a.Inconsistencias, 1, "the sum is not checked with fractions missing")
+}
+
+func TestConsultaCondominio(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/consulta/sql/000.000.0002-1":
+			w.Write([]byte(`{"sql":"000.000.0002-1","logradouro":"Rua Augusta","numero":"100"}`))
+		case "/consulta/endereco":
+			assert.Equal(t, "Rua Augusta", r.URL.Query().Get("logradouro"))
+			assert.Equal(t, "100", r.URL.Query().Get("numero"))
+			w.Write([]byte(`{"sql":"000.000.0001-1","logradouro":"Rua Augusta","numero":"100","cep":"01305-000"}`))
+		case "/consulta/cep/01305-000":
+			w.Write([]byte(`[
+				{"sql":"000.000.0002-1","logradouro":"Rua Augusta","numero":"100","complemento":"Apto 12","valor_venal_total":400000,"area_construida":70},
+				{"sql":"000.000.0009-1","logradouro":"Rua Augusta","numero":"102"},
+				{"sql":"000.000.0001-1","logradouro":"Rua Augusta","numero":"100","complemento":"Apto 11","valor_venal_total":410000,"area_construida":72}
+			]`))
+		default:
+			t.Errorf("unexpected path %s", r.URL.Path)
+		}
+	}))
+	defer server.Close()
+
+	client := NewClient("test_key", WithBaseURL(server.URL), WithRetry(&RetryConfig{MaxRetries: 0}))
+	predio, err := client.ConsultaCondominio(context.Background(), &ConsultaCondominioParams{SQL: "000.000.0002-1"})
+	require.NoError(t, err)
+	assert.Equal(t, "01305-000", predio.CEP)
+	require.Len(t, predio.Unidades, 2)
+	assert.Equal(t, "000.000.0001-1", predio.Unidades[0].SQL)
+	assert.Equal(t, 810000.0, predio.Aggregate().ValorVenalTotal)
+
+	_, err = client.ConsultaCondominio(context.Background(), &ConsultaCondominioParams{Logradouro: "Rua Augusta"})
+	assert.True(t, IsValidation(err))
+}
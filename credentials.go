@@ -0,0 +1,108 @@
+package iptuapi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// maxClockSkew is the clock difference above which VerifyCredentials
+// reports a problem.
+const maxClockSkew = 30 * time.Second
+
+// CredentialsReport is the result of VerifyCredentials.
+type CredentialsReport struct {
+	// Plano is the plan reported by the API in the X-Plan header, or empty
+	// if it was not reported.
+	Plano Plano `json:"plano,omitempty"`
+	// RateLimit is the quota reported with the response, if any.
+	RateLimit *RateLimitInfo `json:"rate_limit,omitempty"`
+	// ClockSkew is the local clock minus the API clock, from the Date
+	// header; positive means the local clock is ahead. Its resolution is
+	// one second.
+	ClockSkew time.Duration `json:"clock_skew_ns"`
+	Latency   time.Duration `json:"latency_ns"`
+	RequestID string        `json:"request_id,omitempty"`
+	// Problems lists conditions that do not prevent calls but need
+	// attention, such as an exhausted quota or a wrong local clock.
+	Problems []string `json:"problems,omitempty"`
+}
+
+// CredentialsError is returned by VerifyCredentials when the client cannot
+// make authenticated calls. Hint says what to fix.
+type CredentialsError struct {
+	Hint string
+	Err  error
+}
+
+func (e *CredentialsError) Error() string {
+	if e.Err == nil {
+		return "iptuapi: credentials check failed: " + e.Hint
+	}
+	return fmt.Sprintf("iptuapi: credentials check failed: %v: %s", e.Err, e.Hint)
+}
+
+func (e *CredentialsError) Unwrap() error {
+	return e.Err
+}
+
+// VerifyCredentials makes one cheap authenticated call and reports the
+// plan, quota and clock skew. Call it at startup so that a missing or
+// revoked key, an unreachable API or a wrong clock surface immediately
+// rather than on the first real lookup. The call bypasses the cache.
+func (c *Client) VerifyCredentials(ctx context.Context) (*CredentialsReport, error) {
+	if c.apiKey == "" {
+		return nil, &CredentialsError{Hint: "no API key configured; pass it to NewClient"}
+	}
+
+	var (
+		result CidadesResult
+		header http.Header
+	)
+	start := time.Now()
+	err := c.doRequest(ctx, "GET", "/iptu-tools/cidades", nil, nil, &result, withResponseHeader(&header))
+	received := time.Now()
+	if err != nil && !IsRateLimit(err) {
+		return nil, &CredentialsError{Hint: credentialsHint(err, c.baseURL), Err: err}
+	}
+
+	report := &CredentialsReport{Latency: received.Sub(start), ClockSkew: c.ClockSkew()}
+	report.RateLimit, report.RequestID = c.lastResponseInfo()
+	if header != nil {
+		report.Plano = Plano(header.Get("X-Plan"))
+		report.RequestID = header.Get("X-Request-ID")
+	}
+
+	if err != nil {
+		report.Problems = append(report.Problems, "the key is valid but the quota is exhausted; calls will fail until the rate limit resets")
+	} else if rl := report.RateLimit; rl != nil && rl.Limit > 0 && rl.Remaining == 0 {
+		report.Problems = append(report.Problems, "the quota is exhausted; calls will fail until "+rl.ResetTime.Format(time.RFC3339))
+	}
+	if skew := report.ClockSkew; skew > maxClockSkew || skew < -maxClockSkew {
+		report.Problems = append(report.Problems, fmt.Sprintf(
+			"the local clock differs from the API clock by %v; synchronize it with NTP so rate-limit reset times are correct", skew))
+	}
+	return report, nil
+}
+
+// credentialsHint suggests a fix for a failed credentials check.
+func credentialsHint(err error, baseURL string) string {
+	var urlErr *url.Error
+	switch {
+	case IsAuthError(err):
+		return "the API key was rejected; check that it was copied in full and is active in the dashboard"
+	case IsForbidden(err):
+		return "the API key is valid but its plan does not allow this call"
+	case IsServerError(err) || IsFonteIndisponivel(err):
+		return "the API is unavailable; try again later"
+	case errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded):
+		return "the check timed out; the API may be unreachable from this network"
+	case errors.As(err, &urlErr):
+		return "could not reach " + baseURL + "; check DNS, proxy and firewall settings"
+	default:
+		return "unexpected response; include the request ID when contacting support"
+	}
+}
@@ -0,0 +1,63 @@
+package iptuapi
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVerifyCredentials(t *testing.T) {
+	serverNow := time.Now().Add(-2 * time.Minute)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/iptu-tools/cidades", r.URL.Path)
+		w.Header().Set("Date", serverNow.UTC().Format(http.TimeFormat))
+		w.Header().Set("X-Plan", "pro")
+		w.Header().Set("X-Request-ID", "req-1")
+		w.Header().Set("X-RateLimit-Limit", "1000")
+		w.Header().Set("X-RateLimit-Remaining", "999")
+		w.Header().Set("X-RateLimit-Reset", strconv.FormatInt(time.Now().Add(time.Hour).Unix(), 10))
+		w.Write([]byte(`{"cidades":[],"total":0}`))
+	}))
+	defer server.Close()
+
+	client := NewClient("test_key", WithBaseURL(server.URL))
+	report, err := client.VerifyCredentials(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, PlanoPro, report.Plano)
+	assert.Equal(t, "req-1", report.RequestID)
+	require.NotNil(t, report.RateLimit)
+	assert.Equal(t, 999, report.RateLimit.Remaining)
+	assert.InDelta(t, (2 * time.Minute).Seconds(), report.ClockSkew.Seconds(), 2)
+	require.Len(t, report.Problems, 1)
+	assert.Contains(t, report.Problems[0], "NTP")
+}
+
+func TestVerifyCredentialsFailures(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+		w.Write([]byte(`{"detail":"API key invalida"}`))
+	}))
+	defer server.Close()
+
+	_, err := NewClient("", WithBaseURL(server.URL)).VerifyCredentials(context.Background())
+	assert.ErrorContains(t, err, "no API key configured")
+
+	_, err = NewClient("bad", WithBaseURL(server.URL)).VerifyCredentials(context.Background())
+	var credErr *CredentialsError
+	require.True(t, errors.As(err, &credErr))
+	assert.Contains(t, credErr.Hint, "rejected")
+	var authErr *AuthenticationError
+	assert.True(t, errors.As(err, &authErr))
+
+	server.Close()
+	_, err = NewClient("key", WithBaseURL(server.URL), WithRetry(&RetryConfig{NetworkRetries: -1})).VerifyCredentials(context.Background())
+	require.True(t, errors.As(err, &credErr))
+	assert.Contains(t, credErr.Hint, "could not reach")
+}
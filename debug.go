@@ -0,0 +1,146 @@
+package iptuapi
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// maxDebugBody caps the bytes of each body written by the debug dump.
+const maxDebugBody = 64 << 10
+
+// sensitiveHeaders are masked in debug dumps.
+var sensitiveHeaders = map[string]bool{
+	"X-Api-Key":     true,
+	"Authorization": true,
+	"Cookie":        true,
+	"Set-Cookie":    true,
+}
+
+// debugState holds the WithDebug writer and the runtime toggle.
+type debugState struct {
+	enabled atomic.Bool
+	mu      sync.Mutex
+	w       io.Writer
+}
+
+// WithDebug dumps every HTTP attempt to w: method, URL, headers and body of
+// the request and the response, with API keys and cookies masked. Use it to
+// troubleshoot mismatched parameters or unexpected 422 responses; it is too
+// verbose for production. See Client.SetDebug.
+func WithDebug(w io.Writer) ClientOption {
+	return func(c *Client) {
+		c.debug.w = w
+		c.debug.enabled.Store(w != nil)
+	}
+}
+
+// SetDebug turns the debug dump on or off at runtime. Without WithDebug the
+// dump goes to os.Stderr. It is safe for concurrent use with calls.
+func (c *Client) SetDebug(on bool) {
+	c.debug.enabled.Store(on)
+}
+
+// debugTransport dumps authenticated attempts while debugging is on. It sits
+// below the auth layer so the dump shows the headers actually sent.
+type debugTransport struct {
+	c    *Client
+	next http.RoundTripper
+}
+
+func (t *debugTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if !t.c.debug.enabled.Load() {
+		return t.next.RoundTrip(req)
+	}
+
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "--> %s %s\n", req.Method, t.c.redactString(req.URL.String()))
+	t.writeHeader(&b, req.Header)
+	if req.Body != nil && req.GetBody != nil {
+		if body, err := req.GetBody(); err == nil {
+			data, _ := io.ReadAll(body)
+			body.Close()
+			t.writeBody(&b, data)
+		}
+	}
+
+	start := time.Now()
+	resp, err := t.next.RoundTrip(req)
+	latency := time.Since(start).Round(time.Millisecond)
+	if err != nil {
+		fmt.Fprintf(&b, "<-- error (%v): %s\n", latency, t.c.redactString(err.Error()))
+		t.flush(b.Bytes())
+		return nil, err
+	}
+
+	data, readErr := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	resp.Body = io.NopCloser(bytes.NewReader(data))
+	fmt.Fprintf(&b, "<-- %s (%v)\n", resp.Status, latency)
+	t.writeHeader(&b, resp.Header)
+	t.writeBody(&b, data)
+	if readErr != nil {
+		fmt.Fprintf(&b, "(body read failed: %v)\n", readErr)
+		resp.Body = io.NopCloser(io.MultiReader(bytes.NewReader(data), errReader{readErr}))
+	}
+	t.flush(b.Bytes())
+	return resp, nil
+}
+
+func (t *debugTransport) writeHeader(b *bytes.Buffer, h http.Header) {
+	names := make([]string, 0, len(h))
+	for name := range h {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		for _, v := range h[name] {
+			if sensitiveHeaders[http.CanonicalHeaderKey(name)] {
+				v = maskKey(v)
+			}
+			fmt.Fprintf(b, "%s: %s\n", name, v)
+		}
+	}
+}
+
+func (t *debugTransport) writeBody(b *bytes.Buffer, data []byte) {
+	if len(data) == 0 {
+		return
+	}
+	b.WriteByte('\n')
+	truncated := len(data) - maxDebugBody
+	if truncated > 0 {
+		data = data[:maxDebugBody]
+	}
+	b.WriteString(t.c.redactString(string(data)))
+	if data[len(data)-1] != '\n' {
+		b.WriteByte('\n')
+	}
+	if truncated > 0 {
+		fmt.Fprintf(b, "... (%d bytes truncated)\n", truncated)
+	}
+}
+
+// flush writes one dump at a time, so concurrent attempts do not
+// interleave.
+func (t *debugTransport) flush(dump []byte) {
+	d := &t.c.debug
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	w := d.w
+	if w == nil {
+		w = os.Stderr
+	}
+	w.Write(append(dump, '\n'))
+}
+
+// errReader returns err once the buffered body has been read.
+type errReader struct{ err error }
+
+func (r errReader) Read([]byte) (int, error) { return 0, r.err }
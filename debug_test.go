@@ -0,0 +1,41 @@
+package iptuapi
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithDebug(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		w.Write([]byte(`{"detail":"area_construida invalida"}`))
+	}))
+	defer server.Close()
+
+	var buf bytes.Buffer
+	client := NewClient("secret_key_1234", WithBaseURL(server.URL),
+		WithRetry(&RetryConfig{MaxRetries: 0}), WithDebug(&buf))
+
+	_, err := client.ValuationEstimate(context.Background(), &ValuationParams{AreaTerreno: 100, AreaConstruida: -1, Bairro: "Pinheiros"}, WithSkipValidation())
+	require.Error(t, err)
+
+	dump := buf.String()
+	assert.Contains(t, dump, "--> POST "+server.URL+"/valuation/estimate")
+	assert.Contains(t, dump, "X-Api-Key: ****1234")
+	assert.NotContains(t, dump, "secret_key_1234")
+	assert.Contains(t, dump, `"area_construida":-1`)
+	assert.Contains(t, dump, "<-- 422 Unprocessable Entity")
+	assert.Contains(t, dump, `{"detail":"area_construida invalida"}`)
+
+	buf.Reset()
+	client.SetDebug(false)
+	_, err = client.ValuationEstimate(context.Background(), &ValuationParams{AreaTerreno: 100, AreaConstruida: -1, Bairro: "Pinheiros"}, WithSkipValidation())
+	require.Error(t, err)
+	assert.Empty(t, buf.String())
+}
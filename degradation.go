@@ -0,0 +1,68 @@
+package iptuapi
+
+import (
+	"context"
+	"log/slog"
+)
+
+// AvisoEnriquecimentoOmitido marks results whose optional enrichments were
+// dropped by WithDegradation. Campos lists the dropped sections.
+const AvisoEnriquecimentoOmitido = "ENRIQUECIMENTO_OMITIDO"
+
+// WithDegradation drops the optional enrichments of ConsultaEndereco
+// (IncluirHistorico, IncluirComparaveis, IncluirZoneamento) while the
+// rate-limit headroom, Remaining/Limit from the last response, is below
+// threshold (e.g. 0.1 for 10%). Core lookups keep working during traffic
+// spikes; affected results have Degraded set and an
+// AvisoEnriquecimentoOmitido warning.
+func WithDegradation(threshold float64) ClientOption {
+	return func(c *Client) {
+		c.degradeBelow = threshold
+	}
+}
+
+// underPressure reports whether the rate-limit headroom is below the
+// WithDegradation threshold.
+func (c *Client) underPressure() bool {
+	if c.degradeBelow <= 0 {
+		return false
+	}
+	rl, _ := c.lastResponseInfo()
+	if rl == nil || rl.Limit <= 0 || !c.clock.Now().Before(rl.ResetTime) {
+		return false
+	}
+	return float64(rl.Remaining)/float64(rl.Limit) < c.degradeBelow
+}
+
+// degrade returns a copy of p without optional enrichments and the names
+// of the dropped sections, or p and nil if nothing is dropped.
+func (c *Client) degrade(ctx context.Context, p *ConsultaEnderecoParams) (*ConsultaEnderecoParams, []string) {
+	if !(p.IncluirHistorico || p.IncluirComparaveis || p.IncluirZoneamento) || !c.underPressure() {
+		return p, nil
+	}
+	var dropped []string
+	if p.IncluirHistorico {
+		dropped = append(dropped, "historico")
+	}
+	if p.IncluirComparaveis {
+		dropped = append(dropped, "comparaveis")
+	}
+	if p.IncluirZoneamento {
+		dropped = append(dropped, "zoneamento")
+	}
+	c.log(ctx, SubsystemHTTP, slog.LevelInfo, "Low rate-limit headroom, dropping optional enrichments", "dropped", dropped)
+
+	cp := *p
+	cp.IncluirHistorico, cp.IncluirComparaveis, cp.IncluirZoneamento = false, false, false
+	return &cp, dropped
+}
+
+func markDegraded(r *ConsultaEnderecoResult, dropped []string) {
+	r.Degraded = true
+	r.Avisos = append(r.Avisos, Aviso{
+		Codigo:   AvisoEnriquecimentoOmitido,
+		Fonte:    "sdk",
+		Mensagem: "Enriquecimentos opcionais omitidos por limite de requisições",
+		Campos:   dropped,
+	})
+}
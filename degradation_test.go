@@ -0,0 +1,58 @@
+package iptuapi
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithDegradation(t *testing.T) {
+	var queries []url.Values
+	remaining := "50"
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		queries = append(queries, r.URL.Query())
+		w.Header().Set("X-RateLimit-Limit", "100")
+		w.Header().Set("X-RateLimit-Remaining", remaining)
+		w.Header().Set("X-RateLimit-Reset", strconv.FormatInt(time.Now().Add(time.Hour).Unix(), 10))
+		w.Write([]byte(`{"sql":"000.000.0000-0"}`))
+	}))
+	defer server.Close()
+
+	client := NewClient("test_key", WithBaseURL(server.URL), WithDegradation(0.1))
+	p := &ConsultaEnderecoParams{Logradouro: "Avenida Paulista", IncluirHistorico: true, IncluirZoneamento: true}
+
+	result, err := client.ConsultaEndereco(context.Background(), p)
+	require.NoError(t, err)
+	assert.False(t, result.Degraded)
+	assert.Equal(t, "true", queries[0].Get("incluir_historico"))
+
+	remaining = "5"
+	_, err = client.ConsultaEndereco(context.Background(), p)
+	require.NoError(t, err)
+
+	result, err = client.ConsultaEndereco(context.Background(), p)
+	require.NoError(t, err)
+	assert.True(t, result.Degraded)
+	assert.True(t, result.Avisos.Degradado())
+	assert.Equal(t, []string{"historico", "zoneamento"}, result.Avisos[0].Campos)
+	assert.Empty(t, queries[2].Get("incluir_historico"))
+	assert.Empty(t, queries[2].Get("incluir_zoneamento"))
+	assert.True(t, p.IncluirHistorico, "caller params are not modified")
+}
+
+func TestWithDegradationCoreLookup(t *testing.T) {
+	client := NewClient("test_key", WithDegradation(0.1))
+	client.RateLimit = &RateLimitInfo{Limit: 100, Remaining: 0, ResetTime: time.Now().Add(time.Hour)}
+
+	p := &ConsultaEnderecoParams{Logradouro: "Avenida Paulista"}
+	got, dropped := client.degrade(context.Background(), p)
+	assert.Same(t, p, got)
+	assert.Nil(t, dropped)
+}
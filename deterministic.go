@@ -0,0 +1,42 @@
+package iptuapi
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"net/url"
+	"strconv"
+)
+
+// Reproducao records what is needed to reproduce a valuation later: the
+// hash of the canonical inputs, the seed sent to the API and the model
+// version that answered.
+type Reproducao struct {
+	InputsHash   string `json:"inputs_hash"`
+	Seed         int64  `json:"seed"`
+	ModeloVersao string `json:"modelo_versao,omitempty"`
+}
+
+// WithDeterministic asks the API for a reproducible result. The seed is
+// derived from the canonical inputs, so the same inputs always send the same
+// seed, and the result carries a Reproducao record to store alongside it.
+// It currently applies to ValuationEstimate.
+func WithDeterministic(on bool) RequestOption {
+	return func(o *requestOptions) {
+		o.deterministic = on
+	}
+}
+
+func newReproducao(method, endpoint string, params url.Values) *Reproducao {
+	hash := CanonicalHash(method, endpoint, params)
+	raw, _ := hex.DecodeString(hash[:16])
+	seed := int64(binary.BigEndian.Uint64(raw) >> 1)
+	return &Reproducao{InputsHash: hash, Seed: seed}
+}
+
+// option sends the reproducibility parameters.
+func (r *Reproducao) option() RequestOption {
+	return func(o *requestOptions) {
+		o.setQuery("deterministic", "true")
+		o.setQuery("seed", strconv.FormatInt(r.Seed, 10))
+	}
+}
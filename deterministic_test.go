@@ -0,0 +1,48 @@
+package iptuapi
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithDeterministic(t *testing.T) {
+	var seeds []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		seeds = append(seeds, q.Get("seed"))
+		if q.Get("deterministic") != "" {
+			assert.Equal(t, "true", q.Get("deterministic"))
+		}
+		w.Header().Set("X-Model-Version", "avm-2026.03")
+		json.NewEncoder(w).Encode(ValuationResult{ValorEstimado: 1000000})
+	}))
+	defer server.Close()
+
+	client := NewClient("test_key", WithBaseURL(server.URL), WithRetry(&RetryConfig{MaxRetries: 0}))
+	ctx := context.Background()
+	p := &ValuationParams{AreaTerreno: 250, AreaConstruida: 180, Bairro: "Pinheiros"}
+
+	first, err := client.ValuationEstimate(ctx, p, WithDeterministic(true))
+	require.NoError(t, err)
+	second, err := client.ValuationEstimate(ctx, p, WithDeterministic(true))
+	require.NoError(t, err)
+	plain, err := client.ValuationEstimate(ctx, p)
+	require.NoError(t, err)
+
+	require.NotNil(t, first.Reproducao)
+	assert.Equal(t, first.Reproducao, second.Reproducao)
+	assert.Equal(t, "avm-2026.03", first.Reproducao.ModeloVersao)
+	assert.Equal(t, CanonicalHash("POST", "/valuation/estimate", p.Values()), first.Reproducao.InputsHash)
+	assert.NotEmpty(t, seeds[0])
+	assert.Equal(t, seeds[0], seeds[1])
+
+	assert.Nil(t, plain.Reproducao)
+	assert.Empty(t, seeds[2])
+	assert.Equal(t, "avm-2026.03", plain.ModeloVersao)
+}
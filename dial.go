@@ -0,0 +1,303 @@
+package iptuapi
+
+import (
+	"context"
+	"crypto/tls"
+	"net"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+)
+
+// defaultConnectTimeout matches the dial timeout of http.DefaultTransport.
+const defaultConnectTimeout = 30 * time.Second
+
+// IPMode selects the address families used to connect to the API.
+type IPMode int
+
+const (
+	// IPDualStack connects over IPv6 or IPv4, racing the second family
+	// after the fallback delay ("happy eyeballs").
+	IPDualStack IPMode = iota
+	// IPv4Only never uses IPv6, for networks with broken IPv6 routes.
+	IPv4Only
+	// IPv6Only never uses IPv4.
+	IPv6Only
+)
+
+// dialConfig holds the connection options applied to the base transport.
+type dialConfig struct {
+	resolver       *net.Resolver
+	cache          *DNSCache
+	mode           IPMode
+	connectTimeout time.Duration
+	fallbackDelay  time.Duration
+	tlsConfig      *tls.Config
+	proxy          func(*http.Request) (*url.URL, error)
+}
+
+func (c *Client) dialConfig() *dialConfig {
+	if c.dial == nil {
+		c.dial = &dialConfig{}
+	}
+	return c.dial
+}
+
+// WithResolver sets the resolver used to look up the API host, e.g. one
+// pointing at a local caching DNS server.
+//
+// Dial options apply when the base transport is an *http.Transport (the
+// default); it is cloned, so a transport passed with WithHTTPClient is not
+// modified. Other RoundTrippers are used as is.
+func WithResolver(r *net.Resolver) ClientOption {
+	return func(c *Client) {
+		c.dialConfig().resolver = r
+	}
+}
+
+// WithDNSCache resolves the API host through cache, so high-QPS workers do
+// not look it up for every new connection. The cache may be shared by
+// several clients. See WithResolver for when dial options apply.
+func WithDNSCache(cache *DNSCache) ClientOption {
+	return func(c *Client) {
+		c.dialConfig().cache = cache
+	}
+}
+
+// WithIPMode restricts the address families used to connect (default
+// IPDualStack). See WithResolver for when dial options apply.
+func WithIPMode(mode IPMode) ClientOption {
+	return func(c *Client) {
+		c.dialConfig().mode = mode
+	}
+}
+
+// WithConnectTimeout bounds each TCP connection attempt (default 30s). With
+// a DNS cache the timeout applies to each cached address in turn, so a dead
+// address fails fast and the next one is tried. It does not bound the
+// request; see WithTimeout and WithEndpointTimeouts.
+func WithConnectTimeout(d time.Duration) ClientOption {
+	return func(c *Client) {
+		c.dialConfig().connectTimeout = d
+	}
+}
+
+// WithFallbackDelay sets how long IPDualStack waits on the first address
+// family before racing the other (default 300ms). A negative value disables
+// the race, trying addresses one at a time.
+func WithFallbackDelay(d time.Duration) ClientOption {
+	return func(c *Client) {
+		c.dialConfig().fallbackDelay = d
+	}
+}
+
+// WithTLSConfig sets the TLS configuration used to connect to the API, e.g.
+// to trust a corporate root CA or pin certificates with
+// VerifyPeerCertificate. The config is cloned. See WithResolver for when
+// dial options apply.
+func WithTLSConfig(cfg *tls.Config) ClientOption {
+	return func(c *Client) {
+		c.dialConfig().tlsConfig = cfg.Clone()
+	}
+}
+
+// WithProxy sets the function choosing the proxy for each request, e.g.
+// http.ProxyURL(u). By default the HTTP_PROXY/HTTPS_PROXY environment
+// variables are used; a function returning nil connects directly. See
+// WithResolver for when dial options apply.
+func WithProxy(proxy func(*http.Request) (*url.URL, error)) ClientOption {
+	return func(c *Client) {
+		c.dialConfig().proxy = proxy
+	}
+}
+
+// applyDial installs the dial options into the base transport.
+func (c *Client) applyDial() {
+	if c.dial == nil {
+		return
+	}
+	var t *http.Transport
+	switch base := c.httpClient.Transport.(type) {
+	case nil:
+		t = http.DefaultTransport.(*http.Transport).Clone()
+	case *http.Transport:
+		t = base.Clone()
+	default:
+		return
+	}
+	t.DialContext = c.dial.dialContext
+	if c.dial.tlsConfig != nil {
+		t.TLSClientConfig = c.dial.tlsConfig
+	}
+	if c.dial.proxy != nil {
+		t.Proxy = c.dial.proxy
+	}
+	c.httpClient.Transport = t
+}
+
+func (d *dialConfig) dialContext(ctx context.Context, network, addr string) (net.Conn, error) {
+	dialer := &net.Dialer{
+		Timeout:       d.connectTimeout,
+		KeepAlive:     30 * time.Second,
+		Resolver:      d.resolver,
+		FallbackDelay: d.fallbackDelay,
+	}
+	if dialer.Timeout <= 0 {
+		dialer.Timeout = defaultConnectTimeout
+	}
+	switch d.mode {
+	case IPv4Only:
+		network = "tcp4"
+	case IPv6Only:
+		network = "tcp6"
+	}
+
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil || d.cache == nil || net.ParseIP(host) != nil {
+		return dialer.DialContext(ctx, network, addr)
+	}
+
+	addrs, err := d.cache.lookup(ctx, d.resolver, host)
+	if err != nil {
+		return nil, err
+	}
+	addrs = d.filter(addrs)
+	if len(addrs) == 0 {
+		return nil, &net.DNSError{Err: "no addresses for " + network, Name: host, IsNotFound: true}
+	}
+	var firstErr error
+	for _, ip := range addrs {
+		conn, err := dialer.DialContext(ctx, network, net.JoinHostPort(ip, port))
+		if err == nil {
+			return conn, nil
+		}
+		if firstErr == nil {
+			firstErr = err
+		}
+		if ctx.Err() != nil {
+			break
+		}
+	}
+	// None of the cached addresses answered; look the host up again next time.
+	d.cache.Forget(host)
+	return nil, firstErr
+}
+
+// filter drops the addresses of families excluded by the IP mode.
+func (d *dialConfig) filter(addrs []string) []string {
+	if d.mode == IPDualStack {
+		return addrs
+	}
+	out := make([]string, 0, len(addrs))
+	for _, a := range addrs {
+		ip := net.ParseIP(a)
+		if ip == nil {
+			continue
+		}
+		if is4 := ip.To4() != nil; is4 == (d.mode == IPv4Only) {
+			out = append(out, a)
+		}
+	}
+	return out
+}
+
+// DNSCache caches host lookups for TTL. When a refresh fails, the expired
+// addresses are used until a lookup succeeds again, so a resolver hiccup
+// does not fail requests. Concurrent lookups of the same host are merged.
+type DNSCache struct {
+	ttl time.Duration
+
+	mu       sync.Mutex
+	entries  map[string]dnsEntry
+	inflight map[string]*dnsCall
+
+	// lookupHost replaces the resolver in tests.
+	lookupHost func(ctx context.Context, host string) ([]string, error)
+	now        func() time.Time
+}
+
+type dnsEntry struct {
+	addrs   []string
+	expires time.Time
+}
+
+type dnsCall struct {
+	done  chan struct{}
+	addrs []string
+	err   error
+}
+
+// NewDNSCache returns a cache keeping addresses for ttl (one minute if ttl
+// is not positive).
+func NewDNSCache(ttl time.Duration) *DNSCache {
+	if ttl <= 0 {
+		ttl = time.Minute
+	}
+	return &DNSCache{
+		ttl:      ttl,
+		entries:  make(map[string]dnsEntry),
+		inflight: make(map[string]*dnsCall),
+		now:      time.Now,
+	}
+}
+
+// Forget drops the cached addresses of host.
+func (d *DNSCache) Forget(host string) {
+	d.mu.Lock()
+	delete(d.entries, host)
+	d.mu.Unlock()
+}
+
+func (d *DNSCache) lookup(ctx context.Context, resolver *net.Resolver, host string) ([]string, error) {
+	d.mu.Lock()
+	e, cached := d.entries[host]
+	if cached && d.now().Before(e.expires) {
+		d.mu.Unlock()
+		return e.addrs, nil
+	}
+	call, ok := d.inflight[host]
+	if !ok {
+		call = &dnsCall{done: make(chan struct{})}
+		d.inflight[host] = call
+		go d.resolve(resolver, host, call)
+	}
+	d.mu.Unlock()
+
+	select {
+	case <-call.done:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	if call.err != nil {
+		if cached {
+			return e.addrs, nil
+		}
+		return nil, call.err
+	}
+	return call.addrs, nil
+}
+
+// resolve runs one lookup detached from the caller's context, so a
+// cancelled caller does not fail the others waiting on it.
+func (d *DNSCache) resolve(resolver *net.Resolver, host string, call *dnsCall) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	lookup := d.lookupHost
+	if lookup == nil {
+		if resolver == nil {
+			resolver = net.DefaultResolver
+		}
+		lookup = resolver.LookupHost
+	}
+	call.addrs, call.err = lookup(ctx, host)
+
+	d.mu.Lock()
+	if call.err == nil {
+		d.entries[host] = dnsEntry{addrs: call.addrs, expires: d.now().Add(d.ttl)}
+	}
+	delete(d.inflight, host)
+	d.mu.Unlock()
+	close(call.done)
+}
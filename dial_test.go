@@ -0,0 +1,155 @@
+package iptuapi
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDNSCache(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+	var lookups int32
+	fail := false
+	cache := NewDNSCache(time.Minute)
+	cache.now = func() time.Time { return now }
+	cache.lookupHost = func(ctx context.Context, host string) ([]string, error) {
+		atomic.AddInt32(&lookups, 1)
+		if fail {
+			return nil, errors.New("resolver unavailable")
+		}
+		return []string{"10.0.0.1"}, nil
+	}
+	ctx := context.Background()
+
+	addrs, err := cache.lookup(ctx, nil, "api.iptuapi.com.br")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"10.0.0.1"}, addrs)
+	_, err = cache.lookup(ctx, nil, "api.iptuapi.com.br")
+	require.NoError(t, err)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&lookups), "served from cache")
+
+	now = now.Add(2 * time.Minute)
+	fail = true
+	addrs, err = cache.lookup(ctx, nil, "api.iptuapi.com.br")
+	require.NoError(t, err, "stale addresses survive a failed refresh")
+	assert.Equal(t, []string{"10.0.0.1"}, addrs)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&lookups))
+
+	cache.Forget("api.iptuapi.com.br")
+	_, err = cache.lookup(ctx, nil, "api.iptuapi.com.br")
+	assert.Error(t, err)
+}
+
+func TestWithDNSCache(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"sql":"000.000.0000-0"}`))
+	}))
+	defer server.Close()
+	u, err := url.Parse(server.URL)
+	require.NoError(t, err)
+
+	var lookups int32
+	cache := NewDNSCache(time.Minute)
+	cache.lookupHost = func(ctx context.Context, host string) ([]string, error) {
+		atomic.AddInt32(&lookups, 1)
+		assert.Equal(t, "api.test", host)
+		return []string{u.Hostname()}, nil
+	}
+
+	client := NewClient("test_key", WithBaseURL("http://api.test:"+u.Port()),
+		WithRetry(&RetryConfig{MaxRetries: 0}), WithDNSCache(cache))
+	for i := 0; i < 3; i++ {
+		_, err := client.ConsultaSQL(context.Background(), "000.000.0000-0", CidadeSaoPaulo)
+		require.NoError(t, err)
+	}
+	assert.Equal(t, int32(1), atomic.LoadInt32(&lookups))
+}
+
+func TestDialOptionsKeepCustomTransport(t *testing.T) {
+	custom := roundTripFunc(func(req *http.Request) (*http.Response, error) { return nil, errors.New("unused") })
+	client := NewClient("test_key", WithHTTPClient(&http.Client{Transport: custom}), WithDNSCache(NewDNSCache(0)))
+	_, ok := client.httpClient.Transport.(roundTripFunc)
+	assert.True(t, ok, "non-*http.Transport bases are used as is")
+
+	base := &http.Transport{}
+	client = NewClient("test_key", WithHTTPClient(&http.Client{Transport: base}), WithDNSCache(NewDNSCache(0)))
+	assert.NotSame(t, base, client.httpClient.Transport)
+	assert.Nil(t, base.DialContext, "caller's transport is not modified")
+}
+
+func TestDialIPMode(t *testing.T) {
+	addrs := []string{"2001:db8::1", "10.0.0.1", "10.0.0.2"}
+	assert.Equal(t, addrs, (&dialConfig{}).filter(addrs))
+	assert.Equal(t, []string{"10.0.0.1", "10.0.0.2"}, (&dialConfig{mode: IPv4Only}).filter(addrs))
+	assert.Equal(t, []string{"2001:db8::1"}, (&dialConfig{mode: IPv6Only}).filter(addrs))
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"sql":"000.000.0000-0"}`))
+	}))
+	defer server.Close()
+	u, err := url.Parse(server.URL)
+	require.NoError(t, err)
+
+	newCache := func() *DNSCache {
+		cache := NewDNSCache(time.Minute)
+		cache.lookupHost = func(ctx context.Context, host string) ([]string, error) {
+			return []string{"2001:db8::1", u.Hostname()}, nil
+		}
+		return cache
+	}
+
+	client := NewClient("test_key", WithBaseURL("http://api.test:"+u.Port()),
+		WithRetry(&RetryConfig{MaxRetries: 0, NetworkRetries: -1}),
+		WithDNSCache(newCache()), WithIPMode(IPv4Only), WithConnectTimeout(time.Second))
+	_, err = client.ConsultaSQL(context.Background(), "000.000.0000-0", CidadeSaoPaulo)
+	require.NoError(t, err, "the unreachable IPv6 address is never dialed")
+
+	client = NewClient("test_key", WithBaseURL("http://api.test:"+u.Port()),
+		WithRetry(&RetryConfig{MaxRetries: 0, NetworkRetries: -1}),
+		WithDNSCache(newCache()), WithIPMode(IPv6Only), WithConnectTimeout(50*time.Millisecond))
+	_, err = client.ConsultaSQL(context.Background(), "000.000.0000-0", CidadeSaoPaulo)
+	assert.Error(t, err)
+}
+
+func TestWithTLSConfig(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"sql":"000.000.0000-0"}`))
+	}))
+	defer server.Close()
+
+	_, err := NewClient("test_key", WithBaseURL(server.URL), WithRetry(&RetryConfig{MaxRetries: 0})).
+		ConsultaSQL(context.Background(), "000.000.0000-0", CidadeSaoPaulo)
+	require.Error(t, err, "the test CA is not trusted by default")
+
+	pool := x509.NewCertPool()
+	pool.AddCert(server.Certificate())
+	client := NewClient("test_key", WithBaseURL(server.URL), WithTLSConfig(&tls.Config{RootCAs: pool}))
+	_, err = client.ConsultaSQL(context.Background(), "000.000.0000-0", CidadeSaoPaulo)
+	require.NoError(t, err)
+}
+
+func TestWithProxy(t *testing.T) {
+	var host string
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		host = r.Host
+		w.Write([]byte(`{"sql":"000.000.0000-0"}`))
+	}))
+	defer proxy.Close()
+	proxyURL, err := url.Parse(proxy.URL)
+	require.NoError(t, err)
+
+	client := NewClient("test_key", WithBaseURL("http://api.iptuapi.invalid/v1"), WithProxy(http.ProxyURL(proxyURL)))
+	_, err = client.ConsultaSQL(context.Background(), "000.000.0000-0", CidadeSaoPaulo)
+	require.NoError(t, err)
+	assert.Equal(t, "api.iptuapi.invalid", host)
+}
@@ -0,0 +1,69 @@
+package iptuapi
+
+import "context"
+
+// DicionarioCampo describes one field returned by the API.
+type DicionarioCampo struct {
+	// Campo is the JSON field name, e.g. "valor_venal_total".
+	Campo string `json:"campo"`
+	// Tipo is the JSON type: "string", "number", "integer", "boolean",
+	// "array" or "object".
+	Tipo string `json:"tipo"`
+	// Unidade is the unit of measure, e.g. "m2" or "BRL", when applicable.
+	Unidade string `json:"unidade,omitempty"`
+	// Descricao maps a language code ("pt", "en", "es") to the description.
+	Descricao map[string]string `json:"descricao"`
+	// Endpoints lists the endpoints that return the field.
+	Endpoints []string `json:"endpoints,omitempty"`
+	// Cidades lists the cities where the field is populated. Empty means
+	// every city.
+	Cidades []Cidade `json:"cidades,omitempty"`
+}
+
+// DescricaoEm returns the description in lang, falling back to Portuguese.
+func (f *DicionarioCampo) DescricaoEm(lang string) string {
+	if d, ok := f.Descricao[lang]; ok {
+		return d
+	}
+	return f.Descricao["pt"]
+}
+
+// DisponivelEm reports whether the field is populated for cidade.
+func (f *DicionarioCampo) DisponivelEm(cidade Cidade) bool {
+	if len(f.Cidades) == 0 {
+		return true
+	}
+	for _, c := range f.Cidades {
+		if c == cidade {
+			return true
+		}
+	}
+	return false
+}
+
+// DicionarioResult is the API data dictionary.
+type DicionarioResult struct {
+	Versao string            `json:"versao"`
+	Campos []DicionarioCampo `json:"campos"`
+}
+
+// Campo returns the entry for the named field.
+func (d *DicionarioResult) Campo(nome string) (*DicionarioCampo, bool) {
+	for i := range d.Campos {
+		if d.Campos[i].Campo == nome {
+			return &d.Campos[i], true
+		}
+	}
+	return nil, false
+}
+
+// Dicionario returns the data dictionary describing every field the API
+// returns, with units, per-language descriptions and per-city availability.
+func (c *Client) Dicionario(ctx context.Context, opts ...RequestOption) (*DicionarioResult, error) {
+	var result DicionarioResult
+	err := c.doRequest(ctx, "GET", "/dicionario", nil, nil, &result, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
@@ -0,0 +1,41 @@
+package iptuapi
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDicionario(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/dicionario", r.URL.Path)
+		w.Write([]byte(`{"versao":"2026.1","campos":[
+			{"campo":"area_terreno","tipo":"number","unidade":"m2",
+			 "descricao":{"pt":"Área do terreno","en":"Lot area"}},
+			{"campo":"zona","tipo":"string","descricao":{"pt":"Zona de uso"},"cidades":["sp"]}
+		]}`))
+	}))
+	defer server.Close()
+
+	client := NewClient("test_key", WithBaseURL(server.URL), WithRetry(&RetryConfig{MaxRetries: 0}))
+	dic, err := client.Dicionario(context.Background())
+	require.NoError(t, err)
+
+	area, ok := dic.Campo("area_terreno")
+	require.True(t, ok)
+	assert.Equal(t, "m2", area.Unidade)
+	assert.Equal(t, "Lot area", area.DescricaoEm("en"))
+	assert.True(t, area.DisponivelEm(CidadeCuritiba))
+
+	zona, ok := dic.Campo("zona")
+	require.True(t, ok)
+	assert.Equal(t, "Zona de uso", zona.DescricaoEm("es"))
+	assert.False(t, zona.DisponivelEm(CidadeCuritiba))
+
+	_, ok = dic.Campo("inexistente")
+	assert.False(t, ok)
+}
@@ -0,0 +1,76 @@
+package iptuapi
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// AvisoSecaoIndisponivel marks a Dossie section that could not be fetched.
+const AvisoSecaoIndisponivel = "SECAO_INDISPONIVEL"
+
+// Dossie gathers everything known about one property: the cadastral record,
+// IPTU history, ITBI transactions and neighborhood statistics.
+type Dossie struct {
+	SQL          string                     `json:"sql"`
+	Cidade       Cidade                     `json:"cidade"`
+	GeradoEm     time.Time                  `json:"gerado_em"`
+	Imovel       *ConsultaSQLResult         `json:"imovel"`
+	Historico    []HistoricoItem            `json:"historico,omitempty"`
+	Transacoes   []ITBITransacao            `json:"transacoes,omitempty"`
+	Estatisticas *ValuationStatisticsResult `json:"estatisticas,omitempty"`
+	// Avisos lists the sections that could not be fetched; the rest of the
+	// dossier is still usable.
+	Avisos Avisos `json:"avisos,omitempty"`
+}
+
+// DossieITBIAnos is how many years of ITBI transactions a Dossie includes.
+const DossieITBIAnos = 5
+
+// Dossie fetches the cadastral record for sql and then its history, the
+// last DossieITBIAnos years of ITBI transactions and the neighborhood
+// statistics. Only the cadastral lookup is required: a failure in any other
+// section is recorded in Avisos. Context cancellation aborts the whole call.
+// opts apply to every underlying call.
+func (c *Client) Dossie(ctx context.Context, sql string, cidade Cidade, opts ...RequestOption) (*Dossie, error) {
+	cidade = cidadeOrDefault(newRequestOptions(opts).cidadeOr(cidade))
+	imovel, err := c.ConsultaSQL(ctx, sql, cidade, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	d := &Dossie{SQL: sql, Cidade: cidade, GeradoEm: time.Now().UTC(), Imovel: imovel}
+	section := func(name string, err error) error {
+		if err == nil {
+			return nil
+		}
+		if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+			return err
+		}
+		d.Avisos = append(d.Avisos, Aviso{Codigo: AvisoSecaoIndisponivel, Fonte: name, Mensagem: err.Error()})
+		return nil
+	}
+
+	d.Historico, err = c.DadosIPTUHistorico(ctx, sql, cidade, opts...)
+	if err := section("historico", err); err != nil {
+		return nil, err
+	}
+
+	d.Transacoes, err = c.ITBITransacoes(ctx, &ITBIParams{
+		SQL:        sql,
+		Cidade:     cidade,
+		DataInicio: d.GeradoEm.AddDate(-DossieITBIAnos, 0, 0),
+		DataFim:    d.GeradoEm,
+	}, opts...)
+	if err := section("itbi", err); err != nil {
+		return nil, err
+	}
+
+	if imovel.Bairro != "" {
+		d.Estatisticas, err = c.ValuationStatistics(ctx, imovel.Bairro, cidade, opts...)
+		if err := section("estatisticas", err); err != nil {
+			return nil, err
+		}
+	}
+	return d, nil
+}
@@ -0,0 +1,39 @@
+package iptuapi
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDossie(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case strings.HasPrefix(r.URL.Path, "/consulta/sql/"):
+			json.NewEncoder(w).Encode(ConsultaSQLResult{SQL: "1", Bairro: "Moema"})
+		case strings.HasPrefix(r.URL.Path, "/dados/iptu/historico/"):
+			json.NewEncoder(w).Encode([]HistoricoItem{{Ano: 2025}})
+		case r.URL.Path == "/dados/itbi/transacoes":
+			w.WriteHeader(http.StatusForbidden)
+		case strings.HasPrefix(r.URL.Path, "/valuation/statistics/"):
+			json.NewEncoder(w).Encode(ValuationStatisticsResult{Bairro: "Moema", Mediana: 12000})
+		}
+	}))
+	defer server.Close()
+
+	client := NewClient("test_key", WithBaseURL(server.URL), WithRetry(&RetryConfig{MaxRetries: 0}))
+	d, err := client.Dossie(context.Background(), "1", "")
+	require.NoError(t, err)
+
+	assert.Equal(t, CidadeSaoPaulo, d.Cidade)
+	assert.Len(t, d.Historico, 1)
+	assert.Equal(t, 12000.0, d.Estatisticas.Mediana)
+	require.Len(t, d.Avisos, 1)
+	assert.Equal(t, "itbi", d.Avisos[0].Fonte)
+}
@@ -0,0 +1,111 @@
+package iptuapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+)
+
+// enderecoAliases maps field names used by the wrapped /consulta/endereco
+// response to the names of ConsultaEnderecoResult.
+var enderecoAliases = map[string]string{
+	"valor_venal": "valor_venal_total",
+}
+
+// enderecoNumericStrings are string fields that some responses send as
+// JSON numbers.
+var enderecoNumericStrings = []string{"numero", "cep", "sql"}
+
+// UnmarshalJSON accepts both shapes of the /consulta/endereco response:
+// the flat object and the envelope
+//
+//	{"success": true, "data": {...}, "dados_iptu": {...}}
+//
+// where data holds the address (or a list of matches, of which the first is
+// used) and dados_iptu the tax values. Both decode into the same result, so
+// callers never see the difference. An envelope with "success": false is
+// an error.
+func (r *ConsultaEnderecoResult) UnmarshalJSON(b []byte) error {
+	type flat ConsultaEnderecoResult
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(b, &fields); err != nil {
+		return json.Unmarshal(b, (*flat)(r))
+	}
+	data, wrapped := fields["data"]
+	dados, hasDados := fields["dados_iptu"]
+	if !wrapped && !hasDados {
+		return decodeEndereco(fields, (*flat)(r))
+	}
+
+	var env struct {
+		Success *bool  `json:"success"`
+		Message string `json:"message"`
+		Detail  string `json:"detail"`
+	}
+	if err := json.Unmarshal(b, &env); err != nil {
+		return err
+	}
+	if env.Success != nil && !*env.Success {
+		msg := env.Detail
+		if msg == "" {
+			msg = env.Message
+		}
+		return fmt.Errorf("iptuapi: consulta endereco: response reports failure: %s", msg)
+	}
+
+	delete(fields, "data")
+	delete(fields, "dados_iptu")
+	delete(fields, "success")
+	if err := decodeEndereco(fields, (*flat)(r)); err != nil {
+		return err
+	}
+	for _, part := range []json.RawMessage{data, dados} {
+		part = bytes.TrimSpace(part)
+		if len(part) == 0 || bytes.Equal(part, []byte("null")) {
+			continue
+		}
+		if part[0] == '[' {
+			var list []json.RawMessage
+			if err := json.Unmarshal(part, &list); err != nil {
+				return err
+			}
+			if len(list) == 0 {
+				continue
+			}
+			part = list[0]
+		}
+		var m map[string]json.RawMessage
+		if err := json.Unmarshal(part, &m); err != nil {
+			return fmt.Errorf("iptuapi: consulta endereco: %w", err)
+		}
+		if err := decodeEndereco(m, (*flat)(r)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// decodeEndereco normalizes aliased names and numeric strings in fields and
+// decodes them into v, leaving the fields not present untouched.
+func decodeEndereco(fields map[string]json.RawMessage, v interface{}) error {
+	for alias, name := range enderecoAliases {
+		if raw, ok := fields[alias]; ok {
+			if _, exists := fields[name]; !exists {
+				fields[name] = raw
+			}
+			delete(fields, alias)
+		}
+	}
+	for _, name := range enderecoNumericStrings {
+		raw := bytes.TrimSpace(fields[name])
+		if len(raw) > 0 && (raw[0] == '-' || (raw[0] >= '0' && raw[0] <= '9')) {
+			quoted, _ := json.Marshal(string(raw))
+			fields[name] = quoted
+		}
+	}
+	b, err := json.Marshal(fields)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(b, v)
+}
@@ -0,0 +1,77 @@
+package iptuapi
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConsultaEnderecoResultVariants(t *testing.T) {
+	want := ConsultaEnderecoResult{
+		SQL:             "000.000.0000-0",
+		Logradouro:      "Avenida Paulista",
+		Numero:          "1000",
+		Bairro:          "Bela Vista",
+		CEP:             "01310100",
+		ValorVenalTotal: 500000,
+		IPTUValor:       1200,
+	}
+
+	tests := []struct {
+		name string
+		body string
+	}{
+		{"flat", `{"sql":"000.000.0000-0","logradouro":"Avenida Paulista","numero":"1000","bairro":"Bela Vista","cep":"01310100","valor_venal_total":500000,"iptu_valor":1200}`},
+		{"flat with numeric numero", `{"sql":"000.000.0000-0","logradouro":"Avenida Paulista","numero":1000,"bairro":"Bela Vista","cep":"01310100","valor_venal_total":500000,"iptu_valor":1200}`},
+		{"envelope with data only", `{"success":true,"data":{"sql":"000.000.0000-0","logradouro":"Avenida Paulista","numero":"1000","bairro":"Bela Vista","cep":"01310100","valor_venal_total":500000,"iptu_valor":1200}}`},
+		{"envelope with dados_iptu", `{"success":true,"data":{"sql":"000.000.0000-0","logradouro":"Avenida Paulista","numero":"1000","bairro":"Bela Vista","cep":"01310100"},"dados_iptu":{"valor_venal":500000,"iptu_valor":1200}}`},
+		{"envelope with data list", `{"success":true,"data":[{"sql":"000.000.0000-0","logradouro":"Avenida Paulista","numero":"1000","bairro":"Bela Vista","cep":"01310100"},{"sql":"other"}],"dados_iptu":{"valor_venal_total":500000,"iptu_valor":1200}}`},
+		{"envelope with null dados_iptu", `{"success":true,"data":{"sql":"000.000.0000-0","logradouro":"Avenida Paulista","numero":1000,"bairro":"Bela Vista","cep":"01310100","valor_venal":500000,"iptu_valor":1200},"dados_iptu":null}`},
+		{"envelope without success", `{"data":{"sql":"000.000.0000-0","logradouro":"Avenida Paulista","numero":"1000","bairro":"Bela Vista","cep":"01310100"},"dados_iptu":{"valor_venal_total":500000,"iptu_valor":1200}}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got ConsultaEnderecoResult
+			require.NoError(t, json.Unmarshal([]byte(tt.body), &got))
+			assert.Equal(t, want, got)
+		})
+	}
+}
+
+func TestConsultaEnderecoResultEnvelopeFields(t *testing.T) {
+	body := `{"success":true,"schema_version":1,"avisos":[{"codigo":"DADO_ESTIMADO"}],"data":{"sql":"1"},"dados_iptu":{"valor_venal_total":10}}`
+	var got ConsultaEnderecoResult
+	require.NoError(t, json.Unmarshal([]byte(body), &got))
+	assert.Equal(t, 1, got.SchemaVersion)
+	assert.True(t, got.Avisos.Has("DADO_ESTIMADO"), "top-level envelope fields are kept")
+	assert.Equal(t, 10.0, got.ValorVenalTotal)
+}
+
+func TestConsultaEnderecoResultFailure(t *testing.T) {
+	var got ConsultaEnderecoResult
+	err := json.Unmarshal([]byte(`{"success":false,"message":"imovel nao encontrado","data":null}`), &got)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "imovel nao encontrado")
+
+	err = json.Unmarshal([]byte(`{"success":true,"data":"invalido"}`), &got)
+	assert.Error(t, err)
+}
+
+func TestConsultaEnderecoEnvelope(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"success":true,"data":{"sql":"000.000.0000-0","logradouro":"Avenida Paulista"},"dados_iptu":{"valor_venal":500000}}`))
+	}))
+	defer server.Close()
+
+	client := NewClient("test_key", WithBaseURL(server.URL))
+	result, err := client.ConsultaEndereco(context.Background(), &ConsultaEnderecoParams{Logradouro: "Avenida Paulista", Numero: "1000"})
+	require.NoError(t, err)
+	assert.Equal(t, "000.000.0000-0", result.SQL)
+	assert.Equal(t, 500000.0, result.ValorVenalTotal)
+	assert.Equal(t, CurrentSchemaVersion, result.SchemaVersion)
+}
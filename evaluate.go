@@ -0,0 +1,81 @@
+package iptuapi
+
+import "context"
+
+// EvaluateParams identifies the property evaluated by ValuationEvaluate.
+type EvaluateParams struct {
+	SQL string `json:"sql"`
+	// Cidade defaults to CidadeSaoPaulo.
+	Cidade Cidade `json:"cidade,omitempty"`
+}
+
+// Validate checks the parameters as ValuationEvaluate does before sending
+// them. It returns a *ValidationError listing every invalid field.
+func (p *EvaluateParams) Validate() error {
+	return validateSQL(p.SQL, p.Cidade)
+}
+
+// AvaliacaoAVM is the model-based part of an EvaluateResult.
+type AvaliacaoAVM struct {
+	ValorEstimado         float64 `json:"valor_estimado"`
+	ValorMinimo           float64 `json:"valor_minimo,omitempty"`
+	ValorMaximo           float64 `json:"valor_maximo,omitempty"`
+	Confianca             float64 `json:"confianca,omitempty"`
+	Metodo                string  `json:"metodo,omitempty"`
+	ComparaveisUtilizados int     `json:"comparaveis_utilizados,omitempty"`
+	ModeloVersao          string  `json:"modelo_versao,omitempty"`
+}
+
+// AvaliacaoITBI is the transaction-based part of an EvaluateResult, from
+// the ITBI records of comparable units.
+type AvaliacaoITBI struct {
+	ValorEstimado   float64 `json:"valor_estimado"`
+	ValorM2Mediano  float64 `json:"valor_m2_mediano,omitempty"`
+	Confianca       float64 `json:"confianca,omitempty"`
+	TotalTransacoes int     `json:"total_transacoes"`
+	// PeriodoInicio and PeriodoFim bound the transaction dates used, as
+	// YYYY-MM-DD.
+	PeriodoInicio string `json:"periodo_inicio,omitempty"`
+	PeriodoFim    string `json:"periodo_fim,omitempty"`
+}
+
+// EvaluateResult is the evaluation of a property combining the AVM
+// estimate with recent ITBI transactions.
+type EvaluateResult struct {
+	Schema
+	SQL    string `json:"sql"`
+	Cidade Cidade `json:"cidade,omitempty"`
+	// ValorFinal is the combined value, weighted by the confidence of each
+	// part. Metodo says how they were combined, e.g. "avm+itbi", or
+	// "avm" when there were too few transactions.
+	ValorFinal    float64 `json:"valor_final"`
+	ValorMinimo   float64 `json:"valor_minimo,omitempty"`
+	ValorMaximo   float64 `json:"valor_maximo,omitempty"`
+	Confianca     float64 `json:"confianca,omitempty"`
+	Metodo        string  `json:"metodo,omitempty"`
+	DataAvaliacao string  `json:"data_avaliacao,omitempty"`
+	// AvaliacaoAvm and AvaliacaoItbi are nil when the API could not
+	// compute that part.
+	AvaliacaoAvm  *AvaliacaoAVM  `json:"avaliacao_avm,omitempty"`
+	AvaliacaoItbi *AvaliacaoITBI `json:"avaliacao_itbi,omitempty"`
+	Avisos        Avisos         `json:"avisos,omitempty"`
+}
+
+// ValuationEvaluate evaluates a property by its SQL, combining the AVM
+// estimate with the ITBI transactions of comparable units, so callers do
+// not have to look the property up before estimating it.
+// Requires Pro plan or higher.
+func (c *Client) ValuationEvaluate(ctx context.Context, p *EvaluateParams, opts ...RequestOption) (*EvaluateResult, error) {
+	cp := *p
+	cp.Cidade = cidadeOrDefault(newRequestOptions(opts).cidadeOr(p.Cidade))
+	if err := c.check(opts, cp.Validate); err != nil {
+		return nil, err
+	}
+
+	var result EvaluateResult
+	err := c.doRequest(ctx, "POST", "/valuation/evaluate", nil, &cp, &result, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
@@ -0,0 +1,47 @@
+package iptuapi
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValuationEvaluate(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "POST", r.Method)
+		assert.Equal(t, "/valuation/evaluate", r.URL.Path)
+		var body map[string]interface{}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		assert.Equal(t, map[string]interface{}{"sql": "000.000.0000-0", "cidade": "sp"}, body)
+		w.Write([]byte(`{
+			"sql": "000.000.0000-0",
+			"valor_final": 1250000,
+			"confianca": 0.82,
+			"metodo": "avm+itbi",
+			"avaliacao_avm": {"valor_estimado": 1200000, "confianca": 0.8, "metodo": "gradient_boosting"},
+			"avaliacao_itbi": {"valor_estimado": 1300000, "total_transacoes": 14, "valor_m2_mediano": 13000}
+		}`))
+	}))
+	defer server.Close()
+
+	client := NewClient("test_key", WithBaseURL(server.URL), WithRetry(&RetryConfig{MaxRetries: 0}))
+	p := &EvaluateParams{SQL: "000.000.0000-0"}
+	result, err := client.ValuationEvaluate(context.Background(), p)
+	require.NoError(t, err)
+	assert.Empty(t, p.Cidade, "caller params are not modified")
+
+	assert.Equal(t, 1250000.0, result.ValorFinal)
+	assert.Equal(t, "avm+itbi", result.Metodo)
+	require.NotNil(t, result.AvaliacaoAvm)
+	assert.Equal(t, 0.8, result.AvaliacaoAvm.Confianca)
+	require.NotNil(t, result.AvaliacaoItbi)
+	assert.Equal(t, 14, result.AvaliacaoItbi.TotalTransacoes)
+
+	_, err = client.ValuationEvaluate(context.Background(), &EvaluateParams{})
+	assert.True(t, IsValidation(err))
+}
@@ -3,8 +3,10 @@ package main
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
+	"log/slog"
 	"os"
 	"time"
 
@@ -17,9 +19,10 @@ func main() {
 		log.Fatal("IPTU_API_KEY environment variable is required")
 	}
 
-	// Cliente com timeout customizado
+	// Cliente com timeout customizado e logs estruturados
 	client := iptuapi.NewClient(apiKey,
 		iptuapi.WithTimeout(60*time.Second),
+		iptuapi.WithSlogLogger(slog.New(slog.NewTextHandler(os.Stderr, nil))),
 	)
 
 	ctx := context.Background()
@@ -66,7 +69,7 @@ func handleError(err error) {
 		fmt.Println("Erro: Imóvel não encontrado")
 	} else if iptuapi.IsRateLimit(err) {
 		fmt.Println("Erro: Rate limit excedido. Aguarde antes de tentar novamente.")
-	} else if apiErr, ok := err.(*iptuapi.APIError); ok {
+	} else if apiErr := (*iptuapi.APIError)(nil); errors.As(err, &apiErr) {
 		fmt.Printf("Erro da API (status %d): %s\n", apiErr.StatusCode, apiErr.Message)
 	} else {
 		fmt.Printf("Erro: %v\n", err)
@@ -0,0 +1,47 @@
+// Exemplo de gateway HTTP sobre o SDK, repassando o estado de quota da API
+// aos clientes e recusando requisicoes com 503 enquanto o circuit breaker
+// estiver aberto.
+//
+// Com Echo, use o mesmo middleware:
+//
+//	e.Use(echo.WrapMiddleware(bp.Middleware))
+//
+// Com Gin:
+//
+//	r.Use(func(c *gin.Context) {
+//		if !bp.Allow(c.Writer, c.Request) {
+//			c.Abort()
+//		}
+//	})
+package main
+
+import (
+	"log"
+	"net/http"
+	"os"
+	"time"
+
+	iptuapi "github.com/raphaeltorquat0/iptuapi-go"
+	"github.com/raphaeltorquat0/iptuapi-go/iptuhttp"
+)
+
+func main() {
+	apiKey := os.Getenv("IPTU_API_KEY")
+	if apiKey == "" {
+		log.Fatal("IPTU_API_KEY environment variable is required")
+	}
+
+	client := iptuapi.NewClient(apiKey,
+		iptuapi.WithCircuitBreaker(iptuapi.BreakerConfig{FailureThreshold: 5, Cooldown: 30 * time.Second}),
+	)
+	bp := iptuhttp.NewBackpressure(client)
+
+	mux := http.NewServeMux()
+	mux.Handle("/imoveis/sql", iptuhttp.Handler(client, iptuhttp.ConsultaSQL))
+	mux.Handle("/imoveis/endereco", iptuhttp.Handler(client, iptuhttp.ConsultaEndereco))
+	mux.Handle("/imoveis/cep", iptuhttp.Handler(client, iptuhttp.ConsultaCEP))
+
+	addr := ":8080"
+	log.Printf("gateway ouvindo em %s", addr)
+	log.Fatal(http.ListenAndServe(addr, bp.Middleware(mux)))
+}
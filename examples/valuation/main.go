@@ -32,7 +32,7 @@ func main() {
 		Cidade:         iptuapi.CidadeSaoPaulo,
 	})
 	if err != nil {
-		if apiErr, ok := err.(*iptuapi.APIError); ok && apiErr.StatusCode == 403 {
+		if iptuapi.IsForbidden(err) {
 			fmt.Println("Este endpoint requer plano Pro ou superior")
 			return
 		}
@@ -48,7 +48,7 @@ func main() {
 	fmt.Println("\n=== Imóveis Comparáveis ===")
 	comparaveis, err := client.ValuationComparables(ctx, "Pinheiros", 200, 300, iptuapi.CidadeSaoPaulo, 5)
 	if err != nil {
-		if apiErr, ok := err.(*iptuapi.APIError); ok && apiErr.StatusCode == 403 {
+		if iptuapi.IsForbidden(err) {
 			fmt.Println("Este endpoint requer plano Pro ou superior")
 			return
 		}
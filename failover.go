@@ -0,0 +1,188 @@
+package iptuapi
+
+import (
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// defaultFailoverCooldown is how long a failed base URL is skipped before
+// it is tried again.
+const defaultFailoverCooldown = 30 * time.Second
+
+// WithBaseURLs sets the primary base URL and fallbacks tried in order when
+// a base URL fails with a connection error or a 502/503 response. A failed
+// base URL is skipped for 30 seconds; the primary is used again as soon as
+// it recovers. See Client.BaseURLStatus for the tracked health.
+func WithBaseURLs(primary string, fallbacks ...string) ClientOption {
+	return func(c *Client) {
+		c.baseURL = primary
+		c.failover = newFailover(append([]string{primary}, fallbacks...))
+	}
+}
+
+// BaseURLStatus is the health of one base URL configured with WithBaseURLs.
+type BaseURLStatus struct {
+	URL     string
+	Healthy bool
+	// Failures counts consecutive failures.
+	Failures int
+	// DownUntil is when an unhealthy base URL is tried again.
+	DownUntil time.Time
+}
+
+// BaseURLStatus reports the health of each base URL in failover order. It
+// returns nil unless WithBaseURLs was used.
+func (c *Client) BaseURLStatus() []BaseURLStatus {
+	if c.failover == nil {
+		return nil
+	}
+	return c.failover.status()
+}
+
+type failover struct {
+	urls     []string
+	cooldown time.Duration
+	now      func() time.Time
+
+	mu        sync.Mutex
+	failures  []int
+	downUntil []time.Time
+}
+
+func newFailover(urls []string) *failover {
+	return &failover{
+		urls:      urls,
+		cooldown:  defaultFailoverCooldown,
+		now:       time.Now,
+		failures:  make([]int, len(urls)),
+		downUntil: make([]time.Time, len(urls)),
+	}
+}
+
+func (f *failover) status() []BaseURLStatus {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	now := f.now()
+	out := make([]BaseURLStatus, len(f.urls))
+	for i, u := range f.urls {
+		out[i] = BaseURLStatus{
+			URL:       u,
+			Healthy:   !now.Before(f.downUntil[i]),
+			Failures:  f.failures[i],
+			DownUntil: f.downUntil[i],
+		}
+	}
+	return out
+}
+
+// order returns the base URLs to try: healthy ones in configured order,
+// then those still cooling down, soonest recovery first.
+func (f *failover) order() []int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	now := f.now()
+	var healthy, down []int
+	for i := range f.urls {
+		if now.Before(f.downUntil[i]) {
+			down = append(down, i)
+		} else {
+			healthy = append(healthy, i)
+		}
+	}
+	for i := 1; i < len(down); i++ {
+		for j := i; j > 0 && f.downUntil[down[j]].Before(f.downUntil[down[j-1]]); j-- {
+			down[j], down[j-1] = down[j-1], down[j]
+		}
+	}
+	return append(healthy, down...)
+}
+
+func (f *failover) mark(i int, ok bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if ok {
+		f.failures[i] = 0
+		f.downUntil[i] = time.Time{}
+		return
+	}
+	f.failures[i]++
+	f.downUntil[i] = f.now().Add(f.cooldown)
+}
+
+// failoverTransport sends each attempt to the first healthy base URL and
+// moves on to the next one on connection errors or 502/503 responses.
+type failoverTransport struct {
+	c    *Client
+	f    *failover
+	next http.RoundTripper
+}
+
+func (t *failoverTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+	rest, ok := strings.CutPrefix(req.URL.String(), t.f.urls[0])
+	if !ok {
+		return t.next.RoundTrip(req)
+	}
+
+	var (
+		resp *http.Response
+		err  error
+	)
+	order := t.f.order()
+	for n, i := range order {
+		r := req
+		if n > 0 || i != 0 {
+			u, perr := url.Parse(t.f.urls[i] + rest)
+			if perr != nil {
+				return nil, perr
+			}
+			r = req.Clone(ctx)
+			r.URL, r.Host = u, ""
+			if n > 0 && req.GetBody != nil {
+				if r.Body, err = req.GetBody(); err != nil {
+					return nil, err
+				}
+			}
+		}
+
+		resp, err = t.next.RoundTrip(r)
+		if ctx.Err() != nil || !shouldFailover(resp, err) {
+			if err == nil {
+				t.f.mark(i, true)
+			}
+			return resp, err
+		}
+		t.f.mark(i, false)
+
+		rewindable := req.Body == nil || req.Body == http.NoBody || req.GetBody != nil
+		if n == len(order)-1 || !rewindable {
+			return resp, err
+		}
+		if resp != nil {
+			io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+		}
+		t.c.log(ctx, SubsystemHTTP, slog.LevelWarn, "Base URL failed, failing over",
+			"from", t.f.urls[i], "to", t.f.urls[order[n+1]], "status", statusOf(resp), "error", err)
+	}
+	return resp, err
+}
+
+func shouldFailover(resp *http.Response, err error) bool {
+	if err != nil {
+		return true
+	}
+	return resp.StatusCode == http.StatusBadGateway || resp.StatusCode == http.StatusServiceUnavailable
+}
+
+func statusOf(resp *http.Response) int {
+	if resp == nil {
+		return 0
+	}
+	return resp.StatusCode
+}
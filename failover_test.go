@@ -0,0 +1,95 @@
+package iptuapi
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithBaseURLsFailover(t *testing.T) {
+	var primaryCalls, fallbackCalls int32
+	var primaryDown atomic.Bool
+	primaryDown.Store(true)
+	primary := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&primaryCalls, 1)
+		if primaryDown.Load() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte(`{"sql":"primary"}`))
+	}))
+	defer primary.Close()
+	fallback := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&fallbackCalls, 1)
+		assert.Equal(t, "/api/v1/consulta/sql/000.000.0000-0", r.URL.Path)
+		assert.Equal(t, "test_key", r.Header.Get("X-API-Key"))
+		w.Write([]byte(`{"sql":"fallback"}`))
+	}))
+	defer fallback.Close()
+
+	now := time.Unix(1_700_000_000, 0)
+	client := NewClient("test_key", WithBaseURLs(primary.URL+"/api/v1", fallback.URL+"/api/v1"),
+		WithRetry(&RetryConfig{MaxRetries: 0}))
+	client.failover.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	r, err := client.ConsultaSQL(ctx, "000.000.0000-0", CidadeSaoPaulo)
+	require.NoError(t, err)
+	assert.Equal(t, "fallback", r.SQL)
+
+	status := client.BaseURLStatus()
+	require.Len(t, status, 2)
+	assert.False(t, status[0].Healthy)
+	assert.Equal(t, 1, status[0].Failures)
+	assert.True(t, status[1].Healthy)
+
+	_, err = client.ConsultaSQL(ctx, "000.000.0000-0", CidadeSaoPaulo)
+	require.NoError(t, err)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&primaryCalls), "primary skipped while cooling down")
+	assert.Equal(t, int32(2), atomic.LoadInt32(&fallbackCalls))
+
+	primaryDown.Store(false)
+	now = now.Add(defaultFailoverCooldown)
+	r, err = client.ConsultaSQL(ctx, "000.000.0000-0", CidadeSaoPaulo)
+	require.NoError(t, err)
+	assert.Equal(t, "primary", r.SQL, "recovers back to the primary")
+	assert.True(t, client.BaseURLStatus()[0].Healthy)
+}
+
+func TestFailoverOnConnectionError(t *testing.T) {
+	dead := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	dead.Close()
+	live := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		assert.Contains(t, string(body), `"area_terreno":100`, "body is resent to the fallback")
+		w.Write([]byte(`{"valor_estimado":1000}`))
+	}))
+	defer live.Close()
+
+	client := NewClient("test_key", WithBaseURLs(dead.URL, live.URL), WithRetry(&RetryConfig{MaxRetries: 0}))
+	r, err := client.ValuationEstimate(context.Background(), &ValuationParams{AreaTerreno: 100, AreaConstruida: 80, Bairro: "Pinheiros"})
+	require.NoError(t, err)
+	assert.Equal(t, 1000.0, r.ValorEstimado)
+}
+
+func TestFailoverAllDown(t *testing.T) {
+	down := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+		w.Write([]byte(`{"detail":"bad gateway"}`))
+	}))
+	defer down.Close()
+
+	client := NewClient("test_key", WithBaseURLs(down.URL, down.URL+"/"), WithRetry(&RetryConfig{MaxRetries: 0}))
+	_, err := client.ConsultaSQL(context.Background(), "000.000.0000-0", CidadeSaoPaulo)
+	assert.True(t, IsServerError(err))
+	for _, s := range client.BaseURLStatus() {
+		assert.False(t, s.Healthy)
+	}
+}
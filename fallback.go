@@ -0,0 +1,97 @@
+package iptuapi
+
+import (
+	"context"
+	"errors"
+)
+
+// CodigoCidadeNaoSuportada is the error code the API returns for cities it
+// does not cover.
+const CodigoCidadeNaoSuportada = "CIDADE_NAO_SUPORTADA"
+
+// AvisoFonteAlternativa marks a result served by the FallbackProvider
+// instead of the API.
+const AvisoFonteAlternativa = "FONTE_ALTERNATIVA"
+
+var (
+	// ErrCityNotSupported is matched by errors for cities the API does not
+	// cover (see CityNotSupportedError).
+	ErrCityNotSupported = errors.New("iptuapi: cidade não suportada")
+	// ErrNoFallbackData is returned by a FallbackProvider that has no record
+	// for a lookup; the client then returns the original API error.
+	ErrNoFallbackData = errors.New("iptuapi: fallback provider has no data")
+)
+
+// CityNotSupportedError indicates that the API does not cover the requested
+// city. It matches ErrCityNotSupported with errors.Is.
+type CityNotSupportedError struct {
+	*APIError
+}
+
+// Is reports whether target is ErrCityNotSupported.
+func (e *CityNotSupportedError) Is(target error) bool {
+	return target == ErrCityNotSupported
+}
+
+func (e *CityNotSupportedError) Unwrap() error { return unwrapAPI(e.APIError) }
+
+// FallbackProvider supplies records the API does not have, typically from
+// the caller's own municipal extracts. See the fallback package for a
+// CSV-backed implementation.
+type FallbackProvider interface {
+	ConsultaEndereco(ctx context.Context, p *ConsultaEnderecoParams) (*ConsultaEnderecoResult, error)
+	ConsultaSQL(ctx context.Context, sql string, cidade Cidade) (*ConsultaSQLResult, error)
+}
+
+// WithFallbackProvider makes ConsultaEndereco and ConsultaSQL consult p when
+// the API reports an unsupported city or no record. Results from p carry an
+// AvisoFonteAlternativa warning.
+func WithFallbackProvider(p FallbackProvider) ClientOption {
+	return func(c *Client) {
+		c.fallback = p
+	}
+}
+
+// useFallback reports whether err from the API should be retried against
+// the fallback provider.
+func (c *Client) useFallback(err error) bool {
+	return c.fallback != nil && (errors.Is(err, ErrCityNotSupported) || IsNotFound(err))
+}
+
+// fallbackAviso is attached to every result served by the fallback
+// provider.
+var fallbackAviso = Aviso{
+	Codigo:   AvisoFonteAlternativa,
+	Fonte:    "fallback",
+	Mensagem: "Dados fornecidos por fonte alternativa",
+}
+
+func (c *Client) fallbackEndereco(ctx context.Context, p *ConsultaEnderecoParams, apiErr error) (*ConsultaEnderecoResult, error) {
+	r, err := c.fallback.ConsultaEndereco(ctx, p)
+	if errors.Is(err, ErrNoFallbackData) {
+		return nil, apiErr
+	}
+	if err != nil {
+		return nil, err
+	}
+	r.Avisos = append(r.Avisos, fallbackAviso)
+	if err := c.postProcess("/consulta/endereco", r); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+func (c *Client) fallbackSQL(ctx context.Context, sql string, cidade Cidade, apiErr error) (*ConsultaSQLResult, error) {
+	r, err := c.fallback.ConsultaSQL(ctx, sql, cidade)
+	if errors.Is(err, ErrNoFallbackData) {
+		return nil, apiErr
+	}
+	if err != nil {
+		return nil, err
+	}
+	r.Avisos = append(r.Avisos, fallbackAviso)
+	if err := c.postProcess("/consulta/sql/{sql}", r); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
@@ -0,0 +1,228 @@
+// Package fallback provides a FallbackProvider backed by the caller's own
+// municipal CSV extracts, for cities or records the API does not cover.
+//
+// Example:
+//
+//	p := fallback.NewCSV()
+//	if err := p.LoadFile("jundiai", "extratos/jundiai.csv"); err != nil {
+//		log.Fatal(err)
+//	}
+//	client := iptuapi.NewClient(key, iptuapi.WithFallbackProvider(p))
+//
+// Extracts need a header row with column names matching the JSON fields of
+// iptuapi.ConsultaEnderecoResult ("sql", "logradouro", "numero", "bairro",
+// "valor_venal_total", ...). Unknown columns are ignored. Files may be
+// UTF-8 or Latin-1 and delimited by ";" or ",", and numbers may use the
+// Brazilian format ("1.234,56").
+package fallback
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+
+	iptuapi "github.com/raphaeltorquat0/iptuapi-go"
+)
+
+// CSV serves lookups from CSV extracts loaded into memory.
+type CSV struct {
+	mu        sync.RWMutex
+	bySQL     map[string]*iptuapi.ConsultaEnderecoResult
+	byAddress map[string]*iptuapi.ConsultaEnderecoResult
+}
+
+var _ iptuapi.FallbackProvider = (*CSV)(nil)
+
+// NewCSV returns an empty provider; add extracts with Load or LoadFile.
+func NewCSV() *CSV {
+	return &CSV{
+		bySQL:     make(map[string]*iptuapi.ConsultaEnderecoResult),
+		byAddress: make(map[string]*iptuapi.ConsultaEnderecoResult),
+	}
+}
+
+// LoadFile loads the extract at path for cidade.
+func (p *CSV) LoadFile(cidade iptuapi.Cidade, path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return p.Load(cidade, f)
+}
+
+// Load reads an extract for cidade. Records replace earlier ones with the
+// same SQL or address.
+func (p *CSV) Load(cidade iptuapi.Cidade, r io.Reader) error {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	text := strings.TrimPrefix(iptuapi.DecodeText(data), "\ufeff")
+
+	cr := csv.NewReader(strings.NewReader(text))
+	cr.Comma = detectDelimiter(text)
+	cr.FieldsPerRecord = -1
+	cr.LazyQuotes = true
+
+	header, err := cr.Read()
+	if err == io.EOF {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("fallback: %w", err)
+	}
+	cols := make(map[string]int, len(header))
+	for i, h := range header {
+		cols[strings.ToLower(strings.TrimSpace(h))] = i
+	}
+	if _, ok := cols["sql"]; !ok {
+		if _, ok := cols["logradouro"]; !ok {
+			return fmt.Errorf("fallback: extract needs a %q or %q column", "sql", "logradouro")
+		}
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for line := 2; ; line++ {
+		row, err := cr.Read()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("fallback: %w", err)
+		}
+		rec, err := parseRecord(row, cols)
+		if err != nil {
+			return fmt.Errorf("fallback: line %d: %w", line, err)
+		}
+		if rec.SQL != "" {
+			p.bySQL[sqlKey(cidade, rec.SQL)] = rec
+		}
+		if rec.Logradouro != "" {
+			p.byAddress[addressKey(cidade, rec.Logradouro, rec.Numero)] = rec
+		}
+	}
+}
+
+// ConsultaEndereco returns the record loaded for the address, or
+// iptuapi.ErrNoFallbackData.
+func (p *CSV) ConsultaEndereco(ctx context.Context, params *iptuapi.ConsultaEnderecoParams) (*iptuapi.ConsultaEnderecoResult, error) {
+	p.mu.RLock()
+	rec, ok := p.byAddress[addressKey(cidadeOrDefault(params.Cidade), params.Logradouro, params.Numero)]
+	p.mu.RUnlock()
+	if !ok {
+		return nil, iptuapi.ErrNoFallbackData
+	}
+	r := *rec
+	return &r, nil
+}
+
+// ConsultaSQL returns the record loaded for sql, or
+// iptuapi.ErrNoFallbackData.
+func (p *CSV) ConsultaSQL(ctx context.Context, sql string, cidade iptuapi.Cidade) (*iptuapi.ConsultaSQLResult, error) {
+	p.mu.RLock()
+	rec, ok := p.bySQL[sqlKey(cidadeOrDefault(cidade), sql)]
+	p.mu.RUnlock()
+	if !ok {
+		return nil, iptuapi.ErrNoFallbackData
+	}
+	return &iptuapi.ConsultaSQLResult{
+		SQL:                  rec.SQL,
+		ValorVenal:           rec.ValorVenalTotal,
+		ValorVenalTerreno:    rec.ValorVenalTerreno,
+		ValorVenalConstrucao: rec.ValorVenalConstrucao,
+		ValorVenalTotal:      rec.ValorVenalTotal,
+		IPTUValor:            rec.IPTUValor,
+		Logradouro:           rec.Logradouro,
+		Numero:               rec.Numero,
+		Bairro:               rec.Bairro,
+		AreaTerreno:          rec.AreaTerreno,
+		AreaConstruida:       rec.AreaConstruida,
+	}, nil
+}
+
+func parseRecord(row []string, cols map[string]int) (*iptuapi.ConsultaEnderecoResult, error) {
+	get := func(name string) string {
+		if i, ok := cols[name]; ok && i < len(row) {
+			return iptuapi.SanitizeText(row[i])
+		}
+		return ""
+	}
+	var err error
+	num := func(name string) float64 {
+		v, perr := parseNumber(get(name))
+		if perr != nil && err == nil {
+			err = fmt.Errorf("column %s: %w", name, perr)
+		}
+		return v
+	}
+
+	rec := &iptuapi.ConsultaEnderecoResult{
+		SQL:                  get("sql"),
+		Logradouro:           get("logradouro"),
+		Numero:               get("numero"),
+		Complemento:          get("complemento"),
+		Bairro:               get("bairro"),
+		CEP:                  get("cep"),
+		AreaTerreno:          num("area_terreno"),
+		AreaConstruida:       num("area_construida"),
+		ValorVenalTerreno:    num("valor_venal_terreno"),
+		ValorVenalConstrucao: num("valor_venal_construcao"),
+		ValorVenalTotal:      num("valor_venal_total"),
+		IPTUValor:            num("iptu_valor"),
+		AnoConstrucao:        int(num("ano_construcao")),
+		TipoUso:              get("tipo_uso"),
+		Zona:                 get("zona"),
+	}
+	if rec.ValorVenalTotal == 0 {
+		rec.ValorVenalTotal = rec.ValorVenalTerreno + rec.ValorVenalConstrucao
+	}
+	return rec, err
+}
+
+// parseNumber accepts "1234.56" and the Brazilian "1.234,56".
+func parseNumber(s string) (float64, error) {
+	if s == "" {
+		return 0, nil
+	}
+	if strings.Contains(s, ",") {
+		s = strings.ReplaceAll(strings.ReplaceAll(s, ".", ""), ",", ".")
+	}
+	return strconv.ParseFloat(s, 64)
+}
+
+// detectDelimiter picks ";" or "," by counting them in the header line.
+func detectDelimiter(text string) rune {
+	header, _, _ := strings.Cut(text, "\n")
+	if strings.Count(header, ";") > strings.Count(header, ",") {
+		return ';'
+	}
+	return ','
+}
+
+func sqlKey(cidade iptuapi.Cidade, sql string) string {
+	var b strings.Builder
+	for _, r := range sql {
+		if r >= '0' && r <= '9' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' {
+			b.WriteRune(r)
+		}
+	}
+	return string(cidade) + "|" + strings.ToUpper(b.String())
+}
+
+func addressKey(cidade iptuapi.Cidade, logradouro, numero string) string {
+	return string(cidade) + "|" + iptuapi.NormalizeAddress(logradouro) + "|" + iptuapi.NormalizeNumero(numero)
+}
+
+func cidadeOrDefault(cidade iptuapi.Cidade) iptuapi.Cidade {
+	if cidade == "" {
+		return iptuapi.CidadeSaoPaulo
+	}
+	return cidade
+}
@@ -0,0 +1,80 @@
+package fallback
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	iptuapi "github.com/raphaeltorquat0/iptuapi-go"
+)
+
+func TestCSVLoad(t *testing.T) {
+	p := NewCSV()
+	require.NoError(t, p.LoadFile("jundiai", "testdata/jundiai.csv"))
+
+	r, err := p.ConsultaSQL(context.Background(), "012345678", "jundiai")
+	require.NoError(t, err)
+	assert.Equal(t, "01.234.567-8", r.SQL)
+	assert.Equal(t, 360000.0, r.ValorVenalTotal)
+	assert.Equal(t, 2345.67, r.IPTUValor)
+	assert.Equal(t, 180.5, r.AreaConstruida)
+
+	e, err := p.ConsultaEndereco(context.Background(), &iptuapi.ConsultaEnderecoParams{
+		Logradouro: "avenida nove de julho", Numero: "1.500", Cidade: "jundiai",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "Anhangabaú", e.Bairro)
+
+	_, err = p.ConsultaSQL(context.Background(), "012345678", iptuapi.CidadeSaoPaulo)
+	assert.ErrorIs(t, err, iptuapi.ErrNoFallbackData)
+
+	err = NewCSV().Load("x", strings.NewReader("bairro,cep\nCentro,1\n"))
+	assert.Error(t, err, "extract without sql or logradouro")
+	err = NewCSV().Load("x", strings.NewReader("sql,area_terreno\n1,abc\n"))
+	assert.ErrorContains(t, err, "line 2")
+}
+
+func TestClientFallback(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("cidade") == "jundiai" {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"detail":"Cidade não suportada","codigo":"CIDADE_NAO_SUPORTADA"}`))
+			return
+		}
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	p := NewCSV()
+	require.NoError(t, p.LoadFile("jundiai", "testdata/jundiai.csv"))
+	client := iptuapi.NewClient("test_key",
+		iptuapi.WithBaseURL(server.URL),
+		iptuapi.WithRetry(&iptuapi.RetryConfig{MaxRetries: 0}),
+		iptuapi.WithFallbackProvider(p),
+	)
+	ctx := context.Background()
+
+	r, err := client.ConsultaSQL(ctx, "01.234.999-1", "jundiai")
+	require.NoError(t, err)
+	assert.Equal(t, 890000.0, r.ValorVenalTotal)
+	assert.True(t, r.Avisos.Has(iptuapi.AvisoFonteAlternativa))
+
+	e, err := client.ConsultaEndereco(ctx, &iptuapi.ConsultaEnderecoParams{
+		Logradouro: "R. Barao de Jundiai", Numero: "120", Cidade: "jundiai",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "01.234.567-8", e.SQL)
+	assert.True(t, e.Avisos.Has(iptuapi.AvisoFonteAlternativa))
+
+	// Without fallback data the API error is returned unchanged.
+	_, err = client.ConsultaSQL(ctx, "999", "jundiai")
+	assert.True(t, errors.Is(err, iptuapi.ErrCityNotSupported))
+	_, err = client.ConsultaSQL(ctx, "999", iptuapi.CidadeSaoPaulo)
+	assert.True(t, iptuapi.IsNotFound(err))
+}
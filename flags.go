@@ -0,0 +1,31 @@
+package iptuapi
+
+import "context"
+
+// Flags tweak how the client handles the calls made with a context, e.g.
+// to bypass the cache for one user action without new method variants.
+type Flags struct {
+	// DisableCache neither reads nor writes the response cache.
+	DisableCache bool
+	// ForceRefresh skips the cached response, sends Cache-Control:
+	// no-cache and stores the fresh response in its place, e.g. for a
+	// "refresh" button. See also NoCache.
+	ForceRefresh bool
+	// Verbose logs the call's debug messages at info level, so one call
+	// can be traced without lowering the client's log level.
+	Verbose bool
+}
+
+type flagsKey struct{}
+
+// WithFlags returns a copy of ctx carrying flags, read by the client and
+// by layers built on it such as mirror.Mirror.
+func WithFlags(ctx context.Context, flags Flags) context.Context {
+	return context.WithValue(ctx, flagsKey{}, flags)
+}
+
+// FlagsFrom returns the flags set on ctx with WithFlags, or the zero Flags.
+func FlagsFrom(ctx context.Context) Flags {
+	f, _ := ctx.Value(flagsKey{}).(Flags)
+	return f
+}
@@ -0,0 +1,86 @@
+package iptuapi
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFlagsCache(t *testing.T) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&calls, 1)
+		w.Write([]byte(`{"sql":"1","bairro":"v` + strconv.Itoa(int(n)) + `"}`))
+	}))
+	defer server.Close()
+
+	client := NewClient("test_key", WithBaseURL(server.URL), WithCache(NewLRUCache(10), time.Minute))
+	ctx := context.Background()
+
+	r, err := client.ConsultaSQL(ctx, "1", CidadeSaoPaulo)
+	require.NoError(t, err)
+	assert.Equal(t, "v1", r.Bairro)
+
+	r, err = client.ConsultaSQL(WithFlags(ctx, Flags{DisableCache: true}), "1", CidadeSaoPaulo)
+	require.NoError(t, err)
+	assert.Equal(t, "v2", r.Bairro)
+	r, err = client.ConsultaSQL(ctx, "1", CidadeSaoPaulo)
+	require.NoError(t, err)
+	assert.Equal(t, "v1", r.Bairro, "DisableCache does not write the cache")
+
+	r, err = client.ConsultaSQL(WithFlags(ctx, Flags{ForceRefresh: true}), "1", CidadeSaoPaulo)
+	require.NoError(t, err)
+	assert.Equal(t, "v3", r.Bairro)
+	r, err = client.ConsultaSQL(ctx, "1", CidadeSaoPaulo)
+	require.NoError(t, err)
+	assert.Equal(t, "v3", r.Bairro, "ForceRefresh overwrites the cached response")
+	assert.Equal(t, int32(3), atomic.LoadInt32(&calls))
+}
+
+func TestNoCache(t *testing.T) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&calls, 1)
+		if n > 1 {
+			assert.Equal(t, "no-cache", r.Header.Get("Cache-Control"))
+		}
+		w.Write([]byte(`{"sql":"1","bairro":"v` + strconv.Itoa(int(n)) + `"}`))
+	}))
+	defer server.Close()
+
+	client := NewClient("test_key", WithBaseURL(server.URL), WithCache(NewLRUCache(10), time.Minute))
+	ctx := context.Background()
+
+	_, err := client.ConsultaSQL(ctx, "1", CidadeSaoPaulo)
+	require.NoError(t, err)
+	r, err := client.ConsultaSQL(ctx, "1", CidadeSaoPaulo, NoCache())
+	require.NoError(t, err)
+	assert.Equal(t, "v2", r.Bairro)
+	r, err = client.ConsultaSQL(ctx, "1", CidadeSaoPaulo)
+	require.NoError(t, err)
+	assert.Equal(t, "v2", r.Bairro, "the fresh response replaces the cached one")
+	assert.Equal(t, int32(2), atomic.LoadInt32(&calls))
+}
+
+func TestFlagsVerbose(t *testing.T) {
+	var buf bytes.Buffer
+	client := NewClient("test_key", WithSlogLogger(slog.New(slog.NewTextHandler(&buf, nil))))
+
+	client.log(context.Background(), SubsystemHTTP, slog.LevelDebug, "Quiet")
+	client.log(WithFlags(context.Background(), Flags{Verbose: true}), SubsystemHTTP, slog.LevelDebug, "Loud")
+	assert.NotContains(t, buf.String(), "Quiet")
+	assert.Contains(t, buf.String(), "msg=Loud")
+}
+
+func TestFlagsFromEmpty(t *testing.T) {
+	assert.Equal(t, Flags{}, FlagsFrom(context.Background()))
+}
@@ -0,0 +1,164 @@
+package iptuapi
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// HealthStatus is the status of a subsystem or of the client as a whole.
+type HealthStatus string
+
+const (
+	HealthOK       HealthStatus = "ok"
+	HealthDegraded HealthStatus = "degraded"
+	HealthDown     HealthStatus = "down"
+)
+
+// worse reports whether s is more severe than other.
+func (s HealthStatus) worse(other HealthStatus) bool {
+	rank := map[HealthStatus]int{HealthOK: 0, HealthDegraded: 1, HealthDown: 2}
+	return rank[s] > rank[other]
+}
+
+// HealthCheck is the status of one subsystem.
+type HealthCheck struct {
+	Name   string       `json:"name"`
+	Status HealthStatus `json:"status"`
+	Detail string       `json:"detail,omitempty"`
+}
+
+// SelfCheckResult aggregates the status of the client's subsystems.
+// Status is the worst status among Checks.
+type SelfCheckResult struct {
+	Status    HealthStatus  `json:"status"`
+	Checks    []HealthCheck `json:"checks"`
+	CheckedAt time.Time     `json:"checked_at"`
+}
+
+// Ready reports whether the client can serve requests, possibly degraded.
+func (r *SelfCheckResult) Ready() bool {
+	return r.Status != HealthDown
+}
+
+// HealthChecker reports the status of one subsystem.
+type HealthChecker func(ctx context.Context) HealthCheck
+
+// healthState holds registered checks and the cached API probe.
+type healthState struct {
+	mu        sync.Mutex
+	checks    []HealthChecker
+	probeTTL  time.Duration
+	probedAt  time.Time
+	lastProbe HealthCheck
+}
+
+func newHealthState() *healthState {
+	return &healthState{probeTTL: 30 * time.Second}
+}
+
+// WithHealthCheck adds a custom check to SelfCheck. A nil error from fn is
+// reported as ok, any other error as down.
+func WithHealthCheck(name string, fn func(ctx context.Context) error) ClientOption {
+	return func(c *Client) {
+		c.health.checks = append(c.health.checks, func(ctx context.Context) HealthCheck {
+			if err := fn(ctx); err != nil {
+				return HealthCheck{Name: name, Status: HealthDown, Detail: err.Error()}
+			}
+			return HealthCheck{Name: name, Status: HealthOK}
+		})
+	}
+}
+
+// SelfCheck reports the status of the credentials, rate-limit headroom, the
+// registered subsystem checks and a live API probe. The probe result is
+// cached for 30 seconds so SelfCheck can back a /readyz endpoint without
+// spending quota on every poll.
+func (c *Client) SelfCheck(ctx context.Context) *SelfCheckResult {
+	checks := []HealthCheck{c.checkCredentials(), c.checkRateLimit()}
+	if c.breaker != nil {
+		checks = append(checks, c.checkBreaker())
+	}
+	for _, check := range c.health.checks {
+		checks = append(checks, check(ctx))
+	}
+	checks = append(checks, c.probeAPI(ctx))
+
+	result := &SelfCheckResult{Status: HealthOK, Checks: checks, CheckedAt: c.clock.Now()}
+	for _, check := range checks {
+		if check.Status.worse(result.Status) {
+			result.Status = check.Status
+		}
+	}
+	return result
+}
+
+func (c *Client) checkCredentials() HealthCheck {
+	if c.apiKey == "" {
+		return HealthCheck{Name: "credentials", Status: HealthDown, Detail: "API key not configured"}
+	}
+	return HealthCheck{Name: "credentials", Status: HealthOK}
+}
+
+func (c *Client) checkRateLimit() HealthCheck {
+	rl, _ := c.lastResponseInfo()
+	if rl == nil || rl.Limit <= 0 {
+		return HealthCheck{Name: "rate_limit", Status: HealthOK, Detail: "no rate limit observed yet"}
+	}
+	detail := fmt.Sprintf("%d/%d remaining", rl.Remaining, rl.Limit)
+	switch {
+	case rl.Remaining <= 0 && c.clock.Now().Before(rl.ResetTime):
+		return HealthCheck{Name: "rate_limit", Status: HealthDegraded, Detail: detail + ", resets at " + rl.ResetTime.Format(time.RFC3339)}
+	case float64(rl.Remaining) < 0.1*float64(rl.Limit):
+		return HealthCheck{Name: "rate_limit", Status: HealthDegraded, Detail: detail}
+	}
+	return HealthCheck{Name: "rate_limit", Status: HealthOK, Detail: detail}
+}
+
+func (c *Client) checkBreaker() HealthCheck {
+	c.breaker.mu.Lock()
+	inc := c.breaker.incident
+	c.breaker.mu.Unlock()
+	switch state := c.breaker.current(); state {
+	case BreakerOpen:
+		return HealthCheck{Name: "circuit_breaker", Status: HealthDown, Detail: string(state)}
+	case BreakerMaintenance:
+		detail := string(state)
+		if inc != nil {
+			detail += ": " + inc.Titulo
+		}
+		return HealthCheck{Name: "circuit_breaker", Status: HealthDegraded, Detail: detail}
+	default:
+		return HealthCheck{Name: "circuit_breaker", Status: HealthOK, Detail: string(state)}
+	}
+}
+
+// probeAPI calls the lightweight cities endpoint, reusing a recent result.
+func (c *Client) probeAPI(ctx context.Context) HealthCheck {
+	h := c.health
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if !h.probedAt.IsZero() && c.clock.Now().Sub(h.probedAt) < h.probeTTL {
+		return h.lastProbe
+	}
+
+	start := time.Now()
+	// NoCache: a cached response says nothing about the API now.
+	_, err := c.IPTUToolsCidades(ctx, NoCache())
+	elapsed := time.Since(start).Round(time.Millisecond)
+
+	switch {
+	case ctx.Err() != nil:
+		// The caller gave up; don't cache a result that says nothing about the API.
+		return HealthCheck{Name: "api", Status: HealthDegraded, Detail: ctx.Err().Error()}
+	case err == nil:
+		h.lastProbe = HealthCheck{Name: "api", Status: HealthOK, Detail: fmt.Sprintf("responded in %v", elapsed)}
+	case IsRateLimit(err):
+		h.lastProbe = HealthCheck{Name: "api", Status: HealthDegraded, Detail: err.Error()}
+	default:
+		h.lastProbe = HealthCheck{Name: "api", Status: HealthDown, Detail: err.Error()}
+	}
+	h.probedAt = c.clock.Now()
+	return h.lastProbe
+}
@@ -0,0 +1,88 @@
+package iptuapi
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSelfCheck(t *testing.T) {
+	probes := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		probes++
+		w.Header().Set("X-RateLimit-Limit", "1000")
+		w.Header().Set("X-RateLimit-Remaining", "50")
+		w.Header().Set("X-RateLimit-Reset", "1893456000")
+		json.NewEncoder(w).Encode(CidadesResult{})
+	}))
+	defer server.Close()
+
+	client := NewClient("test_key",
+		WithBaseURL(server.URL),
+		WithRetry(&RetryConfig{MaxRetries: 0}),
+		WithHealthCheck("cache", func(ctx context.Context) error { return nil }),
+	)
+
+	result := client.SelfCheck(context.Background())
+	assert.Equal(t, HealthOK, result.Status)
+	require.Len(t, result.Checks, 4)
+	assert.Equal(t, "api", result.Checks[3].Name)
+
+	// The probe set rate-limit headers below 10% headroom.
+	result = client.SelfCheck(context.Background())
+	assert.Equal(t, HealthDegraded, result.Status)
+	assert.True(t, result.Ready())
+	assert.Equal(t, 1, probes, "probe result should be cached")
+}
+
+func TestSelfCheckDown(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	client := NewClient("test_key",
+		WithBaseURL(server.URL),
+		WithRetry(&RetryConfig{MaxRetries: 0}),
+		WithHealthCheck("db", func(ctx context.Context) error { return errors.New("connection refused") }),
+	)
+	client.health.probeTTL = time.Nanosecond
+
+	result := client.SelfCheck(context.Background())
+	assert.Equal(t, HealthDown, result.Status)
+	assert.False(t, result.Ready())
+	assert.Equal(t, "connection refused", result.Checks[2].Detail)
+	assert.Equal(t, HealthDown, result.Checks[3].Status)
+}
+
+func TestSelfCheckBypassesCache(t *testing.T) {
+	var failing bool
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if failing {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		json.NewEncoder(w).Encode(CidadesResult{})
+	}))
+	defer server.Close()
+
+	client := NewClient("test_key",
+		WithBaseURL(server.URL),
+		WithRetry(&RetryConfig{MaxRetries: 0}),
+		WithCache(NewLRUCache(10), time.Hour),
+	)
+	client.health.probeTTL = time.Nanosecond
+
+	_, err := client.IPTUToolsCidades(context.Background())
+	require.NoError(t, err)
+	failing = true
+	result := client.SelfCheck(context.Background())
+	assert.Equal(t, HealthDown, result.Checks[len(result.Checks)-1].Status)
+}
@@ -0,0 +1,95 @@
+package iptuapi
+
+import (
+	"context"
+	"sort"
+)
+
+// PontoValorVenal is one year of a SerieValorVenal.
+type PontoValorVenal struct {
+	Ano                  int     `json:"ano"`
+	ValorVenal           float64 `json:"valor_venal"`
+	ValorVenalTerreno    float64 `json:"valor_venal_terreno,omitempty"`
+	ValorVenalConstrucao float64 `json:"valor_venal_construcao,omitempty"`
+	IPTU                 float64 `json:"iptu,omitempty"`
+	// Variacao is the change of the valor venal from the previous point,
+	// e.g. 0.05 for 5%. It is nil for the first point and when the
+	// previous value is unknown.
+	Variacao *float64 `json:"variacao,omitempty"`
+}
+
+// SerieValorVenal is the yearly series of valor venal and IPTU of a
+// property, oldest year first.
+type SerieValorVenal struct {
+	SQL    string            `json:"sql"`
+	Cidade Cidade            `json:"cidade"`
+	Pontos []PontoValorVenal `json:"pontos"`
+}
+
+// Ano returns the point of year ano.
+func (s *SerieValorVenal) Ano(ano int) (PontoValorVenal, bool) {
+	for _, p := range s.Pontos {
+		if p.Ano == ano {
+			return p, true
+		}
+	}
+	return PontoValorVenal{}, false
+}
+
+// VariacaoAcumulada returns the change of the valor venal from the first
+// to the last point, or zero with fewer than two points or an unknown
+// first value.
+func (s *SerieValorVenal) VariacaoAcumulada() float64 {
+	if len(s.Pontos) < 2 || s.Pontos[0].ValorVenal <= 0 {
+		return 0
+	}
+	return s.Pontos[len(s.Pontos)-1].ValorVenal/s.Pontos[0].ValorVenal - 1
+}
+
+// HistoricoValorVenal returns the valor venal and IPTU series of a
+// property for its last anos years (all years when anos <= 0), without the
+// cost of a full ConsultaEndereco, e.g. for dashboards. Years reported
+// twice are kept once.
+func (c *Client) HistoricoValorVenal(ctx context.Context, sql string, cidade Cidade, anos int, opts ...RequestOption) (*SerieValorVenal, error) {
+	cidade = cidadeOrDefault(newRequestOptions(opts).cidadeOr(cidade))
+	if err := c.check(opts, func() error { return validateSQL(sql, cidade) }); err != nil {
+		return nil, err
+	}
+	items, err := c.DadosIPTUHistorico(ctx, sql, cidade, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return newSerieValorVenal(sql, cidade, items, anos), nil
+}
+
+func newSerieValorVenal(sql string, cidade Cidade, items []HistoricoItem, anos int) *SerieValorVenal {
+	byAno := make(map[int]HistoricoItem, len(items))
+	for _, it := range items {
+		byAno[it.Ano] = it
+	}
+	s := &SerieValorVenal{SQL: sql, Cidade: cidade, Pontos: make([]PontoValorVenal, 0, len(byAno))}
+	for _, it := range byAno {
+		valor := it.ValorVenalTotal
+		if valor == 0 {
+			valor = it.ValorVenalTerreno + it.ValorVenalConstrucao
+		}
+		s.Pontos = append(s.Pontos, PontoValorVenal{
+			Ano:                  it.Ano,
+			ValorVenal:           valor,
+			ValorVenalTerreno:    it.ValorVenalTerreno,
+			ValorVenalConstrucao: it.ValorVenalConstrucao,
+			IPTU:                 it.IPTUValor,
+		})
+	}
+	sort.Slice(s.Pontos, func(i, j int) bool { return s.Pontos[i].Ano < s.Pontos[j].Ano })
+	if anos > 0 && len(s.Pontos) > anos {
+		s.Pontos = s.Pontos[len(s.Pontos)-anos:]
+	}
+	for i := 1; i < len(s.Pontos); i++ {
+		if prev := s.Pontos[i-1].ValorVenal; prev > 0 && s.Pontos[i].ValorVenal > 0 {
+			v := s.Pontos[i].ValorVenal/prev - 1
+			s.Pontos[i].Variacao = &v
+		}
+	}
+	return s
+}
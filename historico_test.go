@@ -0,0 +1,60 @@
+package iptuapi
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHistoricoValorVenal(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/dados/iptu/historico/000.000.0000-0", r.URL.Path)
+		assert.Equal(t, "sp", r.URL.Query().Get("cidade"))
+		w.Write([]byte(`[
+			{"ano":2024,"valor_venal_total":220000,"iptu_valor":2200},
+			{"ano":2021,"valor_venal_terreno":120000,"valor_venal_construcao":80000,"iptu_valor":1900},
+			{"ano":2022,"valor_venal_total":0},
+			{"ano":2023,"valor_venal_total":200000,"iptu_valor":2000}
+		]`))
+	}))
+	defer server.Close()
+
+	client := NewClient("test_key", WithBaseURL(server.URL))
+	ctx := context.Background()
+
+	serie, err := client.HistoricoValorVenal(ctx, "000.000.0000-0", "", 0)
+	require.NoError(t, err)
+	assert.Equal(t, CidadeSaoPaulo, serie.Cidade)
+	require.Len(t, serie.Pontos, 4)
+	assert.Equal(t, 2021, serie.Pontos[0].Ano)
+	assert.Equal(t, 200000.0, serie.Pontos[0].ValorVenal, "total derived from terreno and construcao")
+	assert.Nil(t, serie.Pontos[0].Variacao)
+	assert.Nil(t, serie.Pontos[2].Variacao, "no variation from an unknown value")
+	require.NotNil(t, serie.Pontos[3].Variacao)
+	assert.InDelta(t, 0.1, *serie.Pontos[3].Variacao, 1e-9)
+	assert.InDelta(t, 0.1, serie.VariacaoAcumulada(), 1e-9)
+
+	p, ok := serie.Ano(2024)
+	require.True(t, ok)
+	assert.Equal(t, 2200.0, p.IPTU)
+	_, ok = serie.Ano(2019)
+	assert.False(t, ok)
+
+	serie, err = client.HistoricoValorVenal(ctx, "000.000.0000-0", CidadeSaoPaulo, 2)
+	require.NoError(t, err)
+	require.Len(t, serie.Pontos, 2)
+	assert.Equal(t, 2023, serie.Pontos[0].Ano)
+	assert.Nil(t, serie.Pontos[0].Variacao, "the first point of a trimmed series has no variation")
+}
+
+func TestHistoricoValorVenalValidates(t *testing.T) {
+	client := NewClient("test_key", WithBaseURL("http://127.0.0.1:0"))
+	_, err := client.HistoricoValorVenal(context.Background(), "", CidadeSaoPaulo, 5)
+	var verr *ValidationError
+	require.ErrorAs(t, err, &verr)
+	assert.Zero(t, verr.StatusCode)
+}
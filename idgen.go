@@ -0,0 +1,59 @@
+package iptuapi
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/hex"
+	"time"
+)
+
+// IDGenerator creates the IDs the client sends with requests: the
+// X-Request-ID correlation header of every call and the Idempotency-Key of
+// POST calls. Implementations must be safe for concurrent use.
+type IDGenerator interface {
+	NewID() string
+}
+
+// IDFunc adapts a function to IDGenerator, e.g. to generate ULIDs or
+// tenant-prefixed IDs:
+//
+//	iptuapi.WithIDGenerator(iptuapi.IDFunc(func() string {
+//		return "acme-" + iptuapi.NewUUIDv7()
+//	}))
+type IDFunc func() string
+
+// NewID calls f.
+func (f IDFunc) NewID() string { return f() }
+
+// WithIDGenerator replaces the default UUIDv7 generator of request IDs and
+// idempotency keys. A nil generator keeps the default.
+func WithIDGenerator(g IDGenerator) ClientOption {
+	return func(c *Client) {
+		if g != nil {
+			c.ids = g
+		}
+	}
+}
+
+// NewUUIDv7 returns a random, time-ordered UUID (RFC 9562 version 7).
+func NewUUIDv7() string {
+	var u [16]byte
+	var ms [8]byte
+	binary.BigEndian.PutUint64(ms[:], uint64(time.Now().UnixMilli()))
+	copy(u[:6], ms[2:])
+	rand.Read(u[6:])
+	u[6] = u[6]&0x0f | 0x70 // version 7
+	u[8] = u[8]&0x3f | 0x80 // RFC 9562 variant
+
+	var b [36]byte
+	hex.Encode(b[0:8], u[0:4])
+	b[8] = '-'
+	hex.Encode(b[9:13], u[4:6])
+	b[13] = '-'
+	hex.Encode(b[14:18], u[6:8])
+	b[18] = '-'
+	hex.Encode(b[19:23], u[8:10])
+	b[23] = '-'
+	hex.Encode(b[24:], u[10:])
+	return string(b[:])
+}
@@ -0,0 +1,70 @@
+package iptuapi
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewUUIDv7(t *testing.T) {
+	format := regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-7[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`)
+	a := NewUUIDv7()
+	time.Sleep(2 * time.Millisecond)
+	b := NewUUIDv7()
+	assert.Regexp(t, format, a)
+	assert.Regexp(t, format, b)
+	assert.Less(t, a, b, "IDs are time-ordered")
+}
+
+func TestRequestIDs(t *testing.T) {
+	var (
+		mu      sync.Mutex
+		headers []http.Header
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		headers = append(headers, r.Header.Clone())
+		n := len(headers)
+		mu.Unlock()
+		if n == 2 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte(`{"valor_estimado":500000}`))
+	}))
+	defer server.Close()
+
+	var n int
+	client := NewClient("test_key", WithBaseURL(server.URL),
+		WithRetry(&RetryConfig{MaxRetries: 1, InitialDelay: time.Millisecond, RetryableStatus: []int{503}}),
+		WithIDGenerator(IDFunc(func() string {
+			n++
+			return "acme-" + string(rune('0'+n))
+		})))
+
+	_, err := client.ConsultaSQL(context.Background(), "000.000.0000-0", CidadeSaoPaulo)
+	require.NoError(t, err)
+	_, err = client.ValuationEstimate(context.Background(), &ValuationParams{AreaTerreno: 100, AreaConstruida: 80, Bairro: "Pinheiros"})
+	require.NoError(t, err)
+	_, err = client.ConsultaSQL(context.Background(), "000.000.0000-0", CidadeSaoPaulo, WithHeader("X-Request-ID", "mine"))
+	require.NoError(t, err)
+
+	require.Len(t, headers, 4)
+	assert.Equal(t, "acme-1", headers[0].Get("X-Request-ID"))
+	assert.Empty(t, headers[0].Get("Idempotency-Key"), "GET calls are idempotent already")
+
+	// The retried POST keeps its IDs.
+	assert.Equal(t, "acme-2", headers[1].Get("X-Request-ID"))
+	assert.Equal(t, "acme-3", headers[1].Get("Idempotency-Key"))
+	assert.Equal(t, headers[1].Get("X-Request-ID"), headers[2].Get("X-Request-ID"))
+	assert.Equal(t, headers[1].Get("Idempotency-Key"), headers[2].Get("Idempotency-Key"))
+
+	assert.Equal(t, "mine", headers[3].Get("X-Request-ID"))
+}
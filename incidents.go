@@ -0,0 +1,94 @@
+package iptuapi
+
+import (
+	"context"
+	"log/slog"
+	"time"
+)
+
+// Incident status values reported by the status feed.
+const (
+	IncidentInvestigando = "investigando"
+	IncidentIdentificado = "identificado"
+	IncidentMonitorando  = "monitorando"
+	IncidentResolvido    = "resolvido"
+)
+
+// Incident is an incident or maintenance window declared on the API status
+// feed.
+type Incident struct {
+	ID          string     `json:"id"`
+	Titulo      string     `json:"titulo"`
+	Status      string     `json:"status"`
+	Severidade  string     `json:"severidade,omitempty"`
+	Componentes []string   `json:"componentes,omitempty"`
+	InicioEm    time.Time  `json:"inicio_em"`
+	FimEm       *time.Time `json:"fim_em,omitempty"`
+	URL         string     `json:"url,omitempty"`
+}
+
+// Ativo reports whether the incident is still open.
+func (i *Incident) Ativo() bool {
+	return i.Status != IncidentResolvido && i.FimEm == nil
+}
+
+// Incidents lists the recent incidents from the API status feed, open ones
+// included. The feed is always fetched fresh, bypassing the response cache.
+func (c *Client) Incidents(ctx context.Context) ([]Incident, error) {
+	var result []Incident
+	err := c.doRequest(ctx, "GET", "/status/incidents", nil, nil, &result, withNoBreaker(), NoCache())
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// WatchIncidents polls the status feed every interval (default 1 minute)
+// until ctx ends. While an incident is open, the circuit breaker set with
+// WithCircuitBreaker is held in BreakerMaintenance, so calls fail fast with
+// a *CircuitOpenError naming the incident instead of raising alerts on
+// every timeout. Feed errors are logged and the previous state is kept.
+// Run it in its own goroutine; it returns ctx.Err().
+func (c *Client) WatchIncidents(ctx context.Context, interval time.Duration) error {
+	if interval <= 0 {
+		interval = time.Minute
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		c.checkIncidents(ctx)
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+func (c *Client) checkIncidents(ctx context.Context) {
+	incidents, err := c.Incidents(ctx)
+	if err != nil {
+		if ctx.Err() == nil {
+			c.log(ctx, SubsystemHTTP, slog.LevelWarn, "Incident feed unavailable", "error", err)
+		}
+		return
+	}
+	var open *Incident
+	for i := range incidents {
+		if incidents[i].Ativo() {
+			open = &incidents[i]
+			break
+		}
+	}
+	if c.breaker == nil {
+		return
+	}
+	was := c.breaker.current() == BreakerMaintenance
+	c.breaker.maintenance(open)
+	switch {
+	case open != nil && !was:
+		c.log(ctx, SubsystemHTTP, slog.LevelWarn, "API incident declared, entering maintenance mode", "incident", open.ID, "titulo", open.Titulo)
+	case open == nil && was:
+		c.log(ctx, SubsystemHTTP, slog.LevelInfo, "API incident resolved, leaving maintenance mode")
+	}
+}
@@ -20,14 +20,20 @@ package iptuapi
 import (
 	"bytes"
 	"context"
+	"crypto"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"log"
-	"math"
+	"log/slog"
+	"net"
 	"net/http"
 	"net/url"
 	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 )
 
@@ -43,14 +49,14 @@ const (
 type Cidade string
 
 const (
-	CidadeSaoPaulo       Cidade = "sp"
-	CidadeBeloHorizonte  Cidade = "bh"
-	CidadeRecife         Cidade = "recife"
-	CidadePortoAlegre    Cidade = "poa"
-	CidadeFortaleza      Cidade = "fortaleza"
-	CidadeCuritiba       Cidade = "curitiba"
-	CidadeRioDeJaneiro   Cidade = "rj"
-	CidadeBrasilia       Cidade = "brasilia"
+	CidadeSaoPaulo      Cidade = "sp"
+	CidadeBeloHorizonte Cidade = "bh"
+	CidadeRecife        Cidade = "recife"
+	CidadePortoAlegre   Cidade = "poa"
+	CidadeFortaleza     Cidade = "fortaleza"
+	CidadeCuritiba      Cidade = "curitiba"
+	CidadeRioDeJaneiro  Cidade = "rj"
+	CidadeBrasilia      Cidade = "brasilia"
 )
 
 // Logger interface for custom logging.
@@ -97,6 +103,28 @@ type RetryConfig struct {
 	MaxDelay        time.Duration
 	BackoffFactor   float64
 	RetryableStatus []int
+	// Jitter randomizes the delays so that clients retrying at the same
+	// time spread out (default JitterNone).
+	Jitter Jitter
+
+	// ShouldRetry, when set, replaces the RetryableStatus check. resp is nil
+	// on transport errors; otherwise its Body can be read again, so body-level
+	// failures returned as 2xx can be retried. err is the transport error or
+	// the API error for non-2xx responses. attempt starts at zero, and
+	// MaxRetries still caps the number of retries.
+	ShouldRetry func(resp *http.Response, err error, attempt int) bool
+
+	// NetworkRetries is the number of retries for GET and HEAD requests
+	// failing with a temporary network error (connection reset, unexpected
+	// EOF, DNS timeout), applied even when MaxRetries is zero. Zero means
+	// DefaultNetworkRetries; a negative value disables it.
+	NetworkRetries int
+
+	// RespectDeadline gives up retrying, with a *RetryDeadlineError, when
+	// the next backoff plus the latency of the last attempt would not fit
+	// in the time left before the context deadline, instead of sleeping
+	// past it.
+	RespectDeadline bool
 }
 
 // DefaultRetryConfig returns the default retry configuration.
@@ -114,7 +142,11 @@ func DefaultRetryConfig() *RetryConfig {
 type RateLimitInfo struct {
 	Limit     int
 	Remaining int
-	Reset     int64
+	// Reset is the X-RateLimit-Reset value as sent, in the API's clock.
+	Reset int64
+	// ResetTime is the reset in the local clock, corrected by the skew
+	// measured from the Date header (see Client.ClockSkew), so waits are
+	// right even when the host clock is wrong.
 	ResetTime time.Time
 }
 
@@ -126,10 +158,57 @@ type Client struct {
 	retryConfig *RetryConfig
 	logger      Logger
 	userAgent   string
-
-	// Rate limit info from last request
+	matcher     Matcher
+	health      *healthState
+	stats       *statsCollector
+	journal     *requestJournal
+	breaker     *breaker
+	sla         *slaTracker
+	slog        *slogState
+	debug       debugState
+	wrappers    []TransportWrapper
+	fallback    FallbackProvider
+	regioes     map[Cidade]Regioes
+	limiter     *RateLimiter
+	dial        *dialConfig
+	failover    *failover
+	keys        *keyPool
+	coalesce    *coalescer
+	// signaturePolicy and signatureKeys configure WithSignatureVerification.
+	signaturePolicy SignaturePolicy
+	signatureKeys   map[string]crypto.PublicKey
+	// degradeBelow is the WithDegradation headroom threshold.
+	degradeBelow float64
+	cache        Cache
+	cacheTTL     time.Duration
+
+	postProcessors []PostProcessor
+	// marshal and unmarshal are the JSON codec; see WithJSONCodec.
+	marshal   func(interface{}) ([]byte, error)
+	unmarshal func([]byte, interface{}) error
+	observers []func(Attempt)
+	// ids generates request IDs and idempotency keys; see WithIDGenerator.
+	ids              IDGenerator
+	maxResponseBytes int64
+	clock            Clock
+
+	endpointTimeouts map[EndpointClass]time.Duration
+	// routes holds the WithRoutes overrides.
+	routes []routeOverride
+	// beta enables the calls marked Beta; see WithBetaFeatures.
+	beta bool
+
+	// Rate limit info from last request.
+	//
+	// Deprecated: reading these fields races with concurrent calls; use
+	// RateLimitState and LastRequest.
 	RateLimit     *RateLimitInfo
 	LastRequestID string
+	// rateMu guards RateLimit, LastRequestID, lastRequest and rateSubs.
+	rateMu      sync.Mutex
+	lastRequest RequestInfo
+	rateSubs    map[chan RateLimitInfo]struct{}
+	clockSkew   atomic.Int64
 }
 
 // ClientOption configures the Client.
@@ -149,10 +228,19 @@ func WithTimeout(timeout time.Duration) ClientOption {
 	}
 }
 
-// WithHTTPClient sets a custom HTTP client.
+// WithHTTPClient sets a custom HTTP client, e.g. with a tuned
+// http.Transport, connection pool limits or a corporate proxy. Its
+// Transport is used as the base of Client.Transport and its Timeout bounds
+// each attempt. The client is copied, so later options such as WithTimeout
+// do not modify it; the Transport itself is shared. A nil client is
+// ignored.
 func WithHTTPClient(httpClient *http.Client) ClientOption {
 	return func(c *Client) {
-		c.httpClient = httpClient
+		if httpClient == nil {
+			return
+		}
+		cp := *httpClient
+		c.httpClient = &cp
 	}
 }
 
@@ -163,9 +251,14 @@ func WithRetry(config *RetryConfig) ClientOption {
 	}
 }
 
-// WithLogger sets a custom logger.
+// WithLogger sets a custom logger. A *slog.Logger is used as with
+// WithSlogLogger, so records keep their attributes.
 func WithLogger(logger Logger) ClientOption {
 	return func(c *Client) {
+		if l, ok := logger.(*slog.Logger); ok {
+			WithSlogLogger(l)(c)
+			return
+		}
 		c.logger = logger
 	}
 }
@@ -179,20 +272,48 @@ func WithUserAgent(userAgent string) ClientOption {
 
 // NewClient creates a new IPTU API client.
 func NewClient(apiKey string, opts ...ClientOption) *Client {
+	defaultHTTPClient := &http.Client{
+		Timeout: defaultTimeout,
+	}
 	c := &Client{
-		apiKey:  apiKey,
-		baseURL: defaultBaseURL,
-		httpClient: &http.Client{
-			Timeout: defaultTimeout,
-		},
+		apiKey:      apiKey,
+		baseURL:     defaultBaseURL,
+		httpClient:  defaultHTTPClient,
 		retryConfig: DefaultRetryConfig(),
 		logger:      &DefaultLogger{Enabled: false},
 		userAgent:   "iptuapi-go/" + Version,
+		matcher:     LevenshteinMatcher,
+		health:      newHealthState(),
+		stats:       newStatsCollector(),
+		journal:     newRequestJournal(journalSize),
+		sla:         newSLATracker(defaultSLASamples, defaultSLAWindow),
+		marshal:     json.Marshal,
+		unmarshal:   json.Unmarshal,
+		ids:         IDFunc(NewUUIDv7),
+		clock:       SystemClock,
 	}
 
 	for _, opt := range opts {
 		opt(c)
 	}
+	// The RateLimiter is left alone: it may be shared with other clients.
+	if c.clock != SystemClock {
+		if c.breaker != nil {
+			c.breaker.now = c.clock.Now
+		}
+		if c.keys != nil {
+			c.keys.now = c.clock.Now
+		}
+		if c.failover != nil {
+			c.failover.now = c.clock.Now
+		}
+	}
+
+	// Endpoint timeouts replace the default client-wide timeout.
+	if c.endpointTimeouts != nil && c.httpClient == defaultHTTPClient && c.httpClient.Timeout == defaultTimeout {
+		c.httpClient.Timeout = 0
+	}
+	c.applyDial()
 
 	return c
 }
@@ -203,17 +324,61 @@ func NewClient(apiKey string, opts ...ClientOption) *Client {
 
 // ConsultaEnderecoParams contains parameters for address query.
 type ConsultaEnderecoParams struct {
-	Logradouro         string
-	Numero             string
-	Complemento        string
-	Cidade             Cidade
+	Logradouro  string
+	Numero      string
+	Complemento string
+	// Cidade defaults to CidadeSaoPaulo.
+	Cidade Cidade
+	// IncluirHistorico, IncluirComparaveis and IncluirZoneamento ask for
+	// the Historico, Comparaveis and Zoneamento fields of the result,
+	// sent as the incluir_* query parameters. They may be dropped under
+	// WithDegradation.
 	IncluirHistorico   bool
 	IncluirComparaveis bool
 	IncluirZoneamento  bool
 }
 
+// Aviso codes reported by the API when a municipal source degraded a result.
+const (
+	AvisoFonteIndisponivel   = "FONTE_INDISPONIVEL"
+	AvisoDadosDesatualizados = "DADOS_DESATUALIZADOS"
+	AvisoDadosParciais       = "DADOS_PARCIAIS"
+)
+
+// Aviso is a warning attached to a successful result, e.g. when one of the
+// municipal sources was down and some fields could not be filled.
+type Aviso struct {
+	Codigo   string `json:"codigo"`
+	Fonte    string `json:"fonte,omitempty"`
+	Mensagem string `json:"mensagem,omitempty"`
+	// Campos lists the result fields affected, when known.
+	Campos []string `json:"campos,omitempty"`
+}
+
+// Avisos is the list of warnings on a result.
+type Avisos []Aviso
+
+// Has reports whether a warning with the given code is present.
+func (a Avisos) Has(codigo string) bool {
+	for _, aviso := range a {
+		if aviso.Codigo == codigo {
+			return true
+		}
+	}
+	return false
+}
+
+// Degradado reports whether any source was unavailable, returned partial
+// data or was skipped, meaning empty fields may be missing rather than
+// absent.
+func (a Avisos) Degradado() bool {
+	return a.Has(AvisoFonteIndisponivel) || a.Has(AvisoDadosParciais) || a.Has(AvisoEnriquecimentoOmitido)
+}
+
 // ConsultaEnderecoResult represents the result of an address query.
 type ConsultaEnderecoResult struct {
+	Schema
+	Frentes
 	SQL                  string            `json:"sql"`
 	Logradouro           string            `json:"logradouro"`
 	Numero               string            `json:"numero,omitempty"`
@@ -222,20 +387,28 @@ type ConsultaEnderecoResult struct {
 	CEP                  string            `json:"cep,omitempty"`
 	AreaTerreno          float64           `json:"area_terreno,omitempty"`
 	AreaConstruida       float64           `json:"area_construida,omitempty"`
+	FracaoIdeal          float64           `json:"fracao_ideal,omitempty"`
 	ValorVenalTerreno    float64           `json:"valor_venal_terreno,omitempty"`
 	ValorVenalConstrucao float64           `json:"valor_venal_construcao,omitempty"`
 	ValorVenalTotal      float64           `json:"valor_venal_total,omitempty"`
 	IPTUValor            float64           `json:"iptu_valor,omitempty"`
 	AnoConstrucao        int               `json:"ano_construcao,omitempty"`
 	TipoUso              string            `json:"tipo_uso,omitempty"`
+	TipoPadrao           string            `json:"tipo_padrao,omitempty"`
 	Zona                 string            `json:"zona,omitempty"`
 	Historico            []HistoricoItem   `json:"historico,omitempty"`
 	Comparaveis          []ComparavelItem  `json:"comparaveis,omitempty"`
 	Zoneamento           *ZoneamentoResult `json:"zoneamento,omitempty"`
+	Avisos               Avisos            `json:"avisos,omitempty"`
+	// Degraded is set when optional enrichments were dropped; see
+	// WithDegradation.
+	Degraded bool `json:"degraded,omitempty"`
 }
 
 // ConsultaSQLResult represents the result of a SQL query.
 type ConsultaSQLResult struct {
+	Schema
+	Frentes
 	SQL                  string  `json:"sql"`
 	Ano                  int     `json:"ano,omitempty"`
 	ValorVenal           float64 `json:"valor_venal,omitempty"`
@@ -248,6 +421,24 @@ type ConsultaSQLResult struct {
 	Bairro               string  `json:"bairro,omitempty"`
 	AreaTerreno          float64 `json:"area_terreno,omitempty"`
 	AreaConstruida       float64 `json:"area_construida,omitempty"`
+	Avisos               Avisos  `json:"avisos,omitempty"`
+}
+
+// Frentes are the frontage attributes of a lot, which materially affect
+// its valuation. They are only filled for cities whose cadastre has them;
+// see Dicionario for the fields available per city.
+type Frentes struct {
+	// Testada is the length in meters of the main frontage, the one of
+	// the lot's address.
+	Testada float64 `json:"testada,omitempty"`
+	// Testadas lists every frontage in meters, main one first, for lots
+	// facing several streets.
+	Testadas []float64 `json:"testadas,omitempty"`
+	// NumeroFrentes is the number of streets the lot faces.
+	NumeroFrentes int `json:"numero_frentes,omitempty"`
+	// Esquina tells whether the lot is on a corner, or is nil when the
+	// cadastre does not say.
+	Esquina *bool `json:"esquina,omitempty"`
 }
 
 // HistoricoItem represents a historical value entry.
@@ -273,12 +464,12 @@ type ComparavelItem struct {
 
 // ZoneamentoResult represents zoning data.
 type ZoneamentoResult struct {
-	Zona                         string  `json:"zona,omitempty"`
-	ZonaDescricao                string  `json:"zona_descricao,omitempty"`
+	Zona                            string  `json:"zona,omitempty"`
+	ZonaDescricao                   string  `json:"zona_descricao,omitempty"`
 	CoeficienteAproveitamentoBasico float64 `json:"coeficiente_aproveitamento_basico,omitempty"`
 	CoeficienteAproveitamentoMaximo float64 `json:"coeficiente_aproveitamento_maximo,omitempty"`
-	TaxaOcupacaoMaxima            float64 `json:"taxa_ocupacao_maxima,omitempty"`
-	GabaritoMaximo                int     `json:"gabarito_maximo,omitempty"`
+	TaxaOcupacaoMaxima              float64 `json:"taxa_ocupacao_maxima,omitempty"`
+	GabaritoMaximo                  int     `json:"gabarito_maximo,omitempty"`
 }
 
 // ValuationParams contains parameters for property valuation.
@@ -295,13 +486,19 @@ type ValuationParams struct {
 
 // ValuationResult represents the result of a valuation estimate.
 type ValuationResult struct {
-	ValorEstimado        float64 `json:"valor_estimado"`
-	ValorMinimo          float64 `json:"valor_minimo,omitempty"`
-	ValorMaximo          float64 `json:"valor_maximo,omitempty"`
-	Confianca            float64 `json:"confianca,omitempty"`
-	Metodo               string  `json:"metodo,omitempty"`
+	Schema
+	ValorEstimado         float64 `json:"valor_estimado"`
+	ValorMinimo           float64 `json:"valor_minimo,omitempty"`
+	ValorMaximo           float64 `json:"valor_maximo,omitempty"`
+	Confianca             float64 `json:"confianca,omitempty"`
+	Metodo                string  `json:"metodo,omitempty"`
 	ComparaveisUtilizados int     `json:"comparaveis_utilizados,omitempty"`
-	DataAvaliacao        string  `json:"data_avaliacao,omitempty"`
+	DataAvaliacao         string  `json:"data_avaliacao,omitempty"`
+	// ModeloVersao is the valuation model version, from the response body
+	// or the X-Model-Version header.
+	ModeloVersao string `json:"modelo_versao,omitempty"`
+	// Reproducao is set for calls made with WithDeterministic.
+	Reproducao *Reproducao `json:"reproducao,omitempty"`
 }
 
 // BatchValuationResult represents batch valuation results.
@@ -331,6 +528,10 @@ type APIError struct {
 }
 
 func (e *APIError) Error() string {
+	if e.StatusCode == 0 {
+		// Rejected before any request, e.g. by local validation.
+		return "iptuapi: " + e.Message
+	}
 	if e.RequestID != "" {
 		return fmt.Sprintf("IPTU API error (status %d, request %s): %s", e.StatusCode, e.RequestID, e.Message)
 	}
@@ -347,22 +548,54 @@ func (e *APIError) IsRetryable() bool {
 	return false
 }
 
+// Sentinels matched by the typed errors with errors.Is, for callers that
+// only branch on the condition:
+//
+//	if errors.Is(err, iptuapi.ErrNotFound) { ... }
+var (
+	ErrNotFound     = errors.New("iptuapi: not found")
+	ErrRateLimited  = errors.New("iptuapi: rate limited")
+	ErrUnauthorized = errors.New("iptuapi: unauthorized")
+	// ErrPlanRequired is matched by a ForbiddenError: the plan does not
+	// include the endpoint.
+	ErrPlanRequired = errors.New("iptuapi: plan required")
+)
+
+// The typed errors below wrap the *APIError of the response, so
+// errors.As(err, &apiErr) finds the status code and request ID whatever the
+// type, and the Is helpers see through errors wrapped with %w.
+
 // AuthenticationError indicates invalid API key.
 type AuthenticationError struct {
 	*APIError
 }
 
+func (e *AuthenticationError) Unwrap() error { return unwrapAPI(e.APIError) }
+
+// Is reports whether target is ErrUnauthorized.
+func (e *AuthenticationError) Is(target error) bool { return target == ErrUnauthorized }
+
 // ForbiddenError indicates plan not authorized.
 type ForbiddenError struct {
 	*APIError
 	RequiredPlan string
 }
 
+func (e *ForbiddenError) Unwrap() error { return unwrapAPI(e.APIError) }
+
+// Is reports whether target is ErrPlanRequired.
+func (e *ForbiddenError) Is(target error) bool { return target == ErrPlanRequired }
+
 // NotFoundError indicates resource not found.
 type NotFoundError struct {
 	*APIError
 }
 
+func (e *NotFoundError) Unwrap() error { return unwrapAPI(e.APIError) }
+
+// Is reports whether target is ErrNotFound.
+func (e *NotFoundError) Is(target error) bool { return target == ErrNotFound }
+
 // RateLimitError indicates rate limit exceeded.
 type RateLimitError struct {
 	*APIError
@@ -371,16 +604,31 @@ type RateLimitError struct {
 	Remaining  int
 }
 
-// ValidationError indicates invalid parameters.
+func (e *RateLimitError) Unwrap() error { return unwrapAPI(e.APIError) }
+
+// Is reports whether target is ErrRateLimited.
+func (e *RateLimitError) Is(target error) bool { return target == ErrRateLimited }
+
+// ValidationError indicates invalid parameters. Its StatusCode is 0 when
+// the parameters were rejected locally, without sending the call; see
+// WithSkipValidation.
 type ValidationError struct {
 	*APIError
 	Errors []FieldError
 }
 
+func (e *ValidationError) Unwrap() error { return unwrapAPI(e.APIError) }
+
 // FieldError represents a field validation error.
 type FieldError struct {
+	// Field is the parameter or body field, e.g. "numero", with nested
+	// fields joined by dots.
 	Field   string `json:"field"`
 	Message string `json:"message"`
+	// Code is the machine-readable reason when the API sends one, e.g.
+	// "missing" or "string_too_short", for forms that show their own
+	// messages. Local validation uses the Codigo constants.
+	Code string `json:"code,omitempty"`
 }
 
 // ServerError indicates internal server error.
@@ -388,40 +636,111 @@ type ServerError struct {
 	*APIError
 }
 
+func (e *ServerError) Unwrap() error { return unwrapAPI(e.APIError) }
+
+// FonteIndisponivelError indicates that the municipal data source behind the
+// API was unavailable, as opposed to the property not existing.
+type FonteIndisponivelError struct {
+	*APIError
+	// Fonte identifies the upstream source, e.g. "prefeitura_sp".
+	Fonte  string
+	Codigo string
+}
+
+func (e *FonteIndisponivelError) Unwrap() error { return unwrapAPI(e.APIError) }
+
+// unwrapAPI returns e as an error, or nil when e is nil rather than a
+// non-nil error holding a nil pointer.
+func unwrapAPI(e *APIError) error {
+	if e == nil {
+		return nil
+	}
+	return e
+}
+
 // IsNotFound returns true if the error is a 404 Not Found.
 func IsNotFound(err error) bool {
-	_, ok := err.(*NotFoundError)
-	return ok
+	var target *NotFoundError
+	return errors.As(err, &target)
 }
 
 // IsRateLimit returns true if the error is a 429 Rate Limit.
 func IsRateLimit(err error) bool {
-	_, ok := err.(*RateLimitError)
-	return ok
+	var target *RateLimitError
+	return errors.As(err, &target)
 }
 
 // IsAuthError returns true if the error is a 401 Authentication error.
 func IsAuthError(err error) bool {
-	_, ok := err.(*AuthenticationError)
-	return ok
+	var target *AuthenticationError
+	return errors.As(err, &target)
 }
 
 // IsForbidden returns true if the error is a 403 Forbidden error.
 func IsForbidden(err error) bool {
-	_, ok := err.(*ForbiddenError)
-	return ok
+	var target *ForbiddenError
+	return errors.As(err, &target)
+}
+
+// IsValidation returns true if the error is a 400 or 422 validation error.
+func IsValidation(err error) bool {
+	var target *ValidationError
+	return errors.As(err, &target)
+}
+
+// IsFonteIndisponivel returns true if the municipal data source was unavailable.
+func IsFonteIndisponivel(err error) bool {
+	var target *FonteIndisponivelError
+	return errors.As(err, &target)
 }
 
 // IsServerError returns true if the error is a 5xx server error.
 func IsServerError(err error) bool {
-	_, ok := err.(*ServerError)
-	return ok
+	var target *ServerError
+	return errors.As(err, &target)
+}
+
+// IsTimeout returns true if the call ran out of time, by its context
+// deadline or a client or network timeout.
+func IsTimeout(err error) bool {
+	var netErr net.Error
+	return errors.Is(err, context.DeadlineExceeded) || (errors.As(err, &netErr) && netErr.Timeout())
+}
+
+// IsNetworkError returns true if the call failed without a response from
+// the API, e.g. on a refused connection, a DNS failure or a dropped
+// connection. Timeouts and cancellations are not network errors.
+func IsNetworkError(err error) bool {
+	if err == nil || IsTimeout(err) || errors.Is(err, context.Canceled) {
+		return false
+	}
+	var (
+		apiErr *APIError
+		netErr net.Error
+		opErr  *net.OpError
+	)
+	if errors.As(err, &apiErr) {
+		return false
+	}
+	return errors.As(err, &netErr) || errors.As(err, &opErr) || isTemporaryNetErr(err)
 }
 
 // =============================================================================
 // Internal Methods
 // =============================================================================
 
+// shouldRetry decides whether an attempt is retried. resp is nil when the
+// request failed before a response was received.
+func (c *Client) shouldRetry(resp *http.Response, err error, attempt int) bool {
+	if c.retryConfig.ShouldRetry != nil {
+		return c.retryConfig.ShouldRetry(resp, err, attempt)
+	}
+	if resp == nil {
+		return true
+	}
+	return c.isRetryable(resp.StatusCode)
+}
+
 func (c *Client) isRetryable(statusCode int) bool {
 	for _, s := range c.retryConfig.RetryableStatus {
 		if statusCode == s {
@@ -431,44 +750,57 @@ func (c *Client) isRetryable(statusCode int) bool {
 	return false
 }
 
-func (c *Client) calculateDelay(attempt int) time.Duration {
-	delay := float64(c.retryConfig.InitialDelay) * math.Pow(c.retryConfig.BackoffFactor, float64(attempt))
-	if delay > float64(c.retryConfig.MaxDelay) {
-		delay = float64(c.retryConfig.MaxDelay)
-	}
-	return time.Duration(delay)
-}
-
-func (c *Client) extractRateLimit(resp *http.Response) {
-	limit := resp.Header.Get("X-RateLimit-Limit")
-	remaining := resp.Header.Get("X-RateLimit-Remaining")
-	reset := resp.Header.Get("X-RateLimit-Reset")
-
-	if limit != "" && remaining != "" && reset != "" {
-		limitInt, _ := strconv.Atoi(limit)
-		remainingInt, _ := strconv.Atoi(remaining)
-		resetInt, _ := strconv.ParseInt(reset, 10, 64)
+func (c *Client) extractRateLimit(resp *http.Response, rec RequestRecord, ownKey bool) *RateLimitInfo {
+	received := c.clock.Now()
+	skew := c.observeClock(resp.Header.Get("Date"), received)
+	rl := c.rateLimitFrom(resp.Header, skew, received)
 
-		c.RateLimit = &RateLimitInfo{
-			Limit:     limitInt,
-			Remaining: remainingInt,
-			Reset:     resetInt,
-			ResetTime: time.Unix(resetInt, 0),
+	c.rateMu.Lock()
+	defer c.rateMu.Unlock()
+	// Quotas of keys set per call are not the client's.
+	if rl != nil && ownKey {
+		c.RateLimit = rl
+		// With several keys the quotas are tracked per key by the pool.
+		if c.limiter != nil && (c.keys == nil || len(c.keys.keys) == 1) {
+			c.limiter.Observe(rl)
 		}
+		c.publishRateLimit(*rl)
 	}
 
 	c.LastRequestID = resp.Header.Get("X-Request-ID")
+	c.lastRequest = RequestInfo{
+		RequestID: rec.RequestID,
+		Method:    rec.Method,
+		Route:     rec.Route,
+		Status:    rec.Status,
+		Time:      received,
+	}
+	return rl
+}
+
+// lastResponseInfo returns the rate limit and request ID of the last
+// response.
+func (c *Client) lastResponseInfo() (*RateLimitInfo, string) {
+	c.rateMu.Lock()
+	defer c.rateMu.Unlock()
+	return c.RateLimit, c.LastRequestID
 }
 
 func (c *Client) handleErrorResponse(resp *http.Response, body []byte) error {
 	var errResp struct {
-		Detail       string       `json:"detail"`
-		RequiredPlan string       `json:"required_plan,omitempty"`
-		Errors       []FieldError `json:"errors,omitempty"`
+		Detail       json.RawMessage `json:"detail"`
+		RequiredPlan string          `json:"required_plan,omitempty"`
+		Errors       []FieldError    `json:"errors,omitempty"`
+		Codigo       string          `json:"codigo,omitempty"`
+		Fonte        string          `json:"fonte,omitempty"`
 	}
-	json.Unmarshal(body, &errResp)
+	c.unmarshal(body, &errResp)
 
-	message := errResp.Detail
+	message, fieldErrors := c.parseDetail(errResp.Detail)
+	if len(errResp.Errors) == 0 {
+		errResp.Errors = fieldErrors
+	}
+	detail := message
 	if message == "" {
 		switch resp.StatusCode {
 		case http.StatusUnauthorized:
@@ -484,10 +816,22 @@ func (c *Client) handleErrorResponse(resp *http.Response, body []byte) error {
 		}
 	}
 
+	rateLimit, requestID := c.lastResponseInfo()
 	baseErr := &APIError{
 		StatusCode: resp.StatusCode,
 		Message:    message,
-		RequestID:  c.LastRequestID,
+		RequestID:  requestID,
+	}
+	c.unmarshal(body, &baseErr.ResponseBody)
+
+	if errResp.Codigo == AvisoFonteIndisponivel || (errResp.Fonte != "" && resp.StatusCode >= 500) {
+		if detail == "" {
+			baseErr.Message = "Fonte de dados municipal indisponível"
+		}
+		return &FonteIndisponivelError{APIError: baseErr, Fonte: errResp.Fonte, Codigo: errResp.Codigo}
+	}
+	if errResp.Codigo == CodigoCidadeNaoSuportada {
+		return &CityNotSupportedError{APIError: baseErr}
 	}
 
 	switch resp.StatusCode {
@@ -498,16 +842,15 @@ func (c *Client) handleErrorResponse(resp *http.Response, body []byte) error {
 	case http.StatusNotFound:
 		return &NotFoundError{APIError: baseErr}
 	case http.StatusTooManyRequests:
-		retryAfter := 0
-		if ra := resp.Header.Get("Retry-After"); ra != "" {
-			retryAfter, _ = strconv.Atoi(ra)
+		var retryAfter int
+		if wait, ok := parseRetryAfter(resp, c.clock.Now()); ok {
+			retryAfter = int((wait + time.Second - 1) / time.Second)
 		}
-		return &RateLimitError{
-			APIError:   baseErr,
-			RetryAfter: retryAfter,
-			Limit:      c.RateLimit.Limit,
-			Remaining:  c.RateLimit.Remaining,
+		rlErr := &RateLimitError{APIError: baseErr, RetryAfter: retryAfter}
+		if rateLimit != nil {
+			rlErr.Limit, rlErr.Remaining = rateLimit.Limit, rateLimit.Remaining
 		}
+		return rlErr
 	case http.StatusBadRequest, 422:
 		return &ValidationError{APIError: baseErr, Errors: errResp.Errors}
 	case http.StatusInternalServerError, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
@@ -517,84 +860,201 @@ func (c *Client) handleErrorResponse(resp *http.Response, body []byte) error {
 	}
 }
 
-func (c *Client) doRequest(ctx context.Context, method, endpoint string, params url.Values, body interface{}, result interface{}) error {
-	u, err := url.Parse(c.baseURL + endpoint)
+// parseDetail reads the detail of an error body: a message, or the list of
+// field errors of a 422 response, as in
+// {"detail": [{"loc": ["query", "numero"], "msg": "...", "type": "missing"}]}.
+// For a list the message summarizes the fields.
+func (c *Client) parseDetail(raw json.RawMessage) (string, []FieldError) {
+	if len(raw) == 0 {
+		return "", nil
+	}
+	var message string
+	if c.unmarshal(raw, &message) == nil {
+		return message, nil
+	}
+	var items []struct {
+		Loc     []interface{} `json:"loc"`
+		Field   string        `json:"field"`
+		Msg     string        `json:"msg"`
+		Message string        `json:"message"`
+		Type    string        `json:"type"`
+		Code    string        `json:"code"`
+	}
+	if c.unmarshal(raw, &items) != nil {
+		return "", nil
+	}
+	fields := make([]FieldError, 0, len(items))
+	parts := make([]string, 0, len(items))
+	for _, item := range items {
+		fe := FieldError{Field: item.Field, Message: item.Msg, Code: item.Code}
+		if fe.Field == "" {
+			fe.Field = fieldPath(item.Loc)
+		}
+		if fe.Message == "" {
+			fe.Message = item.Message
+		}
+		if fe.Code == "" {
+			// Older API versions prefix the type, as in "value_error.missing".
+			fe.Code = item.Type[strings.LastIndex(item.Type, ".")+1:]
+		}
+		fields = append(fields, fe)
+		parts = append(parts, fe.Field+": "+fe.Message)
+	}
+	return strings.Join(parts, "; "), fields
+}
+
+// fieldPath joins the location of a field error, without its source
+// ("query", "body", "path") and with list indexes in brackets.
+func fieldPath(loc []interface{}) string {
+	var b strings.Builder
+	for i, part := range loc {
+		switch v := part.(type) {
+		case string:
+			if i == 0 && (v == "query" || v == "body" || v == "path" || v == "header") {
+				continue
+			}
+			if b.Len() > 0 {
+				b.WriteByte('.')
+			}
+			b.WriteString(v)
+		case float64:
+			b.WriteString("[" + strconv.Itoa(int(v)) + "]")
+		}
+	}
+	return b.String()
+}
+
+func (c *Client) doRequest(ctx context.Context, method, endpoint string, params url.Values, body interface{}, result interface{}, opts ...RequestOption) error {
+	defer c.trackCallGroup(ctx)()
+
+	ro := newRequestOptions(opts)
+	route := ro.routeFor(endpoint)
+	params = ro.mergeQuery(params, body == nil)
+	u, err := url.Parse(c.RequestURL(endpoint, params))
 	if err != nil {
 		return err
 	}
-
-	if params != nil {
-		u.RawQuery = params.Encode()
+	if ro.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, ro.timeout)
+		defer cancel()
 	}
+	ctx, cancel := c.withEndpointDeadline(ctx, route)
+	defer cancel()
 
-	var reqBody io.Reader
+	var jsonBody []byte
 	if body != nil {
-		jsonBody, err := json.Marshal(body)
+		jsonBody, err = c.marshal(body)
 		if err != nil {
 			return err
 		}
-		reqBody = bytes.NewReader(jsonBody)
 	}
 
-	var lastErr error
-	for attempt := 0; attempt <= c.retryConfig.MaxRetries; attempt++ {
-		if attempt > 0 {
-			delay := c.calculateDelay(attempt - 1)
-			c.logger.Warn("Request failed, retrying in %v (attempt %d/%d)", delay, attempt, c.retryConfig.MaxRetries)
-
-			select {
-			case <-time.After(delay):
-			case <-ctx.Done():
-				return ctx.Err()
-			}
-		}
-
-		req, err := http.NewRequestWithContext(ctx, method, u.String(), reqBody)
+	var reqBody io.Reader
+	if jsonBody != nil {
+		reqBody = bytes.NewReader(jsonBody)
+	}
+	apiKey := c.apiKey
+	if ro.apiKey != "" {
+		apiKey = ro.apiKey
+	}
+	flags := ro.flags(ctx)
+	var key string
+	if c.cache != nil && method == http.MethodGet && ro.header == nil && !flags.DisableCache {
+		key = cacheKey(apiKey, endpoint, params)
+	}
+	fetch := func(ctx context.Context) ([]byte, error) {
+		req, err := c.newRequest(ctx, method, u.String(), reqBody, route, ro, flags)
 		if err != nil {
-			return err
+			return nil, err
 		}
 
-		req.Header.Set("X-API-Key", c.apiKey)
-		req.Header.Set("Content-Type", "application/json")
-		req.Header.Set("Accept", "application/json")
-		req.Header.Set("User-Agent", c.userAgent)
-
-		c.logger.Debug("Request: %s %s", method, u.String())
-
-		resp, err := c.httpClient.Do(req)
+		resp, err := c.send(req)
 		if err != nil {
-			lastErr = err
-			if attempt < c.retryConfig.MaxRetries {
-				continue
-			}
-			return err
+			return nil, err
 		}
 		defer resp.Body.Close()
-
 		respBody, err := io.ReadAll(resp.Body)
 		if err != nil {
-			lastErr = err
-			continue
+			return nil, err
 		}
-
-		c.extractRateLimit(resp)
-		c.logger.Debug("Response: %d %s", resp.StatusCode, u.String())
-
-		if resp.StatusCode >= 200 && resp.StatusCode < 300 {
-			return json.Unmarshal(respBody, result)
+		if ro.raw != nil {
+			*ro.raw = RawResponse{StatusCode: resp.StatusCode, Header: resp.Header, Body: respBody}
 		}
 
-		lastErr = c.handleErrorResponse(resp, respBody)
-
-		// Check if retryable
-		if c.isRetryable(resp.StatusCode) && attempt < c.retryConfig.MaxRetries {
-			continue
+		if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+			return nil, c.handleErrorResponse(resp, respBody)
+		}
+		if err := c.verifySignature(resp.Header, respBody); err != nil {
+			return nil, err
 		}
+		if ro.header != nil {
+			*ro.header = resp.Header
+		}
+		return respBody, nil
+	}
 
-		return lastErr
+	var (
+		respBody []byte
+		cached   bool
+	)
+	if !flags.ForceRefresh {
+		respBody, cached = c.cacheGet(ctx, key)
+	}
+	if cached && ro.raw != nil {
+		*ro.raw = RawResponse{StatusCode: http.StatusOK, Body: respBody, Cached: true}
+	}
+	if !cached {
+		if c.coalesce != nil && method == http.MethodGet && ro.header == nil && ro.reqHeader == nil && ro.raw == nil && !flags.ForceRefresh {
+			respBody, err = c.coalesce.do(ctx, cacheKey(apiKey, endpoint, params), fetch)
+		} else {
+			respBody, err = fetch(ctx)
+		}
+		if err != nil {
+			return err
+		}
 	}
+	if err := c.unmarshal(respBody, result); err != nil {
+		return err
+	}
+	if !cached {
+		c.cacheSet(ctx, key, respBody)
+	}
+	stampSchema(result)
+	return c.postProcess(route, result)
+}
 
-	return lastErr
+// newRequest builds the HTTP request of a call to route, with the headers
+// asked for by ro and flags.
+func (c *Client) newRequest(ctx context.Context, method, u string, body io.Reader, route string, ro *requestOptions, flags Flags) (*http.Request, error) {
+	ctx = contextWithRoute(ctx, route)
+	if ro.noBreaker {
+		ctx = context.WithValue(ctx, noBreakerKey{}, true)
+	}
+	if ro.apiKey != "" {
+		ctx = context.WithValue(ctx, apiKeyKey{}, ro.apiKey)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, u, body)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+	if flags.ForceRefresh {
+		req.Header.Set("Cache-Control", "no-cache")
+	}
+	for k, v := range ro.reqHeader {
+		req.Header[k] = v
+	}
+	// IDs set with WithHeader are kept. Both are generated once per call,
+	// so retries carry the same values.
+	if req.Header.Get("X-Request-ID") == "" {
+		req.Header.Set("X-Request-ID", c.ids.NewID())
+	}
+	if !isIdempotent(method) && req.Header.Get("Idempotency-Key") == "" {
+		req.Header.Set("Idempotency-Key", c.ids.NewID())
+	}
+	return req, nil
 }
 
 // =============================================================================
@@ -602,40 +1062,38 @@ func (c *Client) doRequest(ctx context.Context, method, endpoint string, params
 // =============================================================================
 
 // ConsultaEndereco searches for property data by address.
-func (c *Client) ConsultaEndereco(ctx context.Context, p *ConsultaEnderecoParams) (*ConsultaEnderecoResult, error) {
-	params := url.Values{}
-	params.Set("logradouro", p.Logradouro)
-	if p.Numero != "" {
-		params.Set("numero", p.Numero)
-	}
-	if p.Complemento != "" {
-		params.Set("complemento", p.Complemento)
+func (c *Client) ConsultaEndereco(ctx context.Context, p *ConsultaEnderecoParams, opts ...RequestOption) (*ConsultaEnderecoResult, error) {
+	if cidade := newRequestOptions(opts).cidade; cidade != "" {
+		cp := *p
+		cp.Cidade = cidade
+		p = &cp
 	}
-	if p.Cidade != "" {
-		params.Set("cidade", string(p.Cidade))
-	} else {
-		params.Set("cidade", string(CidadeSaoPaulo))
-	}
-	if p.IncluirHistorico {
-		params.Set("incluir_historico", "true")
-	}
-	if p.IncluirComparaveis {
-		params.Set("incluir_comparaveis", "true")
-	}
-	if p.IncluirZoneamento {
-		params.Set("incluir_zoneamento", "true")
+	if err := c.check(opts, p.Validate); err != nil {
+		return nil, err
 	}
+	p, dropped := c.degrade(ctx, p)
+	params := p.Values()
 
 	var result ConsultaEnderecoResult
-	err := c.doRequest(ctx, "GET", "/consulta/endereco", params, nil, &result)
+	err := c.doRequest(ctx, "GET", "/consulta/endereco", params, nil, &result, opts...)
 	if err != nil {
+		if c.useFallback(err) {
+			return c.fallbackEndereco(ctx, p, err)
+		}
 		return nil, err
 	}
+	if dropped != nil {
+		markDegraded(&result, dropped)
+	}
 	return &result, nil
 }
 
 // ConsultaSQL searches for property data by SQL number.
-func (c *Client) ConsultaSQL(ctx context.Context, sql string, cidade Cidade) (*ConsultaSQLResult, error) {
+func (c *Client) ConsultaSQL(ctx context.Context, sql string, cidade Cidade, opts ...RequestOption) (*ConsultaSQLResult, error) {
+	cidade = newRequestOptions(opts).cidadeOr(cidade)
+	if err := c.check(opts, func() error { return validateSQL(sql, cidade) }); err != nil {
+		return nil, err
+	}
 	params := url.Values{}
 	if cidade != "" {
 		params.Set("cidade", string(cidade))
@@ -644,15 +1102,24 @@ func (c *Client) ConsultaSQL(ctx context.Context, sql string, cidade Cidade) (*C
 	}
 
 	var result ConsultaSQLResult
-	err := c.doRequest(ctx, "GET", "/consulta/sql/"+sql, params, nil, &result)
+	err := c.doRequest(ctx, "GET", "/consulta/sql/"+sql, params, nil, &result, append([]RequestOption{withRoute("/consulta/sql/{sql}")}, opts...)...)
 	if err != nil {
+		if c.useFallback(err) {
+			return c.fallbackSQL(ctx, sql, cidadeOrDefault(cidade), err)
+		}
 		return nil, err
 	}
 	return &result, nil
 }
 
-// ConsultaCEP searches for properties by CEP.
-func (c *Client) ConsultaCEP(ctx context.Context, cep string, cidade Cidade) ([]ConsultaEnderecoResult, error) {
+// ConsultaCEP returns every property registered under a CEP, usually
+// one side of a street segment; see ConsultaCEPNumero to narrow it to a
+// number.
+func (c *Client) ConsultaCEP(ctx context.Context, cep string, cidade Cidade, opts ...RequestOption) ([]ConsultaEnderecoResult, error) {
+	cidade = newRequestOptions(opts).cidadeOr(cidade)
+	if err := c.check(opts, func() error { return validateCEP(cep, cidade) }); err != nil {
+		return nil, err
+	}
 	params := url.Values{}
 	if cidade != "" {
 		params.Set("cidade", string(cidade))
@@ -661,7 +1128,7 @@ func (c *Client) ConsultaCEP(ctx context.Context, cep string, cidade Cidade) ([]
 	}
 
 	var result []ConsultaEnderecoResult
-	err := c.doRequest(ctx, "GET", "/consulta/cep/"+cep, params, nil, &result)
+	err := c.doRequest(ctx, "GET", "/consulta/cep/"+cep, params, nil, &result, append([]RequestOption{withRoute("/consulta/cep/{cep}")}, opts...)...)
 	if err != nil {
 		return nil, err
 	}
@@ -669,13 +1136,16 @@ func (c *Client) ConsultaCEP(ctx context.Context, cep string, cidade Cidade) ([]
 }
 
 // ConsultaZoneamento queries zoning by coordinates.
-func (c *Client) ConsultaZoneamento(ctx context.Context, latitude, longitude float64) (*ZoneamentoResult, error) {
+func (c *Client) ConsultaZoneamento(ctx context.Context, latitude, longitude float64, opts ...RequestOption) (*ZoneamentoResult, error) {
+	if err := c.check(opts, func() error { return validateCoordenadas(latitude, longitude) }); err != nil {
+		return nil, err
+	}
 	params := url.Values{}
 	params.Set("latitude", strconv.FormatFloat(latitude, 'f', -1, 64))
 	params.Set("longitude", strconv.FormatFloat(longitude, 'f', -1, 64))
 
 	var result ZoneamentoResult
-	err := c.doRequest(ctx, "GET", "/consulta/zoneamento", params, nil, &result)
+	err := c.doRequest(ctx, "GET", "/consulta/zoneamento", params, nil, &result, opts...)
 	if err != nil {
 		return nil, err
 	}
@@ -684,34 +1154,88 @@ func (c *Client) ConsultaZoneamento(ctx context.Context, latitude, longitude flo
 
 // ValuationEstimate estimates the market value of a property.
 // Requires Pro plan or higher.
-func (c *Client) ValuationEstimate(ctx context.Context, p *ValuationParams) (*ValuationResult, error) {
+func (c *Client) ValuationEstimate(ctx context.Context, p *ValuationParams, opts ...RequestOption) (*ValuationResult, error) {
+	if cidade := newRequestOptions(opts).cidade; cidade != "" {
+		cp := *p
+		cp.Cidade = cidade
+		p = &cp
+	}
+	if err := c.check(opts, p.Validate); err != nil {
+		return nil, err
+	}
+	if c.coalesce != nil && len(opts) == 0 {
+		return c.coalesce.estimate(ctx, c, p)
+	}
+	return c.valuationEstimate(ctx, p, opts)
+}
+
+func (c *Client) valuationEstimate(ctx context.Context, p *ValuationParams, opts []RequestOption) (*ValuationResult, error) {
 	var result ValuationResult
-	err := c.doRequest(ctx, "POST", "/valuation/estimate", nil, p, &result)
+	var header http.Header
+	opts = append([]RequestOption{withResponseHeader(&header)}, opts...)
+
+	var repro *Reproducao
+	if newRequestOptions(opts).deterministic {
+		repro = newReproducao("POST", "/valuation/estimate", p.Values())
+		opts = append(opts, repro.option())
+	}
+
+	err := c.doRequest(ctx, "POST", "/valuation/estimate", nil, p, &result, opts...)
 	if err != nil {
 		return nil, err
 	}
+	if result.ModeloVersao == "" {
+		result.ModeloVersao = header.Get("X-Model-Version")
+	}
+	if repro != nil {
+		repro.ModeloVersao = result.ModeloVersao
+		result.Reproducao = repro
+	}
 	return &result, nil
 }
 
-// ValuationBatch estimates values for multiple properties.
-// Requires Enterprise plan.
-func (c *Client) ValuationBatch(ctx context.Context, imoveis []ValuationParams) (*BatchValuationResult, error) {
+// ValuationBatch estimates values for multiple properties. Results without
+// a modelo_versao take the batch's X-Model-Version header, as in
+// ValuationEstimate. Requires Enterprise plan.
+func (c *Client) ValuationBatch(ctx context.Context, imoveis []ValuationParams, opts ...RequestOption) (*BatchValuationResult, error) {
+	if cidade := newRequestOptions(opts).cidade; cidade != "" {
+		imoveis = append([]ValuationParams(nil), imoveis...)
+		for i := range imoveis {
+			imoveis[i].Cidade = cidade
+		}
+	}
+	if err := c.check(opts, func() error { return validateBatch(imoveis) }); err != nil {
+		return nil, err
+	}
 	body := map[string]interface{}{
 		"imoveis": imoveis,
 	}
 
 	var result BatchValuationResult
-	err := c.doRequest(ctx, "POST", "/valuation/estimate/batch", nil, body, &result)
+	var header http.Header
+	opts = append([]RequestOption{withResponseHeader(&header)}, opts...)
+	err := c.doRequest(ctx, "POST", "/valuation/estimate/batch", nil, body, &result, opts...)
 	if err != nil {
 		return nil, err
 	}
+	for i := range result.Resultados {
+		r := &result.Resultados[i]
+		if r.ModeloVersao == "" {
+			r.ModeloVersao = header.Get("X-Model-Version")
+		}
+		if r.Reproducao != nil && r.Reproducao.ModeloVersao == "" {
+			r.Reproducao.ModeloVersao = r.ModeloVersao
+		}
+	}
 	return &result, nil
 }
 
-// ValuationComparables finds comparable properties.
-func (c *Client) ValuationComparables(ctx context.Context, bairro string, areaMin, areaMax float64, cidade Cidade, limit int) ([]ComparavelItem, error) {
+// ValuationComparables finds comparable properties. bairro is normalized
+// with NormalizeBairro.
+func (c *Client) ValuationComparables(ctx context.Context, bairro string, areaMin, areaMax float64, cidade Cidade, limit int, opts ...RequestOption) ([]ComparavelItem, error) {
+	cidade = newRequestOptions(opts).cidadeOr(cidade)
 	params := url.Values{}
-	params.Set("bairro", bairro)
+	params.Set("bairro", NormalizeBairro(cidadeOrDefault(cidade), bairro))
 	params.Set("area_min", strconv.FormatFloat(areaMin, 'f', -1, 64))
 	params.Set("area_max", strconv.FormatFloat(areaMax, 'f', -1, 64))
 	if cidade != "" {
@@ -722,7 +1246,7 @@ func (c *Client) ValuationComparables(ctx context.Context, bairro string, areaMi
 	}
 
 	var result []ComparavelItem
-	err := c.doRequest(ctx, "GET", "/valuation/comparables", params, nil, &result)
+	err := c.doRequest(ctx, "GET", "/valuation/comparables", params, nil, &result, opts...)
 	if err != nil {
 		return nil, err
 	}
@@ -731,25 +1255,28 @@ func (c *Client) ValuationComparables(ctx context.Context, bairro string, areaMi
 
 // ValuationStatisticsResult contains statistics for a neighborhood.
 type ValuationStatisticsResult struct {
-	Bairro      string  `json:"bairro"`
-	Cidade      string  `json:"cidade"`
-	TotalImoveis int    `json:"total_imoveis"`
-	Media       float64 `json:"media"`
-	Mediana     float64 `json:"mediana"`
-	Min         float64 `json:"min"`
-	Max         float64 `json:"max"`
+	Bairro       string  `json:"bairro"`
+	Cidade       string  `json:"cidade"`
+	TotalImoveis int     `json:"total_imoveis"`
+	Media        float64 `json:"media"`
+	Mediana      float64 `json:"mediana"`
+	Min          float64 `json:"min"`
+	Max          float64 `json:"max"`
 	DesvioPadrao float64 `json:"desvio_padrao,omitempty"`
 }
 
-// ValuationStatistics gets value statistics for a neighborhood.
-func (c *Client) ValuationStatistics(ctx context.Context, bairro string, cidade Cidade) (*ValuationStatisticsResult, error) {
+// ValuationStatistics gets value statistics for a neighborhood. bairro is
+// normalized with NormalizeBairro.
+func (c *Client) ValuationStatistics(ctx context.Context, bairro string, cidade Cidade, opts ...RequestOption) (*ValuationStatisticsResult, error) {
+	cidade = newRequestOptions(opts).cidadeOr(cidade)
 	params := url.Values{}
 	if cidade != "" {
 		params.Set("cidade", string(cidade))
 	}
 
 	var result ValuationStatisticsResult
-	err := c.doRequest(ctx, "GET", "/valuation/statistics/"+url.PathEscape(bairro), params, nil, &result)
+	bairro = NormalizeBairro(cidadeOrDefault(cidade), bairro)
+	err := c.doRequest(ctx, "GET", "/valuation/statistics/"+url.PathEscape(bairro), params, nil, &result, append([]RequestOption{withRoute("/valuation/statistics/{bairro}")}, opts...)...)
 	if err != nil {
 		return nil, err
 	}
@@ -757,14 +1284,15 @@ func (c *Client) ValuationStatistics(ctx context.Context, bairro string, cidade
 }
 
 // DadosIPTUHistorico gets IPTU value history for a property.
-func (c *Client) DadosIPTUHistorico(ctx context.Context, sql string, cidade Cidade) ([]HistoricoItem, error) {
+func (c *Client) DadosIPTUHistorico(ctx context.Context, sql string, cidade Cidade, opts ...RequestOption) ([]HistoricoItem, error) {
+	cidade = newRequestOptions(opts).cidadeOr(cidade)
 	params := url.Values{}
 	if cidade != "" {
 		params.Set("cidade", string(cidade))
 	}
 
 	var result []HistoricoItem
-	err := c.doRequest(ctx, "GET", "/dados/iptu/historico/"+sql, params, nil, &result)
+	err := c.doRequest(ctx, "GET", "/dados/iptu/historico/"+sql, params, nil, &result, append([]RequestOption{withRoute("/dados/iptu/historico/{sql}")}, opts...)...)
 	if err != nil {
 		return nil, err
 	}
@@ -772,9 +1300,9 @@ func (c *Client) DadosIPTUHistorico(ctx context.Context, sql string, cidade Cida
 }
 
 // DadosCNPJ queries company data by CNPJ.
-func (c *Client) DadosCNPJ(ctx context.Context, cnpj string) (map[string]interface{}, error) {
+func (c *Client) DadosCNPJ(ctx context.Context, cnpj string, opts ...RequestOption) (map[string]interface{}, error) {
 	var result map[string]interface{}
-	err := c.doRequest(ctx, "GET", "/dados/cnpj/"+cnpj, nil, nil, &result)
+	err := c.doRequest(ctx, "GET", "/dados/cnpj/"+cnpj, nil, nil, &result, append([]RequestOption{withRoute("/dados/cnpj/{cnpj}")}, opts...)...)
 	if err != nil {
 		return nil, err
 	}
@@ -783,13 +1311,13 @@ func (c *Client) DadosCNPJ(ctx context.Context, cnpj string) (map[string]interfa
 
 // IPCAItem represents a single IPCA index entry.
 type IPCAItem struct {
-	Data   string  `json:"data"`
-	Valor  float64 `json:"valor"`
+	Data             string  `json:"data"`
+	Valor            float64 `json:"valor"`
 	Acumulado12Meses float64 `json:"acumulado_12_meses,omitempty"`
 }
 
 // DadosIPCA gets historical IPCA index data.
-func (c *Client) DadosIPCA(ctx context.Context, dataInicio, dataFim string) ([]IPCAItem, error) {
+func (c *Client) DadosIPCA(ctx context.Context, dataInicio, dataFim string, opts ...RequestOption) ([]IPCAItem, error) {
 	params := url.Values{}
 	if dataInicio != "" {
 		params.Set("data_inicio", dataInicio)
@@ -799,7 +1327,7 @@ func (c *Client) DadosIPCA(ctx context.Context, dataInicio, dataFim string) ([]I
 	}
 
 	var result []IPCAItem
-	err := c.doRequest(ctx, "GET", "/dados/ipca", params, nil, &result)
+	err := c.doRequest(ctx, "GET", "/dados/ipca", params, nil, &result, opts...)
 	if err != nil {
 		return nil, err
 	}
@@ -807,7 +1335,7 @@ func (c *Client) DadosIPCA(ctx context.Context, dataInicio, dataFim string) ([]I
 }
 
 // IPCACorrecao performs inflation adjustment using IPCA.
-func (c *Client) IPCACorrecao(ctx context.Context, valor float64, dataOrigem, dataDestino string) (map[string]interface{}, error) {
+func (c *Client) IPCACorrecao(ctx context.Context, valor float64, dataOrigem, dataDestino string, opts ...RequestOption) (map[string]interface{}, error) {
 	params := url.Values{}
 	params.Set("valor", strconv.FormatFloat(valor, 'f', 2, 64))
 	params.Set("data_origem", dataOrigem)
@@ -816,7 +1344,7 @@ func (c *Client) IPCACorrecao(ctx context.Context, valor float64, dataOrigem, da
 	}
 
 	var result map[string]interface{}
-	err := c.doRequest(ctx, "GET", "/dados/ipca/corrigir", params, nil, &result)
+	err := c.doRequest(ctx, "GET", "/dados/ipca/corrigir", params, nil, &result, opts...)
 	if err != nil {
 		return nil, err
 	}
@@ -829,12 +1357,12 @@ func (c *Client) IPCACorrecao(ctx context.Context, valor float64, dataOrigem, da
 
 // CidadeInfo represents information about a city with IPTU calendar.
 type CidadeInfo struct {
-	Codigo       string `json:"codigo"`
-	Nome         string `json:"nome"`
-	Ano          int    `json:"ano"`
+	Codigo        string `json:"codigo"`
+	Nome          string `json:"nome"`
+	Ano           int    `json:"ano"`
 	DescontoVista string `json:"desconto_vista"`
-	ParcelasMax  int    `json:"parcelas_max"`
-	SiteOficial  string `json:"site_oficial"`
+	ParcelasMax   int    `json:"parcelas_max"`
+	SiteOficial   string `json:"site_oficial"`
 }
 
 // CidadesResult represents the result of listing cities.
@@ -846,30 +1374,30 @@ type CidadesResult struct {
 
 // CalendarioResult represents the IPTU calendar for a city.
 type CalendarioResult struct {
-	Cidade                     string   `json:"cidade"`
-	Ano                        int      `json:"ano"`
-	DescontoVistaPercentual    float64  `json:"desconto_vista_percentual"`
-	DescontoVistaTexto         string   `json:"desconto_vista_texto"`
-	ParcelasMax                int      `json:"parcelas_max"`
-	ValorMinimoParcela         float64  `json:"valor_minimo_parcela"`
-	IsencaoValorVenal          float64  `json:"isencao_valor_venal,omitempty"`
-	IsencaoTexto               string   `json:"isencao_texto,omitempty"`
-	ConsultaOnline             string   `json:"consulta_online,omitempty"`
-	SiteOficial                string   `json:"site_oficial"`
-	Novidades                  []string `json:"novidades,omitempty"`
-	Alertas                    []string `json:"alertas,omitempty"`
-	FormasPagamento            []string `json:"formas_pagamento,omitempty"`
-	VencimentosCotaUnica       []string `json:"vencimentos_cota_unica"`
-	VencimentosParcelado       []string `json:"vencimentos_parcelado"`
-	ProximoVencimento          string   `json:"proximo_vencimento,omitempty"`
-	DiasParaProximoVencimento  int      `json:"dias_para_proximo_vencimento,omitempty"`
+	Cidade                    string   `json:"cidade"`
+	Ano                       int      `json:"ano"`
+	DescontoVistaPercentual   float64  `json:"desconto_vista_percentual"`
+	DescontoVistaTexto        string   `json:"desconto_vista_texto"`
+	ParcelasMax               int      `json:"parcelas_max"`
+	ValorMinimoParcela        float64  `json:"valor_minimo_parcela"`
+	IsencaoValorVenal         float64  `json:"isencao_valor_venal,omitempty"`
+	IsencaoTexto              string   `json:"isencao_texto,omitempty"`
+	ConsultaOnline            string   `json:"consulta_online,omitempty"`
+	SiteOficial               string   `json:"site_oficial"`
+	Novidades                 []string `json:"novidades,omitempty"`
+	Alertas                   []string `json:"alertas,omitempty"`
+	FormasPagamento           []string `json:"formas_pagamento,omitempty"`
+	VencimentosCotaUnica      []string `json:"vencimentos_cota_unica"`
+	VencimentosParcelado      []string `json:"vencimentos_parcelado"`
+	ProximoVencimento         string   `json:"proximo_vencimento,omitempty"`
+	DiasParaProximoVencimento int      `json:"dias_para_proximo_vencimento,omitempty"`
 }
 
 // SimuladorParams contains parameters for payment simulation.
 type SimuladorParams struct {
-	ValorIPTU   float64 `json:"valor_iptu"`
-	Cidade      string  `json:"cidade,omitempty"`
-	ValorVenal  float64 `json:"valor_venal,omitempty"`
+	ValorIPTU  float64 `json:"valor_iptu"`
+	Cidade     string  `json:"cidade,omitempty"`
+	ValorVenal float64 `json:"valor_venal,omitempty"`
 }
 
 // SimuladorResult represents the result of payment simulation.
@@ -905,13 +1433,13 @@ type IsencaoResult struct {
 
 // ProximoVencimentoResult represents next due date information.
 type ProximoVencimentoResult struct {
-	Cidade          string  `json:"cidade"`
-	DataVencimento  string  `json:"data_vencimento"`
-	DiasRestantes   int     `json:"dias_restantes"`
-	Status          string  `json:"status"` // em_dia, proximo, vence_hoje, vencido
-	Mensagem        string  `json:"mensagem"`
-	MultaEstimada   float64 `json:"multa_estimada,omitempty"`
-	JurosEstimados  float64 `json:"juros_estimados,omitempty"`
+	Cidade         string  `json:"cidade"`
+	DataVencimento string  `json:"data_vencimento"`
+	DiasRestantes  int     `json:"dias_restantes"`
+	Status         string  `json:"status"` // em_dia, proximo, vence_hoje, vencido
+	Mensagem       string  `json:"mensagem"`
+	MultaEstimada  float64 `json:"multa_estimada,omitempty"`
+	JurosEstimados float64 `json:"juros_estimados,omitempty"`
 }
 
 // =============================================================================
@@ -919,9 +1447,9 @@ type ProximoVencimentoResult struct {
 // =============================================================================
 
 // IPTUToolsCidades lists all cities with available IPTU calendar.
-func (c *Client) IPTUToolsCidades(ctx context.Context) (*CidadesResult, error) {
+func (c *Client) IPTUToolsCidades(ctx context.Context, opts ...RequestOption) (*CidadesResult, error) {
 	var result CidadesResult
-	err := c.doRequest(ctx, "GET", "/iptu-tools/cidades", nil, nil, &result)
+	err := c.doRequest(ctx, "GET", "/iptu-tools/cidades", nil, nil, &result, opts...)
 	if err != nil {
 		return nil, err
 	}
@@ -929,7 +1457,8 @@ func (c *Client) IPTUToolsCidades(ctx context.Context) (*CidadesResult, error) {
 }
 
 // IPTUToolsCalendario returns the complete IPTU calendar for the specified city.
-func (c *Client) IPTUToolsCalendario(ctx context.Context, cidade Cidade) (*CalendarioResult, error) {
+func (c *Client) IPTUToolsCalendario(ctx context.Context, cidade Cidade, opts ...RequestOption) (*CalendarioResult, error) {
+	cidade = newRequestOptions(opts).cidadeOr(cidade)
 	params := url.Values{}
 	if cidade != "" {
 		params.Set("cidade", string(cidade))
@@ -938,7 +1467,7 @@ func (c *Client) IPTUToolsCalendario(ctx context.Context, cidade Cidade) (*Calen
 	}
 
 	var result CalendarioResult
-	err := c.doRequest(ctx, "GET", "/iptu-tools/calendario", params, nil, &result)
+	err := c.doRequest(ctx, "GET", "/iptu-tools/calendario", params, nil, &result, opts...)
 	if err != nil {
 		return nil, err
 	}
@@ -946,13 +1475,21 @@ func (c *Client) IPTUToolsCalendario(ctx context.Context, cidade Cidade) (*Calen
 }
 
 // IPTUToolsSimulador simulates IPTU payment options (lump sum vs installments).
-func (c *Client) IPTUToolsSimulador(ctx context.Context, p *SimuladorParams) (*SimuladorResult, error) {
+func (c *Client) IPTUToolsSimulador(ctx context.Context, p *SimuladorParams, opts ...RequestOption) (*SimuladorResult, error) {
+	cp := *p
+	p = &cp
+	if cidade := newRequestOptions(opts).cidade; cidade != "" {
+		p.Cidade = string(cidade)
+	}
 	if p.Cidade == "" {
 		p.Cidade = string(CidadeSaoPaulo)
 	}
+	if err := c.check(opts, p.Validate); err != nil {
+		return nil, err
+	}
 
 	var result SimuladorResult
-	err := c.doRequest(ctx, "POST", "/iptu-tools/simulador", nil, p, &result)
+	err := c.doRequest(ctx, "POST", "/iptu-tools/simulador", nil, p, &result, opts...)
 	if err != nil {
 		return nil, err
 	}
@@ -960,7 +1497,8 @@ func (c *Client) IPTUToolsSimulador(ctx context.Context, p *SimuladorParams) (*S
 }
 
 // IPTUToolsIsencao checks if a property is eligible for IPTU exemption.
-func (c *Client) IPTUToolsIsencao(ctx context.Context, valorVenal float64, cidade Cidade) (*IsencaoResult, error) {
+func (c *Client) IPTUToolsIsencao(ctx context.Context, valorVenal float64, cidade Cidade, opts ...RequestOption) (*IsencaoResult, error) {
+	cidade = newRequestOptions(opts).cidadeOr(cidade)
 	params := url.Values{}
 	params.Set("valor_venal", strconv.FormatFloat(valorVenal, 'f', 2, 64))
 	if cidade != "" {
@@ -970,7 +1508,7 @@ func (c *Client) IPTUToolsIsencao(ctx context.Context, valorVenal float64, cidad
 	}
 
 	var result IsencaoResult
-	err := c.doRequest(ctx, "GET", "/iptu-tools/isencao", params, nil, &result)
+	err := c.doRequest(ctx, "GET", "/iptu-tools/isencao", params, nil, &result, opts...)
 	if err != nil {
 		return nil, err
 	}
@@ -978,7 +1516,8 @@ func (c *Client) IPTUToolsIsencao(ctx context.Context, valorVenal float64, cidad
 }
 
 // IPTUToolsProximoVencimento returns information about the next IPTU due date.
-func (c *Client) IPTUToolsProximoVencimento(ctx context.Context, cidade Cidade, parcela int) (*ProximoVencimentoResult, error) {
+func (c *Client) IPTUToolsProximoVencimento(ctx context.Context, cidade Cidade, parcela int, opts ...RequestOption) (*ProximoVencimentoResult, error) {
+	cidade = newRequestOptions(opts).cidadeOr(cidade)
 	params := url.Values{}
 	if cidade != "" {
 		params.Set("cidade", string(cidade))
@@ -990,7 +1529,7 @@ func (c *Client) IPTUToolsProximoVencimento(ctx context.Context, cidade Cidade,
 	}
 
 	var result ProximoVencimentoResult
-	err := c.doRequest(ctx, "GET", "/iptu-tools/proximo-vencimento", params, nil, &result)
+	err := c.doRequest(ctx, "GET", "/iptu-tools/proximo-vencimento", params, nil, &result, opts...)
 	if err != nil {
 		return nil, err
 	}
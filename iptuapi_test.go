@@ -3,6 +3,9 @@ package iptuapi
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
 	"net/http"
 	"net/http/httptest"
 	"testing"
@@ -71,6 +74,32 @@ func TestNewClient(t *testing.T) {
 		assert.Equal(t, 5, client.retryConfig.MaxRetries)
 		assert.Equal(t, 100*time.Millisecond, client.retryConfig.InitialDelay)
 	})
+
+	t.Run("uses custom HTTP client without modifying it", func(t *testing.T) {
+		var used bool
+		transport := roundTripFunc(func(req *http.Request) (*http.Response, error) {
+			used = true
+			return http.DefaultTransport.RoundTrip(req)
+		})
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			json.NewEncoder(w).Encode(CidadesResult{})
+		}))
+		defer server.Close()
+
+		hc := &http.Client{Transport: transport, Timeout: 10 * time.Second}
+		client := NewClient("test_api_key",
+			WithBaseURL(server.URL),
+			WithHTTPClient(hc),
+			WithTimeout(time.Minute),
+			WithHTTPClient(nil),
+		)
+
+		_, err := client.IPTUToolsCidades(context.Background())
+		require.NoError(t, err)
+		assert.True(t, used)
+		assert.Equal(t, time.Minute, client.httpClient.Timeout)
+		assert.Equal(t, 10*time.Second, hc.Timeout, "caller's client is not modified")
+	})
 }
 
 func TestConsultaEndereco(t *testing.T) {
@@ -169,7 +198,100 @@ func TestValuationEstimate(t *testing.T) {
 	})
 }
 
+func TestIPTUTools(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		switch r.URL.Path {
+		case "/iptu-tools/cidades":
+			w.Write([]byte(`{"cidades":[{"codigo":"sp","nome":"São Paulo","ano":2026,"parcelas_max":10}],"total":1}`))
+		case "/iptu-tools/calendario":
+			assert.Equal(t, "bh", q.Get("cidade"))
+			w.Write([]byte(`{"cidade":"bh","ano":2026,"desconto_vista_percentual":7,"vencimentos_cota_unica":["2026-02-10"],"vencimentos_parcelado":["2026-02-10","2026-03-10"],"alertas":["Boletos apenas pelo site oficial"]}`))
+		case "/iptu-tools/simulador":
+			assert.Equal(t, "POST", r.Method)
+			var body map[string]interface{}
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+			assert.Equal(t, map[string]interface{}{"valor_iptu": 2400.0, "cidade": "sp"}, body)
+			w.Write([]byte(`{"valor_original":2400,"valor_vista":2328,"desconto_vista":72,"parcelas":10,"valor_parcela":240,"recomendacao":"vista"}`))
+		case "/iptu-tools/isencao":
+			assert.Equal(t, "120000.00", q.Get("valor_venal"))
+			assert.Equal(t, "sp", q.Get("cidade"))
+			w.Write([]byte(`{"cidade":"sp","valor_venal":120000,"limite_isencao":120000,"elegivel_isencao_total":true,"requisitos_adicionais":["Unico imovel"]}`))
+		default:
+			t.Errorf("unexpected path %s", r.URL.Path)
+		}
+	}))
+	defer server.Close()
+
+	client := NewClient("test_api_key", WithBaseURL(server.URL), WithRetry(&RetryConfig{MaxRetries: 0}))
+	ctx := context.Background()
+
+	cidades, err := client.IPTUToolsCidades(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 10, cidades.Cidades[0].ParcelasMax)
+
+	cal, err := client.IPTUToolsCalendario(ctx, CidadeBeloHorizonte)
+	require.NoError(t, err)
+	assert.Equal(t, 7.0, cal.DescontoVistaPercentual)
+	assert.Len(t, cal.VencimentosParcelado, 2)
+	assert.Equal(t, []string{"Boletos apenas pelo site oficial"}, cal.Alertas)
+
+	p := &SimuladorParams{ValorIPTU: 2400}
+	sim, err := client.IPTUToolsSimulador(ctx, p)
+	require.NoError(t, err)
+	assert.Equal(t, 72.0, sim.DescontoVista)
+	assert.Equal(t, 240.0, sim.ValorParcela)
+	assert.Empty(t, p.Cidade, "caller params are not modified")
+
+	isencao, err := client.IPTUToolsIsencao(ctx, 120000, "")
+	require.NoError(t, err)
+	assert.True(t, isencao.ElegivelIsencaoTotal)
+	assert.Equal(t, 120000.0, isencao.LimiteIsencao)
+}
+
 func TestErrorHandling(t *testing.T) {
+	t.Run("503 with fonte returns FonteIndisponivelError", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			json.NewEncoder(w).Encode(map[string]string{"codigo": "FONTE_INDISPONIVEL", "fonte": "prefeitura_sp"})
+		}))
+		defer server.Close()
+
+		client := NewClient("test_key",
+			WithBaseURL(server.URL),
+			WithRetry(&RetryConfig{MaxRetries: 0}),
+		)
+
+		_, err := client.ConsultaSQL(context.Background(), "000.000.0000-0", "")
+
+		require.Error(t, err)
+		assert.True(t, IsFonteIndisponivel(err))
+		assert.False(t, IsNotFound(err))
+		fonteErr := err.(*FonteIndisponivelError)
+		assert.Equal(t, "prefeitura_sp", fonteErr.Fonte)
+		assert.Equal(t, "Fonte de dados municipal indisponível", fonteErr.Message)
+		assert.True(t, fonteErr.IsRetryable())
+	})
+
+	t.Run("degraded result carries Avisos", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(`{"sql":"1","avisos":[{"codigo":"DADOS_PARCIAIS","fonte":"geosampa","campos":["zona"]}]}`))
+		}))
+		defer server.Close()
+
+		client := NewClient("test_key",
+			WithBaseURL(server.URL),
+			WithRetry(&RetryConfig{MaxRetries: 0}),
+		)
+
+		result, err := client.ConsultaSQL(context.Background(), "1", "")
+
+		require.NoError(t, err)
+		assert.True(t, result.Avisos.Degradado())
+		assert.True(t, result.Avisos.Has(AvisoDadosParciais))
+		assert.Equal(t, []string{"zona"}, result.Avisos[0].Campos)
+	})
+
 	t.Run("401 returns AuthenticationError", func(t *testing.T) {
 		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			w.WriteHeader(http.StatusUnauthorized)
@@ -380,6 +502,74 @@ func TestRetryLogic(t *testing.T) {
 		require.Error(t, err)
 		assert.Equal(t, 3, attempts) // Initial + 2 retries
 	})
+
+	t.Run("ShouldRetry retries body-level failures", func(t *testing.T) {
+		attempts := 0
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			attempts++
+			if attempts < 2 {
+				json.NewEncoder(w).Encode(map[string]interface{}{"success": false, "codigo": "FONTE_TIMEOUT"})
+				return
+			}
+			json.NewEncoder(w).Encode(sampleIPTUResponse)
+		}))
+		defer server.Close()
+
+		var seenAttempts []int
+		client := NewClient("test_key",
+			WithBaseURL(server.URL),
+			WithRetry(&RetryConfig{
+				MaxRetries:    3,
+				InitialDelay:  time.Millisecond,
+				BackoffFactor: 1,
+				ShouldRetry: func(resp *http.Response, err error, attempt int) bool {
+					seenAttempts = append(seenAttempts, attempt)
+					if resp == nil {
+						return true
+					}
+					var body struct {
+						Codigo string `json:"codigo"`
+					}
+					json.NewDecoder(resp.Body).Decode(&body)
+					return body.Codigo == "FONTE_TIMEOUT"
+				},
+			}),
+		)
+
+		result, err := client.ConsultaEndereco(context.Background(), &ConsultaEnderecoParams{
+			Logradouro: "Test",
+		})
+
+		require.NoError(t, err)
+		assert.Equal(t, 2, attempts)
+		assert.Equal(t, []int{0, 1}, seenAttempts)
+		assert.Equal(t, "000.000.0000-0", result.SQL)
+	})
+
+	t.Run("resends request body on retry", func(t *testing.T) {
+		var bodies []string
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			b, _ := io.ReadAll(r.Body)
+			bodies = append(bodies, string(b))
+			if len(bodies) < 2 {
+				w.WriteHeader(http.StatusServiceUnavailable)
+				return
+			}
+			json.NewEncoder(w).Encode(ValuationResult{ValorEstimado: 1})
+		}))
+		defer server.Close()
+
+		client := NewClient("test_key",
+			WithBaseURL(server.URL),
+			WithRetry(&RetryConfig{MaxRetries: 1, InitialDelay: time.Millisecond, BackoffFactor: 1, RetryableStatus: []int{503}}),
+		)
+
+		_, err := client.ValuationEstimate(context.Background(), &ValuationParams{AreaConstruida: 80, Bairro: "Pinheiros"})
+		require.NoError(t, err)
+		require.Len(t, bodies, 2)
+		assert.Equal(t, bodies[0], bodies[1])
+		assert.NotEmpty(t, bodies[1])
+	})
 }
 
 func TestContextCancellation(t *testing.T) {
@@ -479,3 +669,210 @@ func TestAPIError(t *testing.T) {
 		assert.False(t, (&APIError{StatusCode: 404}).IsRetryable())
 	})
 }
+
+func TestWithUpdatedSince(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/consulta/cep/01310-100", r.URL.Path)
+		assert.Equal(t, "sp", r.URL.Query().Get("cidade"))
+		assert.Equal(t, "2026-01-02T03:04:05Z", r.URL.Query().Get("updated_since"))
+		json.NewEncoder(w).Encode([]ConsultaEnderecoResult{sampleIPTUResponse})
+	}))
+	defer server.Close()
+
+	client := NewClient("test_key",
+		WithBaseURL(server.URL),
+		WithRetry(&RetryConfig{MaxRetries: 0}),
+	)
+
+	since := time.Date(2026, 1, 2, 0, 4, 5, 0, time.FixedZone("BRT", -3*3600))
+	results, err := client.ConsultaCEP(context.Background(), "01310-100", CidadeSaoPaulo, WithUpdatedSince(since))
+
+	require.NoError(t, err)
+	assert.Len(t, results, 1)
+}
+
+func TestPerRequestOptions(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/valuation/estimate":
+			var p ValuationParams
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&p))
+			assert.Equal(t, CidadeRecife, p.Cidade)
+			w.Write([]byte(`{"valor_estimado":1}`))
+		case "/slow":
+			time.Sleep(200 * time.Millisecond)
+		default:
+			assert.Equal(t, "trace-1", r.Header.Get("X-Trace-ID"))
+			assert.Equal(t, "bh", r.URL.Query().Get("cidade"))
+			json.NewEncoder(w).Encode(sampleIPTUResponse)
+		}
+	}))
+	defer server.Close()
+
+	client := NewClient("test_key", WithBaseURL(server.URL), WithRetry(&RetryConfig{MaxRetries: 0}))
+	ctx := context.Background()
+
+	_, err := client.ConsultaSQL(ctx, "000.000.0000-0", CidadeSaoPaulo, WithHeader("X-Trace-ID", "trace-1"), WithCidade(CidadeBeloHorizonte))
+	require.NoError(t, err)
+
+	p := &ConsultaEnderecoParams{Logradouro: "Avenida Paulista", Cidade: CidadeSaoPaulo}
+	_, err = client.ConsultaEndereco(ctx, p, WithHeader("X-Trace-ID", "trace-1"), WithCidade(CidadeBeloHorizonte))
+	require.NoError(t, err)
+	assert.Equal(t, CidadeSaoPaulo, p.Cidade, "caller params are not modified")
+
+	v := &ValuationParams{AreaConstruida: 80, Bairro: "Boa Viagem", Cidade: CidadeSaoPaulo}
+	_, err = client.ValuationEstimate(ctx, v, WithCidade(CidadeRecife))
+	require.NoError(t, err)
+	assert.Equal(t, CidadeSaoPaulo, v.Cidade)
+
+	start := time.Now()
+	err = client.doRequest(ctx, "GET", "/slow", nil, nil, &struct{}{}, WithRequestTimeout(20*time.Millisecond))
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Less(t, time.Since(start), 150*time.Millisecond)
+}
+
+func TestWithAPIKey(t *testing.T) {
+	var keys []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := r.Header.Get("X-API-Key")
+		keys = append(keys, key)
+		w.Header().Set("X-RateLimit-Limit", "1000")
+		remaining := "1"
+		if key == "client_key" {
+			remaining = "900"
+		}
+		w.Header().Set("X-RateLimit-Remaining", remaining)
+		w.Header().Set("X-RateLimit-Reset", "1700000000")
+		json.NewEncoder(w).Encode(sampleIPTUResponse)
+	}))
+	defer server.Close()
+
+	wrapper := func(next http.RoundTripper) http.RoundTripper {
+		return roundTripFunc(func(req *http.Request) (*http.Response, error) {
+			assert.Empty(t, req.Header.Get("X-API-Key"), "wrappers must not see per-call keys")
+			return next.RoundTrip(req)
+		})
+	}
+	client := NewClient("client_key", WithBaseURL(server.URL), WithCache(NewLRUCache(10), time.Hour),
+		WithTransportWrapper(wrapper))
+	ctx := context.Background()
+	for _, opts := range [][]RequestOption{{WithAPIKey("tenant_a")}, nil, {WithAPIKey("tenant_b")}, {WithAPIKey("tenant_a")}} {
+		_, err := client.ConsultaSQL(ctx, "000.000.0000-0", CidadeSaoPaulo, opts...)
+		require.NoError(t, err)
+	}
+	assert.Equal(t, []string{"tenant_a", "client_key", "tenant_b"}, keys, "the cache is scoped per key")
+	assert.Equal(t, 900, client.RateLimitState().Remaining, "per-call quotas are not the client's")
+}
+
+func TestTypedErrorsUnwrap(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Request-ID", "req_422")
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		w.Write([]byte(`{"detail":"Parametros invalidos","errors":[{"field":"numero","message":"obrigatorio"}]}`))
+	}))
+	defer server.Close()
+
+	client := NewClient("test_key", WithBaseURL(server.URL), WithRetry(&RetryConfig{MaxRetries: 0}))
+	_, err := client.ConsultaEndereco(context.Background(), &ConsultaEnderecoParams{Logradouro: "Rua Teste"})
+	wrapped := fmt.Errorf("importando planilha: %w", err)
+
+	assert.True(t, IsValidation(wrapped))
+	var validationErr *ValidationError
+	require.True(t, errors.As(wrapped, &validationErr))
+	assert.Equal(t, []FieldError{{Field: "numero", Message: "obrigatorio"}}, validationErr.Errors)
+
+	var apiErr *APIError
+	require.True(t, errors.As(wrapped, &apiErr), "typed errors unwrap to the APIError")
+	assert.Equal(t, http.StatusUnprocessableEntity, apiErr.StatusCode)
+	assert.Equal(t, "req_422", apiErr.RequestID)
+	assert.Equal(t, "Parametros invalidos", apiErr.ResponseBody["detail"])
+
+	assert.False(t, IsNotFound(wrapped))
+	assert.False(t, IsNetworkError(wrapped))
+	assert.Nil(t, (&NotFoundError{}).Unwrap())
+}
+
+func TestNetworkAndTimeoutErrors(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	url := server.URL
+	server.Close()
+
+	client := NewClient("test_key", WithBaseURL(url), WithRetry(&RetryConfig{MaxRetries: 0}))
+	_, err := client.ConsultaSQL(context.Background(), "1", "")
+	assert.True(t, IsNetworkError(err))
+	assert.False(t, IsTimeout(err))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 0)
+	defer cancel()
+	_, err = client.ConsultaSQL(ctx, "1", "")
+	assert.True(t, IsTimeout(err))
+	assert.False(t, IsNetworkError(err))
+}
+
+func TestValidationFieldErrorsFromDetail(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		w.Write([]byte(`{"detail":[
+			{"loc":["query","numero"],"msg":"Field required","type":"missing"},
+			{"loc":["body","imoveis",2,"area_terreno"],"msg":"Input should be greater than 0","type":"value_error.greater_than"}
+		]}`))
+	}))
+	defer server.Close()
+
+	client := NewClient("test_key", WithBaseURL(server.URL), WithRetry(&RetryConfig{MaxRetries: 0}))
+	_, err := client.ConsultaEndereco(context.Background(), &ConsultaEnderecoParams{Logradouro: "Rua Teste"})
+
+	var validationErr *ValidationError
+	require.True(t, errors.As(err, &validationErr))
+	assert.Equal(t, []FieldError{
+		{Field: "numero", Message: "Field required", Code: "missing"},
+		{Field: "imoveis[2].area_terreno", Message: "Input should be greater than 0", Code: "greater_than"},
+	}, validationErr.Errors)
+	assert.Equal(t, "numero: Field required; imoveis[2].area_terreno: Input should be greater than 0", validationErr.Message)
+}
+
+func TestFrentesDecoding(t *testing.T) {
+	var r ConsultaSQLResult
+	require.NoError(t, json.Unmarshal([]byte(`{"sql":"1","testada":12.5,"testadas":[12.5,30],"numero_frentes":2,"esquina":true}`), &r))
+	assert.Equal(t, 12.5, r.Testada)
+	assert.Equal(t, []float64{12.5, 30}, r.Testadas)
+	assert.Equal(t, 2, r.NumeroFrentes)
+	require.NotNil(t, r.Esquina)
+	assert.True(t, *r.Esquina)
+
+	var e ConsultaEnderecoResult
+	require.NoError(t, json.Unmarshal([]byte(`{"sql":"2","esquina":false}`), &e))
+	require.NotNil(t, e.Esquina, "false is kept apart from unknown")
+	assert.False(t, *e.Esquina)
+
+	out, err := json.Marshal(ConsultaEnderecoResult{SQL: "3"})
+	require.NoError(t, err)
+	assert.NotContains(t, string(out), "esquina")
+}
+
+func TestSentinelErrors(t *testing.T) {
+	tests := []struct {
+		status   int
+		sentinel error
+	}{
+		{http.StatusUnauthorized, ErrUnauthorized},
+		{http.StatusForbidden, ErrPlanRequired},
+		{http.StatusNotFound, ErrNotFound},
+		{http.StatusTooManyRequests, ErrRateLimited},
+	}
+	sentinels := []error{ErrUnauthorized, ErrPlanRequired, ErrNotFound, ErrRateLimited}
+	for _, tt := range tests {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(tt.status)
+			w.Write([]byte(`{"detail":"erro"}`))
+		}))
+		client := NewClient("test_key", WithBaseURL(server.URL), WithRetry(&RetryConfig{MaxRetries: 0}))
+		_, err := client.ConsultaSQL(context.Background(), "1", "")
+		server.Close()
+
+		err = fmt.Errorf("lote 3: %w", err)
+		for _, s := range sentinels {
+			assert.Equal(t, s == tt.sentinel, errors.Is(err, s), "status %d, sentinel %v", tt.status, s)
+		}
+	}
+}
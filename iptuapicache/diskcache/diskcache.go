@@ -0,0 +1,273 @@
+// Package diskcache implements iptuapi.Cache on the local file system, so
+// cached responses survive process restarts.
+//
+//	cache, err := diskcache.New("/var/cache/iptuapi", diskcache.Options{})
+//	if err != nil { ... }
+//	client := iptuapi.NewClient(key, iptuapi.WithCache(cache, 24*time.Hour))
+//
+// Each entry records the iptuapi.CurrentSchemaVersion of the SDK that
+// wrote it. After an SDK upgrade, older entries are migrated with
+// iptuapi.Upgrade when read, or dropped if they cannot be, so a payload is
+// never decoded under an incompatible struct layout. Entries written by a
+// newer SDK are ignored. Migrate and Purge run the same checks over the
+// whole directory, e.g. from a deploy script.
+package diskcache
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	iptuapi "github.com/raphaeltorquat0/iptuapi-go"
+)
+
+// entryExt is the file extension of cache entries.
+const entryExt = ".entry"
+
+// Options configures a Cache.
+type Options struct {
+	// TTL overrides the client's cache TTL per endpoint class (see
+	// iptuapi.ClassifyEndpoint); classes not listed use the client's TTL.
+	TTL map[iptuapi.EndpointClass]time.Duration
+}
+
+// Cache is an iptuapi.Cache stored in a directory, one file per entry.
+// Several processes may share the directory.
+type Cache struct {
+	dir  string
+	opts Options
+	now  func() time.Time
+}
+
+var _ iptuapi.Cache = (*Cache)(nil)
+
+// header is the first line of an entry file; the payload follows.
+type header struct {
+	Key           string `json:"key"`
+	SchemaVersion int    `json:"schema_version"`
+	SDKVersion    string `json:"sdk_version"`
+	// Expires is a Unix time in nanoseconds, or 0 for no expiry.
+	Expires int64 `json:"expires,omitempty"`
+}
+
+// New returns a Cache storing entries in dir, creating it if needed.
+func New(dir string, opts Options) (*Cache, error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, fmt.Errorf("diskcache: %w", err)
+	}
+	return &Cache{dir: dir, opts: opts, now: time.Now}, nil
+}
+
+func (c *Cache) path(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return filepath.Join(c.dir, hex.EncodeToString(sum[:])+entryExt)
+}
+
+// Get implements iptuapi.Cache.
+func (c *Cache) Get(_ context.Context, key string) ([]byte, bool, error) {
+	path := c.path(key)
+	h, payload, err := readEntry(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, false, nil
+	}
+	if err != nil {
+		os.Remove(path)
+		return nil, false, nil
+	}
+	if h.Key != key {
+		return nil, false, nil
+	}
+	if c.expired(h) {
+		os.Remove(path)
+		return nil, false, nil
+	}
+	switch {
+	case h.SchemaVersion == iptuapi.CurrentSchemaVersion:
+		return payload, true, nil
+	case h.SchemaVersion > iptuapi.CurrentSchemaVersion:
+		return nil, false, nil
+	}
+	upgraded, err := upgrade(payload, h.SchemaVersion)
+	if err != nil {
+		os.Remove(path)
+		return nil, false, nil
+	}
+	h.SchemaVersion, h.SDKVersion = iptuapi.CurrentSchemaVersion, iptuapi.Version
+	if err := c.write(path, h, upgraded); err != nil {
+		return nil, false, err
+	}
+	return upgraded, true, nil
+}
+
+// Set implements iptuapi.Cache. A non-positive TTL stores the value without
+// expiry.
+func (c *Cache) Set(_ context.Context, key string, value []byte, ttl time.Duration) error {
+	if d, ok := c.opts.TTL[iptuapi.ClassifyEndpoint(iptuapi.CacheKeyEndpoint(key))]; ok {
+		ttl = d
+	}
+	h := header{Key: key, SchemaVersion: iptuapi.CurrentSchemaVersion, SDKVersion: iptuapi.Version}
+	if ttl > 0 {
+		h.Expires = c.now().Add(ttl).UnixNano()
+	}
+	return c.write(c.path(key), h, value)
+}
+
+// Delete removes key from the cache.
+func (c *Cache) Delete(_ context.Context, key string) error {
+	if err := os.Remove(c.path(key)); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("diskcache: %w", err)
+	}
+	return nil
+}
+
+// MigrateReport counts what Migrate did with each entry.
+type MigrateReport struct {
+	// Kept entries are current and unexpired.
+	Kept int `json:"kept"`
+	// Upgraded entries were migrated from an older schema version.
+	Upgraded int `json:"upgraded"`
+	// Removed entries were expired, unreadable or could not be migrated.
+	Removed int `json:"removed"`
+	// Newer entries were written by a newer SDK and left in place.
+	Newer int `json:"newer"`
+}
+
+// Migrate upgrades every entry written under an older schema version and
+// removes expired entries and those that cannot be read or migrated.
+func (c *Cache) Migrate(ctx context.Context) (MigrateReport, error) {
+	var report MigrateReport
+	err := c.walk(ctx, func(path string) error {
+		h, payload, err := readEntry(path)
+		switch {
+		case err != nil || c.expired(h):
+			report.Removed++
+			return removeEntry(path)
+		case h.SchemaVersion == iptuapi.CurrentSchemaVersion:
+			report.Kept++
+			return nil
+		case h.SchemaVersion > iptuapi.CurrentSchemaVersion:
+			report.Newer++
+			return nil
+		}
+		upgraded, err := upgrade(payload, h.SchemaVersion)
+		if err != nil {
+			report.Removed++
+			return removeEntry(path)
+		}
+		h.SchemaVersion, h.SDKVersion = iptuapi.CurrentSchemaVersion, iptuapi.Version
+		report.Upgraded++
+		return c.write(path, h, upgraded)
+	})
+	return report, err
+}
+
+// Purge removes every entry and returns how many were removed.
+func (c *Cache) Purge(ctx context.Context) (int, error) {
+	n := 0
+	err := c.walk(ctx, func(path string) error {
+		n++
+		return removeEntry(path)
+	})
+	return n, err
+}
+
+func (c *Cache) walk(ctx context.Context, fn func(path string) error) error {
+	entries, err := os.ReadDir(c.dir)
+	if err != nil {
+		return fmt.Errorf("diskcache: %w", err)
+	}
+	for _, e := range entries {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if e.IsDir() || !strings.HasSuffix(e.Name(), entryExt) {
+			continue
+		}
+		if err := fn(filepath.Join(c.dir, e.Name())); err != nil {
+			return fmt.Errorf("diskcache: %w", err)
+		}
+	}
+	return nil
+}
+
+func (c *Cache) expired(h header) bool {
+	return h.Expires != 0 && c.now().UnixNano() >= h.Expires
+}
+
+// write replaces the entry at path atomically, so concurrent readers never
+// see a partial file.
+func (c *Cache) write(path string, h header, payload []byte) error {
+	line, err := json.Marshal(h)
+	if err != nil {
+		return fmt.Errorf("diskcache: %w", err)
+	}
+	f, err := os.CreateTemp(c.dir, ".tmp-*")
+	if err != nil {
+		return fmt.Errorf("diskcache: %w", err)
+	}
+	_, err = f.Write(append(append(line, '\n'), payload...))
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Rename(f.Name(), path)
+	}
+	if err != nil {
+		os.Remove(f.Name())
+		return fmt.Errorf("diskcache: %w", err)
+	}
+	return nil
+}
+
+func readEntry(path string) (header, []byte, error) {
+	var h header
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return h, nil, err
+	}
+	line, payload, ok := bytes.Cut(b, []byte("\n"))
+	if !ok {
+		return h, nil, errors.New("diskcache: truncated entry")
+	}
+	if err := json.Unmarshal(line, &h); err != nil {
+		return h, nil, fmt.Errorf("diskcache: bad entry header: %w", err)
+	}
+	return h, payload, nil
+}
+
+func removeEntry(path string) error {
+	if err := os.Remove(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+// upgrade migrates a cached response, a JSON object or an array of
+// objects, from schema version from to the current one.
+func upgrade(payload []byte, from int) ([]byte, error) {
+	trimmed := bytes.TrimSpace(payload)
+	if len(trimmed) == 0 || trimmed[0] != '[' {
+		return iptuapi.Upgrade[json.RawMessage](payload, from)
+	}
+	var items []json.RawMessage
+	if err := json.Unmarshal(trimmed, &items); err != nil {
+		return nil, err
+	}
+	for i, item := range items {
+		out, err := iptuapi.Upgrade[json.RawMessage](item, from)
+		if err != nil {
+			return nil, err
+		}
+		items[i] = out
+	}
+	return json.Marshal(items)
+}
@@ -0,0 +1,87 @@
+package diskcache
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	iptuapi "github.com/raphaeltorquat0/iptuapi-go"
+)
+
+func TestCacheSurvivesRestart(t *testing.T) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.Write([]byte(`{"sql":"000.000.0000-0","bairro":"Bela Vista"}`))
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	for i := 0; i < 2; i++ {
+		cache, err := New(dir, Options{})
+		require.NoError(t, err)
+		client := iptuapi.NewClient("test_key", iptuapi.WithBaseURL(server.URL), iptuapi.WithCache(cache, time.Hour))
+
+		result, err := client.ConsultaSQL(context.Background(), "000.000.0000-0", iptuapi.CidadeSaoPaulo)
+		require.NoError(t, err)
+		assert.Equal(t, "Bela Vista", result.Bairro)
+	}
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+}
+
+func TestCacheSchemaVersions(t *testing.T) {
+	ctx := context.Background()
+	cache, err := New(t.TempDir(), Options{})
+	require.NoError(t, err)
+
+	put := func(key string, version int, payload string) {
+		require.NoError(t, cache.write(cache.path(key), header{Key: key, SchemaVersion: version}, []byte(payload)))
+	}
+	put("old", 0, `{"sql":"1"}`)
+	put("old-list", 0, `[{"sql":"1"},{"sql":"2"}]`)
+	put("newer", iptuapi.CurrentSchemaVersion+1, `{"sql":"1"}`)
+	put("broken", 0, `not json`)
+
+	b, ok, err := cache.Get(ctx, "old")
+	require.NoError(t, err)
+	require.True(t, ok, "older entries are migrated")
+	assert.Equal(t, iptuapi.CurrentSchemaVersion, iptuapi.SchemaVersionOf(b))
+
+	_, ok, _ = cache.Get(ctx, "newer")
+	assert.False(t, ok, "entries from a newer SDK are ignored")
+	_, ok, _ = cache.Get(ctx, "broken")
+	assert.False(t, ok)
+	_, err = os.Stat(cache.path("broken"))
+	assert.ErrorIs(t, err, os.ErrNotExist, "entries that cannot be migrated are dropped")
+
+	put("broken", 0, `not json`)
+	report, err := cache.Migrate(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, MigrateReport{Kept: 1, Upgraded: 1, Removed: 1, Newer: 1}, report)
+
+	n, err := cache.Purge(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 3, n)
+}
+
+func TestCacheExpiry(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+	cache, err := New(t.TempDir(), Options{TTL: map[iptuapi.EndpointClass]time.Duration{iptuapi.EndpointFast: time.Minute}})
+	require.NoError(t, err)
+	cache.now = func() time.Time { return now }
+
+	key := iptuapi.NewClient("k").CacheKey("/consulta/endereco", nil)
+	require.NoError(t, cache.Set(context.Background(), key, []byte(`{}`), time.Hour))
+
+	now = now.Add(2 * time.Minute)
+	_, ok, err := cache.Get(context.Background(), key)
+	require.NoError(t, err)
+	assert.False(t, ok, "the per-class TTL applies")
+}
@@ -0,0 +1,106 @@
+// Package rediscache implements iptuapi.Cache on Redis, so several service
+// instances share one response cache.
+//
+// The package does not depend on a Redis library; it runs commands through
+// a Conn. With go-redis:
+//
+//	conn := rediscache.ConnFunc(func(ctx context.Context, args ...interface{}) (interface{}, error) {
+//		v, err := rdb.Do(ctx, args...).Result()
+//		if err == redis.Nil {
+//			return nil, nil
+//		}
+//		return v, err
+//	})
+//	cache := rediscache.New(conn, rediscache.Options{Prefix: "svc:"})
+//	client := iptuapi.NewClient(key, iptuapi.WithCache(cache, time.Hour))
+//
+// Values are the raw JSON responses, which the client decodes into its
+// typed results, so any SDK version can read them.
+package rediscache
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	iptuapi "github.com/raphaeltorquat0/iptuapi-go"
+)
+
+// Conn runs a Redis command and returns its reply. A missing key must be
+// reported as a nil reply with a nil error.
+type Conn interface {
+	Do(ctx context.Context, args ...interface{}) (interface{}, error)
+}
+
+// ConnFunc adapts a function to the Conn interface.
+type ConnFunc func(ctx context.Context, args ...interface{}) (interface{}, error)
+
+// Do calls f(ctx, args...).
+func (f ConnFunc) Do(ctx context.Context, args ...interface{}) (interface{}, error) {
+	return f(ctx, args...)
+}
+
+// Options configures a Cache.
+type Options struct {
+	// Prefix is prepended to every key, e.g. to separate environments
+	// sharing a Redis instance.
+	Prefix string
+	// TTL overrides the client's cache TTL per endpoint class (see
+	// iptuapi.ClassifyEndpoint); classes not listed use the client's TTL.
+	TTL map[iptuapi.EndpointClass]time.Duration
+}
+
+// Cache is an iptuapi.Cache stored in Redis.
+type Cache struct {
+	conn Conn
+	opts Options
+}
+
+var _ iptuapi.Cache = (*Cache)(nil)
+
+// New returns a Cache running commands on conn.
+func New(conn Conn, opts Options) *Cache {
+	return &Cache{conn: conn, opts: opts}
+}
+
+// Get implements iptuapi.Cache.
+func (c *Cache) Get(ctx context.Context, key string) ([]byte, bool, error) {
+	v, err := c.conn.Do(ctx, "GET", c.opts.Prefix+key)
+	if err != nil {
+		return nil, false, fmt.Errorf("rediscache: get: %w", err)
+	}
+	switch v := v.(type) {
+	case nil:
+		return nil, false, nil
+	case []byte:
+		return v, true, nil
+	case string:
+		return []byte(v), true, nil
+	default:
+		return nil, false, fmt.Errorf("rediscache: get: unexpected reply %T", v)
+	}
+}
+
+// Set implements iptuapi.Cache. A non-positive TTL stores the value without
+// expiry.
+func (c *Cache) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	if d, ok := c.opts.TTL[iptuapi.ClassifyEndpoint(iptuapi.CacheKeyEndpoint(key))]; ok {
+		ttl = d
+	}
+	args := []interface{}{"SET", c.opts.Prefix + key, value}
+	if ttl > 0 {
+		args = append(args, "PX", ttl.Milliseconds())
+	}
+	if _, err := c.conn.Do(ctx, args...); err != nil {
+		return fmt.Errorf("rediscache: set: %w", err)
+	}
+	return nil
+}
+
+// Delete removes key from the cache.
+func (c *Cache) Delete(ctx context.Context, key string) error {
+	if _, err := c.conn.Do(ctx, "DEL", c.opts.Prefix+key); err != nil {
+		return fmt.Errorf("rediscache: del: %w", err)
+	}
+	return nil
+}
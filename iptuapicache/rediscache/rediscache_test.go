@@ -0,0 +1,111 @@
+package rediscache
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	iptuapi "github.com/raphaeltorquat0/iptuapi-go"
+)
+
+// fakeRedis records commands and keeps values in memory.
+type fakeRedis struct {
+	mu   sync.Mutex
+	data map[string][]byte
+	ttls map[string]int64
+}
+
+func newFakeRedis() *fakeRedis {
+	return &fakeRedis{data: make(map[string][]byte), ttls: make(map[string]int64)}
+}
+
+func (f *fakeRedis) Do(_ context.Context, args ...interface{}) (interface{}, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	key := args[1].(string)
+	switch args[0] {
+	case "GET":
+		if v, ok := f.data[key]; ok {
+			return string(v), nil
+		}
+		return nil, nil
+	case "SET":
+		f.data[key] = args[2].([]byte)
+		delete(f.ttls, key)
+		if len(args) == 5 {
+			f.ttls[key] = args[4].(int64)
+		}
+		return "OK", nil
+	case "DEL":
+		delete(f.data, key)
+		return int64(1), nil
+	}
+	return nil, errors.New("unknown command")
+}
+
+func TestCache(t *testing.T) {
+	ctx := context.Background()
+	r := newFakeRedis()
+	c := New(r, Options{
+		Prefix: "test:",
+		TTL:    map[iptuapi.EndpointClass]time.Duration{iptuapi.EndpointValuation: time.Minute},
+	})
+
+	_, ok, err := c.Get(ctx, "iptuapi:00000000:/consulta/sql/1")
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	require.NoError(t, c.Set(ctx, "iptuapi:00000000:/consulta/sql/1", []byte(`{"sql":"1"}`), time.Hour))
+	v, ok, err := c.Get(ctx, "iptuapi:00000000:/consulta/sql/1")
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, `{"sql":"1"}`, string(v))
+	assert.Equal(t, int64(time.Hour/time.Millisecond), r.ttls["test:iptuapi:00000000:/consulta/sql/1"])
+
+	require.NoError(t, c.Set(ctx, "iptuapi:00000000:/valuation/comparables?bairro=x", []byte(`{}`), time.Hour))
+	assert.Equal(t, int64(time.Minute/time.Millisecond), r.ttls["test:iptuapi:00000000:/valuation/comparables?bairro=x"],
+		"per-class TTL overrides the client TTL")
+
+	require.NoError(t, c.Delete(ctx, "iptuapi:00000000:/consulta/sql/1"))
+	_, ok, _ = c.Get(ctx, "iptuapi:00000000:/consulta/sql/1")
+	assert.False(t, ok)
+}
+
+func TestCacheErrors(t *testing.T) {
+	down := ConnFunc(func(ctx context.Context, args ...interface{}) (interface{}, error) {
+		return nil, errors.New("connection refused")
+	})
+	c := New(down, Options{})
+	_, _, err := c.Get(context.Background(), "k")
+	assert.ErrorContains(t, err, "rediscache: get")
+	assert.ErrorContains(t, c.Set(context.Background(), "k", nil, 0), "rediscache: set")
+}
+
+func TestCacheWithClient(t *testing.T) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.Write([]byte(`{"sql":"000.000.0000-0","bairro":"Pinheiros"}`))
+	}))
+	defer server.Close()
+
+	shared := New(newFakeRedis(), Options{})
+	newClient := func() *iptuapi.Client {
+		return iptuapi.NewClient("test_key", iptuapi.WithBaseURL(server.URL), iptuapi.WithCache(shared, time.Hour))
+	}
+
+	_, err := newClient().ConsultaSQL(context.Background(), "000.000.0000-0", iptuapi.CidadeSaoPaulo)
+	require.NoError(t, err)
+	r, err := newClient().ConsultaSQL(context.Background(), "000.000.0000-0", iptuapi.CidadeSaoPaulo)
+	require.NoError(t, err)
+	assert.Equal(t, "Pinheiros", r.Bairro)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls), "instances share the cache")
+}
@@ -0,0 +1,217 @@
+// Package iptuapimetrics exports iptuapi client metrics in the Prometheus
+// text exposition format.
+//
+// The package does not depend on the Prometheus client library; a Collector
+// is an http.Handler serving its own metrics page:
+//
+//	collector := iptuapimetrics.NewCollector(client)
+//	http.Handle("/metrics/iptuapi", collector)
+//
+// To serve them on an existing /metrics endpoint, call WriteTo from the
+// handler after the registry's output. A quota alert can then be written
+// as:
+//
+//	iptuapi_ratelimit_remaining / iptuapi_ratelimit_limit < 0.1
+package iptuapimetrics
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	iptuapi "github.com/raphaeltorquat0/iptuapi-go"
+)
+
+// DefaultBuckets are the upper bounds, in seconds, of the request duration
+// histogram.
+var DefaultBuckets = []float64{0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30}
+
+// Collector accumulates metrics from every HTTP attempt made by a client.
+type Collector struct {
+	buckets []float64
+
+	mu        sync.Mutex
+	requests  map[requestLabels]int64
+	durations map[endpointLabels]*histogram
+	retries   int64
+	rateLimit *iptuapi.RateLimitInfo
+}
+
+type endpointLabels struct {
+	method, endpoint string
+}
+
+type requestLabels struct {
+	endpointLabels
+	status string
+}
+
+type histogram struct {
+	counts []int64 // per bucket, not cumulative
+	sum    float64
+	count  int64
+}
+
+// NewCollector returns a Collector observing client's attempts from now on.
+// Metrics are:
+//
+//   - iptuapi_requests_total{method,endpoint,status}: attempts by endpoint
+//     template and status code ("error" for transport failures);
+//   - iptuapi_request_duration_seconds{method,endpoint}: attempt latency;
+//   - iptuapi_retries_total: attempts beyond the first;
+//   - iptuapi_ratelimit_remaining and iptuapi_ratelimit_limit: the quota
+//     reported with the last response, once one has been received.
+//
+// Like Client.Use, call it before making requests.
+func NewCollector(client *iptuapi.Client) *Collector {
+	c := &Collector{
+		buckets:   DefaultBuckets,
+		requests:  make(map[requestLabels]int64),
+		durations: make(map[endpointLabels]*histogram),
+	}
+	client.OnAttempt(c.observe)
+	return c
+}
+
+func (c *Collector) observe(a iptuapi.Attempt) {
+	status := "error"
+	if a.Status > 0 {
+		status = strconv.Itoa(a.Status)
+	}
+	ep := endpointLabels{method: a.Method, endpoint: a.Route}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.requests[requestLabels{endpointLabels: ep, status: status}]++
+	h, ok := c.durations[ep]
+	if !ok {
+		h = &histogram{counts: make([]int64, len(c.buckets))}
+		c.durations[ep] = h
+	}
+	seconds := a.Latency.Seconds()
+	for i, le := range c.buckets {
+		if seconds <= le {
+			h.counts[i]++
+			break
+		}
+	}
+	h.sum += seconds
+	h.count++
+	if a.Attempt > 0 {
+		c.retries++
+	}
+	if a.RateLimit != nil {
+		rl := *a.RateLimit
+		c.rateLimit = &rl
+	}
+}
+
+// ServeHTTP serves the metrics in the Prometheus text format.
+func (c *Collector) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	c.WriteTo(w)
+}
+
+// WriteTo writes the metrics to w in the Prometheus text format.
+func (c *Collector) WriteTo(w io.Writer) (int64, error) {
+	cw := &countingWriter{w: bufio.NewWriter(w)}
+
+	c.mu.Lock()
+	requests := make([]requestLabels, 0, len(c.requests))
+	for l := range c.requests {
+		requests = append(requests, l)
+	}
+	sort.Slice(requests, func(i, j int) bool {
+		a, b := requests[i], requests[j]
+		if a.endpointLabels != b.endpointLabels {
+			return a.endpointLabels.less(b.endpointLabels)
+		}
+		return a.status < b.status
+	})
+	endpoints := make([]endpointLabels, 0, len(c.durations))
+	for l := range c.durations {
+		endpoints = append(endpoints, l)
+	}
+	sort.Slice(endpoints, func(i, j int) bool { return endpoints[i].less(endpoints[j]) })
+
+	cw.printf("# HELP iptuapi_requests_total HTTP attempts to the IPTU API.\n")
+	cw.printf("# TYPE iptuapi_requests_total counter\n")
+	for _, l := range requests {
+		cw.printf("iptuapi_requests_total{%s,status=%s} %d\n", l.endpointLabels, quote(l.status), c.requests[l])
+	}
+
+	cw.printf("# HELP iptuapi_request_duration_seconds Latency of HTTP attempts to the IPTU API.\n")
+	cw.printf("# TYPE iptuapi_request_duration_seconds histogram\n")
+	for _, l := range endpoints {
+		h := c.durations[l]
+		var cumulative int64
+		for i, le := range c.buckets {
+			cumulative += h.counts[i]
+			cw.printf("iptuapi_request_duration_seconds_bucket{%s,le=%s} %d\n", l, quote(formatFloat(le)), cumulative)
+		}
+		cw.printf("iptuapi_request_duration_seconds_bucket{%s,le=\"+Inf\"} %d\n", l, h.count)
+		cw.printf("iptuapi_request_duration_seconds_sum{%s} %s\n", l, formatFloat(h.sum))
+		cw.printf("iptuapi_request_duration_seconds_count{%s} %d\n", l, h.count)
+	}
+
+	cw.printf("# HELP iptuapi_retries_total HTTP attempts beyond the first.\n")
+	cw.printf("# TYPE iptuapi_retries_total counter\n")
+	cw.printf("iptuapi_retries_total %d\n", c.retries)
+
+	if rl := c.rateLimit; rl != nil {
+		cw.printf("# HELP iptuapi_ratelimit_remaining Requests left in the current rate-limit window.\n")
+		cw.printf("# TYPE iptuapi_ratelimit_remaining gauge\n")
+		cw.printf("iptuapi_ratelimit_remaining %d\n", rl.Remaining)
+		cw.printf("# HELP iptuapi_ratelimit_limit Requests allowed in the current rate-limit window.\n")
+		cw.printf("# TYPE iptuapi_ratelimit_limit gauge\n")
+		cw.printf("iptuapi_ratelimit_limit %d\n", rl.Limit)
+	}
+	c.mu.Unlock()
+
+	if cw.err == nil {
+		cw.err = cw.w.Flush()
+	}
+	return cw.n, cw.err
+}
+
+func (l endpointLabels) less(o endpointLabels) bool {
+	if l.endpoint != o.endpoint {
+		return l.endpoint < o.endpoint
+	}
+	return l.method < o.method
+}
+
+func (l endpointLabels) String() string {
+	return "method=" + quote(l.method) + ",endpoint=" + quote(l.endpoint)
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func quote(v string) string {
+	return `"` + labelEscaper.Replace(v) + `"`
+}
+
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
+
+// countingWriter keeps the first write error and the bytes written.
+type countingWriter struct {
+	w   *bufio.Writer
+	n   int64
+	err error
+}
+
+func (cw *countingWriter) printf(format string, args ...interface{}) {
+	if cw.err != nil {
+		return
+	}
+	n, err := fmt.Fprintf(cw.w, format, args...)
+	cw.n += int64(n)
+	cw.err = err
+}
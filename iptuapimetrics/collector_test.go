@@ -0,0 +1,65 @@
+package iptuapimetrics
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	iptuapi "github.com/raphaeltorquat0/iptuapi-go"
+)
+
+func TestCollector(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Header().Set("X-RateLimit-Limit", "1000")
+		w.Header().Set("X-RateLimit-Remaining", "42")
+		w.Header().Set("X-RateLimit-Reset", "1700000000")
+		w.Write([]byte(`{"cidades":[]}`))
+	}))
+	defer server.Close()
+
+	client := iptuapi.NewClient("test_key", iptuapi.WithBaseURL(server.URL),
+		iptuapi.WithRetry(&iptuapi.RetryConfig{MaxRetries: 1, InitialDelay: time.Millisecond, MaxDelay: time.Millisecond, RetryableStatus: []int{503}}))
+	collector := NewCollector(client)
+	_, err := client.IPTUToolsCidades(context.Background())
+	require.NoError(t, err)
+
+	rec := httptest.NewRecorder()
+	collector.ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	body := rec.Body.String()
+	assert.True(t, strings.HasPrefix(rec.Header().Get("Content-Type"), "text/plain"))
+
+	ep := `method="GET",endpoint="/iptu-tools/cidades"`
+	assert.Contains(t, body, "iptuapi_requests_total{"+ep+`,status="200"} 1`)
+	assert.Contains(t, body, "iptuapi_requests_total{"+ep+`,status="503"} 1`)
+	assert.Contains(t, body, "iptuapi_request_duration_seconds_bucket{"+ep+`,le="+Inf"} 2`)
+	assert.Contains(t, body, "iptuapi_request_duration_seconds_count{"+ep+"} 2")
+	assert.Contains(t, body, "iptuapi_retries_total 1")
+	assert.Contains(t, body, "iptuapi_ratelimit_remaining 42")
+	assert.Contains(t, body, "iptuapi_ratelimit_limit 1000")
+}
+
+func TestCollectorNoRateLimit(t *testing.T) {
+	client := iptuapi.NewClient("test_key")
+	var sb strings.Builder
+	n, err := NewCollector(client).WriteTo(&sb)
+	require.NoError(t, err)
+	assert.Equal(t, int64(sb.Len()), n)
+	assert.Contains(t, sb.String(), "iptuapi_retries_total 0")
+	assert.NotContains(t, sb.String(), "ratelimit", "the gauge is omitted until a response reports the quota")
+}
+
+func TestQuote(t *testing.T) {
+	assert.Equal(t, `"a\"b\\c\nd"`, quote("a\"b\\c\nd"))
+}
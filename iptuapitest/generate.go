@@ -0,0 +1,259 @@
+// Package iptuapitest generates synthetic iptuapi results for demos and
+// load tests, so they neither spend quota nor expose real records:
+//
+//	results := iptuapitest.GenerateResults(10000, 42, iptuapitest.SaoPaulo)
+//
+// Results are plausible, not real: SQLs follow the city's format, values
+// follow a per-bairro distribution and addresses come from a small list of
+// well-known streets. The same seed always yields the same results.
+package iptuapitest
+
+import (
+	"math"
+	"math/rand"
+	"strconv"
+	"strings"
+
+	iptuapi "github.com/raphaeltorquat0/iptuapi-go"
+)
+
+// BairroProfile describes the properties of one neighborhood.
+type BairroProfile struct {
+	Nome string
+	// Peso is the relative share of the city's properties in the bairro.
+	Peso float64
+	// ValorM2 is the median venal value per built square meter, land
+	// included. Values are log-normally distributed around it.
+	ValorM2 float64
+	// CEPPrefixo is the 5-digit prefix of the bairro's CEPs.
+	CEPPrefixo string
+	// Zonas are the zoning codes found in the bairro.
+	Zonas []string
+}
+
+// CityProfile describes the properties of a city.
+type CityProfile struct {
+	Cidade  iptuapi.Cidade
+	Bairros []BairroProfile
+	// Logradouros are the street names addresses are drawn from.
+	Logradouros []string
+	// SQLFormat is the layout of the cadastral number, with # for each
+	// digit. The first three digits identify the bairro.
+	SQLFormat string
+	// Aliquota is the IPTU rate applied to the total venal value.
+	Aliquota float64
+	// Dispersao is the standard deviation of the log of the value per
+	// square meter; 0.3 keeps most values within a third of the median.
+	Dispersao float64
+}
+
+// SaoPaulo is a profile modeled on the city of Sao Paulo.
+var SaoPaulo = CityProfile{
+	Cidade: iptuapi.CidadeSaoPaulo,
+	Bairros: []BairroProfile{
+		{Nome: "Pinheiros", Peso: 3, ValorM2: 11000, CEPPrefixo: "05422", Zonas: []string{"ZM", "ZC", "ZEU"}},
+		{Nome: "Vila Mariana", Peso: 4, ValorM2: 9500, CEPPrefixo: "04101", Zonas: []string{"ZM", "ZC"}},
+		{Nome: "Moema", Peso: 3, ValorM2: 12000, CEPPrefixo: "04077", Zonas: []string{"ZM", "ZER-1"}},
+		{Nome: "Itaim Bibi", Peso: 2, ValorM2: 14000, CEPPrefixo: "04530", Zonas: []string{"ZC", "ZEU"}},
+		{Nome: "Tatuapé", Peso: 4, ValorM2: 7500, CEPPrefixo: "03310", Zonas: []string{"ZM", "ZC"}},
+		{Nome: "Santana", Peso: 3, ValorM2: 7000, CEPPrefixo: "02012", Zonas: []string{"ZM", "ZER-1"}},
+		{Nome: "Jabaquara", Peso: 3, ValorM2: 5500, CEPPrefixo: "04309", Zonas: []string{"ZM", "ZEIS-1"}},
+		{Nome: "Penha", Peso: 3, ValorM2: 5000, CEPPrefixo: "03602", Zonas: []string{"ZM", "ZEIS-1"}},
+	},
+	Logradouros: []string{
+		"Rua Augusta", "Rua Teodoro Sampaio", "Rua Vergueiro", "Avenida Paulista",
+		"Rua Domingos de Morais", "Avenida Ibirapuera", "Rua Tuiuti", "Rua Voluntários da Pátria",
+		"Avenida Jabaquara", "Rua Padre Benedito de Camargo",
+	},
+	SQLFormat: "###.###.####-#",
+	Aliquota:  0.01,
+	Dispersao: 0.3,
+}
+
+// RioDeJaneiro is a profile modeled on the city of Rio de Janeiro.
+var RioDeJaneiro = CityProfile{
+	Cidade: iptuapi.CidadeRioDeJaneiro,
+	Bairros: []BairroProfile{
+		{Nome: "Copacabana", Peso: 4, ValorM2: 12000, CEPPrefixo: "22041", Zonas: []string{"ZR-3", "ZC"}},
+		{Nome: "Leblon", Peso: 1, ValorM2: 22000, CEPPrefixo: "22441", Zonas: []string{"ZR-3"}},
+		{Nome: "Tijuca", Peso: 4, ValorM2: 8000, CEPPrefixo: "20511", Zonas: []string{"ZR-3", "ZC"}},
+		{Nome: "Barra da Tijuca", Peso: 3, ValorM2: 10000, CEPPrefixo: "22620", Zonas: []string{"ZR-1", "ZR-3"}},
+		{Nome: "Méier", Peso: 3, ValorM2: 5000, CEPPrefixo: "20720", Zonas: []string{"ZR-3", "ZC"}},
+	},
+	Logradouros: []string{
+		"Avenida Nossa Senhora de Copacabana", "Rua Dias Ferreira", "Rua Conde de Bonfim",
+		"Avenida das Américas", "Rua Dias da Cruz", "Rua Barata Ribeiro",
+	},
+	SQLFormat: "#######-#",
+	Aliquota:  0.01,
+	Dispersao: 0.35,
+}
+
+// BeloHorizonte is a profile modeled on the city of Belo Horizonte.
+var BeloHorizonte = CityProfile{
+	Cidade: iptuapi.CidadeBeloHorizonte,
+	Bairros: []BairroProfile{
+		{Nome: "Savassi", Peso: 2, ValorM2: 10000, CEPPrefixo: "30112", Zonas: []string{"ZCBH", "ZA"}},
+		{Nome: "Lourdes", Peso: 2, ValorM2: 11000, CEPPrefixo: "30170", Zonas: []string{"ZA"}},
+		{Nome: "Buritis", Peso: 3, ValorM2: 7000, CEPPrefixo: "30575", Zonas: []string{"ZAR-2"}},
+		{Nome: "Sion", Peso: 2, ValorM2: 8500, CEPPrefixo: "30310", Zonas: []string{"ZA", "ZAR-2"}},
+		{Nome: "Centro", Peso: 3, ValorM2: 6000, CEPPrefixo: "30120", Zonas: []string{"ZCBH"}},
+	},
+	Logradouros: []string{
+		"Avenida Afonso Pena", "Rua Pernambuco", "Avenida do Contorno",
+		"Rua Professor Mário Werneck", "Rua Grão Mogol",
+	},
+	SQLFormat: "###.###.###.####",
+	Aliquota:  0.008,
+	Dispersao: 0.3,
+}
+
+// tiposUso are the uses of generated properties and their weights.
+var tiposUso = []struct {
+	nome string
+	peso float64
+}{
+	{"Residencial", 0.8},
+	{"Comercial", 0.15},
+	{"Industrial", 0.05},
+}
+
+// GenerateResults returns n synthetic properties of profile. Results are
+// deterministic for a given seed and SQLs are unique within them.
+func GenerateResults(n int, seed int64, profile CityProfile) []iptuapi.ConsultaEnderecoResult {
+	if n <= 0 || len(profile.Bairros) == 0 {
+		return nil
+	}
+	g := &generator{
+		rnd:     rand.New(rand.NewSource(seed)),
+		profile: profile,
+		seen:    make(map[string]bool, n),
+	}
+	for _, b := range profile.Bairros {
+		g.totalPeso += b.Peso
+	}
+	results := make([]iptuapi.ConsultaEnderecoResult, n)
+	for i := range results {
+		results[i] = g.result()
+	}
+	return results
+}
+
+type generator struct {
+	rnd       *rand.Rand
+	profile   CityProfile
+	totalPeso float64
+	seen      map[string]bool
+}
+
+func (g *generator) result() iptuapi.ConsultaEnderecoResult {
+	index, bairro := g.bairro()
+	r := iptuapi.ConsultaEnderecoResult{
+		SQL:        g.sql(index),
+		Logradouro: g.pick(g.profile.Logradouros),
+		Numero:     strconv.Itoa(1 + g.rnd.Intn(3000)),
+		Bairro:     bairro.Nome,
+		CEP:        bairro.CEPPrefixo + g.digits(3),
+		TipoUso:    g.tipoUso(),
+		Zona:       g.pick(bairro.Zonas),
+	}
+
+	apartment := r.TipoUso == "Residencial" && g.rnd.Float64() < 0.6
+	if apartment {
+		r.Complemento = "Apto " + strconv.Itoa(10*(1+g.rnd.Intn(20))+1+g.rnd.Intn(4))
+		r.AreaConstruida = round(g.logNormal(75, 0.4), 1)
+		// The ideal fraction of the building's land.
+		r.AreaTerreno = round(r.AreaConstruida*(0.15+0.2*g.rnd.Float64()), 1)
+	} else {
+		r.AreaTerreno = round(g.logNormal(250, 0.5), 1)
+		r.AreaConstruida = round(r.AreaTerreno*(0.5+1.5*g.rnd.Float64()), 1)
+	}
+	r.AnoConstrucao = 1950 + g.rnd.Intn(75)
+
+	total := r.AreaConstruida * g.logNormal(bairro.ValorM2, g.profile.Dispersao)
+	terreno := total * (0.3 + 0.3*g.rnd.Float64())
+	r.ValorVenalTerreno = round(terreno, 2)
+	r.ValorVenalConstrucao = round(total-terreno, 2)
+	r.ValorVenalTotal = round(r.ValorVenalTerreno+r.ValorVenalConstrucao, 2)
+	r.IPTUValor = round(r.ValorVenalTotal*g.profile.Aliquota, 2)
+	return r
+}
+
+// bairro draws a bairro by weight.
+func (g *generator) bairro() (int, BairroProfile) {
+	x := g.rnd.Float64() * g.totalPeso
+	for i, b := range g.profile.Bairros {
+		if x < b.Peso {
+			return i, b
+		}
+		x -= b.Peso
+	}
+	last := len(g.profile.Bairros) - 1
+	return last, g.profile.Bairros[last]
+}
+
+// sql returns an unused cadastral number whose first digits identify the
+// bairro, so properties of a bairro share a sector as in real cadastres.
+func (g *generator) sql(bairro int) string {
+	format := g.profile.SQLFormat
+	if format == "" {
+		format = SaoPaulo.SQLFormat
+	}
+	sector := strconv.Itoa(100 + 7*bairro)
+	for {
+		var b strings.Builder
+		digit := 0
+		for _, c := range format {
+			if c != '#' {
+				b.WriteRune(c)
+				continue
+			}
+			if digit < len(sector) {
+				b.WriteByte(sector[digit])
+			} else {
+				b.WriteByte(byte('0' + g.rnd.Intn(10)))
+			}
+			digit++
+		}
+		if sql := b.String(); !g.seen[sql] {
+			g.seen[sql] = true
+			return sql
+		}
+	}
+}
+
+func (g *generator) tipoUso() string {
+	x := g.rnd.Float64()
+	for _, t := range tiposUso {
+		if x < t.peso {
+			return t.nome
+		}
+		x -= t.peso
+	}
+	return tiposUso[0].nome
+}
+
+func (g *generator) digits(n int) string {
+	b := make([]byte, n)
+	for i := range b {
+		b[i] = byte('0' + g.rnd.Intn(10))
+	}
+	return string(b)
+}
+
+func (g *generator) pick(values []string) string {
+	if len(values) == 0 {
+		return ""
+	}
+	return values[g.rnd.Intn(len(values))]
+}
+
+// logNormal draws a value whose log is normal around log(median).
+func (g *generator) logNormal(median, sigma float64) float64 {
+	return median * math.Exp(sigma*g.rnd.NormFloat64())
+}
+
+func round(x float64, decimals int) float64 {
+	scale := math.Pow(10, float64(decimals))
+	return math.Round(x*scale) / scale
+}
@@ -0,0 +1,57 @@
+package iptuapitest
+
+import (
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerateResultsDeterministic(t *testing.T) {
+	a := GenerateResults(100, 42, SaoPaulo)
+	require.Len(t, a, 100)
+	assert.Equal(t, a, GenerateResults(100, 42, SaoPaulo))
+	assert.NotEqual(t, a, GenerateResults(100, 43, SaoPaulo))
+	assert.Nil(t, GenerateResults(0, 42, SaoPaulo))
+}
+
+func TestGenerateResultsPlausible(t *testing.T) {
+	results := GenerateResults(2000, 7, SaoPaulo)
+
+	sqls := make(map[string]bool)
+	valoresM2 := make(map[string][]float64)
+	for _, r := range results {
+		assert.False(t, sqls[r.SQL], "duplicate SQL %s", r.SQL)
+		sqls[r.SQL] = true
+		assert.Len(t, r.SQL, len(SaoPaulo.SQLFormat))
+		assert.Equal(t, "-", r.SQL[12:13])
+		assert.Len(t, r.CEP, 8)
+		assert.NotEmpty(t, r.Logradouro)
+		assert.InDelta(t, r.ValorVenalTotal, r.ValorVenalTerreno+r.ValorVenalConstrucao, 0.01)
+		assert.InDelta(t, r.ValorVenalTotal*SaoPaulo.Aliquota, r.IPTUValor, 0.01)
+		assert.Positive(t, r.AreaConstruida)
+		valoresM2[r.Bairro] = append(valoresM2[r.Bairro], r.ValorVenalTotal/r.AreaConstruida)
+	}
+
+	// Medians follow the bairro profiles.
+	for _, b := range SaoPaulo.Bairros {
+		values := valoresM2[b.Nome]
+		require.NotEmpty(t, values, b.Nome)
+		sort.Float64s(values)
+		assert.InEpsilon(t, b.ValorM2, values[len(values)/2], 0.15, b.Nome)
+	}
+}
+
+func TestGenerateResultsSQLIdentifiesBairro(t *testing.T) {
+	sectors := make(map[string]string)
+	for _, r := range GenerateResults(500, 1, BeloHorizonte) {
+		sector, _, _ := strings.Cut(r.SQL, ".")
+		if bairro, ok := sectors[sector]; ok {
+			assert.Equal(t, bairro, r.Bairro)
+		}
+		sectors[sector] = r.Bairro
+	}
+	assert.Len(t, sectors, len(BeloHorizonte.Bairros))
+}
@@ -0,0 +1,122 @@
+package iptuhttp
+
+import (
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	iptuapi "github.com/raphaeltorquat0/iptuapi-go"
+)
+
+// DefaultMaintenanceRetryAfter is the Retry-After sent while the circuit
+// breaker is in maintenance mode, whose end is unknown.
+const DefaultMaintenanceRetryAfter = 60 * time.Second
+
+// Backpressure exposes the SDK's view of the upstream API to the clients
+// of a gateway built on it: responses carry the upstream rate-limit
+// headers, and requests are rejected with 503 while the circuit breaker is
+// open instead of queueing behind a failing API.
+//
+// Middleware fits net/http routers, chi and Echo (echo.WrapMiddleware).
+// For Gin, call Allow from a handler:
+//
+//	bp := iptuhttp.NewBackpressure(client)
+//	r.Use(func(c *gin.Context) {
+//		if !bp.Allow(c.Writer, c.Request) {
+//			c.Abort()
+//		}
+//	})
+type Backpressure struct {
+	client *iptuapi.Client
+
+	mu        sync.Mutex
+	rateLimit *iptuapi.RateLimitInfo
+}
+
+// NewBackpressure tracks client's rate-limit state from now on. Like
+// Client.OnAttempt, call it before making requests.
+func NewBackpressure(client *iptuapi.Client) *Backpressure {
+	b := &Backpressure{client: client}
+	client.OnAttempt(func(a iptuapi.Attempt) {
+		if a.RateLimit == nil {
+			return
+		}
+		rl := *a.RateLimit
+		b.mu.Lock()
+		b.rateLimit = &rl
+		b.mu.Unlock()
+	})
+	return b
+}
+
+// Middleware wraps next with Allow. The rate-limit headers are written
+// with next's response, so they reflect the upstream calls next made.
+func (b *Backpressure) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !b.Allow(w, r) {
+			return
+		}
+		next.ServeHTTP(&headerWriter{ResponseWriter: w, b: b}, r)
+	})
+}
+
+// Allow writes the current rate-limit headers to w and reports whether the
+// request may proceed. While the circuit breaker is open or in
+// maintenance, it answers 503 with Retry-After and returns false.
+func (b *Backpressure) Allow(w http.ResponseWriter, r *http.Request) bool {
+	b.WriteHeaders(w.Header())
+	switch b.client.BreakerState() {
+	case iptuapi.BreakerOpen, iptuapi.BreakerMaintenance:
+	default:
+		return true
+	}
+	wait := DefaultMaintenanceRetryAfter
+	if at := b.client.BreakerRetryAt(); !at.IsZero() {
+		wait = time.Until(at)
+	}
+	w.Header().Set("Retry-After", strconv.Itoa(int(wait.Seconds())+1))
+	writeJSON(w, http.StatusServiceUnavailable, errorBody{Code: "circuit_open", Message: "a API de IPTU esta indisponivel"})
+	return false
+}
+
+// WriteHeaders sets X-RateLimit-Limit, X-RateLimit-Remaining and
+// X-RateLimit-Reset on h from the last upstream response, if any.
+func (b *Backpressure) WriteHeaders(h http.Header) {
+	b.mu.Lock()
+	rl := b.rateLimit
+	b.mu.Unlock()
+	if rl == nil {
+		return
+	}
+	h.Set("X-RateLimit-Limit", strconv.Itoa(rl.Limit))
+	h.Set("X-RateLimit-Remaining", strconv.Itoa(rl.Remaining))
+	h.Set("X-RateLimit-Reset", strconv.FormatInt(rl.Reset, 10))
+}
+
+// headerWriter refreshes the rate-limit headers when the response starts.
+type headerWriter struct {
+	http.ResponseWriter
+	b       *Backpressure
+	written bool
+}
+
+func (w *headerWriter) WriteHeader(status int) {
+	if !w.written {
+		w.written = true
+		w.b.WriteHeaders(w.Header())
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *headerWriter) Write(p []byte) (int, error) {
+	if !w.written {
+		w.WriteHeader(http.StatusOK)
+	}
+	return w.ResponseWriter.Write(p)
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer.
+func (w *headerWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
@@ -0,0 +1,57 @@
+package iptuhttp
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	iptuapi "github.com/raphaeltorquat0/iptuapi-go"
+)
+
+func TestBackpressure(t *testing.T) {
+	var calls, remaining atomic.Int32
+	remaining.Store(100)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.Header().Set("X-RateLimit-Limit", "100")
+		w.Header().Set("X-RateLimit-Remaining", strconv.Itoa(int(remaining.Add(-1))))
+		w.Header().Set("X-RateLimit-Reset", "1760000000")
+		if r.URL.Path == "/consulta/sql/500" {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.Write([]byte(`{"sql":"1"}`))
+	}))
+	defer server.Close()
+
+	client := iptuapi.NewClient("test_key", iptuapi.WithBaseURL(server.URL),
+		iptuapi.WithRetry(&iptuapi.RetryConfig{MaxRetries: 0}),
+		iptuapi.WithCircuitBreaker(iptuapi.BreakerConfig{FailureThreshold: 1, Cooldown: time.Minute}))
+	bp := NewBackpressure(client)
+	gateway := bp.Middleware(Handler(client, ConsultaSQL, WithCache(nil, 0)))
+
+	rec, _ := get(gateway, "/?sql=1")
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "99", rec.Header().Get("X-RateLimit-Remaining"), "headers reflect the call made for this request")
+	assert.Equal(t, "100", rec.Header().Get("X-RateLimit-Limit"))
+
+	rec, _ = get(gateway, "/?sql=500")
+	assert.Equal(t, http.StatusBadGateway, rec.Code)
+	assert.Equal(t, "98", rec.Header().Get("X-RateLimit-Remaining"))
+
+	// The failure opened the breaker: the gateway sheds load itself.
+	rec, body := get(gateway, "/?sql=1")
+	assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+	assert.Equal(t, "circuit_open", body["code"])
+	retryAfter, err := strconv.Atoi(rec.Header().Get("Retry-After"))
+	require.NoError(t, err)
+	assert.InDelta(t, 60, retryAfter, 1)
+	assert.Equal(t, "98", rec.Header().Get("X-RateLimit-Remaining"))
+	assert.Equal(t, int32(2), calls.Load())
+}
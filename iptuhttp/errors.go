@@ -0,0 +1,117 @@
+package iptuhttp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	iptuapi "github.com/raphaeltorquat0/iptuapi-go"
+)
+
+// InputError reports an invalid query parameter, rejected before calling
+// the API.
+type InputError struct {
+	Field   string
+	Message string
+}
+
+func (e *InputError) Error() string {
+	return "iptuhttp: " + e.Field + ": " + e.Message
+}
+
+func invalid(field, msg string) error {
+	return &InputError{Field: field, Message: msg}
+}
+
+// errorBody is the JSON body of error responses.
+type errorBody struct {
+	Code      string               `json:"code"`
+	Message   string               `json:"message"`
+	RequestID string               `json:"request_id,omitempty"`
+	Fields    []iptuapi.FieldError `json:"fields,omitempty"`
+}
+
+// WriteError writes err as a JSON error response:
+//
+//   - invalid input and API validation errors: 400 and 422, with the fields;
+//   - property not found: 404;
+//   - rate limit: 429 with Retry-After;
+//   - municipal source unavailable or circuit breaker open: 503;
+//   - timeouts: 504;
+//   - authentication, plan and other API failures: 502, since the caller
+//     cannot fix them.
+//
+// The body is {"code": ..., "message": ..., "request_id": ...}. Use it in
+// custom handlers to answer like the ones of this package.
+func WriteError(w http.ResponseWriter, err error) {
+	var (
+		input     *InputError
+		valid     *iptuapi.ValidationError
+		notFound  *iptuapi.NotFoundError
+		rateLimit *iptuapi.RateLimitError
+		fonte     *iptuapi.FonteIndisponivelError
+		open      *iptuapi.CircuitOpenError
+		auth      *iptuapi.AuthenticationError
+		forbidden *iptuapi.ForbiddenError
+		server    *iptuapi.ServerError
+	)
+	body := errorBody{Message: err.Error()}
+	fromAPI := func(e *iptuapi.APIError) {
+		if e != nil {
+			body.Message, body.RequestID = e.Message, e.RequestID
+		}
+	}
+
+	status := http.StatusBadGateway
+	switch {
+	case errors.As(err, &input):
+		status, body.Code, body.Message = http.StatusBadRequest, "invalid_input", input.Message
+		body.Fields = []iptuapi.FieldError{{Field: input.Field, Message: input.Message}}
+	case errors.As(err, &valid):
+		fromAPI(valid.APIError)
+		status, body.Code, body.Fields = http.StatusUnprocessableEntity, "validation_failed", valid.Errors
+	case errors.As(err, &notFound):
+		fromAPI(notFound.APIError)
+		status, body.Code = http.StatusNotFound, "not_found"
+	case errors.As(err, &rateLimit):
+		fromAPI(rateLimit.APIError)
+		status, body.Code = http.StatusTooManyRequests, "rate_limited"
+		if rateLimit.RetryAfter > 0 {
+			w.Header().Set("Retry-After", strconv.Itoa(rateLimit.RetryAfter))
+		}
+	case errors.As(err, &fonte):
+		fromAPI(fonte.APIError)
+		status, body.Code = http.StatusServiceUnavailable, "source_unavailable"
+	case errors.As(err, &open):
+		status, body.Code = http.StatusServiceUnavailable, "circuit_open"
+		if wait := time.Until(open.RetryAt); wait > 0 {
+			w.Header().Set("Retry-After", strconv.Itoa(int(wait.Seconds())+1))
+		}
+	case errors.Is(err, context.DeadlineExceeded):
+		status, body.Code = http.StatusGatewayTimeout, "timeout"
+	case errors.Is(err, context.Canceled):
+		// The caller went away; the status is only for logs.
+		status, body.Code = 499, "canceled"
+	case errors.As(err, &auth):
+		fromAPI(auth.APIError)
+		body.Code = "upstream_auth"
+	case errors.As(err, &forbidden):
+		fromAPI(forbidden.APIError)
+		body.Code = "upstream_plan"
+	case errors.As(err, &server):
+		fromAPI(server.APIError)
+		body.Code = "upstream_error"
+	default:
+		body.Code = "upstream_error"
+	}
+	writeJSON(w, status, body)
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,246 @@
+// Package iptuhttp exposes common iptuapi lookups as net/http handlers, for
+// thin internal APIs built over the SDK:
+//
+//	mux := http.NewServeMux()
+//	mux.Handle("/imoveis/sql", iptuhttp.Handler(client, iptuhttp.ConsultaSQL))
+//	mux.Handle("/imoveis/endereco", iptuhttp.Handler(client, iptuhttp.ConsultaEndereco))
+//	mux.Handle("/imoveis/cep", iptuhttp.Handler(client, iptuhttp.ConsultaCEP))
+//
+// Handlers answer GET requests with the API result as JSON. Inputs come
+// from the query string and are validated before any quota is spent;
+// successful responses are cached in memory; and SDK errors are mapped to
+// HTTP statuses with a JSON body (see WriteError). Backpressure propagates
+// the upstream quota and circuit breaker state to the gateway's clients.
+package iptuhttp
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	iptuapi "github.com/raphaeltorquat0/iptuapi-go"
+)
+
+// Route selects the lookup served by a handler.
+type Route string
+
+const (
+	// ConsultaSQL serves ?sql=&cidade=.
+	ConsultaSQL Route = "consulta_sql"
+	// ConsultaEndereco serves ?logradouro=&numero=&complemento=&cidade=.
+	ConsultaEndereco Route = "consulta_endereco"
+	// ConsultaCEP serves ?cep=&cidade= with the list of properties.
+	ConsultaCEP Route = "consulta_cep"
+	// ConsultaZoneamento serves ?latitude=&longitude=.
+	ConsultaZoneamento Route = "consulta_zoneamento"
+)
+
+// Default cache settings of Handler.
+const (
+	DefaultCacheSize = 1000
+	DefaultCacheTTL  = 5 * time.Minute
+)
+
+// Option configures a handler.
+type Option func(*handler)
+
+// WithCache caches successful responses in cache for ttl, keyed by route
+// and validated input. A nil cache disables caching.
+func WithCache(cache iptuapi.Cache, ttl time.Duration) Option {
+	return func(h *handler) {
+		h.cache, h.ttl = cache, ttl
+	}
+}
+
+// WithMaxAge sets the Cache-Control max-age of successful responses, so
+// callers and proxies may cache them too. Without it responses are marked
+// no-store.
+func WithMaxAge(d time.Duration) Option {
+	return func(h *handler) {
+		h.maxAge = d
+	}
+}
+
+type handler struct {
+	client *iptuapi.Client
+	route  Route
+	lookup lookupFunc
+	cache  iptuapi.Cache
+	ttl    time.Duration
+	maxAge time.Duration
+}
+
+// lookupFunc validates the query and runs the lookup.
+type lookupFunc func(ctx context.Context, c *iptuapi.Client, q url.Values, opts []iptuapi.RequestOption) (interface{}, error)
+
+var lookups = map[Route]lookupFunc{
+	ConsultaSQL:        lookupSQL,
+	ConsultaEndereco:   lookupEndereco,
+	ConsultaCEP:        lookupCEP,
+	ConsultaZoneamento: lookupZoneamento,
+}
+
+// Handler returns a handler serving route with client. Responses are
+// cached in memory for DefaultCacheTTL unless WithCache says otherwise.
+// It panics on an unknown route.
+func Handler(client *iptuapi.Client, route Route, opts ...Option) http.Handler {
+	lookup, ok := lookups[route]
+	if !ok {
+		panic("iptuhttp: unknown route " + strconv.Quote(string(route)))
+	}
+	h := &handler{
+		client: client,
+		route:  route,
+		lookup: lookup,
+		cache:  iptuapi.NewLRUCache(DefaultCacheSize),
+		ttl:    DefaultCacheTTL,
+	}
+	for _, opt := range opts {
+		opt(h)
+	}
+	return h
+}
+
+func (h *handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		writeJSON(w, http.StatusMethodNotAllowed, errorBody{Code: "method_not_allowed", Message: "use GET"})
+		return
+	}
+	ctx := r.Context()
+	q, err := canonicalQuery(r.URL.Query())
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	key := string(h.route) + "?" + q.Encode()
+	if h.cache != nil {
+		if body, ok, _ := h.cache.Get(ctx, key); ok {
+			w.Header().Set("X-Cache", "HIT")
+			h.writeBody(w, body)
+			return
+		}
+	}
+
+	var opts []iptuapi.RequestOption
+	if id := r.Header.Get("X-Request-ID"); id != "" {
+		opts = append(opts, iptuapi.WithHeader("X-Request-ID", id))
+	}
+	result, err := h.lookup(ctx, h.client, q, opts)
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+	body, err := json.Marshal(result)
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+	if h.cache != nil {
+		h.cache.Set(ctx, key, body, h.ttl)
+		w.Header().Set("X-Cache", "MISS")
+	}
+	h.writeBody(w, body)
+}
+
+func (h *handler) writeBody(w http.ResponseWriter, body []byte) {
+	if h.maxAge > 0 {
+		w.Header().Set("Cache-Control", "private, max-age="+strconv.Itoa(int(h.maxAge.Seconds())))
+	} else {
+		w.Header().Set("Cache-Control", "no-store")
+	}
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.Write(body)
+}
+
+// canonicalQuery trims and cleans the query values, keeping the first of
+// repeated parameters, and validates the city.
+func canonicalQuery(in url.Values) (url.Values, error) {
+	q := url.Values{}
+	for name, values := range in {
+		if v := iptuapi.SanitizeText(values[0]); v != "" {
+			q.Set(name, v)
+		}
+	}
+	if cidade := q.Get("cidade"); cidade != "" {
+		cidade = strings.ToLower(cidade)
+		q.Set("cidade", cidade)
+		if !knownCidade(iptuapi.Cidade(cidade)) {
+			return nil, invalid("cidade", "cidade nao suportada: "+cidade)
+		}
+	}
+	return q, nil
+}
+
+func knownCidade(cidade iptuapi.Cidade) bool {
+	for _, c := range iptuapi.Capabilities().Cidades() {
+		if c == cidade {
+			return true
+		}
+	}
+	return false
+}
+
+func lookupSQL(ctx context.Context, c *iptuapi.Client, q url.Values, opts []iptuapi.RequestOption) (interface{}, error) {
+	sql := q.Get("sql")
+	if sql == "" {
+		return nil, invalid("sql", "parametro obrigatorio")
+	}
+	if len(sql) > 32 || strings.Trim(sql, "0123456789.-/ ") != "" {
+		return nil, invalid("sql", "formato invalido")
+	}
+	return c.ConsultaSQL(ctx, sql, iptuapi.Cidade(q.Get("cidade")), opts...)
+}
+
+func lookupEndereco(ctx context.Context, c *iptuapi.Client, q url.Values, opts []iptuapi.RequestOption) (interface{}, error) {
+	p := &iptuapi.ConsultaEnderecoParams{
+		Logradouro:  q.Get("logradouro"),
+		Numero:      q.Get("numero"),
+		Complemento: q.Get("complemento"),
+		Cidade:      iptuapi.Cidade(q.Get("cidade")),
+	}
+	if p.Logradouro == "" {
+		return nil, invalid("logradouro", "parametro obrigatorio")
+	}
+	return c.ConsultaEndereco(ctx, p, opts...)
+}
+
+func lookupCEP(ctx context.Context, c *iptuapi.Client, q url.Values, opts []iptuapi.RequestOption) (interface{}, error) {
+	cep := strings.NewReplacer("-", "", ".", "", " ", "").Replace(q.Get("cep"))
+	if cep == "" {
+		return nil, invalid("cep", "parametro obrigatorio")
+	}
+	if len(cep) != 8 || strings.Trim(cep, "0123456789") != "" {
+		return nil, invalid("cep", "o CEP deve ter 8 digitos")
+	}
+	return c.ConsultaCEP(ctx, cep, iptuapi.Cidade(q.Get("cidade")), opts...)
+}
+
+func lookupZoneamento(ctx context.Context, c *iptuapi.Client, q url.Values, opts []iptuapi.RequestOption) (interface{}, error) {
+	lat, err := coordinate(q, "latitude", 90)
+	if err != nil {
+		return nil, err
+	}
+	lng, err := coordinate(q, "longitude", 180)
+	if err != nil {
+		return nil, err
+	}
+	return c.ConsultaZoneamento(ctx, lat, lng, opts...)
+}
+
+func coordinate(q url.Values, name string, limit float64) (float64, error) {
+	s := q.Get(name)
+	if s == "" {
+		return 0, invalid(name, "parametro obrigatorio")
+	}
+	v, err := strconv.ParseFloat(s, 64)
+	if err != nil || v < -limit || v > limit {
+		return 0, invalid(name, "coordenada invalida")
+	}
+	return v, nil
+}
@@ -0,0 +1,132 @@
+package iptuhttp
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	iptuapi "github.com/raphaeltorquat0/iptuapi-go"
+)
+
+// fakeAPI counts the calls reaching the API and keeps the last request ID.
+type fakeAPI struct {
+	calls     atomic.Int32
+	requestID atomic.Value
+}
+
+func newAPI(t *testing.T, api *fakeAPI) *iptuapi.Client {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		api.calls.Add(1)
+		api.requestID.Store(r.Header.Get("X-Request-ID"))
+		switch r.URL.Path {
+		case "/consulta/sql/999.999.9999-9":
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"detail":"Imovel nao encontrado"}`))
+		case "/consulta/sql/000.000.0000-0":
+			w.Write([]byte(`{"sql":"000.000.0000-0","bairro":"Pinheiros"}`))
+		case "/consulta/cep/01310100":
+			w.Header().Set("Retry-After", "7")
+			w.WriteHeader(http.StatusTooManyRequests)
+			w.Write([]byte(`{"detail":"Rate limit excedido"}`))
+		default:
+			t.Errorf("unexpected path %s", r.URL.Path)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return iptuapi.NewClient("test_key", iptuapi.WithBaseURL(server.URL),
+		iptuapi.WithRetry(&iptuapi.RetryConfig{MaxRetries: 0}))
+}
+
+func get(h http.Handler, target string) (*httptest.ResponseRecorder, map[string]interface{}) {
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, target, nil))
+	var body map[string]interface{}
+	json.Unmarshal(rec.Body.Bytes(), &body)
+	return rec, body
+}
+
+func TestHandlerCachesResults(t *testing.T) {
+	var api fakeAPI
+	h := Handler(newAPI(t, &api), ConsultaSQL, WithMaxAge(time.Minute))
+
+	rec, body := get(h, "/imoveis?sql=000.000.0000-0&cidade=SP")
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "Pinheiros", body["bairro"])
+	assert.Equal(t, "MISS", rec.Header().Get("X-Cache"))
+	assert.Equal(t, "private, max-age=60", rec.Header().Get("Cache-Control"))
+
+	rec, _ = get(h, "/imoveis?cidade=sp&sql=+000.000.0000-0")
+	assert.Equal(t, "HIT", rec.Header().Get("X-Cache"), "equivalent queries share the cache entry")
+	assert.Equal(t, int32(1), api.calls.Load())
+}
+
+func TestHandlerValidatesInput(t *testing.T) {
+	var api fakeAPI
+	client := newAPI(t, &api)
+
+	tests := []struct {
+		route  Route
+		target string
+		field  string
+	}{
+		{ConsultaSQL, "/?cidade=sp", "sql"},
+		{ConsultaSQL, "/?sql=1;DROP", "sql"},
+		{ConsultaSQL, "/?sql=1&cidade=gotham", "cidade"},
+		{ConsultaEndereco, "/?numero=100", "logradouro"},
+		{ConsultaCEP, "/?cep=0131", "cep"},
+		{ConsultaZoneamento, "/?latitude=-23.5&longitude=200", "longitude"},
+	}
+	for _, tt := range tests {
+		rec, body := get(Handler(client, tt.route), tt.target)
+		assert.Equal(t, http.StatusBadRequest, rec.Code, tt.target)
+		assert.Equal(t, "invalid_input", body["code"], tt.target)
+		fields, _ := body["fields"].([]interface{})
+		require.Len(t, fields, 1, tt.target)
+		assert.Equal(t, tt.field, fields[0].(map[string]interface{})["field"], tt.target)
+	}
+	assert.Zero(t, api.calls.Load(), "invalid input spends no quota")
+}
+
+func TestHandlerMapsErrors(t *testing.T) {
+	var api fakeAPI
+	client := newAPI(t, &api)
+
+	rec, body := get(Handler(client, ConsultaSQL), "/?sql=999.999.9999-9")
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+	assert.Equal(t, "not_found", body["code"])
+	assert.Equal(t, "Imovel nao encontrado", body["message"])
+
+	rec, body = get(Handler(client, ConsultaCEP), "/?cep=01310-100")
+	assert.Equal(t, http.StatusTooManyRequests, rec.Code)
+	assert.Equal(t, "rate_limited", body["code"])
+	assert.Equal(t, "7", rec.Header().Get("Retry-After"))
+
+	rec = httptest.NewRecorder()
+	Handler(client, ConsultaSQL).ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/?sql=1", nil))
+	assert.Equal(t, http.StatusMethodNotAllowed, rec.Code)
+	assert.Equal(t, "GET, HEAD", rec.Header().Get("Allow"))
+}
+
+func TestHandlerForwardsRequestID(t *testing.T) {
+	var api fakeAPI
+	h := Handler(newAPI(t, &api), ConsultaSQL, WithCache(nil, 0))
+
+	req := httptest.NewRequest(http.MethodGet, "/?sql=000.000.0000-0", nil)
+	req.Header.Set("X-Request-ID", "req-42")
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "req-42", api.requestID.Load())
+	assert.Empty(t, rec.Header().Get("X-Cache"))
+}
+
+func TestHandlerUnknownRoute(t *testing.T) {
+	assert.Panics(t, func() { Handler(nil, Route("consulta_iptu")) })
+}
@@ -0,0 +1,232 @@
+package iptuapi
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/url"
+	"time"
+)
+
+// ITBIParams filters ITBI (property transfer tax) transactions. At least one
+// of SQL or Bairro should be set.
+type ITBIParams struct {
+	SQL        string
+	Bairro     string
+	Cidade     Cidade
+	DataInicio time.Time
+	DataFim    time.Time
+}
+
+// Values returns the canonical query parameters for an ITBI query. Dates are
+// serialized as YYYY-MM-DD.
+func (p *ITBIParams) Values() url.Values {
+	v := url.Values{}
+	if p.SQL != "" {
+		v.Set("sql", p.SQL)
+	}
+	if p.Bairro != "" {
+		v.Set("bairro", p.Bairro)
+	}
+	v.Set("cidade", string(cidadeOrDefault(p.Cidade)))
+	if !p.DataInicio.IsZero() {
+		v.Set("data_inicio", p.DataInicio.Format(dateLayout))
+	}
+	if !p.DataFim.IsZero() {
+		v.Set("data_fim", p.DataFim.Format(dateLayout))
+	}
+	return v
+}
+
+const dateLayout = "2006-01-02"
+
+// ITBITransacao is a property transfer recorded for ITBI purposes.
+type ITBITransacao struct {
+	SQL                  string  `json:"sql,omitempty"`
+	Logradouro           string  `json:"logradouro,omitempty"`
+	Numero               string  `json:"numero,omitempty"`
+	Complemento          string  `json:"complemento,omitempty"`
+	Bairro               string  `json:"bairro,omitempty"`
+	CEP                  string  `json:"cep,omitempty"`
+	DataTransacao        string  `json:"data_transacao"`
+	NaturezaTransacao    string  `json:"natureza_transacao,omitempty"`
+	ValorTransacao       float64 `json:"valor_transacao"`
+	ValorVenalReferencia float64 `json:"valor_venal_referencia,omitempty"`
+	ProporcaoTransmitida float64 `json:"proporcao_transmitida,omitempty"`
+	AreaTerreno          float64 `json:"area_terreno,omitempty"`
+	AreaConstruida       float64 `json:"area_construida,omitempty"`
+	TipoUso              string  `json:"tipo_uso,omitempty"`
+}
+
+// ITBITransacoes returns the ITBI transactions matching p in a single call.
+// For ranges spanning years, use ITBIPorMes to avoid server-side timeouts.
+func (c *Client) ITBITransacoes(ctx context.Context, p *ITBIParams, opts ...RequestOption) ([]ITBITransacao, error) {
+	p = p.withCidade(opts)
+	if err := c.check(opts, p.Validate); err != nil {
+		return nil, err
+	}
+	var result []ITBITransacao
+	err := c.doRequest(ctx, "GET", "/dados/itbi/transacoes", p.Values(), nil, &result, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// withCidade returns p with the WithCidade override of opts applied, so
+// the call is validated for the city it is sent for.
+func (p *ITBIParams) withCidade(opts []RequestOption) *ITBIParams {
+	cidade := newRequestOptions(opts).cidadeOr(p.Cidade)
+	if cidade == p.Cidade {
+		return p
+	}
+	cp := *p
+	cp.Cidade = cidade
+	return &cp
+}
+
+// ITBIWindowOptions configures ITBIPorMes.
+type ITBIWindowOptions struct {
+	// Retries is how many times a failed window is retried on timeouts,
+	// server errors, rate limiting or source outages (default 3), on top of
+	// the client's own retries.
+	Retries int
+	// Backoff is the delay before the first window retry, doubled on each
+	// further retry (default 2s).
+	Backoff time.Duration
+	// WindowTimeout bounds each window call (default: no extra bound).
+	WindowTimeout time.Duration
+}
+
+// ITBIIterator walks ITBI transactions one calendar month at a time. Use it
+// like bufio.Scanner:
+//
+//	it := client.ITBIPorMes(params, nil)
+//	for it.Next(ctx) {
+//		inicio, fim := it.Window()
+//		process(inicio, fim, it.Transacoes())
+//	}
+//	if err := it.Err(); err != nil { ... }
+type ITBIIterator struct {
+	client *Client
+	params ITBIParams
+	opts   ITBIWindowOptions
+	reqOps []RequestOption
+
+	next    time.Time
+	start   time.Time
+	end     time.Time
+	current []ITBITransacao
+	err     error
+}
+
+// ITBIPorMes returns an iterator over p's date range split into calendar
+// months. A zero DataFim means today. Each window is retried independently,
+// so a timeout late in a multi-year history does not restart the walk.
+func (c *Client) ITBIPorMes(p *ITBIParams, opts *ITBIWindowOptions, reqOpts ...RequestOption) *ITBIIterator {
+	o := ITBIWindowOptions{}
+	if opts != nil {
+		o = *opts
+	}
+	if o.Retries <= 0 {
+		o.Retries = 3
+	}
+	if o.Backoff <= 0 {
+		o.Backoff = 2 * time.Second
+	}
+
+	it := &ITBIIterator{client: c, params: *p, opts: o, reqOps: reqOpts}
+	if it.params.DataFim.IsZero() {
+		it.params.DataFim = c.clock.Now()
+	}
+	it.params.DataInicio = truncateDay(it.params.DataInicio)
+	it.params.DataFim = truncateDay(it.params.DataFim)
+	it.next = it.params.DataInicio
+	if it.params.DataInicio.IsZero() {
+		it.err = errors.New("iptuapi: ITBIPorMes requires DataInicio")
+	}
+	return it
+}
+
+// Next fetches the next month window. It returns false when the range is
+// exhausted or a window failed after its retries; check Err.
+func (it *ITBIIterator) Next(ctx context.Context) bool {
+	if it.err != nil || it.next.After(it.params.DataFim) {
+		return false
+	}
+
+	start := it.next
+	end := time.Date(start.Year(), start.Month()+1, 1, 0, 0, 0, 0, start.Location()).AddDate(0, 0, -1)
+	if end.After(it.params.DataFim) {
+		end = it.params.DataFim
+	}
+
+	p := it.params
+	p.DataInicio, p.DataFim = start, end
+	txs, err := it.fetchWindow(ctx, &p)
+	if err != nil {
+		it.err = err
+		return false
+	}
+
+	it.start, it.end, it.current = start, end, txs
+	it.next = end.AddDate(0, 0, 1)
+	return true
+}
+
+func (it *ITBIIterator) fetchWindow(ctx context.Context, p *ITBIParams) ([]ITBITransacao, error) {
+	delay := it.opts.Backoff
+	for attempt := 0; ; attempt++ {
+		wctx, cancel := ctx, context.CancelFunc(func() {})
+		if it.opts.WindowTimeout > 0 {
+			wctx, cancel = context.WithTimeout(ctx, it.opts.WindowTimeout)
+		}
+		txs, err := it.client.ITBITransacoes(wctx, p, it.reqOps...)
+		cancel()
+		if err == nil {
+			return txs, nil
+		}
+		if attempt >= it.opts.Retries || ctx.Err() != nil || !isWindowRetryable(err) {
+			return nil, err
+		}
+
+		if err := it.client.clock.Sleep(ctx, delay); err != nil {
+			return nil, err
+		}
+		delay *= 2
+	}
+}
+
+// isWindowRetryable reports whether a failed window is worth retrying.
+func isWindowRetryable(err error) bool {
+	if IsServerError(err) || IsRateLimit(err) || IsFonteIndisponivel(err) {
+		return true
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
+
+// Window returns the first and last day of the current window.
+func (it *ITBIIterator) Window() (inicio, fim time.Time) {
+	return it.start, it.end
+}
+
+// Transacoes returns the transactions of the current window.
+func (it *ITBIIterator) Transacoes() []ITBITransacao {
+	return it.current
+}
+
+// Err returns the error that stopped the iteration, if any.
+func (it *ITBIIterator) Err() error {
+	return it.err
+}
+
+func truncateDay(t time.Time) time.Time {
+	if t.IsZero() {
+		return t
+	}
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+}
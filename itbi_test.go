@@ -0,0 +1,70 @@
+package iptuapi
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestITBIPorMes(t *testing.T) {
+	var windows []string
+	failed := false
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		assert.Equal(t, "Pinheiros", q.Get("bairro"))
+		if q.Get("data_inicio") == "2025-12-01" && !failed {
+			failed = true
+			w.WriteHeader(http.StatusGatewayTimeout)
+			return
+		}
+		windows = append(windows, q.Get("data_inicio")+".."+q.Get("data_fim"))
+		json.NewEncoder(w).Encode([]ITBITransacao{{DataTransacao: q.Get("data_inicio"), ValorTransacao: 1}})
+	}))
+	defer server.Close()
+
+	client := NewClient("test_key", WithBaseURL(server.URL), WithRetry(&RetryConfig{MaxRetries: 0}))
+	it := client.ITBIPorMes(&ITBIParams{
+		Bairro:     "Pinheiros",
+		DataInicio: time.Date(2025, 11, 15, 10, 0, 0, 0, time.UTC),
+		DataFim:    time.Date(2026, 2, 10, 0, 0, 0, 0, time.UTC),
+	}, &ITBIWindowOptions{Backoff: time.Millisecond})
+
+	total := 0
+	for it.Next(context.Background()) {
+		total += len(it.Transacoes())
+	}
+	require.NoError(t, it.Err())
+	assert.Equal(t, 4, total)
+	assert.Equal(t, []string{
+		"2025-11-15..2025-11-30",
+		"2025-12-01..2025-12-31",
+		"2026-01-01..2026-01-31",
+		"2026-02-01..2026-02-10",
+	}, windows)
+}
+
+func TestITBIPorMesStopsOnPermanentError(t *testing.T) {
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	defer server.Close()
+
+	client := NewClient("test_key", WithBaseURL(server.URL), WithRetry(&RetryConfig{MaxRetries: 0}))
+	it := client.ITBIPorMes(&ITBIParams{
+		SQL:        "000.000.0000-0",
+		DataInicio: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC),
+		DataFim:    time.Date(2025, 6, 30, 0, 0, 0, 0, time.UTC),
+	}, &ITBIWindowOptions{Backoff: time.Millisecond})
+
+	assert.False(t, it.Next(context.Background()))
+	assert.True(t, IsForbidden(it.Err()))
+	assert.Equal(t, 1, calls)
+}
@@ -0,0 +1,201 @@
+package iptuapi
+
+import (
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// defaultKeyCooldown is how long a rate-limited key without a reported
+// reset time is skipped.
+const defaultKeyCooldown = time.Minute
+
+// RotationPolicy selects how WithAPIKeys spreads calls over the keys.
+type RotationPolicy int
+
+const (
+	// RotateFailover uses the first key until it is rate limited or its
+	// quota is exhausted, then the next, returning to earlier keys once
+	// they reset.
+	RotateFailover RotationPolicy = iota
+	// RotateRoundRobin spreads calls evenly over the available keys.
+	RotateRoundRobin
+)
+
+// WithAPIKeys sends calls with several API keys, e.g. on different plans.
+// A key answered with 429 or reporting an exhausted quota is skipped until
+// its rate limit resets, and a call rejected with 429 is resent right away
+// with the next key. The first key replaces the NewClient key and scopes
+// cache keys. See Client.APIKeyStatus for the tracked quotas.
+func WithAPIKeys(keys []string, policy RotationPolicy) ClientOption {
+	return func(c *Client) {
+		if len(keys) == 0 {
+			return
+		}
+		c.apiKey = keys[0]
+		c.keys = newKeyPool(keys, policy)
+	}
+}
+
+// APIKeyStatus is the quota state of one key configured with WithAPIKeys.
+type APIKeyStatus struct {
+	// Key is the masked key.
+	Key string
+	// RateLimit is the quota reported with the key's last response.
+	RateLimit *RateLimitInfo
+	Available bool
+	// ExhaustedUntil is when an unavailable key is tried again.
+	ExhaustedUntil time.Time
+}
+
+// APIKeyStatus reports the quota of each key in configured order. It
+// returns nil unless WithAPIKeys was used.
+func (c *Client) APIKeyStatus() []APIKeyStatus {
+	if c.keys == nil {
+		return nil
+	}
+	return c.keys.status()
+}
+
+type keyPool struct {
+	keys   []string
+	policy RotationPolicy
+	now    func() time.Time
+
+	mu             sync.Mutex
+	next           int
+	rateLimits     []*RateLimitInfo
+	exhaustedUntil []time.Time
+}
+
+func newKeyPool(keys []string, policy RotationPolicy) *keyPool {
+	return &keyPool{
+		keys:           keys,
+		policy:         policy,
+		now:            time.Now,
+		rateLimits:     make([]*RateLimitInfo, len(keys)),
+		exhaustedUntil: make([]time.Time, len(keys)),
+	}
+}
+
+func (p *keyPool) status() []APIKeyStatus {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	now := p.now()
+	out := make([]APIKeyStatus, len(p.keys))
+	for i, k := range p.keys {
+		out[i] = APIKeyStatus{
+			Key:            maskKey(k),
+			RateLimit:      p.rateLimits[i],
+			Available:      !now.Before(p.exhaustedUntil[i]),
+			ExhaustedUntil: p.exhaustedUntil[i],
+		}
+	}
+	return out
+}
+
+// order returns the keys to try: available ones, starting after the last
+// key used for RotateRoundRobin, then exhausted ones, soonest reset first.
+func (p *keyPool) order() []int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	now := p.now()
+	start := 0
+	if p.policy == RotateRoundRobin {
+		start = p.next
+		p.next = (p.next + 1) % len(p.keys)
+	}
+	var available, exhausted []int
+	for n := range p.keys {
+		i := (start + n) % len(p.keys)
+		if now.Before(p.exhaustedUntil[i]) {
+			exhausted = append(exhausted, i)
+		} else {
+			available = append(available, i)
+		}
+	}
+	for i := 1; i < len(exhausted); i++ {
+		for j := i; j > 0 && p.exhaustedUntil[exhausted[j]].Before(p.exhaustedUntil[exhausted[j-1]]); j-- {
+			exhausted[j], exhausted[j-1] = exhausted[j-1], exhausted[j]
+		}
+	}
+	return append(available, exhausted...)
+}
+
+// observe records the quota reported for key i. A 429 or an exhausted
+// quota takes the key out of rotation until the reset.
+func (p *keyPool) observe(i int, status int, rl *RateLimitInfo) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if rl != nil {
+		p.rateLimits[i] = rl
+	}
+	exhausted := status == http.StatusTooManyRequests || (rl != nil && rl.Limit > 0 && rl.Remaining <= 0)
+	if !exhausted {
+		p.exhaustedUntil[i] = time.Time{}
+		return
+	}
+	until := p.now().Add(defaultKeyCooldown)
+	if rl != nil && rl.ResetTime.After(p.now()) {
+		until = rl.ResetTime
+	}
+	p.exhaustedUntil[i] = until
+}
+
+// sendWithPool sends r with the pool's keys in rotation order, moving on to
+// the next key when one is answered with 429.
+func (t *authTransport) sendWithPool(r *http.Request) (*http.Response, error) {
+	c, pool := t.c, t.c.keys
+	ctx := r.Context()
+	rewindable := r.Body == nil || r.Body == http.NoBody || r.GetBody != nil
+
+	order := pool.order()
+	for n := 0; ; n++ {
+		i := order[n]
+		if n > 0 && r.GetBody != nil {
+			body, err := r.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			r = r.Clone(ctx)
+			r.Body = body
+		}
+		r.Header.Set("X-API-Key", pool.keys[i])
+
+		resp, err := t.next.RoundTrip(r)
+		if err != nil {
+			return nil, err
+		}
+		pool.observe(i, resp.StatusCode, c.rateLimitFrom(resp.Header, c.ClockSkew(), c.clock.Now()))
+		if resp.StatusCode != http.StatusTooManyRequests || n == len(order)-1 || !rewindable || ctx.Err() != nil {
+			return resp, nil
+		}
+		io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+		c.log(ctx, SubsystemHTTP, slog.LevelWarn, "API key rate limited, rotating",
+			"from", maskKey(pool.keys[i]), "to", maskKey(pool.keys[order[n+1]]))
+	}
+}
+
+// rateLimitFrom parses the X-RateLimit headers, or returns nil if they are
+// missing.
+func (c *Client) rateLimitFrom(h http.Header, skew time.Duration, received time.Time) *RateLimitInfo {
+	limit := h.Get("X-RateLimit-Limit")
+	remaining := h.Get("X-RateLimit-Remaining")
+	reset := h.Get("X-RateLimit-Reset")
+	if limit == "" || remaining == "" || reset == "" {
+		return nil
+	}
+	limitInt, _ := strconv.Atoi(limit)
+	remainingInt, _ := strconv.Atoi(remaining)
+	resetInt, _ := strconv.ParseInt(reset, 10, 64)
+	return &RateLimitInfo{
+		Limit:     limitInt,
+		Remaining: remainingInt,
+		Reset:     resetInt,
+		ResetTime: c.localResetTime(resetInt, skew, received),
+	}
+}
@@ -0,0 +1,82 @@
+package iptuapi
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithAPIKeysRoundRobin(t *testing.T) {
+	var mu sync.Mutex
+	var keys []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		keys = append(keys, r.Header.Get("X-API-Key"))
+		mu.Unlock()
+		w.Write([]byte(`{"sql":"000.000.0000-0"}`))
+	}))
+	defer server.Close()
+
+	client := NewClient("", WithBaseURL(server.URL), WithAPIKeys([]string{"key_a", "key_b"}, RotateRoundRobin))
+	for i := 0; i < 4; i++ {
+		_, err := client.ConsultaSQL(context.Background(), "000.000.0000-0", CidadeSaoPaulo)
+		require.NoError(t, err)
+	}
+	assert.Equal(t, []string{"key_a", "key_b", "key_a", "key_b"}, keys)
+}
+
+func TestWithAPIKeysFailover(t *testing.T) {
+	reset := strconv.FormatInt(time.Now().Add(time.Hour).Unix(), 10)
+	var keys []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := r.Header.Get("X-API-Key")
+		keys = append(keys, key)
+		w.Header().Set("X-RateLimit-Limit", "100")
+		w.Header().Set("X-RateLimit-Reset", reset)
+		if key == "key_a" {
+			w.Header().Set("X-RateLimit-Remaining", "0")
+			w.WriteHeader(http.StatusTooManyRequests)
+			w.Write([]byte(`{"detail":"Limite excedido"}`))
+			return
+		}
+		w.Header().Set("X-RateLimit-Remaining", "42")
+		w.Write([]byte(`{"sql":"000.000.0000-0"}`))
+	}))
+	defer server.Close()
+
+	client := NewClient("", WithBaseURL(server.URL),
+		WithRetry(&RetryConfig{MaxRetries: 0}), WithAPIKeys([]string{"key_a", "key_b"}, RotateFailover))
+
+	_, err := client.ConsultaSQL(context.Background(), "000.000.0000-0", CidadeSaoPaulo)
+	require.NoError(t, err, "a 429 is resent with the next key")
+	_, err = client.ConsultaSQL(context.Background(), "000.000.0000-0", CidadeSaoPaulo)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"key_a", "key_b", "key_b"}, keys, "the exhausted key is skipped until reset")
+
+	status := client.APIKeyStatus()
+	require.Len(t, status, 2)
+	assert.Equal(t, "****ey_a", status[0].Key)
+	assert.False(t, status[0].Available)
+	assert.True(t, status[1].Available)
+	assert.Equal(t, 42, status[1].RateLimit.Remaining)
+}
+
+func TestWithAPIKeysAllExhausted(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTooManyRequests)
+		w.Write([]byte(`{"detail":"Limite excedido"}`))
+	}))
+	defer server.Close()
+
+	client := NewClient("", WithBaseURL(server.URL),
+		WithRetry(&RetryConfig{MaxRetries: 0}), WithAPIKeys([]string{"key_a", "key_b"}, RotateFailover))
+	_, err := client.ConsultaSQL(context.Background(), "000.000.0000-0", CidadeSaoPaulo)
+	assert.True(t, IsRateLimit(err))
+}
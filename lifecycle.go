@@ -0,0 +1,45 @@
+package iptuapi
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+// Component is a background component that owns goroutines, such as
+// queue.Runner. Start launches the work and returns immediately; Close stops
+// accepting new work and waits for in-flight work to drain. If ctx passed to
+// Close expires first, in-flight work is cancelled and Close returns
+// ctx.Err() once the goroutines have exited.
+type Component interface {
+	Start(ctx context.Context) error
+	Close(ctx context.Context) error
+	// InFlight reports the number of work items currently being processed.
+	InFlight() int
+}
+
+// Lifecycle errors returned by Component implementations.
+var (
+	ErrAlreadyStarted = errors.New("iptuapi: component already started")
+	ErrNotStarted     = errors.New("iptuapi: component not started")
+)
+
+// Shutdown closes all components concurrently with the same deadline and
+// returns their joined errors. It is meant to be called on SIGTERM:
+//
+//	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+//	defer cancel()
+//	err := iptuapi.Shutdown(ctx, runner, monitor)
+func Shutdown(ctx context.Context, components ...Component) error {
+	errs := make([]error, len(components))
+	var wg sync.WaitGroup
+	for i, c := range components {
+		wg.Add(1)
+		go func(i int, c Component) {
+			defer wg.Done()
+			errs[i] = c.Close(ctx)
+		}(i, c)
+	}
+	wg.Wait()
+	return errors.Join(errs...)
+}
@@ -0,0 +1,84 @@
+package iptuapi
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// ResponseTooLargeError is returned when a response body exceeds the limit
+// set with WithMaxResponseBytes.
+type ResponseTooLargeError struct {
+	Limit int64
+	// Read is the number of body bytes received before the call was
+	// abandoned; zero if the Content-Length header already exceeded the
+	// limit.
+	Read int64
+	// ContentLength is the declared body size, or -1 if unknown.
+	ContentLength int64
+	RequestID     string
+}
+
+func (e *ResponseTooLargeError) Error() string {
+	if e.Read == 0 && e.ContentLength > e.Limit {
+		return fmt.Sprintf("iptuapi: response of %d bytes exceeds the %d-byte limit", e.ContentLength, e.Limit)
+	}
+	return fmt.Sprintf("iptuapi: response exceeds the %d-byte limit (%d bytes read)", e.Limit, e.Read)
+}
+
+// WithMaxResponseBytes caps the size of response bodies, so a misbehaving
+// endpoint or proxy returning a huge body cannot exhaust memory. Calls
+// whose body exceeds n bytes fail with *ResponseTooLargeError as soon as
+// the limit is crossed; streamed calls count their whole body. Zero, the
+// default, means no limit.
+func WithMaxResponseBytes(n int64) ClientOption {
+	return func(c *Client) {
+		c.maxResponseBytes = n
+	}
+}
+
+// limitTransport enforces WithMaxResponseBytes right above the base
+// transport, before any layer buffers the body.
+type limitTransport struct {
+	limit int64
+	next  http.RoundTripper
+}
+
+func (t *limitTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.next.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.ContentLength > t.limit {
+		resp.Body.Close()
+		return nil, &ResponseTooLargeError{Limit: t.limit, ContentLength: resp.ContentLength, RequestID: resp.Header.Get("X-Request-ID")}
+	}
+	resp.Body = &limitedBody{
+		ReadCloser: resp.Body,
+		err:        &ResponseTooLargeError{Limit: t.limit, ContentLength: resp.ContentLength, RequestID: resp.Header.Get("X-Request-ID")},
+	}
+	return resp, nil
+}
+
+// limitedBody fails with err once more than err.Limit bytes are read.
+type limitedBody struct {
+	io.ReadCloser
+	err *ResponseTooLargeError
+}
+
+func (b *limitedBody) Read(p []byte) (int, error) {
+	if b.err.Read > b.err.Limit {
+		return 0, b.err
+	}
+	// Read one byte past the limit to tell a body of exactly Limit bytes
+	// from a longer one.
+	if left := b.err.Limit + 1 - b.err.Read; int64(len(p)) > left {
+		p = p[:left]
+	}
+	n, err := b.ReadCloser.Read(p)
+	b.err.Read += int64(n)
+	if b.err.Read > b.err.Limit {
+		return n - 1, b.err
+	}
+	return n, err
+}
@@ -0,0 +1,72 @@
+package iptuapi
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithMaxResponseBytes(t *testing.T) {
+	body := `{"sql":"000.000.0000-0","bairro":"` + strings.Repeat("x", 100) + `"}`
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		if r.Header.Get("X-Chunked") != "" {
+			// Without Content-Length the limit is enforced while reading.
+			w.Write([]byte(body[:10]))
+			w.(http.Flusher).Flush()
+			w.Write([]byte(body[10:]))
+			return
+		}
+		w.Write([]byte(body))
+	}))
+	defer server.Close()
+
+	call := func(limit int64, opts ...RequestOption) error {
+		client := NewClient("test_key", WithBaseURL(server.URL), WithMaxResponseBytes(limit))
+		_, err := client.ConsultaSQL(context.Background(), "000.000.0000-0", CidadeSaoPaulo, opts...)
+		return err
+	}
+
+	assert.NoError(t, call(int64(len(body))), "a body of exactly the limit is accepted")
+
+	var tooLarge *ResponseTooLargeError
+	requests.Store(0)
+	err := call(50)
+	require.True(t, errors.As(err, &tooLarge), "got %v", err)
+	assert.Equal(t, int64(len(body)), tooLarge.ContentLength)
+	assert.Zero(t, tooLarge.Read)
+	assert.Equal(t, int32(1), requests.Load(), "oversized responses are not retried")
+
+	err = call(50, WithHeader("X-Chunked", "1"))
+	require.True(t, errors.As(err, &tooLarge), "got %v", err)
+	assert.Equal(t, int64(-1), tooLarge.ContentLength)
+	assert.Equal(t, int64(51), tooLarge.Read)
+	assert.Equal(t, ErrorPermanent, ClassifyError(err))
+}
+
+func TestMaxResponseBytesStream(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`[{"sql":"1"},`))
+		w.(http.Flusher).Flush()
+		w.Write([]byte(`{"sql":"2"},{"sql":"3"}]`))
+	}))
+	defer server.Close()
+
+	client := NewClient("test_key", WithBaseURL(server.URL), WithMaxResponseBytes(20))
+	var got []string
+	err := client.ConsultaCEPStream(context.Background(), "01310100", CidadeSaoPaulo, func(r ConsultaEnderecoResult) error {
+		got = append(got, r.SQL)
+		return nil
+	})
+	var tooLarge *ResponseTooLargeError
+	require.True(t, errors.As(err, &tooLarge), "got %v", err)
+	assert.Equal(t, []string{"1"}, got)
+}
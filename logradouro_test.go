@@ -0,0 +1,120 @@
+package iptuapi
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConsultaLogradouro(t *testing.T) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		assert.Equal(t, "Avenida Paulista", r.URL.Query().Get("logradouro"))
+		numero, _ := strconv.Atoi(r.URL.Query().Get("numero"))
+		switch {
+		case numero%4 == 0:
+			w.Write([]byte(`{"sql":"sql-` + strconv.Itoa(numero) + `","numero":"` + strconv.Itoa(numero) + `"}`))
+		case numero == 1001:
+			// Matched to a number outside the range.
+			w.Write([]byte(`{"sql":"sql-998","numero":"998"}`))
+		case numero == 1009:
+			// Matched to a property already found.
+			w.Write([]byte(`{"sql":"sql-1008","numero":"1008"}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"detail":"Imovel nao encontrado"}`))
+		}
+	}))
+	defer server.Close()
+
+	client := NewClient("test_key", WithBaseURL(server.URL))
+	results, err := client.ConsultaLogradouro(context.Background(), &ConsultaLogradouroParams{
+		Logradouro: "Avenida Paulista",
+		NumeroDe:   1000,
+		NumeroAte:  1010,
+	})
+	require.NoError(t, err)
+	var numeros []string
+	for _, r := range results {
+		numeros = append(numeros, r.Numero)
+	}
+	assert.Equal(t, []string{"1000", "1004", "1008"}, numeros)
+	assert.Equal(t, int32(11), requests.Load())
+}
+
+func TestConsultaLogradouroInvalidRange(t *testing.T) {
+	client := NewClient("test_key", WithBaseURL("http://127.0.0.1:1"))
+	for _, p := range []ConsultaLogradouroParams{
+		{NumeroDe: 1, NumeroAte: 10},
+		{Logradouro: "Rua Augusta", NumeroDe: 10, NumeroAte: 1},
+		{Logradouro: "Rua Augusta", NumeroDe: 1, NumeroAte: 5000},
+	} {
+		_, err := client.ConsultaLogradouro(context.Background(), &p)
+		assert.Error(t, err, "%+v", p)
+	}
+	assert.Empty(t, client.Stats().Requests, "no quota spent")
+}
+
+func TestConsultaLogradouroReturnsPartialResults(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("numero") == "2" {
+			w.WriteHeader(http.StatusForbidden)
+			w.Write([]byte(`{"detail":"Plano insuficiente"}`))
+			return
+		}
+		w.Write([]byte(`{"sql":"sql-` + r.URL.Query().Get("numero") + `","numero":"` + r.URL.Query().Get("numero") + `"}`))
+	}))
+	defer server.Close()
+
+	client := NewClient("test_key", WithBaseURL(server.URL))
+	results, err := client.ConsultaLogradouro(context.Background(), &ConsultaLogradouroParams{
+		Logradouro: "Rua Augusta", NumeroDe: 1, NumeroAte: 3, Concurrency: 1,
+	})
+	assert.True(t, IsForbidden(err))
+	assert.Len(t, results, 2)
+}
+
+func TestConsultaLogradouroLado(t *testing.T) {
+	var numeros []string
+	var mu sync.Mutex
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		numero := r.URL.Query().Get("numero")
+		mu.Lock()
+		numeros = append(numeros, numero)
+		mu.Unlock()
+		if numero == "104" {
+			// Matched to the other side of the street.
+			numero = "105"
+		}
+		w.Write([]byte(`{"sql":"sql-` + numero + `","numero":"` + numero + `"}`))
+	}))
+	defer server.Close()
+
+	client := NewClient("test_key", WithBaseURL(server.URL))
+	results, err := client.ConsultaLogradouro(context.Background(), &ConsultaLogradouroParams{
+		Logradouro: "Rua Augusta", NumeroDe: 99, NumeroAte: 108, Lado: LadoPar, Concurrency: 1,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"100", "102", "104", "106", "108"}, numeros, "odd numbers are not looked up")
+	assert.Len(t, results, 4)
+
+	numeros = nil
+	_, err = client.ConsultaLogradouro(context.Background(), &ConsultaLogradouroParams{
+		Logradouro: "Rua Augusta", NumeroDe: 100, NumeroAte: 110, Lado: LadoImpar, Passo: 2, Concurrency: 1,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"101", "105", "109"}, numeros)
+
+	_, err = client.ConsultaLogradouro(context.Background(), &ConsultaLogradouroParams{
+		Logradouro: "Rua Augusta", NumeroDe: 1, NumeroAte: 2, Lado: "esquerdo",
+	})
+	assert.ErrorContains(t, err, "unknown lado")
+}
@@ -0,0 +1,189 @@
+package iptuapi
+
+import (
+	"sort"
+	"strings"
+)
+
+// Matcher scores the similarity of two normalized address strings.
+// Scores are in [0, 1], where 1 means identical.
+type Matcher interface {
+	Similarity(a, b string) float64
+}
+
+// MatcherFunc adapts a function to the Matcher interface.
+type MatcherFunc func(a, b string) float64
+
+// Similarity calls f(a, b).
+func (f MatcherFunc) Similarity(a, b string) float64 {
+	return f(a, b)
+}
+
+// Built-in matchers.
+var (
+	// LevenshteinMatcher scores 1 - edit distance / longest length. It is the
+	// default and works well for typos.
+	LevenshteinMatcher Matcher = MatcherFunc(similarity)
+	// JaroWinklerMatcher favors strings sharing a common prefix, which suits
+	// truncated street names ("Av. Brig. Faria Lima" vs "Av. Brigadeiro...").
+	JaroWinklerMatcher Matcher = MatcherFunc(jaroWinkler)
+	// TokenSetMatcher ignores word order and duplicated words, which suits
+	// inverted names ("Paulista Avenida") and extra qualifiers.
+	TokenSetMatcher Matcher = MatcherFunc(tokenSetRatio)
+)
+
+// WithMatcher sets the string matcher used to rank address candidates
+// (default LevenshteinMatcher).
+func WithMatcher(m Matcher) ClientOption {
+	return func(c *Client) {
+		c.matcher = m
+	}
+}
+
+// AddressScore returns the similarity in [0, 1] between an input address and
+// a candidate record using m, applying the same normalization and numero
+// handling as Client.Reconcile. It lets matching strategies be evaluated
+// offline against labeled data.
+func AddressScore(m Matcher, in *ConsultaEnderecoParams, r *ConsultaEnderecoResult) float64 {
+	a, b := NormalizeAddress(in.Logradouro), NormalizeAddress(r.Logradouro)
+	score := 1.0
+	if a != b {
+		score = m.Similarity(a, b)
+	}
+
+	inNum, rNum := NormalizeNumero(in.Numero), NormalizeNumero(r.Numero)
+	if inNum != "" && rNum != "" && inNum != rNum {
+		score *= 0.5
+	}
+	return score
+}
+
+// similarity returns 1 - normalized Levenshtein distance between a and b.
+func similarity(a, b string) float64 {
+	if a == b {
+		return 1
+	}
+	ra, rb := []rune(a), []rune(b)
+	longest := max(len(ra), len(rb))
+	if longest == 0 {
+		return 1
+	}
+	return 1 - float64(levenshtein(ra, rb))/float64(longest)
+}
+
+func levenshtein(a, b []rune) int {
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(b)]
+}
+
+// jaroWinkler returns the Jaro-Winkler similarity with the standard 0.1
+// prefix scale and a prefix of at most four characters.
+func jaroWinkler(a, b string) float64 {
+	ra, rb := []rune(a), []rune(b)
+	if len(ra) == 0 && len(rb) == 0 {
+		return 1
+	}
+	if len(ra) == 0 || len(rb) == 0 {
+		return 0
+	}
+
+	window := max(len(ra), len(rb))/2 - 1
+	if window < 0 {
+		window = 0
+	}
+	matchedA := make([]bool, len(ra))
+	matchedB := make([]bool, len(rb))
+	matches := 0
+	for i := range ra {
+		lo, hi := max(0, i-window), min(len(rb), i+window+1)
+		for j := lo; j < hi; j++ {
+			if !matchedB[j] && ra[i] == rb[j] {
+				matchedA[i], matchedB[j] = true, true
+				matches++
+				break
+			}
+		}
+	}
+	if matches == 0 {
+		return 0
+	}
+
+	transpositions, j := 0, 0
+	for i := range ra {
+		if !matchedA[i] {
+			continue
+		}
+		for !matchedB[j] {
+			j++
+		}
+		if ra[i] != rb[j] {
+			transpositions++
+		}
+		j++
+	}
+
+	m := float64(matches)
+	jaro := (m/float64(len(ra)) + m/float64(len(rb)) + (m-float64(transpositions/2))/m) / 3
+
+	prefix := 0
+	for prefix < min(4, len(ra), len(rb)) && ra[prefix] == rb[prefix] {
+		prefix++
+	}
+	return jaro + float64(prefix)*0.1*(1-jaro)
+}
+
+// tokenSetRatio compares the sorted word sets of a and b, scoring the best
+// Levenshtein similarity between the shared words and each side's full set.
+func tokenSetRatio(a, b string) float64 {
+	setA, setB := tokenSet(a), tokenSet(b)
+
+	var inter, onlyA, onlyB []string
+	for w := range setA {
+		if setB[w] {
+			inter = append(inter, w)
+		} else {
+			onlyA = append(onlyA, w)
+		}
+	}
+	for w := range setB {
+		if !setA[w] {
+			onlyB = append(onlyB, w)
+		}
+	}
+	sort.Strings(inter)
+	sort.Strings(onlyA)
+	sort.Strings(onlyB)
+
+	base := strings.Join(inter, " ")
+	withA := strings.TrimSpace(base + " " + strings.Join(onlyA, " "))
+	withB := strings.TrimSpace(base + " " + strings.Join(onlyB, " "))
+
+	best := similarity(withA, withB)
+	if base != "" {
+		best = max(best, similarity(base, withA), similarity(base, withB))
+	}
+	return best
+}
+
+func tokenSet(s string) map[string]bool {
+	set := make(map[string]bool)
+	for _, w := range strings.Fields(s) {
+		set[w] = true
+	}
+	return set
+}
@@ -0,0 +1,33 @@
+package iptuapi
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMatchers(t *testing.T) {
+	assert.Equal(t, 1.0, LevenshteinMatcher.Similarity("rua augusta", "rua augusta"))
+	assert.InDelta(t, 0.909, LevenshteinMatcher.Similarity("rua augusta", "rua agusta"), 0.01)
+
+	assert.InDelta(t, 0.961, JaroWinklerMatcher.Similarity("martha", "marhta"), 0.001)
+	assert.Equal(t, 0.0, JaroWinklerMatcher.Similarity("abc", ""))
+
+	assert.Equal(t, 1.0, TokenSetMatcher.Similarity("paulista avenida", "avenida paulista"))
+	assert.Equal(t, 1.0, TokenSetMatcher.Similarity("avenida paulista", "avenida paulista bela vista"))
+	assert.Less(t, LevenshteinMatcher.Similarity("paulista avenida", "avenida paulista"), 0.8)
+}
+
+func TestAddressScoreWithMatcher(t *testing.T) {
+	in := &ConsultaEnderecoParams{Logradouro: "Paulista, Av.", Numero: "1000"}
+	r := &ConsultaEnderecoResult{Logradouro: "Avenida Paulista", Numero: "1000"}
+
+	assert.Equal(t, 1.0, AddressScore(TokenSetMatcher, in, r))
+	assert.Less(t, AddressScore(LevenshteinMatcher, in, r), 0.8)
+
+	r.Numero = "1002"
+	assert.Equal(t, 0.5, AddressScore(TokenSetMatcher, in, r))
+
+	c := NewClient("test_key", WithMatcher(MatcherFunc(func(a, b string) float64 { return 0.42 })))
+	assert.Equal(t, 0.42, c.matcher.Similarity("x", "y"))
+}
@@ -0,0 +1,34 @@
+package iptuapi
+
+import "net/http"
+
+// RoundTripFunc sends one request. It implements http.RoundTripper.
+type RoundTripFunc func(*http.Request) (*http.Response, error)
+
+// RoundTrip calls f(req).
+func (f RoundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+// Middleware intercepts requests and responses, e.g. for audit logging,
+// header injection or metrics.
+type Middleware func(next RoundTripFunc) RoundTripFunc
+
+// Use appends middleware to the client's chain. Every endpoint method runs
+// through the chain, except calls answered from the cache. Middleware sits
+// with the wrappers of WithTransportWrapper (see Client.Transport): the
+// first registered is the outermost, each call is seen once before
+// retries, and the client's API key is not visible. A middleware may set
+// the X-API-Key header itself, e.g. to rotate keys; the client then leaves
+// it unchanged.
+//
+// Use is not safe for concurrent use with calls; register middleware
+// before making requests.
+func (c *Client) Use(mw ...Middleware) {
+	for _, m := range mw {
+		m := m
+		c.wrappers = append(c.wrappers, func(next http.RoundTripper) http.RoundTripper {
+			return m(next.RoundTrip)
+		})
+	}
+}
@@ -0,0 +1,77 @@
+package iptuapi
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUseMiddleware(t *testing.T) {
+	var attempts int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "trace-1", r.Header.Get("X-Trace-ID"))
+		assert.Equal(t, "test_key", r.Header.Get("X-API-Key"))
+		if atomic.AddInt32(&attempts, 1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte(`{"sql":"000.000.0000-0"}`))
+	}))
+	defer server.Close()
+
+	client := NewClient("test_key", WithBaseURL(server.URL),
+		WithRetry(&RetryConfig{MaxRetries: 1, RetryableStatus: []int{503}}))
+
+	var order []string
+	var seen []int
+	client.Use(
+		func(next RoundTripFunc) RoundTripFunc {
+			return func(req *http.Request) (*http.Response, error) {
+				order = append(order, "outer")
+				assert.Empty(t, req.Header.Get("X-API-Key"), "middleware does not see the key")
+				req.Header.Set("X-Trace-ID", "trace-1")
+				resp, err := next(req)
+				if resp != nil {
+					seen = append(seen, resp.StatusCode)
+				}
+				return resp, err
+			}
+		},
+		func(next RoundTripFunc) RoundTripFunc {
+			return func(req *http.Request) (*http.Response, error) {
+				order = append(order, "inner")
+				return next(req)
+			}
+		},
+	)
+
+	_, err := client.ConsultaSQL(context.Background(), "000.000.0000-0", CidadeSaoPaulo)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"outer", "inner"}, order)
+	assert.Equal(t, []int{200}, seen, "middleware runs once per call, outside retries")
+	assert.Equal(t, int32(2), atomic.LoadInt32(&attempts))
+}
+
+func TestMiddlewareKeyRotation(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "rotated_key", r.Header.Get("X-API-Key"))
+		w.Write([]byte(`{"sql":"000.000.0000-0"}`))
+	}))
+	defer server.Close()
+
+	client := NewClient("test_key", WithBaseURL(server.URL))
+	client.Use(func(next RoundTripFunc) RoundTripFunc {
+		return func(req *http.Request) (*http.Response, error) {
+			req = req.Clone(req.Context())
+			req.Header.Set("X-API-Key", "rotated_key")
+			return next(req)
+		}
+	})
+	_, err := client.ConsultaSQL(context.Background(), "000.000.0000-0", CidadeSaoPaulo)
+	require.NoError(t, err)
+}
@@ -0,0 +1,282 @@
+// Package mirror keeps a local copy of queried IPTU records.
+//
+// A Mirror serves lookups from a local Store (SQLite via SQLStore) and falls
+// back to the API on a miss, storing what it fetched. Sync downloads a list
+// of SQLs incrementally, skipping records that are already fresh, and
+// SyncCEP refreshes whole CEPs differentially, transferring only records
+// updated since the previous run.
+//
+// Example:
+//
+//	db, _ := sql.Open("sqlite", "mirror.db")
+//	store, _ := mirror.NewSQLStore(ctx, db)
+//	m := mirror.New(client, store, mirror.WithMaxAge(30*24*time.Hour))
+//	result, err := m.ConsultaSQL(ctx, "000.000.0000-0", iptuapi.CidadeSaoPaulo)
+package mirror
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	iptuapi "github.com/raphaeltorquat0/iptuapi-go"
+)
+
+// Mirror serves lookups from a local store with API fallback.
+type Mirror struct {
+	client *iptuapi.Client
+	store  Store
+	maxAge time.Duration
+	now    func() time.Time
+}
+
+// Option configures a Mirror.
+type Option func(*Mirror)
+
+// WithMaxAge sets how long a stored record is served before it is fetched
+// again. Zero (the default) serves stored records forever.
+func WithMaxAge(d time.Duration) Option {
+	return func(m *Mirror) {
+		m.maxAge = d
+	}
+}
+
+// New creates a Mirror.
+func New(client *iptuapi.Client, store Store, opts ...Option) *Mirror {
+	m := &Mirror{
+		client: client,
+		store:  store,
+		now:    time.Now,
+	}
+	for _, opt := range opts {
+		opt(m)
+	}
+	return m
+}
+
+// Store returns the underlying store.
+func (m *Mirror) Store() Store {
+	return m.store
+}
+
+// fresh reports whether rec may be served; iptuapi.Flags asking to bypass
+// the cache make every record stale.
+func (m *Mirror) fresh(ctx context.Context, rec *Record) bool {
+	if f := iptuapi.FlagsFrom(ctx); f.DisableCache || f.ForceRefresh {
+		return false
+	}
+	return rec != nil && (m.maxAge <= 0 || m.now().Sub(rec.FetchedAt) < m.maxAge)
+}
+
+func cidadeOrDefault(cidade iptuapi.Cidade) iptuapi.Cidade {
+	if cidade == "" {
+		return iptuapi.CidadeSaoPaulo
+	}
+	return cidade
+}
+
+// ConsultaSQL returns the record for sql, from the store when fresh.
+func (m *Mirror) ConsultaSQL(ctx context.Context, sql string, cidade iptuapi.Cidade) (*iptuapi.ConsultaSQLResult, error) {
+	cidade = cidadeOrDefault(cidade)
+
+	rec, err := m.store.Get(ctx, cidade, sql, KindSQL)
+	if err != nil {
+		return nil, err
+	}
+	if m.fresh(ctx, rec) {
+		var result iptuapi.ConsultaSQLResult
+		if err := json.Unmarshal(rec.Data, &result); err == nil {
+			return &result, nil
+		}
+	}
+	return m.fetchSQL(ctx, sql, cidade)
+}
+
+func (m *Mirror) fetchSQL(ctx context.Context, sql string, cidade iptuapi.Cidade) (*iptuapi.ConsultaSQLResult, error) {
+	result, err := m.client.ConsultaSQL(ctx, sql, cidade)
+	if err != nil {
+		return nil, err
+	}
+	data, err := json.Marshal(result)
+	if err != nil {
+		return nil, err
+	}
+	key := result.SQL
+	if key == "" {
+		key = sql
+	}
+	err = m.store.Put(ctx, Record{
+		Cidade:     cidade,
+		SQL:        key,
+		Kind:       KindSQL,
+		Logradouro: result.Logradouro,
+		Numero:     result.Numero,
+		Bairro:     result.Bairro,
+		Data:       data,
+		FetchedAt:  m.now(),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// ConsultaEndereco returns the record at the given address, from the store
+// when fresh. Queries asking for historico, comparaveis or zoneamento always
+// go to the API, since stored payloads may not include those sections.
+func (m *Mirror) ConsultaEndereco(ctx context.Context, p *iptuapi.ConsultaEnderecoParams) (*iptuapi.ConsultaEnderecoResult, error) {
+	cidade := cidadeOrDefault(p.Cidade)
+	enriched := p.IncluirHistorico || p.IncluirComparaveis || p.IncluirZoneamento
+
+	if !enriched {
+		recs, err := m.store.FindByEndereco(ctx, cidade, p.Logradouro, p.Numero)
+		if err != nil {
+			return nil, err
+		}
+		for i := range recs {
+			rec := &recs[i]
+			if rec.Kind != KindEndereco || rec.Complemento != p.Complemento || !m.fresh(ctx, rec) {
+				continue
+			}
+			var result iptuapi.ConsultaEnderecoResult
+			if err := json.Unmarshal(rec.Data, &result); err == nil {
+				return &result, nil
+			}
+		}
+	}
+
+	result, err := m.client.ConsultaEndereco(ctx, p)
+	if err != nil {
+		return nil, err
+	}
+	data, err := json.Marshal(result)
+	if err != nil {
+		return nil, err
+	}
+	err = m.store.Put(ctx, Record{
+		Cidade:      cidade,
+		SQL:         result.SQL,
+		Kind:        KindEndereco,
+		Logradouro:  p.Logradouro,
+		Numero:      p.Numero,
+		Complemento: p.Complemento,
+		Bairro:      result.Bairro,
+		Data:        data,
+		FetchedAt:   m.now(),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// SyncStats summarizes a Sync run.
+type SyncStats struct {
+	Total   int               `json:"total"`
+	Fetched int               `json:"fetched"`
+	Skipped int               `json:"skipped"`
+	Failed  int               `json:"failed"`
+	Errors  map[string]string `json:"errors,omitempty"`
+}
+
+// Sync downloads the given SQLs into the store, skipping records that are
+// still fresh. Lookup failures are recorded in the stats and do not stop
+// the run; store errors and context cancellation do.
+func (m *Mirror) Sync(ctx context.Context, cidade iptuapi.Cidade, sqls []string) (SyncStats, error) {
+	cidade = cidadeOrDefault(cidade)
+	stats := SyncStats{Total: len(sqls)}
+
+	for _, sql := range sqls {
+		if err := ctx.Err(); err != nil {
+			return stats, err
+		}
+
+		rec, err := m.store.Get(ctx, cidade, sql, KindSQL)
+		if err != nil {
+			return stats, err
+		}
+		if m.fresh(ctx, rec) {
+			stats.Skipped++
+			continue
+		}
+
+		if _, err := m.fetchSQL(ctx, sql, cidade); err != nil {
+			if ctx.Err() != nil {
+				return stats, ctx.Err()
+			}
+			stats.Failed++
+			if stats.Errors == nil {
+				stats.Errors = make(map[string]string)
+			}
+			stats.Errors[sql] = err.Error()
+			continue
+		}
+		stats.Fetched++
+	}
+	return stats, nil
+}
+
+// SyncCEP downloads every record of the given CEPs. After the first run each
+// CEP is refreshed differentially with iptuapi.WithUpdatedSince, so only
+// records changed since its last successful sync are transferred.
+func (m *Mirror) SyncCEP(ctx context.Context, cidade iptuapi.Cidade, ceps []string) (SyncStats, error) {
+	cidade = cidadeOrDefault(cidade)
+	stats := SyncStats{Total: len(ceps)}
+
+	for _, cep := range ceps {
+		if err := ctx.Err(); err != nil {
+			return stats, err
+		}
+
+		scope := "cep:" + string(cidade) + ":" + cep
+		since, err := m.store.LastSync(ctx, scope)
+		if err != nil {
+			return stats, err
+		}
+
+		started := m.now()
+		results, err := m.client.ConsultaCEP(ctx, cep, cidade, iptuapi.WithUpdatedSince(since))
+		if err != nil {
+			if ctx.Err() != nil {
+				return stats, ctx.Err()
+			}
+			stats.Failed++
+			if stats.Errors == nil {
+				stats.Errors = make(map[string]string)
+			}
+			stats.Errors[cep] = err.Error()
+			continue
+		}
+
+		for i := range results {
+			r := &results[i]
+			data, err := json.Marshal(r)
+			if err != nil {
+				return stats, err
+			}
+			err = m.store.Put(ctx, Record{
+				Cidade:      cidade,
+				SQL:         r.SQL,
+				Kind:        KindEndereco,
+				Logradouro:  r.Logradouro,
+				Numero:      r.Numero,
+				Complemento: r.Complemento,
+				Bairro:      r.Bairro,
+				Data:        data,
+				FetchedAt:   started,
+			})
+			if err != nil {
+				return stats, err
+			}
+		}
+		if err := m.store.SetLastSync(ctx, scope, started); err != nil {
+			return stats, err
+		}
+		if len(results) == 0 {
+			stats.Skipped++
+		} else {
+			stats.Fetched++
+		}
+	}
+	return stats, nil
+}
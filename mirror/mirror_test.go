@@ -0,0 +1,261 @@
+package mirror
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	iptuapi "github.com/raphaeltorquat0/iptuapi-go"
+)
+
+// memStore is an in-memory Store used to exercise Mirror without a SQLite driver.
+type memStore struct {
+	mu    sync.Mutex
+	recs  map[string]Record
+	syncs map[string]time.Time
+}
+
+func newMemStore() *memStore {
+	return &memStore{recs: make(map[string]Record), syncs: make(map[string]time.Time)}
+}
+
+func (s *memStore) key(cidade iptuapi.Cidade, sql, kind string) string {
+	return string(cidade) + "|" + sql + "|" + kind
+}
+
+func (s *memStore) Get(ctx context.Context, cidade iptuapi.Cidade, sql, kind string) (*Record, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	rec, ok := s.recs[s.key(cidade, sql, kind)]
+	if !ok {
+		return nil, nil
+	}
+	return &rec, nil
+}
+
+func (s *memStore) FindByEndereco(ctx context.Context, cidade iptuapi.Cidade, logradouro, numero string) ([]Record, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var out []Record
+	for _, rec := range s.recs {
+		if rec.Cidade == cidade && strings.EqualFold(rec.Logradouro, logradouro) && rec.Numero == numero {
+			out = append(out, rec)
+		}
+	}
+	return out, nil
+}
+
+func (s *memStore) FindByBairro(ctx context.Context, cidade iptuapi.Cidade, bairro string) ([]Record, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var out []Record
+	for _, rec := range s.recs {
+		if rec.Cidade == cidade && strings.EqualFold(rec.Bairro, bairro) {
+			out = append(out, rec)
+		}
+	}
+	return out, nil
+}
+
+func (s *memStore) Put(ctx context.Context, rec Record) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.recs[s.key(rec.Cidade, rec.SQL, rec.Kind)] = rec
+	return nil
+}
+
+func (s *memStore) LastSync(ctx context.Context, scope string) (time.Time, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.syncs[scope], nil
+}
+
+func (s *memStore) SetLastSync(ctx context.Context, scope string, t time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.syncs[scope] = t
+	return nil
+}
+
+func newTestServer(t *testing.T, hits *int) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		*hits++
+		switch {
+		case r.URL.Path == "/consulta/endereco":
+			json.NewEncoder(w).Encode(iptuapi.ConsultaEnderecoResult{
+				SQL:        "000.000.0000-0",
+				Logradouro: "Avenida Paulista",
+				Numero:     "1000",
+				Bairro:     "Bela Vista",
+			})
+		case strings.HasPrefix(r.URL.Path, "/consulta/sql/"):
+			sql := strings.TrimPrefix(r.URL.Path, "/consulta/sql/")
+			if sql == "missing" {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			json.NewEncoder(w).Encode(iptuapi.ConsultaSQLResult{SQL: sql, Bairro: "Bela Vista"})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+}
+
+func newTestClient(url string) *iptuapi.Client {
+	return iptuapi.NewClient("test_key",
+		iptuapi.WithBaseURL(url),
+		iptuapi.WithRetry(&iptuapi.RetryConfig{MaxRetries: 0}),
+	)
+}
+
+func TestConsultaSQLServesFromStore(t *testing.T) {
+	hits := 0
+	server := newTestServer(t, &hits)
+	defer server.Close()
+
+	m := New(newTestClient(server.URL), newMemStore())
+	ctx := context.Background()
+
+	first, err := m.ConsultaSQL(ctx, "111", "")
+	require.NoError(t, err)
+	second, err := m.ConsultaSQL(ctx, "111", iptuapi.CidadeSaoPaulo)
+	require.NoError(t, err)
+
+	assert.Equal(t, 1, hits)
+	assert.Equal(t, first, second)
+}
+
+func TestMaxAgeRefetches(t *testing.T) {
+	hits := 0
+	server := newTestServer(t, &hits)
+	defer server.Close()
+
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	m := New(newTestClient(server.URL), newMemStore(), WithMaxAge(time.Hour))
+	m.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	_, err := m.ConsultaSQL(ctx, "111", "")
+	require.NoError(t, err)
+	now = now.Add(30 * time.Minute)
+	_, err = m.ConsultaSQL(ctx, "111", "")
+	require.NoError(t, err)
+	assert.Equal(t, 1, hits)
+
+	now = now.Add(time.Hour)
+	_, err = m.ConsultaSQL(ctx, "111", "")
+	require.NoError(t, err)
+	assert.Equal(t, 2, hits)
+}
+
+func TestForceRefreshFlagRefetches(t *testing.T) {
+	hits := 0
+	server := newTestServer(t, &hits)
+	defer server.Close()
+
+	m := New(newTestClient(server.URL), newMemStore())
+	ctx := context.Background()
+
+	_, err := m.ConsultaSQL(ctx, "111", "")
+	require.NoError(t, err)
+	_, err = m.ConsultaSQL(iptuapi.WithFlags(ctx, iptuapi.Flags{ForceRefresh: true}), "111", "")
+	require.NoError(t, err)
+	assert.Equal(t, 2, hits)
+}
+
+func TestConsultaEndereco(t *testing.T) {
+	hits := 0
+	server := newTestServer(t, &hits)
+	defer server.Close()
+
+	store := newMemStore()
+	m := New(newTestClient(server.URL), store)
+	ctx := context.Background()
+	p := &iptuapi.ConsultaEnderecoParams{Logradouro: "Avenida Paulista", Numero: "1000"}
+
+	_, err := m.ConsultaEndereco(ctx, p)
+	require.NoError(t, err)
+	result, err := m.ConsultaEndereco(ctx, &iptuapi.ConsultaEnderecoParams{Logradouro: "avenida paulista", Numero: "1000"})
+	require.NoError(t, err)
+	assert.Equal(t, "000.000.0000-0", result.SQL)
+	assert.Equal(t, 1, hits)
+
+	byBairro, err := store.FindByBairro(ctx, iptuapi.CidadeSaoPaulo, "bela vista")
+	require.NoError(t, err)
+	assert.Len(t, byBairro, 1)
+
+	// Enriched queries always hit the API.
+	p.IncluirHistorico = true
+	_, err = m.ConsultaEndereco(ctx, p)
+	require.NoError(t, err)
+	assert.Equal(t, 2, hits)
+}
+
+func TestSync(t *testing.T) {
+	hits := 0
+	server := newTestServer(t, &hits)
+	defer server.Close()
+
+	m := New(newTestClient(server.URL), newMemStore())
+	ctx := context.Background()
+
+	stats, err := m.Sync(ctx, iptuapi.CidadeSaoPaulo, []string{"1", "2", "missing"})
+	require.NoError(t, err)
+	assert.Equal(t, 3, stats.Total)
+	assert.Equal(t, 2, stats.Fetched)
+	assert.Equal(t, 1, stats.Failed)
+	assert.Contains(t, stats.Errors, "missing")
+
+	stats, err = m.Sync(ctx, iptuapi.CidadeSaoPaulo, []string{"1", "2", "3"})
+	require.NoError(t, err)
+	assert.Equal(t, 2, stats.Skipped)
+	assert.Equal(t, 1, stats.Fetched)
+}
+
+func TestSyncCEPIsDifferential(t *testing.T) {
+	var sinces []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		since := r.URL.Query().Get("updated_since")
+		sinces = append(sinces, since)
+		if since != "" {
+			json.NewEncoder(w).Encode([]iptuapi.ConsultaEnderecoResult{})
+			return
+		}
+		json.NewEncoder(w).Encode([]iptuapi.ConsultaEnderecoResult{
+			{SQL: "1", Logradouro: "Avenida Paulista", Numero: "1000", Bairro: "Bela Vista"},
+			{SQL: "2", Logradouro: "Avenida Paulista", Numero: "1002", Bairro: "Bela Vista"},
+		})
+	}))
+	defer server.Close()
+
+	store := newMemStore()
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	m := New(newTestClient(server.URL), store)
+	m.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	stats, err := m.SyncCEP(ctx, iptuapi.CidadeSaoPaulo, []string{"01310-100"})
+	require.NoError(t, err)
+	assert.Equal(t, 1, stats.Fetched)
+
+	recs, err := store.FindByBairro(ctx, iptuapi.CidadeSaoPaulo, "Bela Vista")
+	require.NoError(t, err)
+	assert.Len(t, recs, 2)
+
+	now = now.Add(24 * time.Hour)
+	stats, err = m.SyncCEP(ctx, iptuapi.CidadeSaoPaulo, []string{"01310-100"})
+	require.NoError(t, err)
+	assert.Equal(t, 1, stats.Skipped)
+
+	require.Len(t, sinces, 2)
+	assert.Empty(t, sinces[0])
+	assert.Equal(t, "2026-03-01T12:00:00Z", sinces[1])
+}
@@ -0,0 +1,186 @@
+package mirror
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"time"
+
+	iptuapi "github.com/raphaeltorquat0/iptuapi-go"
+)
+
+// Record kinds, matching the API lookup that produced the payload.
+const (
+	KindSQL      = "sql"
+	KindEndereco = "endereco"
+)
+
+// Record is a property payload stored in the mirror.
+type Record struct {
+	Cidade      iptuapi.Cidade
+	SQL         string
+	Kind        string
+	Logradouro  string
+	Numero      string
+	Complemento string
+	Bairro      string
+	Data        json.RawMessage
+	FetchedAt   time.Time
+}
+
+// Store persists mirrored records.
+//
+// Get returns (nil, nil) when the record is not present. LastSync returns the
+// zero time for scopes that were never synced.
+type Store interface {
+	Get(ctx context.Context, cidade iptuapi.Cidade, sql, kind string) (*Record, error)
+	FindByEndereco(ctx context.Context, cidade iptuapi.Cidade, logradouro, numero string) ([]Record, error)
+	FindByBairro(ctx context.Context, cidade iptuapi.Cidade, bairro string) ([]Record, error)
+	Put(ctx context.Context, rec Record) error
+	LastSync(ctx context.Context, scope string) (time.Time, error)
+	SetLastSync(ctx context.Context, scope string, t time.Time) error
+}
+
+const schema = `
+CREATE TABLE IF NOT EXISTS imoveis (
+	cidade      TEXT    NOT NULL,
+	sql         TEXT    NOT NULL,
+	kind        TEXT    NOT NULL,
+	logradouro  TEXT    NOT NULL DEFAULT '',
+	numero      TEXT    NOT NULL DEFAULT '',
+	complemento TEXT    NOT NULL DEFAULT '',
+	bairro      TEXT    NOT NULL DEFAULT '',
+	data        TEXT    NOT NULL,
+	fetched_at  INTEGER NOT NULL,
+	PRIMARY KEY (cidade, sql, kind)
+);
+CREATE INDEX IF NOT EXISTS imoveis_sql ON imoveis (sql);
+CREATE INDEX IF NOT EXISTS imoveis_logradouro ON imoveis (cidade, logradouro COLLATE NOCASE, numero);
+CREATE INDEX IF NOT EXISTS imoveis_bairro ON imoveis (cidade, bairro COLLATE NOCASE);
+CREATE TABLE IF NOT EXISTS sync_state (
+	scope     TEXT    PRIMARY KEY,
+	synced_at INTEGER NOT NULL
+);
+`
+
+// SQLStore is a Store backed by a SQLite database.
+//
+// The SDK does not link a SQLite driver; open db with the driver of your
+// choice (for example modernc.org/sqlite or github.com/mattn/go-sqlite3).
+type SQLStore struct {
+	db *sql.DB
+}
+
+// NewSQLStore creates the mirror schema in db if needed.
+func NewSQLStore(ctx context.Context, db *sql.DB) (*SQLStore, error) {
+	if _, err := db.ExecContext(ctx, schema); err != nil {
+		return nil, err
+	}
+	return &SQLStore{db: db}, nil
+}
+
+// DB returns the underlying database handle.
+func (s *SQLStore) DB() *sql.DB {
+	return s.db
+}
+
+const selectColumns = `SELECT cidade, sql, kind, logradouro, numero, complemento, bairro, data, fetched_at FROM imoveis`
+
+func (s *SQLStore) Get(ctx context.Context, cidade iptuapi.Cidade, sqlNum, kind string) (*Record, error) {
+	row := s.db.QueryRowContext(ctx, selectColumns+` WHERE cidade = ? AND sql = ? AND kind = ?`,
+		string(cidade), sqlNum, kind)
+	rec, err := scanRecord(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &rec, nil
+}
+
+func (s *SQLStore) FindByEndereco(ctx context.Context, cidade iptuapi.Cidade, logradouro, numero string) ([]Record, error) {
+	return s.query(ctx, selectColumns+` WHERE cidade = ? AND logradouro = ? COLLATE NOCASE AND numero = ? ORDER BY sql, kind`,
+		string(cidade), logradouro, numero)
+}
+
+func (s *SQLStore) FindByBairro(ctx context.Context, cidade iptuapi.Cidade, bairro string) ([]Record, error) {
+	return s.query(ctx, selectColumns+` WHERE cidade = ? AND bairro = ? COLLATE NOCASE ORDER BY sql, kind`,
+		string(cidade), bairro)
+}
+
+func (s *SQLStore) Put(ctx context.Context, rec Record) error {
+	_, err := s.db.ExecContext(ctx, `
+INSERT INTO imoveis (cidade, sql, kind, logradouro, numero, complemento, bairro, data, fetched_at)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+ON CONFLICT (cidade, sql, kind) DO UPDATE SET
+	logradouro = excluded.logradouro,
+	numero = excluded.numero,
+	complemento = excluded.complemento,
+	bairro = excluded.bairro,
+	data = excluded.data,
+	fetched_at = excluded.fetched_at`,
+		string(rec.Cidade), rec.SQL, rec.Kind, rec.Logradouro, rec.Numero, rec.Complemento,
+		rec.Bairro, string(rec.Data), rec.FetchedAt.Unix())
+	return err
+}
+
+func (s *SQLStore) LastSync(ctx context.Context, scope string) (time.Time, error) {
+	var syncedAt int64
+	err := s.db.QueryRowContext(ctx, `SELECT synced_at FROM sync_state WHERE scope = ?`, scope).Scan(&syncedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return time.Time{}, nil
+	}
+	if err != nil {
+		return time.Time{}, err
+	}
+	return time.Unix(syncedAt, 0), nil
+}
+
+func (s *SQLStore) SetLastSync(ctx context.Context, scope string, t time.Time) error {
+	_, err := s.db.ExecContext(ctx, `
+INSERT INTO sync_state (scope, synced_at) VALUES (?, ?)
+ON CONFLICT (scope) DO UPDATE SET synced_at = excluded.synced_at`, scope, t.Unix())
+	return err
+}
+
+func (s *SQLStore) query(ctx context.Context, query string, args ...interface{}) ([]Record, error) {
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var recs []Record
+	for rows.Next() {
+		rec, err := scanRecord(rows)
+		if err != nil {
+			return nil, err
+		}
+		recs = append(recs, rec)
+	}
+	return recs, rows.Err()
+}
+
+type scanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanRecord(row scanner) (Record, error) {
+	var (
+		rec       Record
+		cidade    string
+		data      string
+		fetchedAt int64
+	)
+	err := row.Scan(&cidade, &rec.SQL, &rec.Kind, &rec.Logradouro, &rec.Numero,
+		&rec.Complemento, &rec.Bairro, &data, &fetchedAt)
+	if err != nil {
+		return Record{}, err
+	}
+	rec.Cidade = iptuapi.Cidade(cidade)
+	rec.Data = json.RawMessage(data)
+	rec.FetchedAt = time.Unix(fetchedAt, 0)
+	return rec, nil
+}
@@ -0,0 +1,91 @@
+package mirror
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	_ "modernc.org/sqlite"
+
+	iptuapi "github.com/raphaeltorquat0/iptuapi-go"
+)
+
+func newSQLStore(t *testing.T) *SQLStore {
+	t.Helper()
+	db, err := sql.Open("sqlite", filepath.Join(t.TempDir(), "mirror.db"))
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+	store, err := NewSQLStore(context.Background(), db)
+	require.NoError(t, err)
+	return store
+}
+
+func TestSQLStore(t *testing.T) {
+	ctx := context.Background()
+	store := newSQLStore(t)
+	fetched := time.Unix(1700000000, 0)
+
+	rec, err := store.Get(ctx, iptuapi.CidadeSaoPaulo, "000.000.0001-1", KindSQL)
+	require.NoError(t, err)
+	assert.Nil(t, rec)
+
+	put := func(sqlNum, kind, bairro string) {
+		require.NoError(t, store.Put(ctx, Record{
+			Cidade: iptuapi.CidadeSaoPaulo, SQL: sqlNum, Kind: kind,
+			Logradouro: "Avenida Paulista", Numero: "1000", Bairro: bairro,
+			Data: json.RawMessage(`{"sql":"` + sqlNum + `"}`), FetchedAt: fetched,
+		}))
+	}
+	put("000.000.0002-1", KindEndereco, "Bela Vista")
+	put("000.000.0001-1", KindSQL, "Cerqueira Cesar")
+	put("000.000.0001-1", KindSQL, "Bela Vista")
+
+	rec, err = store.Get(ctx, iptuapi.CidadeSaoPaulo, "000.000.0001-1", KindSQL)
+	require.NoError(t, err)
+	require.NotNil(t, rec)
+	assert.Equal(t, "Bela Vista", rec.Bairro, "Put replaces the record")
+	assert.JSONEq(t, `{"sql":"000.000.0001-1"}`, string(rec.Data))
+	assert.True(t, fetched.Equal(rec.FetchedAt))
+
+	recs, err := store.FindByEndereco(ctx, iptuapi.CidadeSaoPaulo, "AVENIDA PAULISTA", "1000")
+	require.NoError(t, err)
+	require.Len(t, recs, 2)
+	assert.Equal(t, "000.000.0001-1", recs[0].SQL)
+
+	recs, err = store.FindByBairro(ctx, iptuapi.CidadeSaoPaulo, "bela vista")
+	require.NoError(t, err)
+	assert.Len(t, recs, 2)
+	recs, err = store.FindByBairro(ctx, iptuapi.CidadeRecife, "Bela Vista")
+	require.NoError(t, err)
+	assert.Empty(t, recs)
+
+	last, err := store.LastSync(ctx, "cep:01310100")
+	require.NoError(t, err)
+	assert.True(t, last.IsZero())
+	require.NoError(t, store.SetLastSync(ctx, "cep:01310100", fetched))
+	require.NoError(t, store.SetLastSync(ctx, "cep:01310100", fetched.Add(time.Hour)))
+	last, err = store.LastSync(ctx, "cep:01310100")
+	require.NoError(t, err)
+	assert.True(t, fetched.Add(time.Hour).Equal(last))
+}
+
+func TestSQLStoreBacksMirror(t *testing.T) {
+	hits := 0
+	server := newTestServer(t, &hits)
+	defer server.Close()
+
+	m := New(newTestClient(server.URL), newSQLStore(t))
+	ctx := context.Background()
+
+	first, err := m.ConsultaSQL(ctx, "111", iptuapi.CidadeSaoPaulo)
+	require.NoError(t, err)
+	second, err := m.ConsultaSQL(ctx, "111", iptuapi.CidadeSaoPaulo)
+	require.NoError(t, err)
+	assert.Equal(t, 1, hits)
+	assert.Equal(t, first, second)
+}
@@ -0,0 +1,91 @@
+package iptuapi
+
+import (
+	"strings"
+	"unicode"
+)
+
+// accentFolding maps accented Latin characters to their ASCII base letter.
+var accentFolding = map[rune]rune{
+	'á': 'a', 'à': 'a', 'â': 'a', 'ã': 'a', 'ä': 'a',
+	'é': 'e', 'è': 'e', 'ê': 'e', 'ë': 'e',
+	'í': 'i', 'ì': 'i', 'î': 'i', 'ï': 'i',
+	'ó': 'o', 'ò': 'o', 'ô': 'o', 'õ': 'o', 'ö': 'o',
+	'ú': 'u', 'ù': 'u', 'û': 'u', 'ü': 'u',
+	'ç': 'c', 'ñ': 'n',
+	'Á': 'A', 'À': 'A', 'Â': 'A', 'Ã': 'A', 'Ä': 'A',
+	'É': 'E', 'È': 'E', 'Ê': 'E', 'Ë': 'E',
+	'Í': 'I', 'Ì': 'I', 'Î': 'I', 'Ï': 'I',
+	'Ó': 'O', 'Ò': 'O', 'Ô': 'O', 'Õ': 'O', 'Ö': 'O',
+	'Ú': 'U', 'Ù': 'U', 'Û': 'U', 'Ü': 'U',
+	'Ç': 'C', 'Ñ': 'N',
+}
+
+// logradouroAbbreviations expands common street-type abbreviations.
+var logradouroAbbreviations = map[string]string{
+	"av":   "avenida",
+	"avda": "avenida",
+	"r":    "rua",
+	"al":   "alameda",
+	"pca":  "praca",
+	"pc":   "praca",
+	"est":  "estrada",
+	"rod":  "rodovia",
+	"tv":   "travessa",
+	"trav": "travessa",
+	"lgo":  "largo",
+	"lg":   "largo",
+	"vd":   "viaduto",
+	"pq":   "parque",
+	"jd":   "jardim",
+	"vl":   "vila",
+	"dr":   "doutor",
+	"prof": "professor",
+	"sta":  "santa",
+	"sto":  "santo",
+	"pres": "presidente",
+	"gal":  "general",
+	"cel":  "coronel",
+	"eng":  "engenheiro",
+}
+
+// FoldAccents replaces accented Latin letters with their ASCII base.
+func FoldAccents(s string) string {
+	return strings.Map(func(r rune) rune {
+		if f, ok := accentFolding[r]; ok {
+			return f
+		}
+		return r
+	}, s)
+}
+
+// NormalizeAddress sanitizes s (see SanitizeText), lowercases, folds
+// accents, drops punctuation and expands abbreviations so equivalent
+// spellings of a street compare equal.
+func NormalizeAddress(s string) string {
+	s = strings.ToLower(FoldAccents(SanitizeText(s)))
+	s = strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return r
+		}
+		return ' '
+	}, s)
+
+	words := strings.Fields(s)
+	for i, w := range words {
+		if full, ok := logradouroAbbreviations[w]; ok {
+			words[i] = full
+		}
+	}
+	return strings.Join(words, " ")
+}
+
+// NormalizeNumero keeps only the digits of a street number.
+func NormalizeNumero(s string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsDigit(r) {
+			return r
+		}
+		return -1
+	}, s)
+}
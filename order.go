@@ -0,0 +1,206 @@
+package iptuapi
+
+import (
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Sortable is satisfied by the property results, ConsultaEnderecoResult and
+// ConsultaSQLResult, accepted by SortBy, TopN and GroupBy.
+type Sortable interface {
+	sortFields() sortFields
+}
+
+// sortFields are the values results are ordered by.
+type sortFields struct {
+	sql, logradouro, numero, complemento, bairro string
+	valorVenal                                   float64
+}
+
+func (r ConsultaEnderecoResult) sortFields() sortFields {
+	return sortFields{sql: r.SQL, logradouro: r.Logradouro, numero: r.Numero, complemento: r.Complemento,
+		bairro: r.Bairro, valorVenal: r.ValorVenalTotal}
+}
+
+func (r ConsultaSQLResult) sortFields() sortFields {
+	valor := r.ValorVenalTotal
+	if valor == 0 {
+		valor = r.ValorVenal
+	}
+	return sortFields{sql: r.SQL, logradouro: r.Logradouro, numero: r.Numero, bairro: r.Bairro, valorVenal: valor}
+}
+
+// SortKey orders results by one field, ascending unless made descending
+// with Desc.
+type SortKey struct {
+	compare func(a, b sortFields) int
+	// missing reports a result without the field, sorted last in either
+	// direction.
+	missing func(sortFields) bool
+	desc    bool
+}
+
+// Desc returns k in descending order.
+func (k SortKey) Desc() SortKey {
+	k.desc = !k.desc
+	return k
+}
+
+// Sort keys for SortBy and TopN.
+var (
+	// ByValorVenal orders by total venal value. Results without a value
+	// sort last in either direction.
+	ByValorVenal = SortKey{
+		compare: func(a, b sortFields) int { return compareFloat(a.valorVenal, b.valorVenal) },
+		missing: func(f sortFields) bool { return f.valorVenal <= 0 || math.IsNaN(f.valorVenal) },
+	}
+	// ByNumero orders by street number, numerically: "9" before "10A"
+	// before "10B" before "100".
+	ByNumero = SortKey{compare: func(a, b sortFields) int { return compareNumero(a.numero, b.numero) }}
+	// ByBairro orders by neighborhood name, ignoring case, accents and
+	// abbreviations.
+	ByBairro = SortKey{compare: func(a, b sortFields) int { return strings.Compare(bairroKey(a.bairro), bairroKey(b.bairro)) }}
+)
+
+// SortBy sorts results in place by keys, in order of precedence. Results
+// equal on every key are ordered by SQL and then address, so the order is
+// the same whatever order the API returned them in, e.g. across the pages
+// of a paginated UI.
+func SortBy[T Sortable](results []T, keys ...SortKey) {
+	fields := make([]sortFields, len(results))
+	for i, r := range results {
+		fields[i] = r.sortFields()
+	}
+	sort.Stable(&resultSorter[T]{results: results, fields: fields, keys: keys})
+}
+
+// TopN returns the first n results by keys, as SortBy would order them,
+// without modifying results.
+func TopN[T Sortable](results []T, n int, keys ...SortKey) []T {
+	if n <= 0 {
+		return nil
+	}
+	sorted := append([]T(nil), results...)
+	SortBy(sorted, keys...)
+	if n < len(sorted) {
+		sorted = sorted[:n:n]
+	}
+	return sorted
+}
+
+// ResultGroup is a set of results sharing a key; see GroupBy.
+type ResultGroup[T any] struct {
+	Key   string
+	Items []T
+}
+
+// GroupBy groups results by key. Groups are ordered by key and keep the
+// order of results within them.
+func GroupBy[T any](results []T, key func(T) string) []ResultGroup[T] {
+	index := make(map[string]int)
+	var groups []ResultGroup[T]
+	for _, r := range results {
+		k := key(r)
+		i, ok := index[k]
+		if !ok {
+			i = len(groups)
+			index[k] = i
+			groups = append(groups, ResultGroup[T]{Key: k})
+		}
+		groups[i].Items = append(groups[i].Items, r)
+	}
+	sort.Slice(groups, func(i, j int) bool { return groups[i].Key < groups[j].Key })
+	return groups
+}
+
+// resultSorter sorts results and their fields together.
+type resultSorter[T Sortable] struct {
+	results []T
+	fields  []sortFields
+	keys    []SortKey
+}
+
+func (s *resultSorter[T]) Len() int { return len(s.results) }
+
+func (s *resultSorter[T]) Swap(i, j int) {
+	s.results[i], s.results[j] = s.results[j], s.results[i]
+	s.fields[i], s.fields[j] = s.fields[j], s.fields[i]
+}
+
+func (s *resultSorter[T]) Less(i, j int) bool {
+	a, b := s.fields[i], s.fields[j]
+	for _, k := range s.keys {
+		if k.missing != nil {
+			ma, mb := k.missing(a), k.missing(b)
+			if ma != mb {
+				return mb
+			}
+			if ma {
+				continue
+			}
+		}
+		c := k.compare(a, b)
+		if k.desc {
+			c = -c
+		}
+		if c != 0 {
+			return c < 0
+		}
+	}
+	if c := strings.Compare(a.sql, b.sql); c != 0 {
+		return c < 0
+	}
+	if c := strings.Compare(a.logradouro, b.logradouro); c != 0 {
+		return c < 0
+	}
+	if c := compareNumero(a.numero, b.numero); c != 0 {
+		return c < 0
+	}
+	return a.complemento < b.complemento
+}
+
+func compareFloat(a, b float64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+// compareNumero compares street numbers by their leading digits and then
+// by the rest, so "10A" sorts between "10" and "11". Numbers without digits
+// ("S/N") sort after all others.
+func compareNumero(a, b string) int {
+	na, restA, okA := splitNumero(a)
+	nb, restB, okB := splitNumero(b)
+	switch {
+	case okA != okB:
+		if okA {
+			return -1
+		}
+		return 1
+	case na != nb:
+		if na < nb {
+			return -1
+		}
+		return 1
+	}
+	return strings.Compare(restA, restB)
+}
+
+func splitNumero(s string) (n int, rest string, ok bool) {
+	s = strings.TrimSpace(s)
+	i := strings.IndexFunc(s, func(r rune) bool { return r < '0' || r > '9' })
+	if i < 0 {
+		i = len(s)
+	}
+	n, err := strconv.Atoi(s[:i])
+	if err != nil {
+		return 0, strings.ToUpper(s), false
+	}
+	return n, strings.ToUpper(strings.TrimSpace(s[i:])), true
+}
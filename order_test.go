@@ -0,0 +1,77 @@
+package iptuapi
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func sqls[T Sortable](results []T) []string {
+	out := make([]string, len(results))
+	for i, r := range results {
+		out[i] = r.sortFields().sql
+	}
+	return out
+}
+
+func TestSortByIsDeterministic(t *testing.T) {
+	results := []ConsultaEnderecoResult{
+		{SQL: "4", ValorVenalTotal: 300000},
+		{SQL: "2", ValorVenalTotal: 500000},
+		{SQL: "3", ValorVenalTotal: 300000},
+		{SQL: "5"},
+		{SQL: "1", ValorVenalTotal: 300000},
+	}
+	want := []string{"2", "1", "3", "4", "5"}
+	rng := rand.New(rand.NewSource(1))
+	for i := 0; i < 10; i++ {
+		rng.Shuffle(len(results), func(a, b int) { results[a], results[b] = results[b], results[a] })
+		SortBy(results, ByValorVenal.Desc())
+		assert.Equal(t, want, sqls(results), "ties broken by SQL, missing values last")
+	}
+
+	SortBy(results, ByValorVenal)
+	assert.Equal(t, []string{"1", "3", "4", "2", "5"}, sqls(results))
+}
+
+func TestSortByNumeroAndBairro(t *testing.T) {
+	results := []ConsultaSQLResult{
+		{SQL: "a", Numero: "100", Bairro: "Jd. Paulista"},
+		{SQL: "b", Numero: "S/N", Bairro: "jardim paulista"},
+		{SQL: "c", Numero: "10B", Bairro: "Aclimação"},
+		{SQL: "d", Numero: "9", Bairro: "Jardim Paulista"},
+		{SQL: "e", Numero: "10A", Bairro: "Bela Vista"},
+	}
+	SortBy(results, ByNumero)
+	assert.Equal(t, []string{"d", "e", "c", "a", "b"}, sqls(results))
+
+	SortBy(results, ByBairro, ByNumero)
+	assert.Equal(t, []string{"c", "e", "d", "a", "b"}, sqls(results))
+}
+
+func TestTopN(t *testing.T) {
+	results := []ConsultaSQLResult{
+		{SQL: "1", ValorVenal: 100},
+		{SQL: "2", ValorVenalTotal: 300},
+		{SQL: "3", ValorVenal: 200},
+	}
+	top := TopN(results, 2, ByValorVenal.Desc())
+	assert.Equal(t, []string{"2", "3"}, sqls(top))
+	assert.Equal(t, []string{"1", "2", "3"}, sqls(results), "input left unchanged")
+	assert.Len(t, TopN(results, 10, ByValorVenal), 3)
+	assert.Nil(t, TopN(results, 0, ByValorVenal))
+}
+
+func TestGroupBy(t *testing.T) {
+	results := []ConsultaEnderecoResult{
+		{SQL: "1", Bairro: "Moema"},
+		{SQL: "2", Bairro: "Aclimação"},
+		{SQL: "3", Bairro: "Moema"},
+	}
+	groups := GroupBy(results, func(r ConsultaEnderecoResult) string { return r.Bairro })
+	assert.Len(t, groups, 2)
+	assert.Equal(t, "Aclimação", groups[0].Key)
+	assert.Equal(t, "Moema", groups[1].Key)
+	assert.Equal(t, []string{"1", "3"}, sqls(groups[1].Items))
+}
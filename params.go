@@ -0,0 +1,110 @@
+package iptuapi
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/url"
+	"strconv"
+)
+
+// Params is implemented by request parameter structs that have a canonical
+// key=value form. The same form is used for query strings, cache keys,
+// deduplication and audit hashes, so every subsystem agrees on what
+// constitutes the same request.
+type Params interface {
+	Values() url.Values
+}
+
+// Values returns the canonical query parameters for an address lookup.
+// Text fields are cleaned with SanitizeText and an empty Cidade is
+// serialized as CidadeSaoPaulo, the API default.
+func (p *ConsultaEnderecoParams) Values() url.Values {
+	v := url.Values{}
+	v.Set("logradouro", SanitizeText(p.Logradouro))
+	if numero := SanitizeText(p.Numero); numero != "" {
+		v.Set("numero", numero)
+	}
+	if complemento := SanitizeText(p.Complemento); complemento != "" {
+		v.Set("complemento", complemento)
+	}
+	v.Set("cidade", string(cidadeOrDefault(p.Cidade)))
+	if p.IncluirHistorico {
+		v.Set("incluir_historico", "true")
+	}
+	if p.IncluirComparaveis {
+		v.Set("incluir_comparaveis", "true")
+	}
+	if p.IncluirZoneamento {
+		v.Set("incluir_zoneamento", "true")
+	}
+	return v
+}
+
+// Values returns the canonical form of a valuation request body.
+func (p *ValuationParams) Values() url.Values {
+	v := url.Values{}
+	v.Set("area_terreno", formatFloat(p.AreaTerreno))
+	v.Set("area_construida", formatFloat(p.AreaConstruida))
+	v.Set("bairro", p.Bairro)
+	v.Set("zona", p.Zona)
+	v.Set("tipo_uso", p.TipoUso)
+	v.Set("tipo_padrao", p.TipoPadrao)
+	if p.AnoConstrucao != 0 {
+		v.Set("ano_construcao", strconv.Itoa(p.AnoConstrucao))
+	}
+	if p.Cidade != "" {
+		v.Set("cidade", string(p.Cidade))
+	}
+	return v
+}
+
+// Values returns the canonical form of a payment simulation request body.
+func (p *SimuladorParams) Values() url.Values {
+	v := url.Values{}
+	v.Set("valor_iptu", formatFloat(p.ValorIPTU))
+	v.Set("cidade", string(cidadeOrDefault(Cidade(p.Cidade))))
+	if p.ValorVenal != 0 {
+		v.Set("valor_venal", formatFloat(p.ValorVenal))
+	}
+	return v
+}
+
+// Canonical returns the canonical serialization of a request: the method,
+// the endpoint and the parameters sorted by key, e.g.
+// "GET /consulta/endereco?cidade=sp&logradouro=Avenida+Paulista".
+func Canonical(method, endpoint string, params url.Values) string {
+	s := method + " " + endpoint
+	if len(params) > 0 {
+		s += "?" + params.Encode()
+	}
+	return s
+}
+
+// CanonicalHash returns the hex SHA-256 of Canonical(method, endpoint, params),
+// suitable as a cache key or audit fingerprint.
+func CanonicalHash(method, endpoint string, params url.Values) string {
+	sum := sha256.Sum256([]byte(Canonical(method, endpoint, params)))
+	return hex.EncodeToString(sum[:])
+}
+
+// RequestURL returns the URL the client would call for endpoint and params,
+// with parameters in canonical order. It performs no request, which makes it
+// useful for dry runs. Endpoints moved with WithRoutes are rewritten.
+func (c *Client) RequestURL(endpoint string, params url.Values) string {
+	u := c.baseURL + c.resolvePath(endpoint)
+	if len(params) > 0 {
+		u += "?" + params.Encode()
+	}
+	return u
+}
+
+func cidadeOrDefault(c Cidade) Cidade {
+	if c == "" {
+		return CidadeSaoPaulo
+	}
+	return c
+}
+
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
@@ -0,0 +1,44 @@
+package iptuapi
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCanonical(t *testing.T) {
+	a := &ConsultaEnderecoParams{Logradouro: "Avenida Paulista", Numero: "1000"}
+	b := &ConsultaEnderecoParams{Numero: "1000", Logradouro: "Avenida Paulista", Cidade: CidadeSaoPaulo}
+
+	assert.Equal(t, "GET /consulta/endereco?cidade=sp&logradouro=Avenida+Paulista&numero=1000",
+		Canonical("GET", "/consulta/endereco", a.Values()))
+	assert.Equal(t, CanonicalHash("GET", "/consulta/endereco", a.Values()),
+		CanonicalHash("GET", "/consulta/endereco", b.Values()))
+
+	b.IncluirHistorico = true
+	assert.NotEqual(t, CanonicalHash("GET", "/consulta/endereco", a.Values()),
+		CanonicalHash("GET", "/consulta/endereco", b.Values()))
+
+	v := &ValuationParams{AreaTerreno: 250.5, Bairro: "Pinheiros"}
+	assert.Equal(t, "250.5", v.Values().Get("area_terreno"))
+}
+
+func TestRequestURLMatchesWire(t *testing.T) {
+	var got string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.URL.RequestURI()
+		w.Write([]byte(`{}`))
+	}))
+	defer server.Close()
+
+	client := NewClient("test_key", WithBaseURL(server.URL), WithRetry(&RetryConfig{MaxRetries: 0}))
+	p := &ConsultaEnderecoParams{Logradouro: "Rua Augusta", Numero: "1", IncluirZoneamento: true}
+
+	_, err := client.ConsultaEndereco(context.Background(), p)
+	require.NoError(t, err)
+	assert.Equal(t, client.RequestURL("/consulta/endereco", p.Values()), server.URL+got)
+}
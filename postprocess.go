@@ -0,0 +1,28 @@
+package iptuapi
+
+import "fmt"
+
+// PostProcessor inspects or modifies a decoded response. endpoint is the
+// route template ("/consulta/sql/{sql}") and v a pointer to the decoded
+// result, e.g. *ConsultaSQLResult or *[]ComparavelItem.
+type PostProcessor func(endpoint string, v interface{}) error
+
+// WithPostProcessor appends fn to the processors run after every response
+// is decoded, in registration order, e.g. to map bairro names onto an
+// internal taxonomy or convert units in one place. Results served by a
+// FallbackProvider are processed too. An error from fn fails the call.
+func WithPostProcessor(fn func(endpoint string, v interface{}) error) ClientOption {
+	return func(c *Client) {
+		c.postProcessors = append(c.postProcessors, fn)
+	}
+}
+
+// postProcess runs the registered post-processors on v.
+func (c *Client) postProcess(endpoint string, v interface{}) error {
+	for _, fn := range c.postProcessors {
+		if err := fn(endpoint, v); err != nil {
+			return fmt.Errorf("iptuapi: post-processor for %s: %w", endpoint, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,52 @@
+package iptuapi
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithPostProcessor(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(ConsultaSQLResult{SQL: "1", Bairro: "JD PAULISTA", AreaTerreno: 100})
+	}))
+	defer server.Close()
+
+	var endpoints []string
+	client := NewClient("test_key",
+		WithBaseURL(server.URL),
+		WithPostProcessor(func(endpoint string, v interface{}) error {
+			endpoints = append(endpoints, endpoint)
+			if r, ok := v.(*ConsultaSQLResult); ok {
+				r.Bairro = strings.Replace(r.Bairro, "JD ", "Jardim ", 1)
+			}
+			return nil
+		}),
+		WithPostProcessor(func(endpoint string, v interface{}) error {
+			if r, ok := v.(*ConsultaSQLResult); ok {
+				r.AreaTerreno *= 10.7639 // m² to ft²
+			}
+			return nil
+		}),
+	)
+
+	r, err := client.ConsultaSQL(context.Background(), "1", CidadeSaoPaulo)
+	require.NoError(t, err)
+	assert.Equal(t, "Jardim PAULISTA", r.Bairro)
+	assert.InDelta(t, 1076.39, r.AreaTerreno, 0.001)
+	assert.Equal(t, []string{"/consulta/sql/{sql}"}, endpoints)
+
+	failing := NewClient("test_key",
+		WithBaseURL(server.URL),
+		WithPostProcessor(func(string, interface{}) error { return errors.New("bairro desconhecido") }),
+	)
+	_, err = failing.ConsultaSQL(context.Background(), "1", CidadeSaoPaulo)
+	assert.ErrorContains(t, err, "bairro desconhecido")
+}
@@ -0,0 +1,81 @@
+package queue
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	iptuapi "github.com/raphaeltorquat0/iptuapi-go"
+)
+
+// Job kinds understood by LookupHandler.
+const (
+	KindConsultaEndereco = "consulta_endereco"
+	KindConsultaSQL      = "consulta_sql"
+)
+
+// SQLLookup is the payload of a KindConsultaSQL job.
+type SQLLookup struct {
+	SQL    string         `json:"sql"`
+	Cidade iptuapi.Cidade `json:"cidade,omitempty"`
+}
+
+// EnqueueEndereco enqueues an address lookup.
+func EnqueueEndereco(q *Queue, p iptuapi.ConsultaEnderecoParams) (Job, error) {
+	return q.Enqueue(KindConsultaEndereco, p)
+}
+
+// EnqueueSQL enqueues a SQL lookup.
+func EnqueueSQL(q *Queue, sql string, cidade iptuapi.Cidade) (Job, error) {
+	return q.Enqueue(KindConsultaSQL, SQLLookup{SQL: sql, Cidade: cidade})
+}
+
+// LookupHandler returns a Handler that runs KindConsultaEndereco and
+// KindConsultaSQL jobs against client. Errors that cannot succeed on retry
+// (not found, authentication, plan, validation and unsupported city errors)
+// dead-letter the job immediately.
+func LookupHandler(client *iptuapi.Client) Handler {
+	return func(ctx context.Context, job Job) (interface{}, error) {
+		var (
+			result interface{}
+			err    error
+		)
+		switch job.Kind {
+		case KindConsultaEndereco:
+			var p iptuapi.ConsultaEnderecoParams
+			if err := job.Decode(&p); err != nil {
+				return nil, Permanent(err)
+			}
+			result, err = client.ConsultaEndereco(ctx, &p)
+		case KindConsultaSQL:
+			var p SQLLookup
+			if err := job.Decode(&p); err != nil {
+				return nil, Permanent(err)
+			}
+			result, err = client.ConsultaSQL(ctx, p.SQL, p.Cidade)
+		default:
+			return nil, Permanent(fmt.Errorf("queue: unknown job kind %q", job.Kind))
+		}
+		if err != nil {
+			return nil, classify(err)
+		}
+		return result, nil
+	}
+}
+
+// classify marks errors that cannot succeed on retry as permanent, wrapped
+// or not.
+func classify(err error) error {
+	var (
+		notFound  *iptuapi.NotFoundError
+		auth      *iptuapi.AuthenticationError
+		forbidden *iptuapi.ForbiddenError
+		invalid   *iptuapi.ValidationError
+		city      *iptuapi.CityNotSupportedError
+	)
+	if errors.As(err, &notFound) || errors.As(err, &auth) || errors.As(err, &forbidden) ||
+		errors.As(err, &invalid) || errors.As(err, &city) {
+		return Permanent(err)
+	}
+	return err
+}
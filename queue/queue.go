@@ -0,0 +1,337 @@
+// Package queue provides a durable job queue for enrichment lookups against
+// the IPTU API.
+//
+// Jobs are persisted in a Store (a fsynced JSON-lines journal by default), so
+// pending lookups survive restarts without external infrastructure. A Runner
+// processes jobs with at-least-once semantics: jobs that were running when
+// the process died are picked up again, failed jobs are retried with backoff
+// and jobs that exhaust their attempts are moved to the dead-letter state.
+//
+// Example:
+//
+//	q, err := queue.Open("lookups.journal")
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	defer q.Close()
+//
+//	queue.EnqueueSQL(q, "000.000.0000-0", iptuapi.CidadeSaoPaulo)
+//
+//	runner := &queue.Runner{Queue: q, Handler: queue.LookupHandler(client)}
+//	runner.Run(ctx)
+package queue
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// State is the lifecycle state of a job.
+type State string
+
+const (
+	StatePending State = "pending"
+	StateRunning State = "running"
+	StateDone    State = "done"
+	StateDead    State = "dead"
+)
+
+// Job is a unit of work in the queue.
+type Job struct {
+	ID        string          `json:"id"`
+	Kind      string          `json:"kind"`
+	Payload   json.RawMessage `json:"payload,omitempty"`
+	State     State           `json:"state"`
+	Attempts  int             `json:"attempts"`
+	LastError string          `json:"last_error,omitempty"`
+	Result    json.RawMessage `json:"result,omitempty"`
+	NotBefore time.Time       `json:"not_before,omitempty"`
+	CreatedAt time.Time       `json:"created_at"`
+	UpdatedAt time.Time       `json:"updated_at"`
+}
+
+// Decode unmarshals the job payload into v.
+func (j Job) Decode(v interface{}) error {
+	return json.Unmarshal(j.Payload, v)
+}
+
+// Stats contains job counts by state.
+type Stats struct {
+	Pending int `json:"pending"`
+	Running int `json:"running"`
+	Done    int `json:"done"`
+	Dead    int `json:"dead"`
+}
+
+// ErrJobNotFound is returned by admin functions for unknown job IDs.
+var ErrJobNotFound = errors.New("queue: job not found")
+
+// Queue is a durable job queue. It is safe for concurrent use.
+type Queue struct {
+	mu    sync.Mutex
+	store Store
+	jobs  map[string]*Job
+	now   func() time.Time
+}
+
+// New creates a queue backed by store. Jobs left in the running state by a
+// previous process are returned to pending.
+func New(store Store) (*Queue, error) {
+	q := &Queue{
+		store: store,
+		jobs:  make(map[string]*Job),
+		now:   time.Now,
+	}
+
+	jobs, err := store.All()
+	if err != nil {
+		return nil, err
+	}
+	for i := range jobs {
+		j := jobs[i]
+		if j.State == StateRunning {
+			j.State = StatePending
+			if err := store.Put(j); err != nil {
+				return nil, err
+			}
+		}
+		q.jobs[j.ID] = &j
+	}
+	return q, nil
+}
+
+// Open creates a queue backed by a FileStore at path.
+func Open(path string) (*Queue, error) {
+	store, err := OpenFileStore(path)
+	if err != nil {
+		return nil, err
+	}
+	q, err := New(store)
+	if err != nil {
+		store.Close()
+		return nil, err
+	}
+	return q, nil
+}
+
+// Close closes the underlying store.
+func (q *Queue) Close() error {
+	return q.store.Close()
+}
+
+// Enqueue adds a pending job of the given kind. The payload is stored as JSON.
+func (q *Queue) Enqueue(kind string, payload interface{}) (Job, error) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return Job{}, err
+	}
+
+	now := q.now()
+	j := Job{
+		ID:        newID(),
+		Kind:      kind,
+		Payload:   data,
+		State:     StatePending,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if err := q.store.Put(j); err != nil {
+		return Job{}, err
+	}
+	q.jobs[j.ID] = &j
+	return j, nil
+}
+
+// Get returns a copy of the job with the given ID.
+func (q *Queue) Get(id string) (Job, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	j, ok := q.jobs[id]
+	if !ok {
+		return Job{}, false
+	}
+	return *j, true
+}
+
+// Inspect returns copies of all jobs in the given state, oldest first.
+// An empty state returns every job.
+func (q *Queue) Inspect(state State) []Job {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	var jobs []Job
+	for _, j := range q.jobs {
+		if state == "" || j.State == state {
+			jobs = append(jobs, *j)
+		}
+	}
+	sort.Slice(jobs, func(a, b int) bool {
+		if jobs[a].CreatedAt.Equal(jobs[b].CreatedAt) {
+			return jobs[a].ID < jobs[b].ID
+		}
+		return jobs[a].CreatedAt.Before(jobs[b].CreatedAt)
+	})
+	return jobs
+}
+
+// Stats returns job counts by state.
+func (q *Queue) Stats() Stats {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	var s Stats
+	for _, j := range q.jobs {
+		switch j.State {
+		case StatePending:
+			s.Pending++
+		case StateRunning:
+			s.Running++
+		case StateDone:
+			s.Done++
+		case StateDead:
+			s.Dead++
+		}
+	}
+	return s
+}
+
+// Requeue moves a dead or done job back to pending and resets its attempts.
+func (q *Queue) Requeue(id string) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	j, ok := q.jobs[id]
+	if !ok {
+		return ErrJobNotFound
+	}
+	if j.State == StateRunning {
+		return fmt.Errorf("queue: job %s is running", id)
+	}
+	return q.update(j, func(j *Job) {
+		j.State = StatePending
+		j.Attempts = 0
+		j.LastError = ""
+		j.NotBefore = time.Time{}
+	})
+}
+
+// RequeueDead moves every dead-lettered job back to pending.
+func (q *Queue) RequeueDead() (int, error) {
+	n := 0
+	for _, j := range q.Inspect(StateDead) {
+		if err := q.Requeue(j.ID); err != nil {
+			return n, err
+		}
+		n++
+	}
+	return n, nil
+}
+
+// Purge removes all jobs in the given state and returns how many were removed.
+func (q *Queue) Purge(state State) (int, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	n := 0
+	for id, j := range q.jobs {
+		if j.State != state {
+			continue
+		}
+		if err := q.store.Delete(id); err != nil {
+			return n, err
+		}
+		delete(q.jobs, id)
+		n++
+	}
+	return n, nil
+}
+
+// claim marks the oldest runnable pending job as running.
+func (q *Queue) claim() (Job, bool, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	now := q.now()
+	var next *Job
+	for _, j := range q.jobs {
+		if j.State != StatePending || j.NotBefore.After(now) {
+			continue
+		}
+		if next == nil || j.CreatedAt.Before(next.CreatedAt) ||
+			(j.CreatedAt.Equal(next.CreatedAt) && j.ID < next.ID) {
+			next = j
+		}
+	}
+	if next == nil {
+		return Job{}, false, nil
+	}
+	err := q.update(next, func(j *Job) {
+		j.State = StateRunning
+		j.Attempts++
+	})
+	return *next, err == nil, err
+}
+
+func (q *Queue) complete(id string, result json.RawMessage) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	j, ok := q.jobs[id]
+	if !ok {
+		return ErrJobNotFound
+	}
+	return q.update(j, func(j *Job) {
+		j.State = StateDone
+		j.Result = result
+		j.LastError = ""
+	})
+}
+
+func (q *Queue) fail(id string, cause error, dead bool, retryAt time.Time) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	j, ok := q.jobs[id]
+	if !ok {
+		return ErrJobNotFound
+	}
+	return q.update(j, func(j *Job) {
+		j.LastError = cause.Error()
+		if dead {
+			j.State = StateDead
+			return
+		}
+		j.State = StatePending
+		j.NotBefore = retryAt
+	})
+}
+
+// update applies fn to a copy of j, persists it and then commits it in memory.
+// Callers must hold q.mu.
+func (q *Queue) update(j *Job, fn func(*Job)) error {
+	next := *j
+	fn(&next)
+	next.UpdatedAt = q.now()
+	if err := q.store.Put(next); err != nil {
+		return err
+	}
+	*j = next
+	return nil
+}
+
+func newID() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+	return hex.EncodeToString(b)
+}
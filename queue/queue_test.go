@@ -0,0 +1,309 @@
+package queue
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	iptuapi "github.com/raphaeltorquat0/iptuapi-go"
+)
+
+func noBackoff(int) time.Duration { return 0 }
+
+func TestFileStoreDurability(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "jobs.journal")
+
+	q, err := Open(path)
+	require.NoError(t, err)
+	job, err := q.Enqueue("test", map[string]string{"sql": "123"})
+	require.NoError(t, err)
+	_, ok, err := q.claim()
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.NoError(t, q.Close())
+
+	// Reopen: the running job must come back as pending (at-least-once).
+	q, err = Open(path)
+	require.NoError(t, err)
+	defer q.Close()
+
+	got, ok := q.Get(job.ID)
+	require.True(t, ok)
+	assert.Equal(t, StatePending, got.State)
+	assert.Equal(t, 1, got.Attempts)
+	assert.JSONEq(t, `{"sql":"123"}`, string(got.Payload))
+}
+
+func TestFileStoreCompaction(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "jobs.journal")
+	store, err := OpenFileStore(path)
+	require.NoError(t, err)
+
+	for i := 0; i < 200; i++ {
+		require.NoError(t, store.Put(Job{ID: "same", Attempts: i}))
+	}
+	require.NoError(t, store.Close())
+
+	store, err = OpenFileStore(path)
+	require.NoError(t, err)
+	defer store.Close()
+
+	jobs, err := store.All()
+	require.NoError(t, err)
+	require.Len(t, jobs, 1)
+	assert.Equal(t, 199, jobs[0].Attempts)
+	assert.LessOrEqual(t, store.entries, 100)
+}
+
+func TestFileStoreTornTail(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "jobs.journal")
+	store, err := OpenFileStore(path)
+	require.NoError(t, err)
+	require.NoError(t, store.Put(Job{ID: "a"}))
+	require.NoError(t, store.Close())
+
+	// A crash in the middle of a write leaves a partial line.
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0o600)
+	require.NoError(t, err)
+	_, err = f.WriteString(`{"job":{"id":"b","sta`)
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+
+	ids := func() []string {
+		t.Helper()
+		store, err := OpenFileStore(path)
+		require.NoError(t, err)
+		defer store.Close()
+		jobs, err := store.All()
+		require.NoError(t, err)
+		var ids []string
+		for _, j := range jobs {
+			ids = append(ids, j.ID)
+		}
+		sort.Strings(ids)
+		return ids
+	}
+
+	store, err = OpenFileStore(path)
+	require.NoError(t, err)
+	require.NoError(t, store.Put(Job{ID: "c"}))
+	require.NoError(t, store.Close())
+	assert.Equal(t, []string{"a", "c"}, ids())
+
+	store, err = OpenFileStore(path)
+	require.NoError(t, err)
+	require.NoError(t, store.Put(Job{ID: "d"}))
+	require.NoError(t, store.Close())
+	assert.Equal(t, []string{"a", "c", "d"}, ids())
+}
+
+func TestRunnerRetryAndDeadLetter(t *testing.T) {
+	q, err := New(NewMemoryStore())
+	require.NoError(t, err)
+
+	ok, _ := q.Enqueue("ok", nil)
+	flaky, _ := q.Enqueue("flaky", nil)
+	broken, _ := q.Enqueue("broken", nil)
+	fatal, _ := q.Enqueue("fatal", nil)
+
+	calls := map[string]int{}
+	r := &Runner{
+		Queue:       q,
+		MaxAttempts: 3,
+		Backoff:     noBackoff,
+		Handler: func(ctx context.Context, job Job) (interface{}, error) {
+			calls[job.Kind]++
+			switch job.Kind {
+			case "flaky":
+				if calls[job.Kind] < 2 {
+					return nil, errors.New("temporary")
+				}
+			case "broken":
+				return nil, errors.New("always fails")
+			case "fatal":
+				return nil, Permanent(errors.New("bad input"))
+			}
+			return map[string]string{"kind": job.Kind}, nil
+		},
+	}
+	require.NoError(t, r.Drain(context.Background()))
+
+	got, _ := q.Get(ok.ID)
+	assert.Equal(t, StateDone, got.State)
+	assert.JSONEq(t, `{"kind":"ok"}`, string(got.Result))
+
+	got, _ = q.Get(flaky.ID)
+	assert.Equal(t, StateDone, got.State)
+	assert.Equal(t, 2, got.Attempts)
+
+	got, _ = q.Get(broken.ID)
+	assert.Equal(t, StateDead, got.State)
+	assert.Equal(t, 3, got.Attempts)
+	assert.Equal(t, "always fails", got.LastError)
+
+	got, _ = q.Get(fatal.ID)
+	assert.Equal(t, StateDead, got.State)
+	assert.Equal(t, 1, got.Attempts)
+
+	assert.Equal(t, Stats{Done: 2, Dead: 2}, q.Stats())
+}
+
+func TestAdmin(t *testing.T) {
+	q, err := New(NewMemoryStore())
+	require.NoError(t, err)
+
+	job, _ := q.Enqueue("x", nil)
+	_, _, _ = q.claim()
+	require.NoError(t, q.fail(job.ID, errors.New("boom"), true, time.Time{}))
+
+	dead := q.Inspect(StateDead)
+	require.Len(t, dead, 1)
+	assert.Equal(t, "boom", dead[0].LastError)
+
+	n, err := q.RequeueDead()
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+
+	got, _ := q.Get(job.ID)
+	assert.Equal(t, StatePending, got.State)
+	assert.Equal(t, 0, got.Attempts)
+
+	assert.ErrorIs(t, q.Requeue("missing"), ErrJobNotFound)
+
+	_, _, _ = q.claim()
+	require.NoError(t, q.complete(job.ID, nil))
+	n, err = q.Purge(StateDone)
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+	assert.Empty(t, q.Inspect(""))
+}
+
+func TestClassify(t *testing.T) {
+	tests := []struct {
+		name      string
+		err       error
+		permanent bool
+	}{
+		{"not found", &iptuapi.NotFoundError{APIError: &iptuapi.APIError{StatusCode: 404}}, true},
+		{"wrapped not found", fmt.Errorf("lookup: %w", &iptuapi.NotFoundError{APIError: &iptuapi.APIError{StatusCode: 404}}), true},
+		{"city not supported", &iptuapi.CityNotSupportedError{APIError: &iptuapi.APIError{StatusCode: 400}}, true},
+		{"server error", &iptuapi.ServerError{APIError: &iptuapi.APIError{StatusCode: 502}}, false},
+		{"timeout", context.DeadlineExceeded, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.permanent, IsPermanent(classify(tt.err)))
+		})
+	}
+}
+
+func TestLookupHandler(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/consulta/sql/missing" {
+			w.WriteHeader(http.StatusNotFound)
+			json.NewEncoder(w).Encode(map[string]string{"detail": "Imóvel não encontrado"})
+			return
+		}
+		json.NewEncoder(w).Encode(iptuapi.ConsultaSQLResult{SQL: "000.000.0000-0", Bairro: "Bela Vista"})
+	}))
+	defer server.Close()
+
+	client := iptuapi.NewClient("test_key",
+		iptuapi.WithBaseURL(server.URL),
+		iptuapi.WithRetry(&iptuapi.RetryConfig{MaxRetries: 0}),
+	)
+
+	q, err := New(NewMemoryStore())
+	require.NoError(t, err)
+	found, _ := EnqueueSQL(q, "000.000.0000-0", iptuapi.CidadeSaoPaulo)
+	missing, _ := EnqueueSQL(q, "missing", iptuapi.CidadeSaoPaulo)
+
+	r := &Runner{Queue: q, Handler: LookupHandler(client), Backoff: noBackoff}
+	require.NoError(t, r.Drain(context.Background()))
+
+	got, _ := q.Get(found.ID)
+	assert.Equal(t, StateDone, got.State)
+	var result iptuapi.ConsultaSQLResult
+	require.NoError(t, json.Unmarshal(got.Result, &result))
+	assert.Equal(t, "Bela Vista", result.Bairro)
+
+	got, _ = q.Get(missing.ID)
+	assert.Equal(t, StateDead, got.State)
+	assert.Equal(t, 1, got.Attempts)
+}
+
+func TestRunnerStartClose(t *testing.T) {
+	q, err := New(NewMemoryStore())
+	require.NoError(t, err)
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+	r := &Runner{
+		Queue:        q,
+		PollInterval: time.Millisecond,
+		Handler: func(ctx context.Context, job Job) (interface{}, error) {
+			if job.Kind == "slow" {
+				close(started)
+				select {
+				case <-release:
+				case <-ctx.Done():
+					return nil, ctx.Err()
+				}
+			}
+			return nil, nil
+		},
+	}
+	require.NoError(t, r.Start(context.Background()))
+	assert.ErrorIs(t, r.Start(context.Background()), iptuapi.ErrAlreadyStarted)
+
+	slow, _ := q.Enqueue("slow", nil)
+	<-started
+	assert.Equal(t, 1, r.InFlight())
+
+	// The deadline expires before the handler finishes: the job is
+	// cancelled and returned to pending.
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	err = iptuapi.Shutdown(ctx, r)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Equal(t, 0, r.InFlight())
+
+	got, _ := q.Get(slow.ID)
+	assert.Equal(t, StatePending, got.State)
+}
+
+func TestRunnerCloseDrainsInFlight(t *testing.T) {
+	q, err := New(NewMemoryStore())
+	require.NoError(t, err)
+
+	started := make(chan struct{})
+	r := &Runner{
+		Queue:        q,
+		PollInterval: time.Millisecond,
+		Handler: func(ctx context.Context, job Job) (interface{}, error) {
+			close(started)
+			time.Sleep(20 * time.Millisecond)
+			return "ok", nil
+		},
+	}
+	assert.ErrorIs(t, r.Close(context.Background()), iptuapi.ErrNotStarted)
+	require.NoError(t, r.Start(context.Background()))
+
+	job, _ := q.Enqueue("lookup", nil)
+	<-started
+	require.NoError(t, r.Close(context.Background()))
+
+	got, _ := q.Get(job.ID)
+	assert.Equal(t, StateDone, got.State)
+}
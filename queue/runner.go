@@ -0,0 +1,242 @@
+package queue
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"math"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	iptuapi "github.com/raphaeltorquat0/iptuapi-go"
+)
+
+// Handler processes a job. The returned result is stored as JSON on the job.
+type Handler func(ctx context.Context, job Job) (interface{}, error)
+
+// permanentError marks an error that must not be retried.
+type permanentError struct {
+	err error
+}
+
+func (e *permanentError) Error() string { return e.err.Error() }
+func (e *permanentError) Unwrap() error { return e.err }
+
+// Permanent wraps err so the Runner dead-letters the job immediately
+// instead of retrying it.
+func Permanent(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &permanentError{err: err}
+}
+
+// IsPermanent reports whether err was wrapped with Permanent.
+func IsPermanent(err error) bool {
+	var p *permanentError
+	return errors.As(err, &p)
+}
+
+// Runner processes queued jobs with a pool of workers.
+type Runner struct {
+	Queue   *Queue
+	Handler Handler
+
+	// Workers is the number of concurrent workers (default 1).
+	Workers int
+	// MaxAttempts is the number of attempts before a job is dead-lettered
+	// (default 5).
+	MaxAttempts int
+	// Backoff returns the delay before the given retry attempt
+	// (default exponential from 1s, capped at 5m).
+	Backoff func(attempt int) time.Duration
+	// PollInterval is how long idle workers wait before polling again
+	// (default 500ms).
+	PollInterval time.Duration
+
+	mu       sync.Mutex
+	started  bool
+	stop     context.CancelFunc
+	abort    context.CancelFunc
+	done     chan struct{}
+	runErr   error
+	inFlight atomic.Int64
+}
+
+var _ iptuapi.Component = (*Runner)(nil)
+
+// DefaultBackoff doubles the delay on every attempt, starting at one second
+// and capped at five minutes.
+func DefaultBackoff(attempt int) time.Duration {
+	d := time.Duration(float64(time.Second) * math.Pow(2, float64(attempt-1)))
+	if d > 5*time.Minute || d <= 0 {
+		d = 5 * time.Minute
+	}
+	return d
+}
+
+// Run processes jobs until ctx is cancelled. Handlers run with ctx, so
+// in-flight handlers are cancelled too and their jobs return to pending.
+// Use Start and Close to let in-flight jobs finish.
+func (r *Runner) Run(ctx context.Context) error {
+	if err := r.run(ctx, ctx); err != nil {
+		return err
+	}
+	return ctx.Err()
+}
+
+// Start runs the workers in the background until ctx is cancelled or Close
+// is called. A Runner can be started only once.
+func (r *Runner) Start(ctx context.Context) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.started {
+		return iptuapi.ErrAlreadyStarted
+	}
+	r.started = true
+
+	jobCtx, abort := context.WithCancel(ctx)
+	claimCtx, stop := context.WithCancel(jobCtx)
+	r.stop, r.abort = stop, abort
+	r.done = make(chan struct{})
+	go func() {
+		defer close(r.done)
+		r.runErr = r.run(claimCtx, jobCtx)
+	}()
+	return nil
+}
+
+// Close stops claiming new jobs and waits for in-flight jobs to finish. If
+// ctx expires first, in-flight handlers are cancelled, their jobs return to
+// pending, and Close returns ctx.Err() once the workers have exited.
+func (r *Runner) Close(ctx context.Context) error {
+	r.mu.Lock()
+	started := r.started
+	r.mu.Unlock()
+	if !started {
+		return iptuapi.ErrNotStarted
+	}
+
+	r.stop()
+	select {
+	case <-r.done:
+		r.abort()
+		return r.runErr
+	case <-ctx.Done():
+		r.abort()
+		<-r.done
+		return ctx.Err()
+	}
+}
+
+// InFlight reports the number of jobs currently being handled.
+func (r *Runner) InFlight() int {
+	return int(r.inFlight.Load())
+}
+
+// run starts the workers. Workers stop claiming when claimCtx is done;
+// handlers run with jobCtx. Only store errors are returned.
+func (r *Runner) run(claimCtx, jobCtx context.Context) error {
+	workers := r.Workers
+	if workers <= 0 {
+		workers = 1
+	}
+
+	var wg sync.WaitGroup
+	errCh := make(chan error, workers)
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := r.work(claimCtx, jobCtx); err != nil {
+				errCh <- err
+			}
+		}()
+	}
+	wg.Wait()
+	close(errCh)
+	return <-errCh
+}
+
+// Drain processes jobs until no runnable pending job is left or ctx is done.
+// Jobs scheduled for a later retry are not waited for.
+func (r *Runner) Drain(ctx context.Context) error {
+	for ctx.Err() == nil {
+		processed, err := r.processNext(ctx)
+		if err != nil {
+			return err
+		}
+		if !processed {
+			return nil
+		}
+	}
+	return ctx.Err()
+}
+
+func (r *Runner) work(claimCtx, jobCtx context.Context) error {
+	poll := r.PollInterval
+	if poll <= 0 {
+		poll = 500 * time.Millisecond
+	}
+
+	for {
+		if claimCtx.Err() != nil {
+			return nil
+		}
+		processed, err := r.processNext(jobCtx)
+		if err != nil {
+			return err
+		}
+		if processed {
+			continue
+		}
+		select {
+		case <-claimCtx.Done():
+			return nil
+		case <-time.After(poll):
+		}
+	}
+}
+
+// processNext claims and handles one job. It returns false when no job was
+// runnable. Only store errors are returned; handler errors are recorded on
+// the job.
+func (r *Runner) processNext(ctx context.Context) (bool, error) {
+	job, ok, err := r.Queue.claim()
+	if err != nil || !ok {
+		return false, err
+	}
+	r.inFlight.Add(1)
+	defer r.inFlight.Add(-1)
+
+	result, herr := r.Handler(ctx, job)
+	if herr == nil {
+		var data json.RawMessage
+		if result != nil {
+			data, herr = json.Marshal(result)
+		}
+		if herr == nil {
+			return true, r.Queue.complete(job.ID, data)
+		}
+		herr = Permanent(herr)
+	}
+
+	maxAttempts := r.MaxAttempts
+	if maxAttempts <= 0 {
+		maxAttempts = 5
+	}
+	backoff := r.Backoff
+	if backoff == nil {
+		backoff = DefaultBackoff
+	}
+
+	// A job interrupted by shutdown goes straight back to pending.
+	if ctx.Err() != nil && !IsPermanent(herr) {
+		return true, r.Queue.fail(job.ID, herr, false, time.Time{})
+	}
+
+	dead := IsPermanent(herr) || job.Attempts >= maxAttempts
+	retryAt := r.Queue.now().Add(backoff(job.Attempts))
+	return true, r.Queue.fail(job.ID, herr, dead, retryAt)
+}
@@ -0,0 +1,245 @@
+package queue
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// Store persists queue jobs.
+//
+// Implementations must be safe for concurrent use. Put inserts or replaces
+// the job with the same ID.
+type Store interface {
+	Put(job Job) error
+	Delete(id string) error
+	All() ([]Job, error)
+	Close() error
+}
+
+// MemoryStore is a non-durable Store, useful for tests and short-lived
+// processes.
+type MemoryStore struct {
+	mu   sync.Mutex
+	jobs map[string]Job
+}
+
+// NewMemoryStore creates an empty in-memory store.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{jobs: make(map[string]Job)}
+}
+
+func (s *MemoryStore) Put(job Job) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.jobs[job.ID] = job
+	return nil
+}
+
+func (s *MemoryStore) Delete(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.jobs, id)
+	return nil
+}
+
+func (s *MemoryStore) All() ([]Job, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	jobs := make([]Job, 0, len(s.jobs))
+	for _, j := range s.jobs {
+		jobs = append(jobs, j)
+	}
+	return jobs, nil
+}
+
+func (s *MemoryStore) Close() error { return nil }
+
+// journalEntry is one line of the FileStore journal.
+type journalEntry struct {
+	Job     *Job   `json:"job,omitempty"`
+	Deleted string `json:"deleted,omitempty"`
+}
+
+// FileStore is a durable Store backed by an append-only JSON-lines journal.
+//
+// Every mutation is appended and fsynced before returning, so a job that was
+// acknowledged survives a crash. The journal is compacted when it grows to
+// more than twice the number of live jobs.
+type FileStore struct {
+	mu      sync.Mutex
+	path    string
+	file    *os.File
+	jobs    map[string]Job
+	entries int
+}
+
+// OpenFileStore opens (or creates) the journal at path and replays it.
+func OpenFileStore(path string) (*FileStore, error) {
+	s := &FileStore{path: path, jobs: make(map[string]Job)}
+	good, err := s.replay()
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		return nil, err
+	}
+	// Drop a torn final write, so the next entry starts on a line of its own.
+	if info, err := f.Stat(); err != nil || info.Size() > good {
+		if err == nil {
+			err = f.Truncate(good)
+		}
+		if err == nil {
+			err = f.Sync()
+		}
+		if err != nil {
+			f.Close()
+			return nil, err
+		}
+	}
+	s.file = f
+	return s, nil
+}
+
+// replay applies the journal entries and returns the offset just past the
+// last complete one.
+func (s *FileStore) replay() (int64, error) {
+	f, err := os.Open(s.path)
+	if os.IsNotExist(err) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+
+	r := bufio.NewReaderSize(f, 64*1024)
+	var good int64
+	line := 0
+	for {
+		data, err := r.ReadBytes('\n')
+		if err == io.EOF {
+			// An unterminated final line is a torn write; it was never
+			// acknowledged.
+			return good, nil
+		}
+		if err != nil {
+			return 0, err
+		}
+		line++
+		var e journalEntry
+		if err := json.Unmarshal(data, &e); err != nil {
+			// A torn final write is expected after a crash; anything else is corruption.
+			if _, err := r.Peek(1); err == io.EOF {
+				return good, nil
+			}
+			return 0, fmt.Errorf("queue: corrupt journal %s at line %d: %w", s.path, line, err)
+		}
+		s.apply(e)
+		good += int64(len(data))
+	}
+}
+
+func (s *FileStore) apply(e journalEntry) {
+	s.entries++
+	if e.Job != nil {
+		s.jobs[e.Job.ID] = *e.Job
+	} else if e.Deleted != "" {
+		delete(s.jobs, e.Deleted)
+	}
+}
+
+func (s *FileStore) append(e journalEntry) error {
+	data, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	data = append(data, '\n')
+	if _, err := s.file.Write(data); err != nil {
+		return err
+	}
+	if err := s.file.Sync(); err != nil {
+		return err
+	}
+	s.apply(e)
+	if s.entries > 64 && s.entries > 2*len(s.jobs) {
+		return s.compact()
+	}
+	return nil
+}
+
+// compact rewrites the journal with one entry per live job.
+func (s *FileStore) compact() error {
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), filepath.Base(s.path)+".tmp*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	w := bufio.NewWriter(tmp)
+	enc := json.NewEncoder(w)
+	for _, j := range s.jobs {
+		j := j
+		if err := enc.Encode(journalEntry{Job: &j}); err != nil {
+			tmp.Close()
+			return err
+		}
+	}
+	if err := w.Flush(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := s.file.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp.Name(), s.path); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(s.path, os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		return err
+	}
+	s.file = f
+	s.entries = len(s.jobs)
+	return nil
+}
+
+func (s *FileStore) Put(job Job) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.append(journalEntry{Job: &job})
+}
+
+func (s *FileStore) Delete(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.append(journalEntry{Deleted: id})
+}
+
+func (s *FileStore) All() ([]Job, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	jobs := make([]Job, 0, len(s.jobs))
+	for _, j := range s.jobs {
+		jobs = append(jobs, j)
+	}
+	return jobs, nil
+}
+
+func (s *FileStore) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.file.Close()
+}
@@ -0,0 +1,117 @@
+package iptuapi
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// RateLimiter throttles outbound requests with a token bucket sized to the
+// plan limit. It also follows the X-RateLimit-Remaining and X-RateLimit-Reset
+// headers of each response: when the server reports the window exhausted,
+// requests wait for the reset instead of failing with 429.
+//
+// A RateLimiter is safe for concurrent use and may be shared by several
+// clients using the same API key.
+type RateLimiter struct {
+	mu     sync.Mutex
+	rate   float64 // tokens per second
+	burst  float64
+	tokens float64
+	last   time.Time
+
+	// Window reported by the server.
+	known     bool
+	remaining int
+	reset     time.Time
+
+	clock Clock
+	now   func() time.Time
+}
+
+// NewRateLimiter returns a limiter allowing limit requests per window, e.g.
+// NewRateLimiter(60, time.Minute). Up to limit requests may be sent in a
+// burst. A non-positive limit or window disables the local bucket, leaving
+// only the server-reported window.
+func NewRateLimiter(limit int, window time.Duration) *RateLimiter {
+	l := &RateLimiter{clock: SystemClock, now: time.Now}
+	if limit > 0 && window > 0 {
+		l.rate = float64(limit) / window.Seconds()
+		l.burst = float64(limit)
+		l.tokens = l.burst
+	}
+	return l
+}
+
+// WithRateLimiter throttles the client's requests with l. Every attempt,
+// including retries, takes a token. l may be shared between clients; it
+// keeps its own clock (see RateLimiter.SetClock).
+func WithRateLimiter(l *RateLimiter) ClientOption {
+	return func(c *Client) {
+		c.limiter = l
+	}
+}
+
+// Wait blocks until a request may be sent or ctx is done.
+func (l *RateLimiter) Wait(ctx context.Context) error {
+	for {
+		d := l.reserve()
+		if d <= 0 {
+			return nil
+		}
+		l.mu.Lock()
+		clock := l.clock
+		l.mu.Unlock()
+		if err := clock.Sleep(ctx, d); err != nil {
+			return err
+		}
+	}
+}
+
+// Observe updates the limiter from the rate-limit headers of a response.
+// It is called by the client; call it directly only when sharing a limiter
+// with requests sent outside the SDK.
+func (l *RateLimiter) Observe(info *RateLimitInfo) {
+	if info == nil {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.known = true
+	l.remaining = info.Remaining
+	l.reset = info.ResetTime
+	if l.burst > 0 && l.tokens > float64(info.Remaining) {
+		l.tokens = max(float64(info.Remaining), 0)
+	}
+}
+
+// reserve takes a token and returns 0, or returns how long to wait before
+// trying again.
+func (l *RateLimiter) reserve() time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	now := l.now()
+
+	if l.known {
+		if !now.Before(l.reset) {
+			l.known = false
+		} else if l.remaining <= 0 {
+			return l.reset.Sub(now)
+		}
+	}
+
+	if l.burst > 0 {
+		if !l.last.IsZero() {
+			l.tokens = min(l.burst, l.tokens+now.Sub(l.last).Seconds()*l.rate)
+		}
+		l.last = now
+		if l.tokens < 1 {
+			return time.Duration((1 - l.tokens) / l.rate * float64(time.Second))
+		}
+		l.tokens--
+	}
+	if l.known {
+		l.remaining--
+	}
+	return 0
+}
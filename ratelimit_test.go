@@ -0,0 +1,74 @@
+package iptuapi
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRateLimiterBucket(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+	l := NewRateLimiter(2, time.Second)
+	l.now = func() time.Time { return now }
+
+	assert.Zero(t, l.reserve())
+	assert.Zero(t, l.reserve())
+	assert.Equal(t, 500*time.Millisecond, l.reserve())
+
+	now = now.Add(500 * time.Millisecond)
+	assert.Zero(t, l.reserve())
+}
+
+func TestRateLimiterObserve(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+	l := NewRateLimiter(100, time.Minute)
+	l.now = func() time.Time { return now }
+
+	l.Observe(&RateLimitInfo{Limit: 100, Remaining: 1, ResetTime: now.Add(10 * time.Second)})
+	assert.Zero(t, l.reserve())
+	assert.Equal(t, 10*time.Second, l.reserve(), "window exhausted waits for reset")
+
+	now = now.Add(10 * time.Second)
+	assert.Zero(t, l.reserve(), "window resets")
+}
+
+func TestRateLimiterWaitCanceled(t *testing.T) {
+	l := NewRateLimiter(0, 0)
+	l.Observe(&RateLimitInfo{Remaining: 0, ResetTime: time.Now().Add(time.Hour)})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, l.Wait(ctx), context.DeadlineExceeded)
+}
+
+func TestWithRateLimiter(t *testing.T) {
+	var calls int32
+	reset := time.Now().Add(time.Hour).Unix()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.Header().Set("X-RateLimit-Limit", "10")
+		w.Header().Set("X-RateLimit-Remaining", "0")
+		w.Header().Set("X-RateLimit-Reset", strconv.FormatInt(reset, 10))
+		w.Write([]byte(`{"sql":"000.000.0000-0"}`))
+	}))
+	defer server.Close()
+
+	client := NewClient("test_key", WithBaseURL(server.URL),
+		WithRetry(&RetryConfig{MaxRetries: 0}), WithRateLimiter(NewRateLimiter(10, time.Second)))
+
+	_, err := client.ConsultaSQL(context.Background(), "000.000.0000-0", CidadeSaoPaulo)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	_, err = client.ConsultaSQL(ctx, "000.000.0000-0", CidadeSaoPaulo)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls), "exhausted window blocks before sending")
+}
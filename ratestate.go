@@ -0,0 +1,71 @@
+package iptuapi
+
+import "time"
+
+// RequestInfo describes the last response received by a client.
+type RequestInfo struct {
+	// RequestID is the X-Request-ID of the response, or the one sent if
+	// the response has none.
+	RequestID string
+	Method    string
+	Route     string
+	Status    int
+	Time      time.Time
+}
+
+// RateLimitState returns a copy of the quota reported by the last response
+// that carried rate limit headers, or the zero value if none did. Unlike
+// the RateLimit field it is safe to call concurrently with requests.
+func (c *Client) RateLimitState() RateLimitInfo {
+	c.rateMu.Lock()
+	defer c.rateMu.Unlock()
+	if c.RateLimit == nil {
+		return RateLimitInfo{}
+	}
+	return *c.RateLimit
+}
+
+// LastRequest returns the last response received, or the zero value before
+// the first one. It is safe to call concurrently with requests.
+func (c *Client) LastRequest() RequestInfo {
+	c.rateMu.Lock()
+	defer c.rateMu.Unlock()
+	return c.lastRequest
+}
+
+// SubscribeRateLimit returns a channel receiving the quota reported by each
+// response, e.g. to drive a dashboard or adapt concurrency. The channel
+// holds only the latest value: a slow reader skips intermediate updates
+// but never blocks requests. cancel stops the updates and closes the
+// channel.
+func (c *Client) SubscribeRateLimit() (updates <-chan RateLimitInfo, cancel func()) {
+	ch := make(chan RateLimitInfo, 1)
+	c.rateMu.Lock()
+	if c.rateSubs == nil {
+		c.rateSubs = make(map[chan RateLimitInfo]struct{})
+	}
+	c.rateSubs[ch] = struct{}{}
+	c.rateMu.Unlock()
+
+	cancel = func() {
+		c.rateMu.Lock()
+		defer c.rateMu.Unlock()
+		if _, ok := c.rateSubs[ch]; ok {
+			delete(c.rateSubs, ch)
+			close(ch)
+		}
+	}
+	return ch, cancel
+}
+
+// publishRateLimit sends rl to the subscribers, replacing any value they
+// have not read yet. The caller holds rateMu.
+func (c *Client) publishRateLimit(rl RateLimitInfo) {
+	for ch := range c.rateSubs {
+		select {
+		case <-ch:
+		default:
+		}
+		ch <- rl
+	}
+}
@@ -0,0 +1,88 @@
+package iptuapi
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRateLimitStateAndLastRequest(t *testing.T) {
+	var remaining atomic.Int32
+	remaining.Store(1000)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-RateLimit-Limit", "1000")
+		w.Header().Set("X-RateLimit-Remaining", strconv.Itoa(int(remaining.Add(-1))))
+		w.Header().Set("X-RateLimit-Reset", strconv.FormatInt(time.Now().Add(time.Hour).Unix(), 10))
+		w.Header().Set("X-Request-ID", "req_"+r.URL.Path[len("/consulta/sql/"):])
+		w.Write([]byte(`{"sql":"1"}`))
+	}))
+	defer server.Close()
+
+	client := NewClient("test_key", WithBaseURL(server.URL))
+	assert.Zero(t, client.RateLimitState())
+	assert.Zero(t, client.LastRequest())
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			_, err := client.ConsultaSQL(context.Background(), strconv.Itoa(i), "")
+			assert.NoError(t, err)
+			client.RateLimitState()
+			client.LastRequest()
+		}(i)
+	}
+	wg.Wait()
+
+	rl := client.RateLimitState()
+	assert.Equal(t, 1000, rl.Limit)
+	assert.GreaterOrEqual(t, rl.Remaining, 980)
+	last := client.LastRequest()
+	assert.Equal(t, http.MethodGet, last.Method)
+	assert.Equal(t, "/consulta/sql/{sql}", last.Route)
+	assert.Equal(t, http.StatusOK, last.Status)
+	assert.Contains(t, last.RequestID, "req_")
+	assert.False(t, last.Time.IsZero())
+}
+
+func TestSubscribeRateLimit(t *testing.T) {
+	var remaining atomic.Int32
+	remaining.Store(10)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-RateLimit-Limit", "10")
+		w.Header().Set("X-RateLimit-Remaining", strconv.Itoa(int(remaining.Add(-1))))
+		w.Header().Set("X-RateLimit-Reset", strconv.FormatInt(time.Now().Add(time.Hour).Unix(), 10))
+		w.Write([]byte(`{"sql":"1"}`))
+	}))
+	defer server.Close()
+
+	client := NewClient("test_key", WithBaseURL(server.URL))
+	updates, cancel := client.SubscribeRateLimit()
+
+	_, err := client.ConsultaSQL(context.Background(), "1", "")
+	require.NoError(t, err)
+	assert.Equal(t, 9, (<-updates).Remaining)
+
+	// An unread update is replaced by the newest one.
+	for i := 0; i < 3; i++ {
+		_, err = client.ConsultaSQL(context.Background(), "1", "")
+		require.NoError(t, err)
+	}
+	assert.Equal(t, 6, (<-updates).Remaining)
+
+	cancel()
+	cancel()
+	_, open := <-updates
+	assert.False(t, open)
+	_, err = client.ConsultaSQL(context.Background(), "1", "")
+	require.NoError(t, err)
+}
@@ -0,0 +1,29 @@
+package iptuapi
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// RawResponse is the response of a call as received, before decoding; see
+// WithRawCapture.
+type RawResponse struct {
+	StatusCode int
+	Header     http.Header
+	Body       json.RawMessage
+	// Cached is set when the body came from the client's cache. Header is
+	// then nil.
+	Cached bool
+}
+
+// WithRawCapture stores the final response of the call in raw, next to the
+// decoded result, e.g. to keep the payload for audit and reprocess it when
+// the schema evolves. Error responses are captured too, so raw holds the
+// API's error body when the call fails with an *APIError. raw is left
+// unchanged when no response was received. Calls capturing the response
+// are never coalesced with others. Stream methods ignore it.
+func WithRawCapture(raw *RawResponse) RequestOption {
+	return func(o *requestOptions) {
+		o.raw = raw
+	}
+}
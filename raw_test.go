@@ -0,0 +1,53 @@
+package iptuapi
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithRawCapture(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Request-ID", "req_raw")
+		if r.URL.Path == "/consulta/sql/999" {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"detail":"Imovel nao encontrado"}`))
+			return
+		}
+		w.Write([]byte(`{"sql":"123","valor_venal":500000,"campo_novo":{"a":1}}`))
+	}))
+	defer server.Close()
+
+	client := NewClient("test_key", WithBaseURL(server.URL), WithRetry(&RetryConfig{MaxRetries: 0}),
+		WithCache(NewLRUCache(10), time.Minute))
+
+	var raw RawResponse
+	result, err := client.ConsultaSQL(context.Background(), "123", "", WithRawCapture(&raw))
+	require.NoError(t, err)
+	assert.Equal(t, 500000.0, result.ValorVenal)
+	assert.Equal(t, http.StatusOK, raw.StatusCode)
+	assert.Equal(t, "req_raw", raw.Header.Get("X-Request-ID"))
+	assert.False(t, raw.Cached)
+	var payload map[string]interface{}
+	require.NoError(t, json.Unmarshal(raw.Body, &payload))
+	assert.Contains(t, payload, "campo_novo", "fields unknown to the SDK are kept")
+
+	raw = RawResponse{}
+	_, err = client.ConsultaSQL(context.Background(), "123", "", WithRawCapture(&raw))
+	require.NoError(t, err)
+	assert.True(t, raw.Cached)
+	assert.Nil(t, raw.Header)
+	assert.JSONEq(t, `{"sql":"123","valor_venal":500000,"campo_novo":{"a":1}}`, string(raw.Body))
+
+	raw = RawResponse{}
+	_, err = client.ConsultaSQL(context.Background(), "999", "", WithRawCapture(&raw))
+	assert.True(t, IsNotFound(err))
+	assert.Equal(t, http.StatusNotFound, raw.StatusCode)
+	assert.JSONEq(t, `{"detail":"Imovel nao encontrado"}`, string(raw.Body))
+}
@@ -0,0 +1,49 @@
+package iptuapi
+
+import "context"
+
+// ReadOnlyClient is the subset of Client that only looks data up. It has no
+// methods that change client configuration or account state, so it can be
+// handed to untrusted plugin code.
+type ReadOnlyClient interface {
+	ConsultaEndereco(ctx context.Context, p *ConsultaEnderecoParams, opts ...RequestOption) (*ConsultaEnderecoResult, error)
+	ConsultaSQL(ctx context.Context, sql string, cidade Cidade, opts ...RequestOption) (*ConsultaSQLResult, error)
+	ConsultaCEP(ctx context.Context, cep string, cidade Cidade, opts ...RequestOption) ([]ConsultaEnderecoResult, error)
+	ConsultaCEPNumero(ctx context.Context, cep, numero string, cidade Cidade, opts ...RequestOption) ([]ConsultaEnderecoResult, error)
+	ConsultaCondominio(ctx context.Context, p *ConsultaCondominioParams, opts ...RequestOption) (*Condominio, error)
+	ConsultaZoneamento(ctx context.Context, latitude, longitude float64, opts ...RequestOption) (*ZoneamentoResult, error)
+
+	ValuationEstimate(ctx context.Context, p *ValuationParams, opts ...RequestOption) (*ValuationResult, error)
+	ValuationEvaluate(ctx context.Context, p *EvaluateParams, opts ...RequestOption) (*EvaluateResult, error)
+	ValuationComparables(ctx context.Context, bairro string, areaMin, areaMax float64, cidade Cidade, limit int, opts ...RequestOption) ([]ComparavelItem, error)
+	ValuationStatistics(ctx context.Context, bairro string, cidade Cidade, opts ...RequestOption) (*ValuationStatisticsResult, error)
+	ValuationStatisticsRegiao(ctx context.Context, regiao string, cidade Cidade, opts ...RequestOption) (*RegiaoStatisticsResult, error)
+	ValuationComparablesRegiao(ctx context.Context, regiao string, areaMin, areaMax float64, cidade Cidade, limit int, opts ...RequestOption) ([]ComparavelItem, error)
+
+	DadosIPTUHistorico(ctx context.Context, sql string, cidade Cidade, opts ...RequestOption) ([]HistoricoItem, error)
+	HistoricoValorVenal(ctx context.Context, sql string, cidade Cidade, anos int, opts ...RequestOption) (*SerieValorVenal, error)
+	DadosCNPJ(ctx context.Context, cnpj string, opts ...RequestOption) (map[string]interface{}, error)
+	DadosIPCA(ctx context.Context, dataInicio, dataFim string, opts ...RequestOption) ([]IPCAItem, error)
+	IPCACorrecao(ctx context.Context, valor float64, dataOrigem, dataDestino string, opts ...RequestOption) (map[string]interface{}, error)
+	ITBITransacoes(ctx context.Context, p *ITBIParams, opts ...RequestOption) ([]ITBITransacao, error)
+	Dossie(ctx context.Context, sql string, cidade Cidade, opts ...RequestOption) (*Dossie, error)
+
+	IPTUToolsCidades(ctx context.Context, opts ...RequestOption) (*CidadesResult, error)
+	IPTUToolsCalendario(ctx context.Context, cidade Cidade, opts ...RequestOption) (*CalendarioResult, error)
+	IPTUToolsSimulador(ctx context.Context, p *SimuladorParams, opts ...RequestOption) (*SimuladorResult, error)
+	IPTUToolsIsencao(ctx context.Context, valorVenal float64, cidade Cidade, opts ...RequestOption) (*IsencaoResult, error)
+	IPTUToolsProximoVencimento(ctx context.Context, cidade Cidade, parcela int, opts ...RequestOption) (*ProximoVencimentoResult, error)
+
+	Capabilities(ctx context.Context, opts ...RequestOption) (*CapabilityMatrix, error)
+	Dicionario(ctx context.Context, opts ...RequestOption) (*DicionarioResult, error)
+}
+
+var _ ReadOnlyClient = (*Client)(nil)
+
+// ReadOnly returns a view of c exposing only lookups. The view cannot be
+// converted back to *Client, so plugin code holding it cannot add
+// middleware, read the configuration or reach mutating endpoints. Calls
+// still share c's quota, cache and rate limiter.
+func ReadOnly(c *Client) ReadOnlyClient {
+	return &ScopedClient{c: c}
+}
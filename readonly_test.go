@@ -0,0 +1,30 @@
+package iptuapi
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadOnly(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(sampleIPTUResponse)
+	}))
+	defer server.Close()
+
+	ro := ReadOnly(NewClient("test_key", WithBaseURL(server.URL)))
+
+	result, err := ro.ConsultaSQL(context.Background(), "000.000.0000-0", CidadeSaoPaulo)
+	require.NoError(t, err)
+	assert.Equal(t, sampleIPTUResponse.SQL, result.SQL)
+
+	_, ok := ro.(*Client)
+	assert.False(t, ok, "the view does not expose the client")
+	_, ok = ro.(interface{ Use(...Middleware) })
+	assert.False(t, ok)
+}
@@ -0,0 +1,261 @@
+package iptuapi
+
+import (
+	"context"
+	"encoding/csv"
+	"io"
+	"strconv"
+	"time"
+)
+
+// MatchOutcome classifies how an input address matched the API records.
+type MatchOutcome string
+
+const (
+	MatchExact     MatchOutcome = "exact"
+	MatchFuzzy     MatchOutcome = "fuzzy"
+	MatchAmbiguous MatchOutcome = "ambiguous"
+	MatchNone      MatchOutcome = "none"
+	MatchError     MatchOutcome = "error"
+)
+
+// ReconcileInput is one address to reconcile. ID is an optional caller key
+// carried through to the report.
+type ReconcileInput struct {
+	ID     string
+	Params ConsultaEnderecoParams
+}
+
+// ReconcileMatch is the reconciliation result for one input.
+type ReconcileMatch struct {
+	ID         string                  `json:"id,omitempty"`
+	Input      ConsultaEnderecoParams  `json:"input"`
+	Outcome    MatchOutcome            `json:"outcome"`
+	Score      float64                 `json:"score"`
+	Candidates int                     `json:"candidates"`
+	Result     *ConsultaEnderecoResult `json:"result,omitempty"`
+	Error      string                  `json:"error,omitempty"`
+	ErrorClass ErrorClass              `json:"error_class,omitempty"`
+	// Attempts is the number of lookups made for the input's address.
+	Attempts int `json:"attempts"`
+}
+
+// ReconcileSummary counts inputs by outcome.
+type ReconcileSummary struct {
+	Total     int `json:"total"`
+	Exact     int `json:"exact"`
+	Fuzzy     int `json:"fuzzy"`
+	Ambiguous int `json:"ambiguous"`
+	None      int `json:"none"`
+	Errors    int `json:"errors"`
+	// RetryableErrors and PermanentErrors split Errors by ErrorClass, and
+	// Recovered counts inputs whose lookup succeeded only in a retry pass.
+	RetryableErrors int `json:"retryable_errors"`
+	PermanentErrors int `json:"permanent_errors"`
+	Recovered       int `json:"recovered"`
+	// MatchRate is the share of inputs matched exactly or fuzzily.
+	MatchRate float64 `json:"match_rate"`
+}
+
+// ReconcileReport is the output of Client.Reconcile.
+type ReconcileReport struct {
+	Matches []ReconcileMatch `json:"matches"`
+	Summary ReconcileSummary `json:"summary"`
+	// Run counts inputs by MatchOutcome, with the lookups' latency, the
+	// quota consumed and the most frequent errors.
+	Run *RunReport `json:"run"`
+}
+
+// ReconcileOptions configures Client.Reconcile.
+type ReconcileOptions struct {
+	// FuzzyThreshold is the minimum score for a fuzzy match (default 0.8).
+	FuzzyThreshold float64
+	// AmbiguityMargin marks a match ambiguous when another candidate scores
+	// within this distance of the best one (default 0.02).
+	AmbiguityMargin float64
+	// Candidates returns the records to compare an input against. The
+	// default runs ConsultaEndereco and returns its single result.
+	Candidates func(ctx context.Context, p *ConsultaEnderecoParams) ([]ConsultaEnderecoResult, error)
+	// Matcher overrides the client's matcher (see WithMatcher) for this run.
+	Matcher Matcher
+	// Batch configures the retry queue for lookups failing with retryable
+	// errors (see RunBatch).
+	Batch *BatchOptions
+}
+
+// Reconcile looks up every input address and records whether it matched
+// exactly, fuzzily (with a similarity score), ambiguously or not at all.
+// Identical inputs are looked up once. Lookups failing with retryable
+// errors are retried after the main pass (see RunBatch). On context
+// cancellation the report built so far is returned along with the error.
+func (c *Client) Reconcile(ctx context.Context, inputs []ReconcileInput, opts *ReconcileOptions) (*ReconcileReport, error) {
+	o := ReconcileOptions{}
+	if opts != nil {
+		o = *opts
+	}
+	if o.FuzzyThreshold <= 0 {
+		o.FuzzyThreshold = 0.8
+	}
+	if o.AmbiguityMargin <= 0 {
+		o.AmbiguityMargin = 0.02
+	}
+	if o.Matcher == nil {
+		o.Matcher = c.matcher
+	}
+	if o.Candidates == nil {
+		o.Candidates = func(ctx context.Context, p *ConsultaEnderecoParams) ([]ConsultaEnderecoResult, error) {
+			r, err := c.ConsultaEndereco(ctx, p)
+			if err != nil {
+				return nil, err
+			}
+			return []ConsultaEnderecoResult{*r}, nil
+		}
+	}
+
+	// Look up each distinct address once.
+	keys := make([]string, len(inputs))
+	index := make(map[string]int)
+	var unique []ConsultaEnderecoParams
+	for i, in := range inputs {
+		key := NormalizeAddress(in.Params.Logradouro) + "|" + NormalizeNumero(in.Params.Numero) +
+			"|" + NormalizeAddress(in.Params.Complemento) + "|" + string(in.Params.Cidade)
+		if _, ok := index[key]; !ok {
+			index[key] = len(unique)
+			unique = append(unique, in.Params)
+		}
+		keys[i] = key
+	}
+	recorder := NewRunRecorder(c)
+	lookups, _, runErr := RunBatch(ctx, unique, func(ctx context.Context, p ConsultaEnderecoParams) ([]ConsultaEnderecoResult, error) {
+		start := time.Now()
+		defer func() { recorder.Latency(time.Since(start)) }()
+		return o.Candidates(ctx, &p)
+	}, o.Batch)
+
+	report := &ReconcileReport{Matches: make([]ReconcileMatch, 0, len(inputs))}
+	for i, in := range inputs {
+		l := lookups[index[keys[i]]]
+		if l.Attempts == 0 {
+			break
+		}
+		var m ReconcileMatch
+		switch {
+		case l.Err == nil:
+			m = classifyMatch(&in.Params, l.Value, &o)
+		case IsNotFound(l.Err):
+			m = ReconcileMatch{Outcome: MatchNone}
+		default:
+			m = ReconcileMatch{Outcome: MatchError, Error: l.Err.Error(), ErrorClass: l.Class}
+		}
+		m.Attempts = l.Attempts
+		m.ID = in.ID
+		m.Input = in.Params
+		report.Matches = append(report.Matches, m)
+		if m.Outcome == MatchError {
+			recorder.Record(string(m.Outcome), l.Err)
+		} else {
+			recorder.Record(string(m.Outcome), nil)
+		}
+	}
+
+	for range inputs[len(report.Matches):] {
+		recorder.Record(OutcomeNotRun, nil)
+	}
+
+	report.summarize()
+	report.Run = recorder.Report()
+	return report, runErr
+}
+
+// classifyMatch scores candidates against the input address.
+func classifyMatch(in *ConsultaEnderecoParams, candidates []ConsultaEnderecoResult, o *ReconcileOptions) ReconcileMatch {
+	m := ReconcileMatch{Outcome: MatchNone, Candidates: len(candidates)}
+	if len(candidates) == 0 {
+		return m
+	}
+
+	best, bestIdx, near := -1.0, -1, 0
+	scores := make([]float64, len(candidates))
+	for i := range candidates {
+		scores[i] = AddressScore(o.Matcher, in, &candidates[i])
+		if scores[i] > best {
+			best, bestIdx = scores[i], i
+		}
+	}
+	for _, s := range scores {
+		if s >= o.FuzzyThreshold && best-s <= o.AmbiguityMargin {
+			near++
+		}
+	}
+
+	m.Score = best
+	switch {
+	case best < o.FuzzyThreshold:
+		m.Outcome = MatchNone
+		return m
+	case near > 1:
+		m.Outcome = MatchAmbiguous
+		return m
+	case best == 1:
+		m.Outcome = MatchExact
+	default:
+		m.Outcome = MatchFuzzy
+	}
+	m.Result = &candidates[bestIdx]
+	return m
+}
+
+func (r *ReconcileReport) summarize() {
+	s := ReconcileSummary{Total: len(r.Matches)}
+	for _, m := range r.Matches {
+		if m.Attempts > 1 && m.Outcome != MatchError {
+			s.Recovered++
+		}
+		switch m.Outcome {
+		case MatchExact:
+			s.Exact++
+		case MatchFuzzy:
+			s.Fuzzy++
+		case MatchAmbiguous:
+			s.Ambiguous++
+		case MatchNone:
+			s.None++
+		case MatchError:
+			s.Errors++
+			if m.ErrorClass == ErrorRetryable {
+				s.RetryableErrors++
+			} else {
+				s.PermanentErrors++
+			}
+		}
+	}
+	if s.Total > 0 {
+		s.MatchRate = float64(s.Exact+s.Fuzzy) / float64(s.Total)
+	}
+	r.Summary = s
+}
+
+// WriteCSV writes one row per input with its outcome, score and matched SQL.
+func (r *ReconcileReport) WriteCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write([]string{"id", "logradouro", "numero", "complemento", "cidade",
+		"outcome", "score", "candidates", "sql", "error"}); err != nil {
+		return err
+	}
+	for _, m := range r.Matches {
+		sql := ""
+		if m.Result != nil {
+			sql = m.Result.SQL
+		}
+		row := []string{
+			m.ID, m.Input.Logradouro, m.Input.Numero, m.Input.Complemento, string(m.Input.Cidade),
+			string(m.Outcome), strconv.FormatFloat(m.Score, 'f', 4, 64),
+			strconv.Itoa(m.Candidates), sql, m.Error,
+		}
+		if err := cw.Write(row); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
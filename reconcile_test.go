@@ -0,0 +1,117 @@
+package iptuapi
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNormalizeAddress(t *testing.T) {
+	assert.Equal(t, "avenida paulista", NormalizeAddress("Av. Paulista"))
+	assert.Equal(t, "rua sao bento", NormalizeAddress("R. São Bento"))
+	assert.Equal(t, "praca da se", NormalizeAddress("PÇA da Sé"))
+	assert.Equal(t, "1000", NormalizeNumero("nº 1.000"))
+}
+
+func TestReconcile(t *testing.T) {
+	lookups := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		lookups++
+		switch r.URL.Query().Get("logradouro") {
+		case "Rua Inexistente":
+			w.WriteHeader(http.StatusNotFound)
+			json.NewEncoder(w).Encode(map[string]string{"detail": "Imóvel não encontrado"})
+		default:
+			json.NewEncoder(w).Encode(sampleIPTUResponse)
+		}
+	}))
+	defer server.Close()
+
+	client := NewClient("test_key",
+		WithBaseURL(server.URL),
+		WithRetry(&RetryConfig{MaxRetries: 0}),
+	)
+
+	report, err := client.Reconcile(context.Background(), []ReconcileInput{
+		{ID: "a", Params: ConsultaEnderecoParams{Logradouro: "Av. Paulista", Numero: "1000"}},
+		{ID: "b", Params: ConsultaEnderecoParams{Logradouro: "Av Paulsta", Numero: "1000"}},
+		{ID: "c", Params: ConsultaEnderecoParams{Logradouro: "Rua Inexistente", Numero: "1"}},
+		{ID: "d", Params: ConsultaEnderecoParams{Logradouro: "AVENIDA PAULISTA", Numero: "1.000"}},
+	}, nil)
+	require.NoError(t, err)
+
+	require.Len(t, report.Matches, 4)
+	assert.Equal(t, MatchExact, report.Matches[0].Outcome)
+	assert.Equal(t, "000.000.0000-0", report.Matches[0].Result.SQL)
+	assert.Equal(t, MatchFuzzy, report.Matches[1].Outcome)
+	assert.InDelta(t, 0.9375, report.Matches[1].Score, 0.001)
+	assert.Equal(t, MatchNone, report.Matches[2].Outcome)
+	assert.Equal(t, MatchExact, report.Matches[3].Outcome)
+	assert.Equal(t, "d", report.Matches[3].ID)
+
+	// "d" normalizes to the same address as "a" and is not looked up again.
+	assert.Equal(t, 3, lookups)
+	assert.Equal(t, ReconcileSummary{Total: 4, Exact: 2, Fuzzy: 1, None: 1, MatchRate: 0.75}, report.Summary)
+	assert.Equal(t, map[string]int{"exact": 2, "fuzzy": 1, "none": 1}, report.Run.Outcomes)
+	assert.Equal(t, int64(3), report.Run.QuotaConsumed)
+
+	var buf bytes.Buffer
+	require.NoError(t, report.WriteCSV(&buf))
+	assert.Contains(t, buf.String(), "b,Av Paulsta,1000,,,fuzzy,0.9375,1,000.000.0000-0,")
+}
+
+func TestReconcileAmbiguous(t *testing.T) {
+	client := NewClient("test_key")
+	candidates := func(ctx context.Context, p *ConsultaEnderecoParams) ([]ConsultaEnderecoResult, error) {
+		return []ConsultaEnderecoResult{
+			{SQL: "1", Logradouro: "Rua Augusta", Numero: "500"},
+			{SQL: "2", Logradouro: "Rua Augusta", Numero: "500"},
+		}, nil
+	}
+
+	report, err := client.Reconcile(context.Background(), []ReconcileInput{
+		{Params: ConsultaEnderecoParams{Logradouro: "R. Augusta", Numero: "500"}},
+	}, &ReconcileOptions{Candidates: candidates})
+	require.NoError(t, err)
+
+	m := report.Matches[0]
+	assert.Equal(t, MatchAmbiguous, m.Outcome)
+	assert.Equal(t, 2, m.Candidates)
+	assert.Nil(t, m.Result)
+}
+
+func TestReconcileRetriesFailedLookups(t *testing.T) {
+	client := NewClient("test_key")
+	calls := map[string]int{}
+	candidates := func(ctx context.Context, p *ConsultaEnderecoParams) ([]ConsultaEnderecoResult, error) {
+		calls[p.Logradouro]++
+		switch {
+		case p.Logradouro == "Rua Augusta" && calls[p.Logradouro] == 1:
+			return nil, &ServerError{APIError: &APIError{StatusCode: 503, Message: "indisponivel"}}
+		case p.Logradouro == "Rua Invalida":
+			return nil, &ValidationError{APIError: &APIError{StatusCode: 422, Message: "numero invalido"}}
+		}
+		return []ConsultaEnderecoResult{{SQL: "1", Logradouro: p.Logradouro, Numero: p.Numero}}, nil
+	}
+
+	report, err := client.Reconcile(context.Background(), []ReconcileInput{
+		{ID: "a", Params: ConsultaEnderecoParams{Logradouro: "Rua Augusta", Numero: "500"}},
+		{ID: "b", Params: ConsultaEnderecoParams{Logradouro: "Rua Invalida", Numero: "x"}},
+	}, &ReconcileOptions{Candidates: candidates, Batch: &BatchOptions{RetryBackoff: time.Millisecond}})
+	require.NoError(t, err)
+
+	assert.Equal(t, MatchExact, report.Matches[0].Outcome)
+	assert.Equal(t, 2, report.Matches[0].Attempts)
+	assert.Equal(t, ErrorPermanent, report.Matches[1].ErrorClass)
+	assert.Equal(t, 1, calls["Rua Invalida"])
+	assert.Equal(t, 1, report.Summary.Recovered)
+	assert.Equal(t, 1, report.Summary.PermanentErrors)
+	assert.Zero(t, report.Summary.RetryableErrors)
+}
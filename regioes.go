@@ -0,0 +1,249 @@
+package iptuapi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+)
+
+// ErrRegiaoDesconhecida is returned for a region missing from the city's
+// mapping.
+var ErrRegiaoDesconhecida = errors.New("iptuapi: região administrativa desconhecida")
+
+// Regioes maps administrative region names (São Paulo subprefeituras, Rio
+// de Janeiro regiões administrativas) to the bairros they contain.
+type Regioes map[string][]string
+
+// regioesPadrao is the mapping shipped with the SDK. Bairros are matched
+// with NormalizeBairro, so abbreviations and known variants are accepted.
+var regioesPadrao = map[Cidade]Regioes{
+	CidadeSaoPaulo: {
+		"Aricanduva/Formosa/Carrão": {"Aricanduva", "Carrão", "Vila Formosa"},
+		"Butantã":                   {"Butantã", "Morumbi", "Raposo Tavares", "Rio Pequeno", "Vila Sônia"},
+		"Campo Limpo":               {"Campo Limpo", "Capão Redondo", "Vila Andrade"},
+		"Capela do Socorro":         {"Cidade Dutra", "Grajaú", "Socorro"},
+		"Casa Verde/Cachoeirinha":   {"Casa Verde", "Cachoeirinha", "Limão"},
+		"Cidade Ademar":             {"Cidade Ademar", "Pedreira"},
+		"Cidade Tiradentes":         {"Cidade Tiradentes"},
+		"Ermelino Matarazzo":        {"Ermelino Matarazzo", "Ponte Rasa"},
+		"Freguesia/Brasilândia":     {"Freguesia do Ó", "Brasilândia"},
+		"Guaianases":                {"Guaianases", "Lajeado"},
+		"Ipiranga":                  {"Ipiranga", "Cursino", "Sacomã"},
+		"Itaim Paulista":            {"Itaim Paulista", "Vila Curuçá"},
+		"Itaquera":                  {"Itaquera", "Cidade Líder", "José Bonifácio", "Parque do Carmo"},
+		"Jabaquara":                 {"Jabaquara"},
+		"Jaçanã/Tremembé":           {"Jaçanã", "Tremembé"},
+		"Lapa":                      {"Lapa", "Barra Funda", "Perdizes", "Vila Leopoldina", "Jaguara", "Jaguaré"},
+		"M'Boi Mirim":               {"Jardim Ângela", "Jardim São Luís"},
+		"Mooca":                     {"Mooca", "Água Rasa", "Belém", "Brás", "Pari", "Tatuapé"},
+		"Parelheiros":               {"Parelheiros", "Marsilac"},
+		"Penha":                     {"Penha", "Artur Alvim", "Cangaíba", "Vila Matilde"},
+		"Perus":                     {"Perus", "Anhanguera"},
+		"Pinheiros":                 {"Pinheiros", "Alto de Pinheiros", "Itaim Bibi", "Jardim Paulista", "Jardim América", "Jardim Europa", "Cerqueira César", "Vila Madalena", "Vila Olímpia", "Vila Nova Conceição"},
+		"Pirituba/Jaraguá":          {"Pirituba", "Jaraguá", "São Domingos"},
+		"Santana/Tucuruvi":          {"Santana", "Tucuruvi", "Mandaqui"},
+		"Santo Amaro":               {"Santo Amaro", "Campo Belo", "Campo Grande", "Brooklin"},
+		"São Mateus":                {"São Mateus", "São Rafael", "Iguatemi"},
+		"São Miguel":                {"São Miguel", "Jardim Helena", "Vila Jacuí"},
+		"Sapopemba":                 {"Sapopemba"},
+		"Sé":                        {"Sé", "Bela Vista", "Bom Retiro", "Cambuci", "Consolação", "Liberdade", "República", "Santa Cecília", "Higienópolis", "Aclimação"},
+		"Vila Maria/Vila Guilherme": {"Vila Maria", "Vila Guilherme", "Vila Medeiros"},
+		"Vila Mariana":              {"Vila Mariana", "Moema", "Saúde", "Paraíso"},
+		"Vila Prudente":             {"Vila Prudente", "São Lucas"},
+	},
+	CidadeRioDeJaneiro: {
+		"Portuária":       {"Saúde", "Gamboa", "Santo Cristo", "Caju"},
+		"Centro":          {"Centro", "Lapa"},
+		"Botafogo":        {"Botafogo", "Flamengo", "Laranjeiras", "Catete", "Glória", "Cosme Velho", "Humaitá", "Urca"},
+		"Copacabana":      {"Copacabana", "Leme"},
+		"Lagoa":           {"Ipanema", "Leblon", "Lagoa", "Gávea", "Jardim Botânico", "São Conrado", "Vidigal"},
+		"Tijuca":          {"Tijuca", "Praça da Bandeira", "Alto da Boa Vista"},
+		"Vila Isabel":     {"Vila Isabel", "Maracanã", "Grajaú", "Andaraí"},
+		"Méier":           {"Méier", "Todos os Santos", "Cachambi", "Engenho de Dentro", "Lins de Vasconcelos", "Engenho Novo"},
+		"Jacarepaguá":     {"Jacarepaguá", "Freguesia", "Pechincha", "Taquara", "Tanque", "Anil", "Curicica"},
+		"Barra da Tijuca": {"Barra da Tijuca", "Recreio dos Bandeirantes", "Joá", "Itanhangá", "Camorim", "Vargem Grande", "Vargem Pequena", "Grumari"},
+	},
+}
+
+// DefaultRegioes returns a copy of the region mapping shipped for cidade,
+// or nil if there is none. Use it as a starting point for WithRegioes.
+func DefaultRegioes(cidade Cidade) Regioes {
+	src, ok := regioesPadrao[cidadeOrDefault(cidade)]
+	if !ok {
+		return nil
+	}
+	out := make(Regioes, len(src))
+	for regiao, bairros := range src {
+		out[regiao] = append([]string(nil), bairros...)
+	}
+	return out
+}
+
+// WithRegioes replaces the region mapping for cidade.
+func WithRegioes(cidade Cidade, r Regioes) ClientOption {
+	return func(c *Client) {
+		if c.regioes == nil {
+			c.regioes = make(map[Cidade]Regioes)
+		}
+		c.regioes[cidadeOrDefault(cidade)] = r
+	}
+}
+
+// regioesFor returns the region mapping in effect for cidade.
+func (c *Client) regioesFor(cidade Cidade) Regioes {
+	cidade = cidadeOrDefault(cidade)
+	if r, ok := c.regioes[cidade]; ok {
+		return r
+	}
+	return regioesPadrao[cidade]
+}
+
+// Regiao returns the administrative region of bairro in cidade, or "" when
+// the bairro is not mapped. If a mapping lists the bairro under several
+// regions, the first in alphabetical order wins.
+func (c *Client) Regiao(cidade Cidade, bairro string) string {
+	cidade = cidadeOrDefault(cidade)
+	key := bairroKey(NormalizeBairro(cidade, bairro))
+	regioes := c.regioesFor(cidade)
+	names := make([]string, 0, len(regioes))
+	for name := range regioes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, regiao := range names {
+		for _, b := range regioes[regiao] {
+			if bairroKey(NormalizeBairro(cidade, b)) == key {
+				return regiao
+			}
+		}
+	}
+	return ""
+}
+
+// BairrosDaRegiao returns the bairros of regiao in cidade, normalized with
+// NormalizeBairro and sorted. Region names are matched without case or
+// accents.
+func (c *Client) BairrosDaRegiao(cidade Cidade, regiao string) ([]string, error) {
+	cidade = cidadeOrDefault(cidade)
+	for name, bairros := range c.regioesFor(cidade) {
+		if bairroKey(name) != bairroKey(regiao) {
+			continue
+		}
+		out := make([]string, len(bairros))
+		for i, b := range bairros {
+			out[i] = NormalizeBairro(cidade, b)
+		}
+		sort.Strings(out)
+		return out, nil
+	}
+	return nil, fmt.Errorf("%w: %q (%s)", ErrRegiaoDesconhecida, regiao, cidade)
+}
+
+// RegiaoStatisticsResult aggregates ValuationStatistics over the bairros of
+// an administrative region.
+type RegiaoStatisticsResult struct {
+	Regiao       string  `json:"regiao"`
+	Cidade       string  `json:"cidade"`
+	TotalImoveis int     `json:"total_imoveis"`
+	Media        float64 `json:"media"`
+	// Mediana is the median of the bairro medians weighted by TotalImoveis,
+	// an approximation of the regional median.
+	Mediana      float64 `json:"mediana"`
+	Min          float64 `json:"min"`
+	Max          float64 `json:"max"`
+	DesvioPadrao float64 `json:"desvio_padrao,omitempty"`
+	// Bairros holds the per-bairro statistics the aggregate was built from.
+	Bairros []ValuationStatisticsResult `json:"bairros"`
+}
+
+// ValuationStatisticsRegiao gets value statistics for an administrative
+// region by combining the statistics of its bairros. Bairros without data
+// (404) are skipped; other errors fail the call.
+func (c *Client) ValuationStatisticsRegiao(ctx context.Context, regiao string, cidade Cidade, opts ...RequestOption) (*RegiaoStatisticsResult, error) {
+	cidade = newRequestOptions(opts).cidadeOr(cidade)
+	bairros, err := c.BairrosDaRegiao(cidade, regiao)
+	if err != nil {
+		return nil, err
+	}
+
+	out := &RegiaoStatisticsResult{Regiao: regiao, Cidade: string(cidadeOrDefault(cidade))}
+	for _, b := range bairros {
+		s, err := c.ValuationStatistics(ctx, b, cidade, opts...)
+		if IsNotFound(err) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		if s.TotalImoveis > 0 {
+			out.Bairros = append(out.Bairros, *s)
+		}
+	}
+	out.aggregate()
+	return out, nil
+}
+
+// aggregate combines the per-bairro statistics: weighted mean, pooled
+// standard deviation and weighted median of medians.
+func (r *RegiaoStatisticsResult) aggregate() {
+	if len(r.Bairros) == 0 {
+		return
+	}
+	var sum float64
+	r.Min, r.Max = math.Inf(1), math.Inf(-1)
+	for _, b := range r.Bairros {
+		r.TotalImoveis += b.TotalImoveis
+		sum += b.Media * float64(b.TotalImoveis)
+		r.Min = math.Min(r.Min, b.Min)
+		r.Max = math.Max(r.Max, b.Max)
+	}
+	n := float64(r.TotalImoveis)
+	r.Media = sum / n
+
+	var ss float64
+	for _, b := range r.Bairros {
+		d := b.Media - r.Media
+		ss += float64(b.TotalImoveis) * (b.DesvioPadrao*b.DesvioPadrao + d*d)
+	}
+	r.DesvioPadrao = math.Sqrt(ss / n)
+
+	sorted := append([]ValuationStatisticsResult(nil), r.Bairros...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Mediana < sorted[j].Mediana })
+	acc := 0
+	for _, b := range sorted {
+		acc += b.TotalImoveis
+		if float64(acc) >= n/2 {
+			r.Mediana = b.Mediana
+			break
+		}
+	}
+}
+
+// ValuationComparablesRegiao finds comparable properties across the bairros
+// of an administrative region, querying each bairro and returning at most
+// limit items overall (no limit when limit <= 0).
+func (c *Client) ValuationComparablesRegiao(ctx context.Context, regiao string, areaMin, areaMax float64, cidade Cidade, limit int, opts ...RequestOption) ([]ComparavelItem, error) {
+	cidade = newRequestOptions(opts).cidadeOr(cidade)
+	bairros, err := c.BairrosDaRegiao(cidade, regiao)
+	if err != nil {
+		return nil, err
+	}
+
+	var out []ComparavelItem
+	for _, b := range bairros {
+		items, err := c.ValuationComparables(ctx, b, areaMin, areaMax, cidade, limit, opts...)
+		if IsNotFound(err) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, items...)
+		if limit > 0 && len(out) >= limit {
+			return out[:limit], nil
+		}
+	}
+	return out, nil
+}
@@ -0,0 +1,66 @@
+package iptuapi
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegiao(t *testing.T) {
+	client := NewClient("test_key")
+	assert.Equal(t, "Pinheiros", client.Regiao(CidadeSaoPaulo, "Jd. Paulista"))
+	assert.Equal(t, "Sé", client.Regiao("", "bixiga"))
+	assert.Equal(t, "Barra da Tijuca", client.Regiao(CidadeRioDeJaneiro, "Recreio"))
+	assert.Empty(t, client.Regiao(CidadeSaoPaulo, "Bairro Inexistente"))
+
+	bairros, err := client.BairrosDaRegiao(CidadeSaoPaulo, "vila mariana")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Moema", "Paraíso", "Saúde", "Vila Mariana"}, bairros)
+
+	_, err = client.BairrosDaRegiao(CidadeRecife, "Centro")
+	assert.True(t, errors.Is(err, ErrRegiaoDesconhecida))
+
+	custom := DefaultRegioes(CidadeSaoPaulo)
+	custom["Centro Expandido"] = []string{"Moema", "Pinheiros"}
+	client = NewClient("test_key", WithRegioes(CidadeSaoPaulo, custom))
+	assert.Equal(t, "Centro Expandido", client.Regiao(CidadeSaoPaulo, "Moema"))
+	assert.NotContains(t, DefaultRegioes(CidadeSaoPaulo), "Centro Expandido", "defaults are copied")
+}
+
+func TestValuationStatisticsRegiao(t *testing.T) {
+	stats := map[string]ValuationStatisticsResult{
+		"Moema":        {TotalImoveis: 100, Media: 10000, Mediana: 9500, Min: 5000, Max: 20000, DesvioPadrao: 2000},
+		"Vila Mariana": {TotalImoveis: 300, Media: 8000, Mediana: 7800, Min: 4000, Max: 15000, DesvioPadrao: 1000},
+		"Saúde":        {TotalImoveis: 0},
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		bairro := strings.TrimPrefix(r.URL.Path, "/valuation/statistics/")
+		s, ok := stats[bairro]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		s.Bairro = bairro
+		json.NewEncoder(w).Encode(s)
+	}))
+	defer server.Close()
+	client := NewClient("test_key", WithBaseURL(server.URL), WithRetry(&RetryConfig{MaxRetries: 0}))
+
+	r, err := client.ValuationStatisticsRegiao(context.Background(), "Vila Mariana", CidadeSaoPaulo)
+	require.NoError(t, err)
+	require.Len(t, r.Bairros, 2)
+	assert.Equal(t, 400, r.TotalImoveis)
+	assert.Equal(t, 8500.0, r.Media)
+	assert.Equal(t, 7800.0, r.Mediana)
+	assert.Equal(t, 4000.0, r.Min)
+	assert.Equal(t, 20000.0, r.Max)
+	// Pooled: (100*(2000²+1500²) + 300*(1000²+500²)) / 400.
+	assert.InDelta(t, 1581.14, r.DesvioPadrao, 0.01)
+}
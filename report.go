@@ -0,0 +1,173 @@
+package iptuapi
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// topErrors is the number of distinct error messages kept in a RunReport.
+const topErrors = 5
+
+// RunReport summarizes a batch run in a form schedulers can store as JSON
+// and check, e.g. to decide whether a nightly enrichment passed.
+type RunReport struct {
+	Started  time.Time     `json:"started"`
+	Duration time.Duration `json:"duration_ns"`
+	// Total is the number of items recorded, normally all the items the
+	// run was given.
+	Total int `json:"total"`
+	// Outcomes counts items by outcome. The names depend on the runner:
+	// RunBatch uses the Outcome constants, Reconcile the MatchOutcome
+	// values and OutcomeNotRun.
+	Outcomes map[string]int `json:"outcomes"`
+	// Requests is the number of HTTP attempts the client made during the
+	// run, and QuotaConsumed those that reached the API and count against
+	// the plan's quota. Both include calls made concurrently outside the
+	// run. QuotaRemaining is the quota left at the end, if reported.
+	Requests       int64 `json:"requests"`
+	QuotaConsumed  int64 `json:"quota_consumed"`
+	QuotaRemaining *int  `json:"quota_remaining,omitempty"`
+	// LatencyP50 and LatencyP95 are percentiles of the time spent on each
+	// item, retries within the client included.
+	LatencyP50 time.Duration `json:"latency_p50_ns"`
+	LatencyP95 time.Duration `json:"latency_p95_ns"`
+	// TopErrors lists the most frequent error messages, most frequent
+	// first.
+	TopErrors []ErrorCount `json:"top_errors,omitempty"`
+}
+
+// ErrorCount is an error message and the number of items that failed with
+// it.
+type ErrorCount struct {
+	Message string `json:"message"`
+	Count   int    `json:"count"`
+}
+
+// Outcomes of the items of a RunBatch run, as counted in RunReport.
+const (
+	OutcomeSucceeded = "succeeded"
+	// OutcomeRecovered is an item that succeeded in a retry pass.
+	OutcomeRecovered = "recovered"
+	OutcomeRetryable = "retryable"
+	OutcomePermanent = "permanent"
+	// OutcomeNotRun is an item not processed because the run was
+	// cancelled.
+	OutcomeNotRun = "not_run"
+)
+
+// FailureRate returns the share of items whose outcome is not one of ok,
+// or zero for an empty run.
+func (r *RunReport) FailureRate(ok ...string) float64 {
+	if r.Total == 0 {
+		return 0
+	}
+	passed := 0
+	for _, name := range ok {
+		passed += r.Outcomes[name]
+	}
+	return float64(r.Total-passed) / float64(r.Total)
+}
+
+// RunRecorder builds a RunReport. Runners call Record once per item and
+// Latency once per processing of an item; both are safe for concurrent
+// use.
+type RunRecorder struct {
+	client   *Client
+	started  time.Time
+	requests map[string]map[string]int64
+
+	mu        sync.Mutex
+	total     int
+	outcomes  map[string]int
+	latencies []time.Duration
+	errors    map[string]int
+}
+
+// NewRunRecorder starts recording a run. client, if not nil, is the
+// client whose requests and quota the report includes.
+func NewRunRecorder(client *Client) *RunRecorder {
+	r := &RunRecorder{
+		client:   client,
+		started:  time.Now(),
+		outcomes: make(map[string]int),
+		errors:   make(map[string]int),
+	}
+	if client != nil {
+		r.requests = client.Stats().Requests
+	}
+	return r
+}
+
+// Latency records the time spent processing an item once.
+func (r *RunRecorder) Latency(d time.Duration) {
+	r.mu.Lock()
+	r.latencies = append(r.latencies, d)
+	r.mu.Unlock()
+}
+
+// Record counts an item under outcome, with the error it failed with, if
+// any.
+func (r *RunRecorder) Record(outcome string, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.total++
+	r.outcomes[outcome]++
+	if err != nil {
+		r.errors[err.Error()]++
+	}
+}
+
+// Report returns the report of the items recorded so far.
+func (r *RunRecorder) Report() *RunReport {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	report := &RunReport{
+		Started:  r.started,
+		Duration: time.Since(r.started),
+		Total:    r.total,
+		Outcomes: make(map[string]int, len(r.outcomes)),
+	}
+	for name, n := range r.outcomes {
+		report.Outcomes[name] = n
+	}
+
+	if len(r.latencies) > 0 {
+		sorted := append([]time.Duration(nil), r.latencies...)
+		sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+		report.LatencyP50 = percentile(sorted, 0.50)
+		report.LatencyP95 = percentile(sorted, 0.95)
+	}
+
+	for msg, n := range r.errors {
+		report.TopErrors = append(report.TopErrors, ErrorCount{Message: msg, Count: n})
+	}
+	sort.Slice(report.TopErrors, func(i, j int) bool {
+		a, b := report.TopErrors[i], report.TopErrors[j]
+		if a.Count != b.Count {
+			return a.Count > b.Count
+		}
+		return a.Message < b.Message
+	})
+	if len(report.TopErrors) > topErrors {
+		report.TopErrors = report.TopErrors[:topErrors]
+	}
+
+	if r.client != nil {
+		for route, byStatus := range r.client.Stats().Requests {
+			for status, n := range byStatus {
+				n -= r.requests[route][status]
+				report.Requests += n
+				if status != "error" {
+					report.QuotaConsumed += n
+				}
+			}
+		}
+		if rl, _ := r.client.lastResponseInfo(); rl != nil {
+			remaining := rl.Remaining
+			report.QuotaRemaining = &remaining
+		}
+	}
+	return report
+}
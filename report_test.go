@@ -0,0 +1,72 @@
+package iptuapi
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunRecorder(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-RateLimit-Limit", "1000")
+		w.Header().Set("X-RateLimit-Remaining", "958")
+		w.Header().Set("X-RateLimit-Reset", "1760000000")
+		w.Write([]byte(`{"sql":"000.000.0000-0"}`))
+	}))
+	defer server.Close()
+	client := NewClient("test_key", WithBaseURL(server.URL))
+
+	// Requests made before the run are not counted.
+	_, err := client.ConsultaSQL(context.Background(), "000.000.0000-0", CidadeSaoPaulo)
+	require.NoError(t, err)
+
+	rec := NewRunRecorder(client)
+	for i := 0; i < 2; i++ {
+		_, err := client.ConsultaSQL(context.Background(), "000.000.0000-0", CidadeSaoPaulo)
+		require.NoError(t, err)
+	}
+	for i := 1; i <= 20; i++ {
+		rec.Latency(time.Duration(i) * time.Millisecond)
+	}
+	rec.Record("ok", nil)
+	rec.Record("ok", nil)
+	for i := 0; i < 3; i++ {
+		rec.Record("erro", errors.New("iptuapi: timeout"))
+	}
+	for i := 0; i < 7; i++ {
+		rec.Record("erro", fmt.Errorf("iptuapi: erro %d", i))
+	}
+
+	report := rec.Report()
+	assert.Equal(t, 12, report.Total)
+	assert.Equal(t, map[string]int{"ok": 2, "erro": 10}, report.Outcomes)
+	assert.Equal(t, int64(2), report.Requests)
+	assert.Equal(t, int64(2), report.QuotaConsumed)
+	require.NotNil(t, report.QuotaRemaining)
+	assert.Equal(t, 958, *report.QuotaRemaining)
+	assert.Equal(t, 10*time.Millisecond, report.LatencyP50)
+	assert.Equal(t, 19*time.Millisecond, report.LatencyP95)
+	require.Len(t, report.TopErrors, 5)
+	assert.Equal(t, ErrorCount{Message: "iptuapi: timeout", Count: 3}, report.TopErrors[0])
+	assert.InDelta(t, 10.0/12, report.FailureRate("ok"), 1e-9)
+
+	b, err := json.Marshal(report)
+	require.NoError(t, err)
+	assert.Contains(t, string(b), `"quota_consumed":2`)
+	assert.Contains(t, string(b), `"latency_p95_ns":19000000`)
+}
+
+func TestRunRecorderWithoutClient(t *testing.T) {
+	report := NewRunRecorder(nil).Report()
+	assert.Zero(t, report.Total)
+	assert.Nil(t, report.QuotaRemaining)
+	assert.Zero(t, report.FailureRate())
+}
@@ -0,0 +1,186 @@
+package iptuapi
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// RequestOption configures a single API call.
+type RequestOption func(*requestOptions)
+
+// requestOptions holds the per-call settings collected from RequestOptions.
+type requestOptions struct {
+	query url.Values
+	// route is the endpoint template used as the stats label when the
+	// endpoint embeds path parameters.
+	route string
+	// header receives the headers of the successful response.
+	header *http.Header
+	// deterministic asks for reproducible results; see WithDeterministic.
+	deterministic bool
+	// reqHeader holds extra request headers; see WithHeader.
+	reqHeader http.Header
+	timeout   time.Duration
+	cidade    Cidade
+	apiKey    string
+	noBreaker bool
+	noCache   bool
+	// raw receives the response as received; see WithRawCapture.
+	raw *RawResponse
+	// skipValidation sends the parameters unchecked; see
+	// WithSkipValidation.
+	skipValidation bool
+}
+
+func newRequestOptions(opts []RequestOption) *requestOptions {
+	o := &requestOptions{}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+// routeFor returns the stats label of a call to endpoint.
+func (o *requestOptions) routeFor(endpoint string) string {
+	if o.route != "" {
+		return o.route
+	}
+	return endpoint
+}
+
+// mergeQuery adds the query parameters set by options to params. WithCidade
+// only applies to the query string of calls without a body.
+func (o *requestOptions) mergeQuery(params url.Values, query bool) url.Values {
+	if o.cidade != "" && query {
+		o.setQuery("cidade", string(o.cidade))
+	}
+	if len(o.query) > 0 {
+		if params == nil {
+			params = url.Values{}
+		}
+		for k, v := range o.query {
+			params[k] = v
+		}
+	}
+	return params
+}
+
+// flags returns the context flags of the call, with NoCache applied.
+func (o *requestOptions) flags(ctx context.Context) Flags {
+	flags := FlagsFrom(ctx)
+	if o.noCache {
+		flags.ForceRefresh = true
+	}
+	return flags
+}
+
+func (o *requestOptions) setQuery(key, value string) {
+	if o.query == nil {
+		o.query = url.Values{}
+	}
+	o.query.Set(key, value)
+}
+
+// cidadeOr returns the WithCidade override, or cidade if there is none.
+func (o *requestOptions) cidadeOr(cidade Cidade) Cidade {
+	if o.cidade != "" {
+		return o.cidade
+	}
+	return cidade
+}
+
+// withRoute labels a call whose endpoint embeds path parameters.
+func withRoute(route string) RequestOption {
+	return func(o *requestOptions) {
+		o.route = route
+	}
+}
+
+// withNoBreaker exempts the call from the circuit breaker.
+func withNoBreaker() RequestOption {
+	return func(o *requestOptions) {
+		o.noBreaker = true
+	}
+}
+
+// withResponseHeader captures the headers of the successful response.
+func withResponseHeader(h *http.Header) RequestOption {
+	return func(o *requestOptions) {
+		o.header = h
+	}
+}
+
+// WithUpdatedSince restricts list endpoints to records changed after t, so
+// periodic refreshes only transfer what changed. A zero t is ignored.
+func WithUpdatedSince(t time.Time) RequestOption {
+	return func(o *requestOptions) {
+		if !t.IsZero() {
+			o.setQuery("updated_since", t.UTC().Format(time.RFC3339))
+		}
+	}
+}
+
+// WithHeader sets a header on the request, e.g. a tracing or correlation
+// ID. It overrides the SDK's own headers with the same name. Calls with
+// extra headers are never coalesced with others.
+func WithHeader(key, value string) RequestOption {
+	return func(o *requestOptions) {
+		if o.reqHeader == nil {
+			o.reqHeader = http.Header{}
+		}
+		o.reqHeader.Set(key, value)
+	}
+}
+
+// WithRequestTimeout bounds the call, including retries, by d. It takes
+// precedence over WithEndpointTimeouts; a shorter context deadline still
+// applies.
+func WithRequestTimeout(d time.Duration) RequestOption {
+	return func(o *requestOptions) {
+		o.timeout = d
+	}
+}
+
+// WithCidade overrides the city of the call, replacing the cidade argument
+// or parameter field.
+func WithCidade(cidade Cidade) RequestOption {
+	return func(o *requestOptions) {
+		o.cidade = cidade
+	}
+}
+
+// WithAno selects the tax year (exercicio) of the call. A non-positive ano
+// is ignored.
+func WithAno(ano int) RequestOption {
+	return func(o *requestOptions) {
+		if ano > 0 {
+			o.setQuery("ano", strconv.Itoa(ano))
+		}
+	}
+}
+
+// NoCache fetches a fresh response, e.g. for an "atualizar agora" button:
+// the cached response is skipped, the call is not coalesced with others,
+// Cache-Control: no-cache is sent so intermediaries revalidate, and the
+// fresh response replaces the cached entry. It is the per-call form of
+// Flags.ForceRefresh.
+func NoCache() RequestOption {
+	return func(o *requestOptions) {
+		o.noCache = true
+	}
+}
+
+// WithAPIKey sends the call with key instead of the client's key, e.g. for
+// multi-tenant services holding one key per customer. Cached responses and
+// coalesced calls are scoped to the key. The key is only added by the
+// SDK's auth layer, so transport wrappers and middleware never see it, and
+// its quota does not feed the client's RateLimiter, RateLimitState or rate
+// limit subscribers.
+func WithAPIKey(key string) RequestOption {
+	return func(o *requestOptions) {
+		o.apiKey = key
+	}
+}
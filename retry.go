@@ -0,0 +1,204 @@
+package iptuapi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"math/rand"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// DefaultNetworkRetries is the number of retries for idempotent requests
+// failing with a temporary network error when RetryConfig.NetworkRetries is
+// zero.
+const DefaultNetworkRetries = 2
+
+// Jitter selects how retry delays are randomized.
+type Jitter int
+
+const (
+	// JitterNone uses the exponential delay as is.
+	JitterNone Jitter = iota
+	// JitterFull waits a random time between zero and the exponential delay.
+	JitterFull
+	// JitterEqual waits half the exponential delay plus a random time up to
+	// the other half.
+	JitterEqual
+	// JitterDecorrelated waits a random time between InitialDelay and three
+	// times the previous delay, independent of the attempt number.
+	JitterDecorrelated
+)
+
+// backoff returns the exponential delay before retry number attempt
+// (starting at zero), capped at MaxDelay.
+func (r *RetryConfig) backoff(attempt int) time.Duration {
+	delay := float64(r.InitialDelay) * math.Pow(r.BackoffFactor, float64(attempt))
+	if delay > float64(r.MaxDelay) {
+		delay = float64(r.MaxDelay)
+	}
+	return time.Duration(delay)
+}
+
+// delay returns the wait before retry number attempt given the previous
+// wait, applying the configured jitter.
+func (r *RetryConfig) delay(attempt int, prev time.Duration) time.Duration {
+	switch r.Jitter {
+	case JitterFull:
+		return randDuration(0, r.backoff(attempt))
+	case JitterEqual:
+		d := r.backoff(attempt)
+		return d/2 + randDuration(0, d-d/2)
+	case JitterDecorrelated:
+		if prev < r.InitialDelay {
+			prev = r.InitialDelay
+		}
+		d := randDuration(r.InitialDelay, 3*prev)
+		if d > r.MaxDelay {
+			d = r.MaxDelay
+		}
+		return d
+	default:
+		return r.backoff(attempt)
+	}
+}
+
+// randDuration returns a random duration in [lo, hi].
+func randDuration(lo, hi time.Duration) time.Duration {
+	if hi <= lo {
+		return lo
+	}
+	return lo + time.Duration(rand.Int63n(int64(hi-lo)+1))
+}
+
+// retryNetErr reports whether a request that failed with the transport
+// error err on retry number attempt should be sent again. Idempotent
+// requests failing with a temporary network error are retried up to
+// NetworkRetries times regardless of MaxRetries and ShouldRetry. A body
+// over the WithMaxResponseBytes limit would only be received again.
+func (c *Client) retryNetErr(req *http.Request, err error, attempt int) bool {
+	var tooLarge *ResponseTooLargeError
+	if errors.As(err, &tooLarge) {
+		return false
+	}
+	if attempt < c.retryConfig.MaxRetries && c.shouldRetry(nil, err, attempt) {
+		return true
+	}
+	n := c.retryConfig.NetworkRetries
+	if n == 0 {
+		n = DefaultNetworkRetries
+	}
+	return attempt < n && isIdempotent(req.Method) && isTemporaryNetErr(err)
+}
+
+func isIdempotent(method string) bool {
+	return method == http.MethodGet || method == http.MethodHead
+}
+
+// isTemporaryNetErr reports whether err is a network failure that is likely
+// to succeed on a new connection: a reset or aborted connection, a broken
+// pipe, an unexpected EOF or a DNS or dial timeout. Context cancellation
+// and deadlines are not temporary.
+func isTemporaryNetErr(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.Is(err, syscall.ECONNRESET) || errors.Is(err, syscall.ECONNABORTED) ||
+		errors.Is(err, syscall.EPIPE) || errors.Is(err, syscall.ECONNREFUSED) {
+		return true
+	}
+	var dnsErr *net.DNSError
+	if errors.As(err, &dnsErr) {
+		return dnsErr.IsTimeout || dnsErr.IsTemporary
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
+
+// RetryDeadlineError is returned when RetryConfig.RespectDeadline abandons
+// the retries of a call because the next attempt would not finish before
+// the context deadline. Err is the failure of the last attempt.
+type RetryDeadlineError struct {
+	// Attempts is the number of attempts made.
+	Attempts int
+	// Delay is the backoff before the abandoned attempt and Remaining the
+	// time that was left before the deadline.
+	Delay     time.Duration
+	Remaining time.Duration
+	Err       error
+}
+
+func (e *RetryDeadlineError) Error() string {
+	msg := fmt.Sprintf("iptuapi: retry abandoned after %d attempts: next attempt in %v would not finish within the %v left before the deadline",
+		e.Attempts, e.Delay, e.Remaining.Round(time.Millisecond))
+	if e.Err != nil {
+		msg += ": " + e.Err.Error()
+	}
+	return msg
+}
+
+func (e *RetryDeadlineError) Unwrap() error {
+	return e.Err
+}
+
+// checkDeadline returns a *RetryDeadlineError when RespectDeadline is set
+// and retry number attempt, sent after delay and expected to take about
+// latency, would end after the deadline of ctx.
+func (r *RetryConfig) checkDeadline(ctx context.Context, attempt int, delay, latency time.Duration, lastErr error) error {
+	if !r.RespectDeadline {
+		return nil
+	}
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		return nil
+	}
+	if remaining := time.Until(deadline); delay+latency > remaining {
+		return &RetryDeadlineError{Attempts: attempt, Delay: delay, Remaining: remaining, Err: lastErr}
+	}
+	return nil
+}
+
+// parseRetryAfter returns the wait asked by the Retry-After header of a 429
+// or 503 response, given in seconds or as an HTTP date. A date is measured
+// from the response's Date header when present, so a wrong local clock
+// does not distort it, and from now otherwise.
+func parseRetryAfter(resp *http.Response, now time.Time) (time.Duration, bool) {
+	if resp.StatusCode != http.StatusTooManyRequests && resp.StatusCode != http.StatusServiceUnavailable {
+		return 0, false
+	}
+	v := strings.TrimSpace(resp.Header.Get("Retry-After"))
+	if v == "" {
+		return 0, false
+	}
+	if secs, err := strconv.Atoi(v); err == nil {
+		if secs < 0 {
+			return 0, false
+		}
+		return time.Duration(secs) * time.Second, true
+	}
+	at, err := http.ParseTime(v)
+	if err != nil {
+		return 0, false
+	}
+	if date, err := http.ParseTime(resp.Header.Get("Date")); err == nil {
+		now = date
+	}
+	if wait := at.Sub(now); wait > 0 {
+		return wait, true
+	}
+	return 0, true
+}
+
+// fitsDeadline reports whether waiting d leaves time before the deadline of
+// ctx, if any.
+func fitsDeadline(ctx context.Context, d time.Duration) bool {
+	deadline, ok := ctx.Deadline()
+	return !ok || d < time.Until(deadline)
+}
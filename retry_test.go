@@ -0,0 +1,250 @@
+package iptuapi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync/atomic"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRetryJitter(t *testing.T) {
+	base := RetryConfig{InitialDelay: 100 * time.Millisecond, MaxDelay: time.Second, BackoffFactor: 2}
+
+	none := base
+	assert.Equal(t, 400*time.Millisecond, none.delay(2, 0))
+	assert.Equal(t, time.Second, none.delay(10, 0), "capped at MaxDelay")
+
+	for i := 0; i < 100; i++ {
+		full := base
+		full.Jitter = JitterFull
+		d := full.delay(2, 0)
+		assert.True(t, d >= 0 && d <= 400*time.Millisecond, "full: %v", d)
+
+		equal := base
+		equal.Jitter = JitterEqual
+		d = equal.delay(2, 0)
+		assert.True(t, d >= 200*time.Millisecond && d <= 400*time.Millisecond, "equal: %v", d)
+
+		decorrelated := base
+		decorrelated.Jitter = JitterDecorrelated
+		d = decorrelated.delay(5, 200*time.Millisecond)
+		assert.True(t, d >= 100*time.Millisecond && d <= 600*time.Millisecond, "decorrelated: %v", d)
+		d = decorrelated.delay(5, time.Second)
+		assert.True(t, d <= time.Second, "decorrelated cap: %v", d)
+	}
+}
+
+func TestRetryStopsOnCancelledContext(t *testing.T) {
+	attempts := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	client := NewClient("test_key",
+		WithBaseURL(server.URL),
+		WithRetry(&RetryConfig{
+			MaxRetries: 5, InitialDelay: time.Millisecond, MaxDelay: time.Millisecond,
+			BackoffFactor: 1, RetryableStatus: []int{503}, Jitter: JitterFull,
+			ShouldRetry: func(resp *http.Response, err error, attempt int) bool {
+				cancel()
+				return true
+			},
+		}),
+	)
+
+	_, err := client.IPTUToolsCidades(ctx)
+	assert.True(t, errors.Is(err, context.Canceled), "got %v", err)
+	assert.Equal(t, 1, attempts, "no attempt is sent after cancellation")
+}
+
+func TestIsTemporaryNetErr(t *testing.T) {
+	reset := &net.OpError{Op: "read", Net: "tcp", Err: os.NewSyscallError("read", syscall.ECONNRESET)}
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"nil", nil, false},
+		{"connection reset", reset, true},
+		{"wrapped reset", fmt.Errorf("get: %w", reset), true},
+		{"eof", io.EOF, true},
+		{"unexpected eof", io.ErrUnexpectedEOF, true},
+		{"broken pipe", syscall.EPIPE, true},
+		{"dns timeout", &net.DNSError{Err: "i/o timeout", Name: "api", IsTimeout: true}, true},
+		{"dns not found", &net.DNSError{Err: "no such host", Name: "api", IsNotFound: true}, false},
+		{"canceled", context.Canceled, false},
+		{"deadline", fmt.Errorf("get: %w", context.DeadlineExceeded), false},
+		{"other", errors.New("x509: certificate signed by unknown authority"), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, isTemporaryNetErr(tt.err))
+		})
+	}
+}
+
+func TestRetryNetErrWithRetriesDisabled(t *testing.T) {
+	// resetOnce returns a server that drops the first connection without
+	// responding. A new server per case keeps net/http from retrying on a
+	// reused idle connection by itself.
+	resetOnce := func(calls *int32) *httptest.Server {
+		return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if atomic.AddInt32(calls, 1) == 1 {
+				conn, _, err := w.(http.Hijacker).Hijack()
+				require.NoError(t, err)
+				conn.Close()
+				return
+			}
+			w.Write([]byte(`{"sql":"000.000.0000-0"}`))
+		}))
+	}
+
+	var calls int32
+	server := resetOnce(&calls)
+	defer server.Close()
+	client := NewClient("test_key", WithBaseURL(server.URL), WithRetry(&RetryConfig{MaxRetries: 0}))
+	_, err := client.ConsultaSQL(context.Background(), "000.000.0000-0", CidadeSaoPaulo)
+	require.NoError(t, err)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&calls))
+
+	var disabledCalls int32
+	disabled := resetOnce(&disabledCalls)
+	defer disabled.Close()
+	client = NewClient("test_key", WithBaseURL(disabled.URL), WithRetry(&RetryConfig{MaxRetries: 0, NetworkRetries: -1}))
+	_, err = client.ConsultaSQL(context.Background(), "000.000.0000-0", CidadeSaoPaulo)
+	assert.Error(t, err)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&disabledCalls))
+}
+
+func TestRetryNetErrSkipsPOST(t *testing.T) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		conn, _, err := w.(http.Hijacker).Hijack()
+		require.NoError(t, err)
+		conn.Close()
+	}))
+	defer server.Close()
+
+	client := NewClient("test_key", WithBaseURL(server.URL), WithRetry(&RetryConfig{MaxRetries: 0}))
+	_, err := client.ValuationEstimate(context.Background(), &ValuationParams{AreaTerreno: 100, AreaConstruida: 80, Bairro: "Pinheiros"})
+	assert.Error(t, err)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+}
+
+func TestRetryRespectDeadline(t *testing.T) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	client := NewClient("test_key", WithBaseURL(server.URL), WithRetry(&RetryConfig{
+		MaxRetries: 3, InitialDelay: time.Second, MaxDelay: time.Second, BackoffFactor: 1,
+		RetryableStatus: []int{503}, RespectDeadline: true,
+	}))
+	ctx, cancel := context.WithTimeout(context.Background(), 300*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	_, err := client.ConsultaSQL(ctx, "1", CidadeSaoPaulo)
+	assert.Less(t, time.Since(start), 250*time.Millisecond, "gives up without sleeping until the deadline")
+
+	var deadlineErr *RetryDeadlineError
+	require.ErrorAs(t, err, &deadlineErr)
+	assert.Equal(t, 1, deadlineErr.Attempts)
+	assert.Equal(t, time.Second, deadlineErr.Delay)
+	var serverErr *ServerError
+	assert.ErrorAs(t, err, &serverErr, "the last failure is wrapped")
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+	assert.Zero(t, client.Stats().Retries)
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	date := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	resp := func(status int, retryAfter string) *http.Response {
+		h := http.Header{}
+		h.Set("Retry-After", retryAfter)
+		h.Set("Date", date.Format(http.TimeFormat))
+		return &http.Response{StatusCode: status, Header: h}
+	}
+
+	wait, ok := parseRetryAfter(resp(429, "3"), date)
+	assert.True(t, ok)
+	assert.Equal(t, 3*time.Second, wait)
+
+	wait, ok = parseRetryAfter(resp(503, date.Add(90*time.Second).Format(http.TimeFormat)), time.Now())
+	assert.True(t, ok)
+	assert.Equal(t, 90*time.Second, wait, "HTTP dates are measured from the Date header")
+
+	noDate := resp(503, date.Add(time.Minute).Format(http.TimeFormat))
+	noDate.Header.Del("Date")
+	wait, ok = parseRetryAfter(noDate, date)
+	assert.True(t, ok)
+	assert.Equal(t, time.Minute, wait, "without a Date header, dates are measured from now")
+
+	_, ok = parseRetryAfter(resp(500, "3"), date)
+	assert.False(t, ok, "only 429 and 503 are honored")
+	_, ok = parseRetryAfter(resp(429, "soon"), date)
+	assert.False(t, ok)
+}
+
+func TestRetryHonorsRetryAfter(t *testing.T) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) == 1 {
+			w.Header().Set("Retry-After", "1")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		w.Write([]byte(`{"sql":"1"}`))
+	}))
+	defer server.Close()
+
+	clock := newFakeClock()
+	client := NewClient("test_key", WithBaseURL(server.URL), WithClock(clock), WithRetry(&RetryConfig{
+		MaxRetries: 1, InitialDelay: time.Millisecond, MaxDelay: 50 * time.Millisecond, BackoffFactor: 1,
+		RetryableStatus: []int{429},
+	}))
+	_, err := client.ConsultaSQL(context.Background(), "1", CidadeSaoPaulo)
+	require.NoError(t, err)
+	assert.Equal(t, []time.Duration{50 * time.Millisecond}, clock.slept(),
+		"Retry-After replaces the backoff, capped by MaxDelay")
+}
+
+func TestRetryAfterBeyondDeadline(t *testing.T) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.Header().Set("Retry-After", "30")
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer server.Close()
+
+	client := NewClient("test_key", WithBaseURL(server.URL))
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	start := time.Now()
+	_, err := client.ConsultaSQL(ctx, "1", CidadeSaoPaulo)
+	assert.Less(t, time.Since(start), time.Second, "does not wait for a retry that cannot happen in time")
+	var rlErr *RateLimitError
+	require.ErrorAs(t, err, &rlErr)
+	assert.Equal(t, 30, rlErr.RetryAfter)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+}
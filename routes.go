@@ -0,0 +1,163 @@
+package iptuapi
+
+import "strings"
+
+// Routes holds the path templates of the API endpoints, with path
+// parameters in braces. Override some of them with WithRoutes when the API
+// moves an endpoint before an SDK release follows; empty fields keep the
+// default.
+type Routes struct {
+	ConsultaEndereco           string
+	ConsultaSQL                string // {sql}
+	ConsultaCEP                string // {cep}
+	ConsultaZoneamento         string
+	ValuationEstimate          string
+	ValuationEvaluate          string
+	ValuationBatch             string
+	ValuationComparables       string
+	ValuationStatistics        string // {bairro}
+	IPTUHistorico              string // {sql}
+	CNPJ                       string // {cnpj}
+	IPCA                       string
+	IPCACorrigir               string
+	ITBITransacoes             string
+	IPTUToolsCidades           string
+	IPTUToolsCalendario        string
+	IPTUToolsSimulador         string
+	IPTUToolsIsencao           string
+	IPTUToolsProximoVencimento string
+	Capabilities               string
+	Dicionario                 string
+	Incidents                  string
+}
+
+// DefaultRoutes returns the path templates the SDK calls by default.
+func DefaultRoutes() Routes {
+	return Routes{
+		ConsultaEndereco:           "/consulta/endereco",
+		ConsultaSQL:                "/consulta/sql/{sql}",
+		ConsultaCEP:                "/consulta/cep/{cep}",
+		ConsultaZoneamento:         "/consulta/zoneamento",
+		ValuationEstimate:          "/valuation/estimate",
+		ValuationEvaluate:          "/valuation/evaluate",
+		ValuationBatch:             "/valuation/estimate/batch",
+		ValuationComparables:       "/valuation/comparables",
+		ValuationStatistics:        "/valuation/statistics/{bairro}",
+		IPTUHistorico:              "/dados/iptu/historico/{sql}",
+		CNPJ:                       "/dados/cnpj/{cnpj}",
+		IPCA:                       "/dados/ipca",
+		IPCACorrigir:               "/dados/ipca/corrigir",
+		ITBITransacoes:             "/dados/itbi/transacoes",
+		IPTUToolsCidades:           "/iptu-tools/cidades",
+		IPTUToolsCalendario:        "/iptu-tools/calendario",
+		IPTUToolsSimulador:         "/iptu-tools/simulador",
+		IPTUToolsIsencao:           "/iptu-tools/isencao",
+		IPTUToolsProximoVencimento: "/iptu-tools/proximo-vencimento",
+		Capabilities:               "/capabilities",
+		Dicionario:                 "/dicionario",
+		Incidents:                  "/status/incidents",
+	}
+}
+
+// pairs returns the templates of r with their defaults, in field order.
+func (r *Routes) pairs() [][2]string {
+	d := DefaultRoutes()
+	return [][2]string{
+		{d.ConsultaEndereco, r.ConsultaEndereco},
+		{d.ConsultaSQL, r.ConsultaSQL},
+		{d.ConsultaCEP, r.ConsultaCEP},
+		{d.ConsultaZoneamento, r.ConsultaZoneamento},
+		{d.ValuationEstimate, r.ValuationEstimate},
+		{d.ValuationEvaluate, r.ValuationEvaluate},
+		{d.ValuationBatch, r.ValuationBatch},
+		{d.ValuationComparables, r.ValuationComparables},
+		{d.ValuationStatistics, r.ValuationStatistics},
+		{d.IPTUHistorico, r.IPTUHistorico},
+		{d.CNPJ, r.CNPJ},
+		{d.IPCA, r.IPCA},
+		{d.IPCACorrigir, r.IPCACorrigir},
+		{d.ITBITransacoes, r.ITBITransacoes},
+		{d.IPTUToolsCidades, r.IPTUToolsCidades},
+		{d.IPTUToolsCalendario, r.IPTUToolsCalendario},
+		{d.IPTUToolsSimulador, r.IPTUToolsSimulador},
+		{d.IPTUToolsIsencao, r.IPTUToolsIsencao},
+		{d.IPTUToolsProximoVencimento, r.IPTUToolsProximoVencimento},
+		{d.Capabilities, r.Capabilities},
+		{d.Dicionario, r.Dicionario},
+		{d.Incidents, r.Incidents},
+	}
+}
+
+// routeOverride maps the default template of an endpoint to its
+// replacement.
+type routeOverride struct {
+	from, to []string
+}
+
+// WithRoutes replaces the path templates of the endpoints whose field is
+// set in r, e.g. Routes{ConsultaEndereco: "/imoveis/busca"}. Path
+// parameters are carried over by name. Stats, endpoint timeouts and
+// capabilities keep using the default templates, so dashboards and
+// configuration do not change with the hotfix.
+func WithRoutes(r Routes) ClientOption {
+	return func(c *Client) {
+		c.routes = nil
+		for _, p := range r.pairs() {
+			if p[1] != "" && p[1] != p[0] {
+				c.routes = append(c.routes, routeOverride{
+					from: strings.Split(p[0], "/"),
+					to:   strings.Split(p[1], "/"),
+				})
+			}
+		}
+	}
+}
+
+// resolvePath returns the path actually called for endpoint, a default
+// template with its parameters filled in.
+func (c *Client) resolvePath(endpoint string) string {
+	if len(c.routes) == 0 {
+		return endpoint
+	}
+	segments := strings.Split(endpoint, "/")
+	for _, r := range c.routes {
+		if vars, ok := matchRoute(r.from, segments); ok {
+			out := make([]string, len(r.to))
+			for i, s := range r.to {
+				if name, ok := routeParam(s); ok {
+					s = vars[name]
+				}
+				out[i] = s
+			}
+			return strings.Join(out, "/")
+		}
+	}
+	return endpoint
+}
+
+// matchRoute matches path segments against a template, returning the
+// values of its parameters.
+func matchRoute(template, segments []string) (map[string]string, bool) {
+	if len(template) != len(segments) {
+		return nil, false
+	}
+	var vars map[string]string
+	for i, t := range template {
+		if name, ok := routeParam(t); ok {
+			if vars == nil {
+				vars = make(map[string]string)
+			}
+			vars[name] = segments[i]
+		} else if t != segments[i] {
+			return nil, false
+		}
+	}
+	return vars, true
+}
+
+func routeParam(segment string) (string, bool) {
+	if len(segment) > 2 && segment[0] == '{' && segment[len(segment)-1] == '}' {
+		return segment[1 : len(segment)-1], true
+	}
+	return "", false
+}
@@ -0,0 +1,48 @@
+package iptuapi
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithRoutes(t *testing.T) {
+	var paths []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.URL.Path)
+		w.Write([]byte(`{"sql":"000.000.0000-0"}`))
+	}))
+	defer server.Close()
+
+	client := NewClient("test_key", WithBaseURL(server.URL), WithRoutes(Routes{
+		ConsultaEndereco: "/imoveis/busca",
+		ConsultaSQL:      "/v2/imoveis/{sql}/iptu",
+	}))
+	ctx := context.Background()
+
+	_, err := client.ConsultaEndereco(ctx, &ConsultaEnderecoParams{Logradouro: "Avenida Paulista"})
+	require.NoError(t, err)
+	_, err = client.ConsultaSQL(ctx, "000.000.0000-0", "")
+	require.NoError(t, err)
+	_, err = client.ConsultaZoneamento(ctx, -23.5, -46.6)
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"/imoveis/busca", "/v2/imoveis/000.000.0000-0/iptu", "/consulta/zoneamento"}, paths)
+	assert.Equal(t, int64(1), client.Stats().Requests["GET /consulta/sql/{sql}"]["200"], "stats keep the default route")
+}
+
+func TestResolvePath(t *testing.T) {
+	client := NewClient("test_key", WithRoutes(Routes{
+		ValuationEstimate: "/avm/estimate",
+		IPCA:              DefaultRoutes().IPCA,
+	}))
+	assert.Equal(t, "/avm/estimate", client.resolvePath("/valuation/estimate"))
+	assert.Equal(t, "/valuation/estimate/batch", client.resolvePath("/valuation/estimate/batch"), "whole segments only")
+	assert.Len(t, client.routes, 1, "defaults are not overrides")
+	assert.Equal(t, "https://example.com/avm/estimate?a=1", NewClient("k", WithBaseURL("https://example.com"),
+		WithRoutes(Routes{ValuationEstimate: "/avm/estimate"})).RequestURL("/valuation/estimate", map[string][]string{"a": {"1"}}))
+}
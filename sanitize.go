@@ -0,0 +1,138 @@
+package iptuapi
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// Text encodings reported by DetectEncoding.
+const (
+	EncodingUTF8   = "utf-8"
+	EncodingLatin1 = "latin1"
+)
+
+// DetectEncoding reports whether b is UTF-8 or, failing that, Latin-1
+// (ISO-8859-1/Windows-1252). Pure ASCII is reported as UTF-8. A rune cut off
+// at the end of b does not count against UTF-8, so a prefix of a larger
+// input can be inspected.
+func DetectEncoding(b []byte) string {
+	for len(b) > 0 {
+		r, size := utf8.DecodeRune(b)
+		if r == utf8.RuneError && size <= 1 {
+			if !utf8.FullRune(b) {
+				break
+			}
+			return EncodingLatin1
+		}
+		b = b[size:]
+	}
+	return EncodingUTF8
+}
+
+// DecodeText converts b to a UTF-8 string, decoding it as Latin-1 when it
+// is not valid UTF-8.
+func DecodeText(b []byte) string {
+	if DetectEncoding(b) == EncodingUTF8 {
+		return string(b)
+	}
+	runes := make([]rune, len(b))
+	for i, c := range b {
+		runes[i] = rune(c)
+	}
+	return string(runes)
+}
+
+// cp1252Runes maps the Windows-1252 characters in 0x80-0x9F back to their
+// byte values, to undo mojibake produced by cp1252 decoders.
+var cp1252Runes = map[rune]byte{
+	'€': 0x80, '‚': 0x82, 'ƒ': 0x83, '„': 0x84, '…': 0x85, '†': 0x86, '‡': 0x87,
+	'ˆ': 0x88, '‰': 0x89, 'Š': 0x8a, '‹': 0x8b, 'Œ': 0x8c, 'Ž': 0x8e,
+	'‘': 0x91, '’': 0x92, '“': 0x93, '”': 0x94, '•': 0x95, '–': 0x96, '—': 0x97,
+	'˜': 0x98, '™': 0x99, 'š': 0x9a, '›': 0x9b, 'œ': 0x9c, 'ž': 0x9e, 'Ÿ': 0x9f,
+}
+
+// FixMojibake repairs UTF-8 text that was decoded as Latin-1 or
+// Windows-1252, e.g. "SÃ£o JoÃ£o" becomes "São João". Doubly mis-decoded
+// text is repaired too. Text that does not look like mojibake is returned
+// unchanged.
+func FixMojibake(s string) string {
+	for i := 0; i < 3 && strings.ContainsAny(s, "ÃÂâ"); i++ {
+		b := make([]byte, 0, len(s))
+		ok := true
+		for _, r := range s {
+			switch c, isCP := cp1252Runes[r]; {
+			case isCP:
+				b = append(b, c)
+			case r <= 0xff:
+				b = append(b, byte(r))
+			default:
+				ok = false
+			}
+			if !ok {
+				break
+			}
+		}
+		if !ok || !utf8.Valid(b) || string(b) == s {
+			return s
+		}
+		s = string(b)
+	}
+	return s
+}
+
+// combiningMarks maps the combining diacritics used in Portuguese to the
+// precomposed letters they form with each base letter.
+var combiningMarks = map[rune]string{
+	'\u0300': "àèìòùÀÈÌÒÙ",
+	'\u0301': "áéíóúÁÉÍÓÚ",
+	'\u0302': "âêîôûÂÊÎÔÛ",
+	'\u0303': "ãõñÃÕÑ",
+	'\u0308': "äëïöüÄËÏÖÜ",
+	'\u0327': "çÇ",
+}
+
+// composeAccents replaces a letter followed by a combining diacritic (as
+// produced by NFD exports, e.g. from macOS) with the precomposed letter.
+func composeAccents(s string) string {
+	if !strings.ContainsAny(s, "\u0300\u0301\u0302\u0303\u0308\u0327") {
+		return s
+	}
+	runes := []rune(s)
+	out := make([]rune, 0, len(runes))
+	for _, r := range runes {
+		if composed, ok := combiningMarks[r]; ok && len(out) > 0 {
+			base := out[len(out)-1]
+			for _, c := range composed {
+				if accentFolding[c] == base {
+					out[len(out)-1] = c
+					break
+				}
+			}
+			// Marks that do not compose with the base are dropped.
+			continue
+		}
+		out = append(out, r)
+	}
+	return string(out)
+}
+
+// SanitizeText cleans free text from municipal exports and customer files:
+// it repairs mojibake, composes decomposed accents, drops control
+// characters and collapses whitespace. Accents are kept; use FoldAccents or
+// NormalizeAddress to strip them for comparisons.
+func SanitizeText(s string) string {
+	if !utf8.ValidString(s) {
+		s = DecodeText([]byte(s))
+	}
+	s = strings.Map(func(r rune) rune {
+		switch {
+		case r == ' ' || r == '\t' || r == '\n' || r == '\r':
+			return ' '
+		case r < 0x20 || r == 0x7f || r == '\ufeff':
+			return -1
+		}
+		return r
+	}, s)
+	s = composeAccents(FixMojibake(s))
+	return strings.Join(strings.Fields(s), " ")
+}
@@ -0,0 +1,43 @@
+package iptuapi
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDetectEncoding(t *testing.T) {
+	assert.Equal(t, EncodingUTF8, DetectEncoding([]byte("Rua Sao Bento")))
+	assert.Equal(t, EncodingUTF8, DetectEncoding([]byte("Rua São Bento")))
+	assert.Equal(t, EncodingLatin1, DetectEncoding([]byte("Rua S\xe3o Bento")))
+	// A rune cut at the end of a prefix is still UTF-8.
+	assert.Equal(t, EncodingUTF8, DetectEncoding([]byte("Rua S\xc3")))
+
+	assert.Equal(t, "Rua São Bento", DecodeText([]byte("Rua S\xe3o Bento")))
+}
+
+func TestFixMojibake(t *testing.T) {
+	assert.Equal(t, "São João", FixMojibake("SÃ£o JoÃ£o"))
+	assert.Equal(t, "Praça – Sé", FixMojibake("PraÃ§a â€“ SÃ©"))
+	assert.Equal(t, "Conceição", FixMojibake("ConceiÃƒÂ§ÃƒÂ£o"), "double encoding")
+	assert.Equal(t, "São Paulo", FixMojibake("São Paulo"))
+	assert.Equal(t, "ÃGUA", FixMojibake("ÃGUA"), "not mojibake")
+}
+
+func TestSanitizeText(t *testing.T) {
+	assert.Equal(t, "Rua São Bento", SanitizeText("  Rua\tSão   Bento\n"))
+	assert.Equal(t, "Praça da Sé", SanitizeText("\ufeffPraÃ§a da SÃ©"))
+	assert.Equal(t, "Rua São Bento", SanitizeText("Rua S\xe3o Bento"))
+
+	// Every spelling normalizes to the same address.
+	for _, s := range []string{"R. São Bento", "R. SÃ£o Bento", "R. Sa\u0303o Bento", "R. S\xe3o Bento"} {
+		assert.Equal(t, "rua sao bento", NormalizeAddress(s), s)
+	}
+}
+
+func TestConsultaEnderecoParamsSanitized(t *testing.T) {
+	p := &ConsultaEnderecoParams{Logradouro: "Rua SÃ£o  Bento", Numero: " 100 "}
+	v := p.Values()
+	assert.Equal(t, "Rua São Bento", v.Get("logradouro"))
+	assert.Equal(t, "100", v.Get("numero"))
+}
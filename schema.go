@@ -0,0 +1,103 @@
+package iptuapi
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+)
+
+// CurrentSchemaVersion is the schema version of the result structs in this
+// release. It is bumped whenever a stored payload would no longer decode
+// into the current structs without a migration.
+const CurrentSchemaVersion = 1
+
+// ErrSchemaTooNew is returned by Upgrade for payloads written by a newer SDK.
+var ErrSchemaTooNew = errors.New("iptuapi: payload schema is newer than this SDK")
+
+// Schema stamps a result with the schema version it was decoded under, so
+// archived payloads can be upgraded with Upgrade after struct changes.
+type Schema struct {
+	SchemaVersion int `json:"schema_version,omitempty"`
+}
+
+func (s *Schema) stampSchema() {
+	if s.SchemaVersion == 0 {
+		s.SchemaVersion = CurrentSchemaVersion
+	}
+}
+
+type schemaStamper interface {
+	stampSchema()
+}
+
+// stampSchema sets the schema version on a decoded result or on every
+// element of a decoded slice of results.
+func stampSchema(result interface{}) {
+	if s, ok := result.(schemaStamper); ok {
+		s.stampSchema()
+		return
+	}
+	v := reflect.ValueOf(result)
+	if v.Kind() != reflect.Ptr || v.Elem().Kind() != reflect.Slice {
+		return
+	}
+	elems := v.Elem()
+	for i := 0; i < elems.Len(); i++ {
+		if s, ok := elems.Index(i).Addr().Interface().(schemaStamper); ok {
+			s.stampSchema()
+		}
+	}
+}
+
+// schemaMigrations[v] rewrites a JSON object from schema v to v+1.
+var schemaMigrations = map[int]func(doc map[string]json.RawMessage) error{
+	// Version 0 is every payload stored before results were stamped. The
+	// fields added since (avisos) are optional, so nothing needs rewriting.
+	0: func(doc map[string]json.RawMessage) error { return nil },
+}
+
+// SchemaVersionOf returns the schema_version stamped on a stored JSON
+// object, or 0 if the payload predates stamping.
+func SchemaVersionOf(data json.RawMessage) int {
+	var s Schema
+	if err := json.Unmarshal(data, &s); err != nil {
+		return 0
+	}
+	return s.SchemaVersion
+}
+
+// Upgrade decodes a result archived under fromVersion into T, applying the
+// migrations needed to reach CurrentSchemaVersion. data must be a JSON object
+// such as a stored ConsultaEnderecoResult; use SchemaVersionOf to read
+// fromVersion from the payload itself.
+func Upgrade[T any](data json.RawMessage, fromVersion int) (T, error) {
+	var out T
+	if fromVersion > CurrentSchemaVersion {
+		return out, fmt.Errorf("%w: version %d, supported %d", ErrSchemaTooNew, fromVersion, CurrentSchemaVersion)
+	}
+
+	var doc map[string]json.RawMessage
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return out, fmt.Errorf("iptuapi: upgrading payload: %w", err)
+	}
+	for v := fromVersion; v < CurrentSchemaVersion; v++ {
+		migrate, ok := schemaMigrations[v]
+		if !ok {
+			return out, fmt.Errorf("iptuapi: no migration from schema version %d", v)
+		}
+		if err := migrate(doc); err != nil {
+			return out, fmt.Errorf("iptuapi: migrating from schema version %d: %w", v, err)
+		}
+	}
+	doc["schema_version"] = json.RawMessage(fmt.Sprint(CurrentSchemaVersion))
+
+	upgraded, err := json.Marshal(doc)
+	if err != nil {
+		return out, err
+	}
+	if err := json.Unmarshal(upgraded, &out); err != nil {
+		return out, fmt.Errorf("iptuapi: decoding upgraded payload: %w", err)
+	}
+	return out, nil
+}
@@ -0,0 +1,53 @@
+package iptuapi
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResultsAreStamped(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/consulta/sql/1" {
+			w.Write([]byte(`{"sql":"1"}`))
+			return
+		}
+		w.Write([]byte(`[{"sql":"1"},{"sql":"2"}]`))
+	}))
+	defer server.Close()
+
+	client := NewClient("test_key", WithBaseURL(server.URL), WithRetry(&RetryConfig{MaxRetries: 0}))
+	ctx := context.Background()
+
+	sql, err := client.ConsultaSQL(ctx, "1", "")
+	require.NoError(t, err)
+	assert.Equal(t, CurrentSchemaVersion, sql.SchemaVersion)
+
+	cep, err := client.ConsultaCEP(ctx, "01310100", "")
+	require.NoError(t, err)
+	require.Len(t, cep, 2)
+	assert.Equal(t, CurrentSchemaVersion, cep[1].SchemaVersion)
+
+	data, err := json.Marshal(sql)
+	require.NoError(t, err)
+	assert.Equal(t, CurrentSchemaVersion, SchemaVersionOf(data))
+}
+
+func TestUpgrade(t *testing.T) {
+	archived := json.RawMessage(`{"sql":"000.000.0000-0","valor_venal_total":500000}`)
+	require.Equal(t, 0, SchemaVersionOf(archived))
+
+	result, err := Upgrade[ConsultaSQLResult](archived, SchemaVersionOf(archived))
+	require.NoError(t, err)
+	assert.Equal(t, "000.000.0000-0", result.SQL)
+	assert.Equal(t, 500000.0, result.ValorVenalTotal)
+	assert.Equal(t, CurrentSchemaVersion, result.SchemaVersion)
+
+	_, err = Upgrade[ConsultaSQLResult](archived, CurrentSchemaVersion+1)
+	assert.ErrorIs(t, err, ErrSchemaTooNew)
+}
@@ -0,0 +1,160 @@
+package iptuapi
+
+import "context"
+
+// ScopedClient is a view of a Client that applies preset RequestOptions to
+// every call, e.g. for services dedicated to one city or tax year. Options
+// passed to a call are applied after the preset ones and take precedence.
+// Scoped views are cheap and share the client's quota, cache and rate
+// limiter.
+type ScopedClient struct {
+	c    *Client
+	opts []RequestOption
+}
+
+// ForCidade returns a view of c whose calls use cidade, whatever city the
+// call arguments name.
+func (c *Client) ForCidade(cidade Cidade) *ScopedClient {
+	return c.Scoped(WithCidade(cidade))
+}
+
+// ForAno returns a view of c whose calls are for the tax year ano.
+func (c *Client) ForAno(ano int) *ScopedClient {
+	return c.Scoped(WithAno(ano))
+}
+
+// Scoped returns a view of c applying opts to every call.
+func (c *Client) Scoped(opts ...RequestOption) *ScopedClient {
+	return &ScopedClient{c: c, opts: opts}
+}
+
+// ForCidade returns a copy of s that also presets cidade.
+func (s *ScopedClient) ForCidade(cidade Cidade) *ScopedClient {
+	return s.Scoped(WithCidade(cidade))
+}
+
+// ForAno returns a copy of s that also presets the tax year ano.
+func (s *ScopedClient) ForAno(ano int) *ScopedClient {
+	return s.Scoped(WithAno(ano))
+}
+
+// Scoped returns a copy of s that also applies opts.
+func (s *ScopedClient) Scoped(opts ...RequestOption) *ScopedClient {
+	return &ScopedClient{c: s.c, opts: s.with(opts)}
+}
+
+// with returns the preset options followed by opts.
+func (s *ScopedClient) with(opts []RequestOption) []RequestOption {
+	if len(s.opts) == 0 {
+		return opts
+	}
+	return append(append(make([]RequestOption, 0, len(s.opts)+len(opts)), s.opts...), opts...)
+}
+
+func (s *ScopedClient) ConsultaEndereco(ctx context.Context, p *ConsultaEnderecoParams, opts ...RequestOption) (*ConsultaEnderecoResult, error) {
+	return s.c.ConsultaEndereco(ctx, p, s.with(opts)...)
+}
+
+func (s *ScopedClient) ConsultaSQL(ctx context.Context, sql string, cidade Cidade, opts ...RequestOption) (*ConsultaSQLResult, error) {
+	return s.c.ConsultaSQL(ctx, sql, cidade, s.with(opts)...)
+}
+
+func (s *ScopedClient) ConsultaCEP(ctx context.Context, cep string, cidade Cidade, opts ...RequestOption) ([]ConsultaEnderecoResult, error) {
+	return s.c.ConsultaCEP(ctx, cep, cidade, s.with(opts)...)
+}
+
+func (s *ScopedClient) ConsultaCEPNumero(ctx context.Context, cep, numero string, cidade Cidade, opts ...RequestOption) ([]ConsultaEnderecoResult, error) {
+	return s.c.ConsultaCEPNumero(ctx, cep, numero, cidade, s.with(opts)...)
+}
+
+func (s *ScopedClient) ConsultaCondominio(ctx context.Context, p *ConsultaCondominioParams, opts ...RequestOption) (*Condominio, error) {
+	return s.c.ConsultaCondominio(ctx, p, s.with(opts)...)
+}
+
+func (s *ScopedClient) ConsultaZoneamento(ctx context.Context, latitude, longitude float64, opts ...RequestOption) (*ZoneamentoResult, error) {
+	return s.c.ConsultaZoneamento(ctx, latitude, longitude, s.with(opts)...)
+}
+
+func (s *ScopedClient) ValuationEstimate(ctx context.Context, p *ValuationParams, opts ...RequestOption) (*ValuationResult, error) {
+	return s.c.ValuationEstimate(ctx, p, s.with(opts)...)
+}
+
+func (s *ScopedClient) ValuationEvaluate(ctx context.Context, p *EvaluateParams, opts ...RequestOption) (*EvaluateResult, error) {
+	return s.c.ValuationEvaluate(ctx, p, s.with(opts)...)
+}
+
+func (s *ScopedClient) ValuationComparables(ctx context.Context, bairro string, areaMin, areaMax float64, cidade Cidade, limit int, opts ...RequestOption) ([]ComparavelItem, error) {
+	return s.c.ValuationComparables(ctx, bairro, areaMin, areaMax, cidade, limit, s.with(opts)...)
+}
+
+func (s *ScopedClient) ValuationStatistics(ctx context.Context, bairro string, cidade Cidade, opts ...RequestOption) (*ValuationStatisticsResult, error) {
+	return s.c.ValuationStatistics(ctx, bairro, cidade, s.with(opts)...)
+}
+
+func (s *ScopedClient) ValuationStatisticsRegiao(ctx context.Context, regiao string, cidade Cidade, opts ...RequestOption) (*RegiaoStatisticsResult, error) {
+	return s.c.ValuationStatisticsRegiao(ctx, regiao, cidade, s.with(opts)...)
+}
+
+func (s *ScopedClient) ValuationComparablesRegiao(ctx context.Context, regiao string, areaMin, areaMax float64, cidade Cidade, limit int, opts ...RequestOption) ([]ComparavelItem, error) {
+	return s.c.ValuationComparablesRegiao(ctx, regiao, areaMin, areaMax, cidade, limit, s.with(opts)...)
+}
+
+func (s *ScopedClient) DadosIPTUHistorico(ctx context.Context, sql string, cidade Cidade, opts ...RequestOption) ([]HistoricoItem, error) {
+	return s.c.DadosIPTUHistorico(ctx, sql, cidade, s.with(opts)...)
+}
+
+func (s *ScopedClient) HistoricoValorVenal(ctx context.Context, sql string, cidade Cidade, anos int, opts ...RequestOption) (*SerieValorVenal, error) {
+	return s.c.HistoricoValorVenal(ctx, sql, cidade, anos, s.with(opts)...)
+}
+
+func (s *ScopedClient) DadosCNPJ(ctx context.Context, cnpj string, opts ...RequestOption) (map[string]interface{}, error) {
+	return s.c.DadosCNPJ(ctx, cnpj, s.with(opts)...)
+}
+
+func (s *ScopedClient) DadosIPCA(ctx context.Context, dataInicio, dataFim string, opts ...RequestOption) ([]IPCAItem, error) {
+	return s.c.DadosIPCA(ctx, dataInicio, dataFim, s.with(opts)...)
+}
+
+func (s *ScopedClient) IPCACorrecao(ctx context.Context, valor float64, dataOrigem, dataDestino string, opts ...RequestOption) (map[string]interface{}, error) {
+	return s.c.IPCACorrecao(ctx, valor, dataOrigem, dataDestino, s.with(opts)...)
+}
+
+func (s *ScopedClient) ITBITransacoes(ctx context.Context, p *ITBIParams, opts ...RequestOption) ([]ITBITransacao, error) {
+	return s.c.ITBITransacoes(ctx, p, s.with(opts)...)
+}
+
+func (s *ScopedClient) Dossie(ctx context.Context, sql string, cidade Cidade, opts ...RequestOption) (*Dossie, error) {
+	return s.c.Dossie(ctx, sql, cidade, s.with(opts)...)
+}
+
+func (s *ScopedClient) IPTUToolsCidades(ctx context.Context, opts ...RequestOption) (*CidadesResult, error) {
+	return s.c.IPTUToolsCidades(ctx, s.with(opts)...)
+}
+
+func (s *ScopedClient) IPTUToolsCalendario(ctx context.Context, cidade Cidade, opts ...RequestOption) (*CalendarioResult, error) {
+	return s.c.IPTUToolsCalendario(ctx, cidade, s.with(opts)...)
+}
+
+func (s *ScopedClient) IPTUToolsSimulador(ctx context.Context, p *SimuladorParams, opts ...RequestOption) (*SimuladorResult, error) {
+	return s.c.IPTUToolsSimulador(ctx, p, s.with(opts)...)
+}
+
+func (s *ScopedClient) IPTUToolsIsencao(ctx context.Context, valorVenal float64, cidade Cidade, opts ...RequestOption) (*IsencaoResult, error) {
+	return s.c.IPTUToolsIsencao(ctx, valorVenal, cidade, s.with(opts)...)
+}
+
+func (s *ScopedClient) IPTUToolsProximoVencimento(ctx context.Context, cidade Cidade, parcela int, opts ...RequestOption) (*ProximoVencimentoResult, error) {
+	return s.c.IPTUToolsProximoVencimento(ctx, cidade, parcela, s.with(opts)...)
+}
+
+func (s *ScopedClient) Capabilities(ctx context.Context, opts ...RequestOption) (*CapabilityMatrix, error) {
+	return s.c.Capabilities(ctx, s.with(opts)...)
+}
+
+func (s *ScopedClient) Dicionario(ctx context.Context, opts ...RequestOption) (*DicionarioResult, error) {
+	return s.c.Dicionario(ctx, s.with(opts)...)
+}
+
+func (s *ScopedClient) ValuationBatch(ctx context.Context, imoveis []ValuationParams, opts ...RequestOption) (*BatchValuationResult, error) {
+	return s.c.ValuationBatch(ctx, imoveis, s.with(opts)...)
+}
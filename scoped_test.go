@@ -0,0 +1,40 @@
+package iptuapi
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestScopedClient(t *testing.T) {
+	var query url.Values
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query = r.URL.Query()
+		json.NewEncoder(w).Encode(CalendarioResult{Cidade: query.Get("cidade")})
+	}))
+	defer server.Close()
+
+	client := NewClient("test_key", WithBaseURL(server.URL))
+	bh := client.ForCidade(CidadeBeloHorizonte)
+	bh2025 := bh.ForAno(2025)
+
+	result, err := bh2025.IPTUToolsCalendario(context.Background(), "")
+	require.NoError(t, err)
+	assert.Equal(t, "bh", result.Cidade)
+	assert.Equal(t, "2025", query.Get("ano"))
+
+	_, err = bh.IPTUToolsCalendario(context.Background(), CidadeSaoPaulo)
+	require.NoError(t, err)
+	assert.Equal(t, "bh", query.Get("cidade"))
+	assert.Empty(t, query.Get("ano"), "deriving a view does not change its parent")
+
+	_, err = bh2025.IPTUToolsCalendario(context.Background(), "", WithCidade(CidadeRecife))
+	require.NoError(t, err)
+	assert.Equal(t, "recife", query.Get("cidade"), "call options take precedence")
+}
@@ -0,0 +1,132 @@
+package iptuapi
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"strings"
+)
+
+// SignatureHeader carries the detached JWS (RFC 7515, appendix F) signing
+// the response body: base64url(protected header) + ".." + base64url(signature).
+const SignatureHeader = "X-JWS-Signature"
+
+// SignaturePolicy selects when responses must carry a valid signature.
+type SignaturePolicy int
+
+const (
+	// SignatureOff skips verification (the default).
+	SignatureOff SignaturePolicy = iota
+	// SignatureIfPresent verifies signed responses and accepts unsigned
+	// ones.
+	SignatureIfPresent
+	// SignatureRequired rejects unsigned responses.
+	SignatureRequired
+)
+
+// WithSignatureVerification verifies the signature of successful responses
+// against keys, indexed by the "kid" of the JWS header. Supported keys are
+// *ecdsa.PublicKey (ES256), ed25519.PublicKey (EdDSA) and *rsa.PublicKey
+// (RS256). A response failing verification is rejected with a
+// *SignatureError and never cached.
+func WithSignatureVerification(policy SignaturePolicy, keys map[string]crypto.PublicKey) ClientOption {
+	return func(c *Client) {
+		c.signaturePolicy = policy
+		c.signatureKeys = keys
+	}
+}
+
+// SignatureError reports a response whose signature is missing or invalid.
+type SignatureError struct {
+	Reason    string
+	RequestID string
+}
+
+func (e *SignatureError) Error() string {
+	if e.RequestID != "" {
+		return fmt.Sprintf("iptuapi: response signature: %s (request_id: %s)", e.Reason, e.RequestID)
+	}
+	return "iptuapi: response signature: " + e.Reason
+}
+
+// IsSignatureError checks if error is a signature verification failure.
+func IsSignatureError(err error) bool {
+	var sigErr *SignatureError
+	return errors.As(err, &sigErr)
+}
+
+// verifySignature applies the client's SignaturePolicy to a successful
+// response.
+func (c *Client) verifySignature(h http.Header, body []byte) error {
+	if c.signaturePolicy == SignatureOff {
+		return nil
+	}
+	sig := h.Get(SignatureHeader)
+	if sig == "" {
+		if c.signaturePolicy == SignatureRequired {
+			return &SignatureError{Reason: "missing " + SignatureHeader + " header", RequestID: h.Get("X-Request-ID")}
+		}
+		return nil
+	}
+	if err := verifyDetachedJWS(sig, body, c.signatureKeys); err != nil {
+		return &SignatureError{Reason: err.Error(), RequestID: h.Get("X-Request-ID")}
+	}
+	return nil
+}
+
+func verifyDetachedJWS(sig string, body []byte, keys map[string]crypto.PublicKey) error {
+	protected, signature, ok := strings.Cut(sig, "..")
+	if !ok {
+		return errors.New("malformed detached JWS")
+	}
+	rawHeader, err := base64.RawURLEncoding.DecodeString(protected)
+	if err != nil {
+		return errors.New("malformed JWS header")
+	}
+	var jws struct {
+		Alg string `json:"alg"`
+		Kid string `json:"kid"`
+	}
+	if err := json.Unmarshal(rawHeader, &jws); err != nil {
+		return errors.New("malformed JWS header")
+	}
+	rawSig, err := base64.RawURLEncoding.DecodeString(signature)
+	if err != nil {
+		return errors.New("malformed JWS signature")
+	}
+	key, ok := keys[jws.Kid]
+	if !ok {
+		return fmt.Errorf("unknown key %q", jws.Kid)
+	}
+
+	input := protected + "." + base64.RawURLEncoding.EncodeToString(body)
+	digest := sha256.Sum256([]byte(input))
+	valid := false
+	switch k := key.(type) {
+	case *ecdsa.PublicKey:
+		if jws.Alg != "ES256" || len(rawSig) != 64 {
+			break
+		}
+		r := new(big.Int).SetBytes(rawSig[:32])
+		s := new(big.Int).SetBytes(rawSig[32:])
+		valid = ecdsa.Verify(k, digest[:], r, s)
+	case ed25519.PublicKey:
+		valid = jws.Alg == "EdDSA" && ed25519.Verify(k, []byte(input), rawSig)
+	case *rsa.PublicKey:
+		valid = jws.Alg == "RS256" && rsa.VerifyPKCS1v15(k, crypto.SHA256, digest[:], rawSig) == nil
+	default:
+		return fmt.Errorf("unsupported key type %T for %q", key, jws.Kid)
+	}
+	if !valid {
+		return fmt.Errorf("invalid %s signature with key %q", jws.Alg, jws.Kid)
+	}
+	return nil
+}
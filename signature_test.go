@@ -0,0 +1,80 @@
+package iptuapi
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// signES256 returns a detached JWS for body.
+func signES256(t *testing.T, key *ecdsa.PrivateKey, kid string, body []byte) string {
+	protected := base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"ES256","kid":"` + kid + `"}`))
+	digest := sha256.Sum256([]byte(protected + "." + base64.RawURLEncoding.EncodeToString(body)))
+	r, s, err := ecdsa.Sign(rand.Reader, key, digest[:])
+	require.NoError(t, err)
+	sig := make([]byte, 64)
+	r.FillBytes(sig[:32])
+	s.FillBytes(sig[32:])
+	return protected + ".." + base64.RawURLEncoding.EncodeToString(sig)
+}
+
+func TestSignatureVerification(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	body := []byte(`{"sql":"000.000.0000-0"}`)
+
+	var sig string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if sig != "" {
+			w.Header().Set(SignatureHeader, sig)
+		}
+		w.Write(body)
+	}))
+	defer server.Close()
+
+	keys := map[string]crypto.PublicKey{"k1": &key.PublicKey}
+	call := func(policy SignaturePolicy) error {
+		client := NewClient("test_key", WithBaseURL(server.URL), WithSignatureVerification(policy, keys))
+		_, err := client.ConsultaSQL(context.Background(), "000.000.0000-0", CidadeSaoPaulo)
+		return err
+	}
+
+	sig = signES256(t, key, "k1", body)
+	assert.NoError(t, call(SignatureRequired))
+
+	sig = signES256(t, key, "k1", []byte(`{"sql":"tampered"}`))
+	assert.True(t, IsSignatureError(call(SignatureIfPresent)))
+	assert.NoError(t, call(SignatureOff))
+
+	sig = signES256(t, key, "unknown", body)
+	assert.ErrorContains(t, call(SignatureIfPresent), "unknown key")
+
+	sig = ""
+	assert.NoError(t, call(SignatureIfPresent))
+	assert.True(t, IsSignatureError(call(SignatureRequired)))
+}
+
+func TestVerifyDetachedJWSEdDSA(t *testing.T) {
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	body := []byte(`{"ok":true}`)
+	protected := base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"EdDSA","kid":"ed"}`))
+	sig := ed25519.Sign(priv, []byte(protected+"."+base64.RawURLEncoding.EncodeToString(body)))
+	jws := protected + ".." + base64.RawURLEncoding.EncodeToString(sig)
+
+	keys := map[string]crypto.PublicKey{"ed": pub}
+	assert.NoError(t, verifyDetachedJWS(jws, body, keys))
+	assert.Error(t, verifyDetachedJWS(jws, []byte(`{"ok":false}`), keys))
+	assert.Error(t, verifyDetachedJWS("not-a-jws", body, keys))
+}
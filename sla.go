@@ -0,0 +1,176 @@
+package iptuapi
+
+import (
+	"math"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	// defaultSLASamples is how many attempts are kept per endpoint.
+	defaultSLASamples = 1000
+	// defaultSLAWindow is how far back SLAReport looks.
+	defaultSLAWindow = time.Hour
+	// maxSLAFailureIDs caps the request IDs of failures kept as evidence.
+	maxSLAFailureIDs = 20
+)
+
+// WithSLAWindow sets how many attempts per endpoint (default 1000) and how
+// far back in time (default 1h) SLAReport considers.
+func WithSLAWindow(samples int, window time.Duration) ClientOption {
+	return func(c *Client) {
+		if samples <= 0 {
+			samples = defaultSLASamples
+		}
+		if window <= 0 {
+			window = defaultSLAWindow
+		}
+		c.sla = newSLATracker(samples, window)
+	}
+}
+
+// EndpointSLA summarizes the recent attempts to one endpoint. An attempt
+// fails on a transport error or a 5xx response; other statuses, including
+// 4xx, count as successes since the API answered.
+type EndpointSLA struct {
+	Requests    int           `json:"requests"`
+	Failures    int           `json:"failures"`
+	SuccessRate float64       `json:"success_rate"`
+	P50         time.Duration `json:"p50_ns"`
+	P90         time.Duration `json:"p90_ns"`
+	P95         time.Duration `json:"p95_ns"`
+	P99         time.Duration `json:"p99_ns"`
+	Max         time.Duration `json:"max_ns"`
+	// From and To bound the attempts summarized.
+	From time.Time `json:"from"`
+	To   time.Time `json:"to"`
+	// FailedRequestIDs lists the request IDs of the most recent failures
+	// that had one, to cite when disputing an SLA breach.
+	FailedRequestIDs []string `json:"failed_request_ids,omitempty"`
+}
+
+// SLAReport is the client-side view of the API's availability and latency.
+type SLAReport struct {
+	GeneratedAt time.Time     `json:"generated_at"`
+	Window      time.Duration `json:"window_ns"`
+	Overall     EndpointSLA   `json:"overall"`
+	// Endpoints is keyed by method and route, e.g. "GET /consulta/sql/{sql}".
+	Endpoints map[string]EndpointSLA `json:"endpoints"`
+}
+
+// SLAReport summarizes the success rate and latency percentiles of the
+// recent attempts per endpoint, measured by the client independently of
+// the vendor. Marshal it to JSON to attach to an SLA dispute.
+func (c *Client) SLAReport() *SLAReport {
+	return c.sla.report(time.Now())
+}
+
+type slaSample struct {
+	at        time.Time
+	latency   time.Duration
+	failed    bool
+	requestID string
+}
+
+// slaRing is a ring buffer of the most recent samples of one endpoint.
+type slaRing struct {
+	samples []slaSample
+	next    int
+	full    bool
+}
+
+type slaTracker struct {
+	size   int
+	window time.Duration
+
+	mu        sync.Mutex
+	endpoints map[string]*slaRing
+}
+
+func newSLATracker(size int, window time.Duration) *slaTracker {
+	return &slaTracker{size: size, window: window, endpoints: make(map[string]*slaRing)}
+}
+
+// record adds an attempt; status 0 means no response was received.
+func (t *slaTracker) record(endpoint string, at time.Time, latency time.Duration, status int, requestID string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	ring, ok := t.endpoints[endpoint]
+	if !ok {
+		ring = &slaRing{samples: make([]slaSample, t.size)}
+		t.endpoints[endpoint] = ring
+	}
+	ring.samples[ring.next] = slaSample{at: at, latency: latency, failed: status == 0 || status >= 500, requestID: requestID}
+	ring.next = (ring.next + 1) % len(ring.samples)
+	if ring.next == 0 {
+		ring.full = true
+	}
+}
+
+func (t *slaTracker) report(now time.Time) *SLAReport {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	out := &SLAReport{GeneratedAt: now.UTC(), Window: t.window, Endpoints: make(map[string]EndpointSLA, len(t.endpoints))}
+	since := now.Add(-t.window)
+	var all []slaSample
+	for endpoint, ring := range t.endpoints {
+		var recent []slaSample
+		if ring.full {
+			recent = appendSince(recent, ring.samples[ring.next:], since)
+		}
+		recent = appendSince(recent, ring.samples[:ring.next], since)
+		if len(recent) == 0 {
+			continue
+		}
+		out.Endpoints[endpoint] = summarizeSLA(recent)
+		all = append(all, recent...)
+	}
+	if len(all) > 0 {
+		sort.Slice(all, func(i, j int) bool { return all[i].at.Before(all[j].at) })
+		out.Overall = summarizeSLA(all)
+	}
+	return out
+}
+
+func appendSince(dst, samples []slaSample, since time.Time) []slaSample {
+	for _, s := range samples {
+		if !s.at.Before(since) {
+			dst = append(dst, s)
+		}
+	}
+	return dst
+}
+
+// summarizeSLA summarizes samples ordered oldest first.
+func summarizeSLA(samples []slaSample) EndpointSLA {
+	out := EndpointSLA{Requests: len(samples), From: samples[0].at, To: samples[len(samples)-1].at}
+	latencies := make([]time.Duration, len(samples))
+	for i, s := range samples {
+		latencies[i] = s.latency
+		if s.failed {
+			out.Failures++
+			if s.requestID != "" {
+				out.FailedRequestIDs = append(out.FailedRequestIDs, s.requestID)
+			}
+		}
+	}
+	if n := len(out.FailedRequestIDs); n > maxSLAFailureIDs {
+		out.FailedRequestIDs = out.FailedRequestIDs[n-maxSLAFailureIDs:]
+	}
+	out.SuccessRate = float64(out.Requests-out.Failures) / float64(out.Requests)
+
+	sort.Slice(latencies, func(i, j int) bool { return latencies[i] < latencies[j] })
+	out.P50 = percentile(latencies, 0.50)
+	out.P90 = percentile(latencies, 0.90)
+	out.P95 = percentile(latencies, 0.95)
+	out.P99 = percentile(latencies, 0.99)
+	out.Max = latencies[len(latencies)-1]
+	return out
+}
+
+// percentile returns the nearest-rank percentile p of sorted.
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	i := int(math.Ceil(float64(len(sorted))*p)) - 1
+	return sorted[max(0, min(i, len(sorted)-1))]
+}
@@ -0,0 +1,58 @@
+package iptuapi
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSLATracker(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+	tr := newSLATracker(4, time.Hour)
+	tr.record("GET /a", now.Add(-2*time.Hour), time.Second, 200, "old")
+	for i := 1; i <= 4; i++ {
+		status := 200
+		if i == 4 {
+			status = 503
+		}
+		tr.record("GET /a", now.Add(time.Duration(i)*time.Second), time.Duration(i)*time.Millisecond, status, "r"+strconv.Itoa(i))
+	}
+	tr.record("GET /b", now, 10*time.Millisecond, 404, "")
+
+	report := tr.report(now.Add(time.Minute))
+	a := report.Endpoints["GET /a"]
+	assert.Equal(t, 4, a.Requests, "the ring keeps the last samples")
+	assert.Equal(t, 1, a.Failures)
+	assert.Equal(t, 0.75, a.SuccessRate)
+	assert.Equal(t, 2*time.Millisecond, a.P50)
+	assert.Equal(t, 4*time.Millisecond, a.P99)
+	assert.Equal(t, []string{"r4"}, a.FailedRequestIDs)
+
+	assert.Equal(t, 1.0, report.Endpoints["GET /b"].SuccessRate, "4xx responses are not SLA failures")
+	assert.Equal(t, 5, report.Overall.Requests)
+
+	report = tr.report(now.Add(2 * time.Hour))
+	assert.Empty(t, report.Endpoints, "samples older than the window are ignored")
+}
+
+func TestClientSLAReport(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Request-ID", "req_1")
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer server.Close()
+
+	client := NewClient("test_key", WithBaseURL(server.URL), WithRetry(&RetryConfig{MaxRetries: 0}))
+	_, err := client.ConsultaSQL(context.Background(), "1", CidadeSaoPaulo)
+	require.Error(t, err)
+
+	sla := client.SLAReport().Endpoints["GET /consulta/sql/{sql}"]
+	assert.Equal(t, 1, sla.Failures)
+	assert.Equal(t, []string{"req_1"}, sla.FailedRequestIDs)
+}
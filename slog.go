@@ -0,0 +1,138 @@
+package iptuapi
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"runtime"
+	"strings"
+	"time"
+)
+
+// Subsystem identifies the part of the SDK emitting a log record. It is
+// attached to every slog record as the "subsystem" attribute.
+type Subsystem string
+
+const (
+	SubsystemHTTP  Subsystem = "http"
+	SubsystemRetry Subsystem = "retry"
+	SubsystemCache Subsystem = "cache"
+	SubsystemBatch Subsystem = "batch"
+)
+
+// LevelConfig sets the minimum slog level per subsystem. Subsystems without
+// an entry in Levels use Default (the zero value is slog.LevelInfo).
+type LevelConfig struct {
+	Default slog.Level
+	Levels  map[Subsystem]slog.Level
+}
+
+func (lc LevelConfig) level(sub Subsystem) slog.Level {
+	if l, ok := lc.Levels[sub]; ok {
+		return l
+	}
+	return lc.Default
+}
+
+// slogState is the slog handler and per-subsystem levels set by WithSlog.
+type slogState struct {
+	handler slog.Handler
+	levels  LevelConfig
+}
+
+// WithSlog logs through a log/slog handler with per-subsystem minimum
+// levels, e.g. to see retry debug records without HTTP request noise:
+//
+//	iptuapi.WithSlog(handler, iptuapi.LevelConfig{
+//		Default: slog.LevelWarn,
+//		Levels:  map[iptuapi.Subsystem]slog.Level{iptuapi.SubsystemRetry: slog.LevelDebug},
+//	})
+//
+// It replaces the Logger set with WithLogger.
+func WithSlog(handler slog.Handler, levels LevelConfig) ClientOption {
+	return func(c *Client) {
+		c.slog = &slogState{handler: handler, levels: levels}
+	}
+}
+
+// WithSlogLogger logs through l, leaving level filtering to its handler.
+// Each HTTP attempt is logged at debug level with method, route, status,
+// latency, attempt number and request ID. Passing a *slog.Logger to
+// WithLogger has the same effect.
+func WithSlogLogger(l *slog.Logger) ClientOption {
+	return WithSlog(l.Handler(), LevelConfig{Default: slog.LevelDebug})
+}
+
+// redact masks the API keys in log values, so a key echoed in an error or
+// URL never reaches the logs.
+func (c *Client) redact(args []interface{}) {
+	for i, arg := range args {
+		var s string
+		switch v := arg.(type) {
+		case string:
+			s = v
+		case error:
+			s = v.Error()
+		case fmt.Stringer:
+			s = v.String()
+		default:
+			continue
+		}
+		if redacted := c.redactString(s); redacted != s {
+			args[i] = redacted
+		}
+	}
+}
+
+// redactString masks the client's API keys in s.
+func (c *Client) redactString(s string) string {
+	keys := []string{c.apiKey}
+	if c.keys != nil {
+		keys = c.keys.keys
+	}
+	for _, key := range keys {
+		if key != "" {
+			s = strings.ReplaceAll(s, key, maskKey(key))
+		}
+	}
+	return s
+}
+
+// log emits a record for sub. args are slog-style key/value pairs; without
+// WithSlog they are appended as key=value to the message sent to Logger.
+func (c *Client) log(ctx context.Context, sub Subsystem, level slog.Level, msg string, args ...interface{}) {
+	if level < slog.LevelInfo && FlagsFrom(ctx).Verbose {
+		level = slog.LevelInfo
+	}
+	if s := c.slog; s != nil {
+		if level < s.levels.level(sub) || !s.handler.Enabled(ctx, level) {
+			return
+		}
+		c.redact(args)
+		var pcs [1]uintptr
+		runtime.Callers(2, pcs[:])
+		r := slog.NewRecord(time.Now(), level, msg, pcs[0])
+		r.AddAttrs(slog.String("subsystem", string(sub)))
+		r.Add(args...)
+		_ = s.handler.Handle(ctx, r)
+		return
+	}
+
+	c.redact(args)
+	var b strings.Builder
+	b.WriteString(msg)
+	for i := 0; i+1 < len(args); i += 2 {
+		fmt.Fprintf(&b, " %v=%v", args[i], args[i+1])
+	}
+	line := b.String()
+	switch {
+	case level >= slog.LevelError:
+		c.logger.Error("%s", line)
+	case level >= slog.LevelWarn:
+		c.logger.Warn("%s", line)
+	case level >= slog.LevelInfo:
+		c.logger.Info("%s", line)
+	default:
+		c.logger.Debug("%s", line)
+	}
+}
@@ -0,0 +1,111 @@
+package iptuapi
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithSlogSubsystemLevels(t *testing.T) {
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if calls == 1 {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		json.NewEncoder(w).Encode(ConsultaSQLResult{SQL: "1"})
+	}))
+	defer server.Close()
+
+	var buf bytes.Buffer
+	handler := slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug})
+	client := NewClient("test_key",
+		WithBaseURL(server.URL),
+		WithRetry(&RetryConfig{MaxRetries: 1, InitialDelay: time.Millisecond, BackoffFactor: 1, RetryableStatus: []int{502}}),
+		WithSlog(handler, LevelConfig{
+			Default: slog.LevelWarn,
+			Levels:  map[Subsystem]slog.Level{SubsystemRetry: slog.LevelDebug},
+		}),
+	)
+
+	_, err := client.ConsultaSQL(context.Background(), "1", "")
+	require.NoError(t, err)
+
+	var records []map[string]interface{}
+	dec := json.NewDecoder(&buf)
+	for dec.More() {
+		var rec map[string]interface{}
+		require.NoError(t, dec.Decode(&rec))
+		records = append(records, rec)
+	}
+	require.Len(t, records, 1, "http debug records must be filtered out")
+	assert.Equal(t, "retry", records[0]["subsystem"])
+	assert.Equal(t, "/consulta/sql/{sql}", records[0]["route"])
+}
+
+type recordingLogger struct {
+	lines []string
+}
+
+func (l *recordingLogger) Debug(msg string, args ...interface{}) {
+	l.lines = append(l.lines, fmt.Sprintf(msg, args...))
+}
+func (l *recordingLogger) Info(msg string, args ...interface{})  {}
+func (l *recordingLogger) Warn(msg string, args ...interface{})  {}
+func (l *recordingLogger) Error(msg string, args ...interface{}) {}
+
+func TestLogFallsBackToLogger(t *testing.T) {
+	logger := &recordingLogger{}
+	client := NewClient("test_key", WithLogger(logger))
+
+	client.log(context.Background(), SubsystemHTTP, slog.LevelDebug, "Request", "method", "GET")
+	assert.Equal(t, []string{"Request method=GET"}, logger.lines)
+}
+
+func TestWithLoggerSlog(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Request-ID", "req_1")
+		json.NewEncoder(w).Encode(ConsultaSQLResult{SQL: "1"})
+	}))
+	defer server.Close()
+
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	client := NewClient("secret_key_1234", WithBaseURL(server.URL), WithLogger(logger))
+
+	_, err := client.ConsultaSQL(context.Background(), "1", "")
+	require.NoError(t, err)
+	client.log(context.Background(), SubsystemHTTP, slog.LevelWarn, "Echo", "error", fmt.Errorf("bad key secret_key_1234"))
+
+	var response, echo map[string]interface{}
+	dec := json.NewDecoder(&buf)
+	for dec.More() {
+		var rec map[string]interface{}
+		require.NoError(t, dec.Decode(&rec))
+		switch rec["msg"] {
+		case "Response":
+			response = rec
+		case "Echo":
+			echo = rec
+		}
+	}
+	require.NotNil(t, response)
+	assert.Equal(t, "GET", response["method"])
+	assert.Equal(t, "/consulta/sql/{sql}", response["route"])
+	assert.Equal(t, float64(200), response["status"])
+	assert.Equal(t, float64(0), response["attempt"])
+	assert.Equal(t, "req_1", response["request_id"])
+	assert.Contains(t, response, "latency")
+	assert.Equal(t, "bad key ****1234", echo["error"], "the API key is redacted")
+	assert.NotContains(t, buf.String(), "secret_key_1234")
+}
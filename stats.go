@@ -0,0 +1,126 @@
+package iptuapi
+
+import (
+	"expvar"
+	"strconv"
+	"sync"
+)
+
+// Stats is a snapshot of the client's internal counters.
+type Stats struct {
+	// Requests counts HTTP attempts by route ("GET /consulta/sql/{sql}") and
+	// by status code; transport failures are counted under "error".
+	Requests map[string]map[string]int64 `json:"requests"`
+	// Retries counts attempts beyond the first.
+	Retries       int64   `json:"retries"`
+	CacheHits     int64   `json:"cache_hits"`
+	CacheMisses   int64   `json:"cache_misses"`
+	CacheHitRatio float64 `json:"cache_hit_ratio"`
+	BreakerTrips  int64   `json:"breaker_trips"`
+	// GroupCalls counts calls made under a CallGroup, and GroupCancelled
+	// those interrupted by CallGroup.Cancel.
+	GroupCalls     int64 `json:"group_calls"`
+	GroupCancelled int64 `json:"group_cancelled"`
+}
+
+// statsCollector accumulates the counters behind Client.Stats.
+type statsCollector struct {
+	mu       sync.Mutex
+	requests map[string]map[string]int64
+	retries  int64
+	hits     int64
+	misses   int64
+	trips    int64
+
+	groupCalls     int64
+	groupCancelled int64
+}
+
+func newStatsCollector() *statsCollector {
+	return &statsCollector{requests: make(map[string]map[string]int64)}
+}
+
+// request records one attempt; status 0 means the request failed before a
+// response was received.
+func (s *statsCollector) request(method, route string, status int) {
+	code := "error"
+	if status > 0 {
+		code = strconv.Itoa(status)
+	}
+	key := method + " " + route
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	byStatus, ok := s.requests[key]
+	if !ok {
+		byStatus = make(map[string]int64)
+		s.requests[key] = byStatus
+	}
+	byStatus[code]++
+}
+
+func (s *statsCollector) add(counter *int64) {
+	s.mu.Lock()
+	*counter++
+	s.mu.Unlock()
+}
+
+func (s *statsCollector) retry()       { s.add(&s.retries) }
+func (s *statsCollector) cacheHit()    { s.add(&s.hits) }
+func (s *statsCollector) cacheMiss()   { s.add(&s.misses) }
+func (s *statsCollector) breakerTrip() { s.add(&s.trips) }
+
+// Stats returns a snapshot of the client's counters.
+func (c *Client) Stats() Stats {
+	s := c.stats
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	out := Stats{
+		Requests:       make(map[string]map[string]int64, len(s.requests)),
+		Retries:        s.retries,
+		CacheHits:      s.hits,
+		CacheMisses:    s.misses,
+		BreakerTrips:   s.trips,
+		GroupCalls:     s.groupCalls,
+		GroupCancelled: s.groupCancelled,
+	}
+	for route, byStatus := range s.requests {
+		cp := make(map[string]int64, len(byStatus))
+		for code, n := range byStatus {
+			cp[code] = n
+		}
+		out.Requests[route] = cp
+	}
+	if total := s.hits + s.misses; total > 0 {
+		out.CacheHitRatio = float64(s.hits) / float64(total)
+	}
+	return out
+}
+
+// ExpvarFunc returns an expvar.Var that renders Stats as JSON, for use with
+// expvar.Publish or any handler that serves expvar values.
+func (c *Client) ExpvarFunc() expvar.Func {
+	return func() interface{} { return c.Stats() }
+}
+
+// PublishExpvar publishes the client's Stats under name on /debug/vars.
+// Like expvar.Publish, it panics if name is already registered.
+func (c *Client) PublishExpvar(name string) {
+	expvar.Publish(name, c.ExpvarFunc())
+}
+
+// Attempt describes one HTTP attempt, as passed to OnAttempt observers.
+type Attempt struct {
+	RequestRecord
+	// RateLimit is the quota reported with the response, or nil.
+	RateLimit *RateLimitInfo
+}
+
+// OnAttempt registers fn to be called after every HTTP attempt, retries
+// included, e.g. to export metrics (see package iptuapimetrics). fn runs on
+// the calling goroutine and must not block. Like Use, OnAttempt is not safe
+// for concurrent use with calls; register observers before making requests.
+func (c *Client) OnAttempt(fn func(Attempt)) {
+	c.observers = append(c.observers, fn)
+}
@@ -0,0 +1,44 @@
+package iptuapi
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStats(t *testing.T) {
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if calls == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		json.NewEncoder(w).Encode(ConsultaSQLResult{SQL: "1"})
+	}))
+	defer server.Close()
+
+	client := NewClient("test_key",
+		WithBaseURL(server.URL),
+		WithRetry(&RetryConfig{MaxRetries: 1, InitialDelay: time.Millisecond, BackoffFactor: 1, RetryableStatus: []int{503}}),
+	)
+	ctx := context.Background()
+	_, err := client.ConsultaSQL(ctx, "111", "")
+	require.NoError(t, err)
+	_, err = client.ConsultaSQL(ctx, "222", "")
+	require.NoError(t, err)
+
+	stats := client.Stats()
+	assert.Equal(t, map[string]int64{"503": 1, "200": 2}, stats.Requests["GET /consulta/sql/{sql}"])
+	assert.Equal(t, int64(1), stats.Retries)
+
+	data, err := json.Marshal(client.ExpvarFunc().Value())
+	require.NoError(t, err)
+	assert.Contains(t, string(data), `"retries":1`)
+}
@@ -0,0 +1,150 @@
+package iptuapi
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+)
+
+type streamKey struct{}
+
+// streamed reports whether resp is the successful response of a streamed
+// call, whose body is decoded as it arrives instead of being buffered.
+func streamed(ctx context.Context, resp *http.Response) bool {
+	stream, _ := ctx.Value(streamKey{}).(bool)
+	return stream && resp.StatusCode >= 200 && resp.StatusCode < 300
+}
+
+// cancelOnClose releases the attempt timeout of a streamed body.
+type cancelOnClose struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (b *cancelOnClose) Close() error {
+	err := b.ReadCloser.Close()
+	b.cancel()
+	return err
+}
+
+// ConsultaCEPStream is ConsultaCEP for CEPs with thousands of units, such
+// as large condominiums: fn is called with each property as it is decoded
+// from the response, so memory stays flat however long the list is.
+// Returning an error from fn stops the stream and is returned as is. See
+// streamArray for what differs from buffered calls.
+func (c *Client) ConsultaCEPStream(ctx context.Context, cep string, cidade Cidade, fn func(ConsultaEnderecoResult) error, opts ...RequestOption) error {
+	cidade = newRequestOptions(opts).cidadeOr(cidade)
+	if err := c.check(opts, func() error { return validateCEP(cep, cidade) }); err != nil {
+		return err
+	}
+	params := url.Values{}
+	params.Set("cidade", string(cidadeOrDefault(cidade)))
+	opts = append([]RequestOption{withRoute("/consulta/cep/{cep}")}, opts...)
+	return streamArray(ctx, c, "/consulta/cep/"+cep, params, fn, opts)
+}
+
+// ITBITransacoesStream is ITBITransacoes with each transaction passed to fn
+// as it is decoded, for date ranges returning thousands of records.
+// Returning an error from fn stops the stream and is returned as is.
+func (c *Client) ITBITransacoesStream(ctx context.Context, p *ITBIParams, fn func(ITBITransacao) error, opts ...RequestOption) error {
+	p = p.withCidade(opts)
+	if err := c.check(opts, p.Validate); err != nil {
+		return err
+	}
+	return streamArray(ctx, c, "/dados/itbi/transacoes", p.Values(), fn, opts)
+}
+
+// streamArray makes a GET call whose response is a JSON array and passes
+// each element to fn. Retries, rate limiting and the circuit breaker apply
+// as usual until the response headers arrive; the body is then decoded
+// one element at a time with the client's codec. Unlike buffered calls:
+//
+//   - the response cache and request coalescing are bypassed;
+//   - post-processors see each element, e.g. *ITBITransacao rather than
+//     *[]ITBITransacao;
+//   - a failure midway leaves the elements already passed to fn delivered.
+//
+// With response signatures enabled, or in debug mode, the body is still
+// read in full first, since it must be verified or dumped as a whole.
+func streamArray[T any](ctx context.Context, c *Client, endpoint string, params url.Values, fn func(T) error, opts []RequestOption) error {
+	defer c.trackCallGroup(ctx)()
+
+	ro := newRequestOptions(opts)
+	route := ro.routeFor(endpoint)
+	params = ro.mergeQuery(params, true)
+	if ro.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, ro.timeout)
+		defer cancel()
+	}
+	ctx, cancel := c.withEndpointDeadline(ctx, route)
+	defer cancel()
+
+	req, err := c.newRequest(context.WithValue(ctx, streamKey{}, true), http.MethodGet,
+		c.RequestURL(endpoint, params), nil, route, ro, ro.flags(ctx))
+	if err != nil {
+		return err
+	}
+	resp, err := c.send(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		body, err := io.ReadAll(resp.Body)
+		if err != nil {
+			return err
+		}
+		return c.handleErrorResponse(resp, body)
+	}
+	var body io.Reader = resp.Body
+	if c.signaturePolicy != SignatureOff {
+		data, err := io.ReadAll(resp.Body)
+		if err != nil {
+			return err
+		}
+		if err := c.verifySignature(resp.Header, data); err != nil {
+			return err
+		}
+		body = bytes.NewReader(data)
+	}
+	if ro.header != nil {
+		*ro.header = resp.Header
+	}
+
+	dec := json.NewDecoder(body)
+	tok, err := dec.Token()
+	if err != nil {
+		return err
+	}
+	if tok == nil {
+		return nil // null, as for an empty list
+	}
+	if delim, ok := tok.(json.Delim); !ok || delim != '[' {
+		return fmt.Errorf("iptuapi: %s: response is not a JSON array", route)
+	}
+	for dec.More() {
+		var raw json.RawMessage
+		if err := dec.Decode(&raw); err != nil {
+			return err
+		}
+		var item T
+		if err := c.unmarshal(raw, &item); err != nil {
+			return err
+		}
+		stampSchema(&item)
+		if err := c.postProcess(route, &item); err != nil {
+			return err
+		}
+		if err := fn(item); err != nil {
+			return err
+		}
+	}
+	_, err = dec.Token()
+	return err
+}
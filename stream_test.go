@@ -0,0 +1,122 @@
+package iptuapi
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConsultaCEPStream(t *testing.T) {
+	received := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/consulta/cep/01310100", r.URL.Path)
+		assert.Equal(t, "sp", r.URL.Query().Get("cidade"))
+		w.Write([]byte(`[{"sql":"000.000.0001-1","numero":100},`))
+		w.(http.Flusher).Flush()
+		// The rest is only sent once the first unit has been delivered.
+		select {
+		case <-received:
+		case <-time.After(5 * time.Second):
+			t.Error("first element not delivered before the body ended")
+		}
+		w.Write([]byte(`{"sql":"000.000.0002-1"}]`))
+	}))
+	defer server.Close()
+
+	client := NewClient("test_key", WithBaseURL(server.URL))
+	var sqls []string
+	err := client.ConsultaCEPStream(context.Background(), "01310100", "", func(r ConsultaEnderecoResult) error {
+		if len(sqls) == 0 {
+			assert.Equal(t, "100", r.Numero)
+			close(received)
+		}
+		sqls = append(sqls, r.SQL)
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"000.000.0001-1", "000.000.0002-1"}, sqls)
+	assert.Equal(t, int64(1), client.Stats().Requests["GET /consulta/cep/{cep}"]["200"])
+}
+
+func TestStreamStopsOnCallbackError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`[{"sql":"1"},{"sql":"2"},{"sql":"3"}]`))
+	}))
+	defer server.Close()
+
+	stop := errors.New("stop")
+	var calls int
+	client := NewClient("test_key", WithBaseURL(server.URL))
+	err := client.ITBITransacoesStream(context.Background(), &ITBIParams{}, func(ITBITransacao) error {
+		calls++
+		return stop
+	})
+	assert.Same(t, stop, err)
+	assert.Equal(t, 1, calls)
+}
+
+func TestStreamRetriesBeforeTheBody(t *testing.T) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if requests.Add(1) == 1 {
+			w.WriteHeader(http.StatusBadGateway)
+			w.Write([]byte(`{"detail":"upstream"}`))
+			return
+		}
+		w.Write([]byte(`[{"valor_transacao":350000}]`))
+	}))
+	defer server.Close()
+
+	client := NewClient("test_key", WithBaseURL(server.URL),
+		WithRetry(&RetryConfig{MaxRetries: 1, InitialDelay: time.Millisecond, RetryableStatus: []int{502}}))
+	var valores []float64
+	err := client.ITBITransacoesStream(context.Background(), &ITBIParams{}, func(tx ITBITransacao) error {
+		valores = append(valores, tx.ValorTransacao)
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []float64{350000}, valores)
+	assert.Equal(t, int32(2), requests.Load())
+}
+
+func TestStreamErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		status int
+		body   string
+		check  func(t *testing.T, err error)
+	}{
+		{"not found", http.StatusNotFound, `{"detail":"CEP nao encontrado"}`, func(t *testing.T, err error) {
+			assert.True(t, IsNotFound(err))
+		}},
+		{"not an array", http.StatusOK, `{"sql":"1"}`, func(t *testing.T, err error) {
+			assert.ErrorContains(t, err, "not a JSON array")
+		}},
+		{"truncated", http.StatusOK, `[{"sql":"1"},{"sql"`, func(t *testing.T, err error) {
+			assert.Error(t, err)
+		}},
+		{"null", http.StatusOK, `null`, func(t *testing.T, err error) {
+			assert.NoError(t, err)
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.status)
+				w.Write([]byte(tt.body))
+			}))
+			defer server.Close()
+
+			client := NewClient("test_key", WithBaseURL(server.URL), WithRetry(&RetryConfig{MaxRetries: 0}))
+			err := client.ConsultaCEPStream(context.Background(), "01310100", CidadeSaoPaulo, func(ConsultaEnderecoResult) error { return nil })
+			tt.check(t, err)
+		})
+	}
+}
@@ -0,0 +1,104 @@
+package iptuapi
+
+import "math"
+
+// DefaultCAMinimo is the coefficient of use below which a built lot is
+// considered underused, the lowest minimum CA of São Paulo's master plan.
+const DefaultCAMinimo = 0.3
+
+// SituacaoLote is the use of a lot as classified by CalcularSubutilizacao.
+type SituacaoLote string
+
+const (
+	// LoteVago is a lot without built area.
+	LoteVago SituacaoLote = "vago"
+	// LoteSubutilizado is a lot built below the minimum CA.
+	LoteSubutilizado SituacaoLote = "subutilizado"
+	LoteUtilizado    SituacaoLote = "utilizado"
+	// LoteIndeterminado is a lot without land area, which cannot be
+	// classified.
+	LoteIndeterminado SituacaoLote = "indeterminado"
+)
+
+// SubutilizacaoOptions configures CalcularSubutilizacao.
+type SubutilizacaoOptions struct {
+	// CAMinimo is the coefficient of use below which a lot is underused
+	// (default DefaultCAMinimo). Use the zone's own minimum when known.
+	CAMinimo float64
+}
+
+// Subutilizacao compares how much of a lot is built with what its zoning
+// allows, for land-banking analyses.
+type Subutilizacao struct {
+	Situacao SituacaoLote `json:"situacao"`
+	// CAUtilizado is the coefficient of use, built area over land area.
+	CAUtilizado float64 `json:"ca_utilizado"`
+	// AproveitamentoBasico and AproveitamentoMaximo are the shares of the
+	// basic and maximum building potential in use, e.g. 0.25 for a lot
+	// built to a quarter of its CA. They are zero without zoning data.
+	AproveitamentoBasico float64 `json:"aproveitamento_basico,omitempty"`
+	AproveitamentoMaximo float64 `json:"aproveitamento_maximo,omitempty"`
+	// PotencialRestante is the area in m² that could still be built up
+	// to the maximum CA, or up to the basic CA when the maximum is
+	// unknown. It is zero without zoning data.
+	PotencialRestante float64 `json:"potencial_restante,omitempty"`
+}
+
+// CalcularSubutilizacao computes the underutilization indicators of a lot
+// from its land and built areas and its zoning, which may be nil. It is
+// meant for whole lots: for units of a condominium, pass the lot's areas
+// rather than the unit's.
+func CalcularSubutilizacao(areaTerreno, areaConstruida float64, z *ZoneamentoResult, opts *SubutilizacaoOptions) Subutilizacao {
+	caMinimo := DefaultCAMinimo
+	if opts != nil && opts.CAMinimo > 0 {
+		caMinimo = opts.CAMinimo
+	}
+	if !(areaTerreno > 0) {
+		return Subutilizacao{Situacao: LoteIndeterminado}
+	}
+
+	s := Subutilizacao{CAUtilizado: math.Max(areaConstruida, 0) / areaTerreno}
+	switch {
+	case s.CAUtilizado == 0:
+		s.Situacao = LoteVago
+	case s.CAUtilizado < caMinimo:
+		s.Situacao = LoteSubutilizado
+	default:
+		s.Situacao = LoteUtilizado
+	}
+
+	if z != nil {
+		if z.CoeficienteAproveitamentoBasico > 0 {
+			s.AproveitamentoBasico = s.CAUtilizado / z.CoeficienteAproveitamentoBasico
+		}
+		if z.CoeficienteAproveitamentoMaximo > 0 {
+			s.AproveitamentoMaximo = s.CAUtilizado / z.CoeficienteAproveitamentoMaximo
+		}
+		ca := z.CoeficienteAproveitamentoMaximo
+		if ca == 0 {
+			ca = z.CoeficienteAproveitamentoBasico
+		}
+		s.PotencialRestante = math.Max(ca*areaTerreno-areaConstruida, 0)
+	}
+	return s
+}
+
+// AnalisarSubutilizacao is CalcularSubutilizacao for a result of
+// ConsultaEndereco, using its Zoneamento when the query asked for it with
+// IncluirZoneamento.
+func AnalisarSubutilizacao(r *ConsultaEnderecoResult, opts *SubutilizacaoOptions) Subutilizacao {
+	return CalcularSubutilizacao(r.AreaTerreno, r.AreaConstruida, r.Zoneamento, opts)
+}
+
+// LotesSubutilizados returns the results whose lots are vacant or
+// underused, in their original order.
+func LotesSubutilizados(results []ConsultaEnderecoResult, opts *SubutilizacaoOptions) []ConsultaEnderecoResult {
+	var out []ConsultaEnderecoResult
+	for i := range results {
+		switch AnalisarSubutilizacao(&results[i], opts).Situacao {
+		case LoteVago, LoteSubutilizado:
+			out = append(out, results[i])
+		}
+	}
+	return out
+}
@@ -0,0 +1,45 @@
+package iptuapi
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCalcularSubutilizacao(t *testing.T) {
+	z := &ZoneamentoResult{CoeficienteAproveitamentoBasico: 1, CoeficienteAproveitamentoMaximo: 4}
+
+	s := CalcularSubutilizacao(1000, 200, z, nil)
+	assert.Equal(t, LoteSubutilizado, s.Situacao)
+	assert.InDelta(t, 0.2, s.CAUtilizado, 1e-9)
+	assert.InDelta(t, 0.2, s.AproveitamentoBasico, 1e-9)
+	assert.InDelta(t, 0.05, s.AproveitamentoMaximo, 1e-9)
+	assert.Equal(t, 3800.0, s.PotencialRestante)
+
+	assert.Equal(t, LoteUtilizado, CalcularSubutilizacao(1000, 200, z, &SubutilizacaoOptions{CAMinimo: 0.1}).Situacao)
+	assert.Equal(t, LoteVago, CalcularSubutilizacao(500, 0, z, nil).Situacao)
+	assert.Equal(t, Subutilizacao{Situacao: LoteIndeterminado}, CalcularSubutilizacao(0, 80, z, nil))
+
+	// Over the maximum CA, nothing is left to build.
+	s = CalcularSubutilizacao(100, 500, z, nil)
+	assert.Equal(t, LoteUtilizado, s.Situacao)
+	assert.Zero(t, s.PotencialRestante)
+
+	// Without zoning only the coefficient of use is known.
+	s = AnalisarSubutilizacao(&ConsultaEnderecoResult{AreaTerreno: 400, AreaConstruida: 600}, nil)
+	assert.Equal(t, Subutilizacao{Situacao: LoteUtilizado, CAUtilizado: 1.5}, s)
+
+	s = CalcularSubutilizacao(400, 100, &ZoneamentoResult{CoeficienteAproveitamentoBasico: 2}, nil)
+	assert.Equal(t, 700.0, s.PotencialRestante, "up to the basic CA when the maximum is unknown")
+}
+
+func TestLotesSubutilizados(t *testing.T) {
+	results := []ConsultaEnderecoResult{
+		{SQL: "1", AreaTerreno: 600},
+		{SQL: "2", AreaTerreno: 600, AreaConstruida: 900},
+		{SQL: "3", AreaTerreno: 600, AreaConstruida: 60},
+		{SQL: "4", AreaConstruida: 60},
+	}
+	got := LotesSubutilizados(results, nil)
+	assert.Equal(t, []string{"1", "3"}, []string{got[0].SQL, got[1].SQL})
+}
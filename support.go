@@ -0,0 +1,172 @@
+package iptuapi
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"runtime"
+	"sync"
+	"time"
+)
+
+// journalSize is the number of recent requests kept for SupportBundle.
+const journalSize = 200
+
+// RequestRecord describes one HTTP attempt. It carries the endpoint
+// template, never the URL, so no addresses or identifiers are recorded.
+type RequestRecord struct {
+	Time    time.Time     `json:"time"`
+	Method  string        `json:"method"`
+	Route   string        `json:"route"`
+	Attempt int           `json:"attempt"`
+	Status  int           `json:"status,omitempty"`
+	Latency time.Duration `json:"latency_ns"`
+	// RequestID is the X-Request-ID of the response, or the one sent if
+	// the response has none.
+	RequestID string `json:"request_id,omitempty"`
+	Error     string `json:"error,omitempty"`
+}
+
+// requestJournal is a ring buffer of the most recent attempts.
+type requestJournal struct {
+	mu      sync.Mutex
+	records []RequestRecord
+	next    int
+	full    bool
+}
+
+func newRequestJournal(size int) *requestJournal {
+	return &requestJournal{records: make([]RequestRecord, size)}
+}
+
+func (j *requestJournal) add(r RequestRecord) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.records[j.next] = r
+	j.next = (j.next + 1) % len(j.records)
+	if j.next == 0 {
+		j.full = true
+	}
+}
+
+// last returns up to n records, oldest first; n <= 0 returns all.
+func (j *requestJournal) last(n int) []RequestRecord {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	var all []RequestRecord
+	if j.full {
+		all = append(all, j.records[j.next:]...)
+	}
+	all = append(all, j.records[:j.next]...)
+	if n > 0 && n < len(all) {
+		all = all[len(all)-n:]
+	}
+	return all
+}
+
+// journalError returns err's message without the request URL, which may
+// carry addresses or property identifiers.
+func journalError(err error) string {
+	var uerr *url.Error
+	if errors.As(err, &uerr) {
+		err = uerr.Err
+	}
+	return err.Error()
+}
+
+// SupportConfig is the client configuration with secrets masked.
+type SupportConfig struct {
+	BaseURL          string                          `json:"base_url"`
+	BaseURLs         []string                        `json:"base_urls,omitempty"`
+	APIKey           string                          `json:"api_key"`
+	APIKeys          int                             `json:"api_keys,omitempty"`
+	UserAgent        string                          `json:"user_agent"`
+	Timeout          time.Duration                   `json:"timeout_ns"`
+	EndpointTimeouts map[EndpointClass]time.Duration `json:"endpoint_timeouts_ns,omitempty"`
+	MaxRetries       int                             `json:"max_retries"`
+	RetryableStatus  []int                           `json:"retryable_status,omitempty"`
+	Cache            bool                            `json:"cache"`
+	RateLimiter      bool                            `json:"rate_limiter"`
+	Coalescing       time.Duration                   `json:"coalescing_ns,omitempty"`
+	Fallback         bool                            `json:"fallback_provider"`
+	CircuitBreaker   *BreakerConfig                  `json:"circuit_breaker,omitempty"`
+	Wrappers         int                             `json:"transport_wrappers"`
+	PostProcessors   int                             `json:"post_processors"`
+}
+
+// SupportBundle gathers diagnostics to attach to a support ticket. It holds
+// no API key, URLs with query parameters or response bodies.
+type SupportBundle struct {
+	GeneratedAt time.Time        `json:"generated_at"`
+	SDKVersion  string           `json:"sdk_version"`
+	GoVersion   string           `json:"go_version"`
+	Platform    string           `json:"platform"`
+	Config      SupportConfig    `json:"config"`
+	RateLimit   *RateLimitInfo   `json:"rate_limit,omitempty"`
+	Stats       Stats            `json:"stats"`
+	SLA         *SLAReport       `json:"sla"`
+	Health      *SelfCheckResult `json:"health"`
+	Requests    []RequestRecord  `json:"requests"`
+}
+
+// SupportBundle collects the last lastN requests (all retained ones, up to
+// 200, if lastN <= 0) with their request IDs, statuses and latencies, the
+// SDK and Go versions, the sanitized configuration, Stats, the SLAReport
+// and a SelfCheck.
+// Marshal it to JSON to attach to a ticket.
+func (c *Client) SupportBundle(ctx context.Context, lastN int) *SupportBundle {
+	// Snapshot the journal before SelfCheck adds its probe.
+	requests := c.journal.last(lastN)
+	rateLimit, _ := c.lastResponseInfo()
+	return &SupportBundle{
+		GeneratedAt: time.Now().UTC(),
+		SDKVersion:  Version,
+		GoVersion:   runtime.Version(),
+		Platform:    runtime.GOOS + "/" + runtime.GOARCH,
+		Config:      c.supportConfig(),
+		RateLimit:   rateLimit,
+		Stats:       c.Stats(),
+		SLA:         c.SLAReport(),
+		Health:      c.SelfCheck(ctx),
+		Requests:    requests,
+	}
+}
+
+func (c *Client) supportConfig() SupportConfig {
+	cfg := SupportConfig{
+		BaseURL:          c.baseURL,
+		APIKey:           maskKey(c.apiKey),
+		UserAgent:        c.userAgent,
+		Timeout:          c.httpClient.Timeout,
+		EndpointTimeouts: c.endpointTimeouts,
+		MaxRetries:       c.retryConfig.MaxRetries,
+		RetryableStatus:  c.retryConfig.RetryableStatus,
+		Cache:            c.cache != nil,
+		RateLimiter:      c.limiter != nil,
+		Fallback:         c.fallback != nil,
+		Wrappers:         len(c.wrappers),
+		PostProcessors:   len(c.postProcessors),
+	}
+	if c.failover != nil {
+		cfg.BaseURLs = c.failover.urls
+	}
+	if c.keys != nil {
+		cfg.APIKeys = len(c.keys.keys)
+	}
+	if c.breaker != nil {
+		breakerCfg := c.breaker.cfg
+		cfg.CircuitBreaker = &breakerCfg
+	}
+	if c.coalesce != nil {
+		cfg.Coalescing = c.coalesce.window
+	}
+	return cfg
+}
+
+// maskKey keeps the last four characters of an API key.
+func maskKey(key string) string {
+	if len(key) <= 4 {
+		return "****"
+	}
+	return "****" + key[len(key)-4:]
+}
@@ -0,0 +1,61 @@
+package iptuapi
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSupportBundle(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Request-ID", "req-"+r.URL.Query().Get("cidade"))
+		if r.URL.Query().Get("cidade") == "bh" {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"detail":"nao encontrado"}`))
+			return
+		}
+		w.Write([]byte(`{"sql":"000.000.0000-0"}`))
+	}))
+	defer server.Close()
+
+	client := NewClient("sk_live_secret1234", WithBaseURL(server.URL), WithRetry(&RetryConfig{MaxRetries: 0}))
+	ctx := context.Background()
+	_, err := client.ConsultaSQL(ctx, "000.000.0000-0", CidadeSaoPaulo)
+	require.NoError(t, err)
+	_, err = client.ConsultaSQL(ctx, "111.111.1111-1", CidadeBeloHorizonte)
+	require.Error(t, err)
+
+	b := client.SupportBundle(ctx, 1)
+	assert.Equal(t, Version, b.SDKVersion)
+	assert.Equal(t, "****1234", b.Config.APIKey)
+	require.Len(t, b.Requests, 1)
+	assert.Equal(t, "/consulta/sql/{sql}", b.Requests[0].Route)
+	assert.Equal(t, http.StatusNotFound, b.Requests[0].Status)
+	assert.Equal(t, "req-bh", b.Requests[0].RequestID)
+
+	assert.Len(t, client.SupportBundle(ctx, 0).Requests, 3, "includes the health probe")
+
+	data, err := json.Marshal(b)
+	require.NoError(t, err)
+	assert.False(t, strings.Contains(string(data), "secret"), "API key is masked")
+	assert.False(t, strings.Contains(string(data), "111.111.1111-1"), "identifiers are not recorded")
+}
+
+func TestRequestJournalWraps(t *testing.T) {
+	j := newRequestJournal(3)
+	for i := 0; i < 5; i++ {
+		j.add(RequestRecord{Attempt: i})
+	}
+	var attempts []int
+	for _, r := range j.last(0) {
+		attempts = append(attempts, r.Attempt)
+	}
+	assert.Equal(t, []int{2, 3, 4}, attempts)
+	assert.Len(t, j.last(2), 2)
+}
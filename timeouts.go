@@ -0,0 +1,83 @@
+package iptuapi
+
+import (
+	"context"
+	"strings"
+	"time"
+)
+
+// EndpointClass groups endpoints with similar latency for timeout purposes.
+type EndpointClass string
+
+const (
+	// EndpointFast covers single-record lookups and reference data.
+	EndpointFast EndpointClass = "fast"
+	// EndpointValuation covers model-backed valuation endpoints.
+	EndpointValuation EndpointClass = "valuation"
+	// EndpointExport covers batch and bulk listing endpoints.
+	EndpointExport EndpointClass = "export"
+)
+
+// DefaultEndpointTimeouts returns the per-class timeouts used by
+// WithEndpointTimeouts for classes missing from its map.
+func DefaultEndpointTimeouts() map[EndpointClass]time.Duration {
+	return map[EndpointClass]time.Duration{
+		EndpointFast:      5 * time.Second,
+		EndpointValuation: 20 * time.Second,
+		EndpointExport:    120 * time.Second,
+	}
+}
+
+// exportRoutes lists the routes classified as EndpointExport.
+var exportRoutes = map[string]bool{
+	"/valuation/estimate/batch": true,
+	"/dados/itbi/transacoes":    true,
+}
+
+// ClassifyEndpoint returns the class of an endpoint route such as
+// "/valuation/estimate" or "/consulta/sql/{sql}".
+func ClassifyEndpoint(route string) EndpointClass {
+	switch {
+	case exportRoutes[route] || strings.HasSuffix(route, "/batch"):
+		return EndpointExport
+	case strings.HasPrefix(route, "/valuation/"):
+		return EndpointValuation
+	default:
+		return EndpointFast
+	}
+}
+
+// WithEndpointTimeouts gives every call a deadline based on its endpoint
+// class, covering all retry attempts. Classes missing from timeouts use
+// DefaultEndpointTimeouts, and a zero duration disables the deadline for
+// that class. A deadline already set on the call's context takes
+// precedence.
+//
+// The client-wide default timeout is dropped so that slow classes are not
+// cut short; an explicit WithTimeout or WithHTTPClient still bounds each
+// attempt.
+func WithEndpointTimeouts(timeouts map[EndpointClass]time.Duration) ClientOption {
+	return func(c *Client) {
+		merged := DefaultEndpointTimeouts()
+		for class, d := range timeouts {
+			merged[class] = d
+		}
+		c.endpointTimeouts = merged
+	}
+}
+
+// withEndpointDeadline applies the class timeout for route to ctx unless
+// ctx already has a deadline.
+func (c *Client) withEndpointDeadline(ctx context.Context, route string) (context.Context, context.CancelFunc) {
+	if c.endpointTimeouts == nil {
+		return ctx, func() {}
+	}
+	if _, ok := ctx.Deadline(); ok {
+		return ctx, func() {}
+	}
+	d := c.endpointTimeouts[ClassifyEndpoint(route)]
+	if d <= 0 {
+		return ctx, func() {}
+	}
+	return context.WithTimeout(ctx, d)
+}
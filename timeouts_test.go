@@ -0,0 +1,57 @@
+package iptuapi
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClassifyEndpoint(t *testing.T) {
+	assert.Equal(t, EndpointFast, ClassifyEndpoint("/consulta/sql/{sql}"))
+	assert.Equal(t, EndpointFast, ClassifyEndpoint("/iptu-tools/cidades"))
+	assert.Equal(t, EndpointValuation, ClassifyEndpoint("/valuation/estimate"))
+	assert.Equal(t, EndpointExport, ClassifyEndpoint("/valuation/estimate/batch"))
+	assert.Equal(t, EndpointExport, ClassifyEndpoint("/dados/itbi/transacoes"))
+}
+
+func TestWithEndpointTimeouts(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(100 * time.Millisecond)
+		json.NewEncoder(w).Encode(map[string]interface{}{})
+	}))
+	defer server.Close()
+
+	client := NewClient("test_key",
+		WithBaseURL(server.URL),
+		WithRetry(&RetryConfig{MaxRetries: 0}),
+		WithEndpointTimeouts(map[EndpointClass]time.Duration{EndpointFast: 20 * time.Millisecond}),
+	)
+	assert.Zero(t, client.httpClient.Timeout, "default client-wide timeout is dropped")
+	assert.Equal(t, 20*time.Second, client.endpointTimeouts[EndpointValuation], "missing classes use defaults")
+
+	_, err := client.IPTUToolsCidades(context.Background())
+	require.Error(t, err)
+	assert.True(t, errors.Is(err, context.DeadlineExceeded), "got %v", err)
+
+	// The valuation class keeps its 20s default.
+	_, err = client.ValuationEstimate(context.Background(), &ValuationParams{AreaTerreno: 1, AreaConstruida: 1, Bairro: "Moema"})
+	require.NoError(t, err)
+
+	// A caller deadline takes precedence over the class timeout.
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	_, err = client.IPTUToolsCidades(ctx)
+	require.NoError(t, err)
+}
+
+func TestEndpointTimeoutsKeepExplicitTimeout(t *testing.T) {
+	client := NewClient("test_key", WithTimeout(time.Minute), WithEndpointTimeouts(nil))
+	assert.Equal(t, time.Minute, client.httpClient.Timeout)
+}
@@ -0,0 +1,306 @@
+package iptuapi
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"time"
+)
+
+// TransportWrapper wraps a RoundTripper, e.g. to add tracing, logging or
+// metrics around the requests the client sends.
+type TransportWrapper func(http.RoundTripper) http.RoundTripper
+
+// WithTransportWrapper registers wrappers around the SDK transport. The
+// first wrapper registered is the outermost. See Client.Transport for where
+// wrappers sit relative to retries and authentication.
+func WithTransportWrapper(wrappers ...TransportWrapper) ClientOption {
+	return func(c *Client) {
+		c.wrappers = append(c.wrappers, wrappers...)
+	}
+}
+
+// Transport returns the RoundTripper the client sends every request
+// through. Layers are always composed in this order, outermost first:
+//
+//	user wrappers → SDK retry → SDK failover → SDK auth → base transport
+//
+// Wrappers registered with WithTransportWrapper therefore see each call
+// once, before retries, and never see the API key. The failover layer is
+// present only with WithBaseURLs; the dump of WithDebug is taken between
+// the auth layer and the base transport, and WithMaxResponseBytes applies
+// right above the base transport. The base transport is
+// the Transport of the HTTP client set with WithHTTPClient
+// (http.DefaultTransport by default); it receives authenticated requests,
+// so custom RoundTrippers belong in WithTransportWrapper instead.
+//
+// The returned RoundTripper can also be used in another http.Client to
+// send authenticated, retried requests to the API.
+func (c *Client) Transport() http.RoundTripper {
+	base := c.httpClient.Transport
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	if c.maxResponseBytes > 0 {
+		base = &limitTransport{limit: c.maxResponseBytes, next: base}
+	}
+	var rt http.RoundTripper = &authTransport{c: c, next: &debugTransport{c: c, next: base}}
+	if c.failover != nil {
+		rt = &failoverTransport{c: c, f: c.failover, next: rt}
+	}
+	rt = &retryTransport{c: c, next: rt}
+	for i := len(c.wrappers) - 1; i >= 0; i-- {
+		rt = c.wrappers[i](rt)
+	}
+	return rt
+}
+
+// send runs req through Transport, honoring the redirect policy and cookie
+// jar of the configured HTTP client. Its Timeout applies per attempt.
+func (c *Client) send(req *http.Request) (*http.Response, error) {
+	hc := &http.Client{
+		Transport:     c.Transport(),
+		CheckRedirect: c.httpClient.CheckRedirect,
+		Jar:           c.httpClient.Jar,
+	}
+	return hc.Do(req)
+}
+
+type routeKey struct{}
+
+// contextWithRoute records the endpoint template used to label stats and
+// logs for the request.
+func contextWithRoute(ctx context.Context, route string) context.Context {
+	return context.WithValue(ctx, routeKey{}, route)
+}
+
+type noBreakerKey struct{}
+
+type apiKeyKey struct{}
+
+// callAPIKey returns the key set for the call with WithAPIKey, if any. It
+// travels in the context so that wrappers and middleware never see it.
+func callAPIKey(ctx context.Context) string {
+	key, _ := ctx.Value(apiKeyKey{}).(string)
+	return key
+}
+
+// breakerExempt reports whether the request bypasses the circuit breaker,
+// like the incident feed polled to leave maintenance mode.
+func breakerExempt(ctx context.Context) bool {
+	exempt, _ := ctx.Value(noBreakerKey{}).(bool)
+	return exempt
+}
+
+func routeFrom(req *http.Request) string {
+	if route, ok := req.Context().Value(routeKey{}).(string); ok {
+		return route
+	}
+	return req.URL.Path
+}
+
+// authTransport adds the API key and User-Agent to every request. A key
+// already set by a wrapper or middleware is kept; otherwise the key set with
+// WithAPIKey is used, or keys set with WithAPIKeys are rotated.
+type authTransport struct {
+	c    *Client
+	next http.RoundTripper
+}
+
+func (t *authTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	r := req.Clone(req.Context())
+	r.Header.Set("User-Agent", t.c.userAgent)
+	if r.Header.Get("X-API-Key") == "" {
+		if key := callAPIKey(r.Context()); key != "" {
+			r.Header.Set("X-API-Key", key)
+			return t.next.RoundTrip(r)
+		}
+		if t.c.keys != nil {
+			return t.sendWithPool(r)
+		}
+		r.Header.Set("X-API-Key", t.c.apiKey)
+	}
+	return t.next.RoundTrip(r)
+}
+
+// retryTransport retries attempts according to the client's RetryConfig.
+// Response bodies are read in full so that ShouldRetry can inspect them;
+// the returned response carries the buffered body. Successful responses of
+// streamed calls are passed through unread.
+type retryTransport struct {
+	c    *Client
+	next http.RoundTripper
+}
+
+func (t *retryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	c := t.c
+	ctx := req.Context()
+	route := routeFrom(req)
+	maxRetries := c.retryConfig.MaxRetries
+
+	var (
+		delay, lastLatency, retryAfter time.Duration
+		lastErr                        error
+	)
+	for attempt := 0; ; attempt++ {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		if attempt > 0 {
+			delay = c.retryConfig.delay(attempt-1, delay)
+			if retryAfter > 0 {
+				delay = retryAfter
+				if maxDelay := c.retryConfig.MaxDelay; maxDelay > 0 && delay > maxDelay {
+					delay = maxDelay
+				}
+				retryAfter = 0
+			}
+			if err := c.retryConfig.checkDeadline(ctx, attempt, delay, lastLatency, lastErr); err != nil {
+				c.log(ctx, SubsystemRetry, slog.LevelWarn, "Retry abandoned before the deadline",
+					"route", route, "delay", delay, "attempt", attempt, "error", err)
+				return nil, err
+			}
+			c.stats.retry()
+			c.log(ctx, SubsystemRetry, slog.LevelWarn, "Request failed, retrying",
+				"route", route, "delay", delay, "attempt", attempt, "max_retries", maxRetries)
+
+			if err := c.clock.Sleep(ctx, delay); err != nil {
+				return nil, err
+			}
+		}
+
+		if c.limiter != nil {
+			if err := c.limiter.Wait(ctx); err != nil {
+				return nil, err
+			}
+		}
+
+		r := req
+		if attempt > 0 && req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			r = req.Clone(ctx)
+			r.Body = body
+		}
+
+		useBreaker := c.breaker != nil && !breakerExempt(ctx)
+		if useBreaker {
+			if err := c.breaker.allow(); err != nil {
+				return nil, err
+			}
+		}
+
+		c.log(ctx, SubsystemHTTP, slog.LevelDebug, "Request", "method", req.Method, "url", req.URL.String())
+
+		start := time.Now()
+		resp, respBody, err := t.attempt(r)
+		stream := err == nil && streamed(ctx, resp)
+		if useBreaker {
+			t.recordBreaker(ctx, resp, err)
+		}
+		rec := RequestRecord{Time: start, Method: req.Method, Route: route, Attempt: attempt, Latency: time.Since(start),
+			RequestID: req.Header.Get("X-Request-ID")}
+		if resp != nil {
+			rec.Status = resp.StatusCode
+			if id := resp.Header.Get("X-Request-ID"); id != "" {
+				rec.RequestID = id
+			}
+		}
+		if err != nil {
+			rec.Error = journalError(err)
+		}
+		c.journal.add(rec)
+		c.sla.record(req.Method+" "+route, rec.Time, rec.Latency, rec.Status, rec.RequestID)
+		c.stats.request(req.Method, route, rec.Status)
+		var rl *RateLimitInfo
+		if err == nil {
+			rl = c.extractRateLimit(resp, rec, req.Header.Get("X-API-Key") == "" && callAPIKey(ctx) == "")
+		}
+		for _, fn := range c.observers {
+			fn(Attempt{RequestRecord: rec, RateLimit: rl})
+		}
+		lastErr, lastLatency = err, rec.Latency
+		if err != nil {
+			if ctx.Err() == nil && c.retryNetErr(req, err, attempt) {
+				continue
+			}
+			return nil, err
+		}
+
+		c.log(ctx, SubsystemHTTP, slog.LevelDebug, "Response", "method", req.Method, "route", route,
+			"status", resp.StatusCode, "latency", rec.Latency, "attempt", attempt, "request_id", rec.RequestID)
+
+		if attempt < maxRetries {
+			var apiErr error
+			if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+				apiErr = c.handleErrorResponse(resp, respBody)
+			}
+			if c.shouldRetry(resp, apiErr, attempt) {
+				if stream {
+					resp.Body.Close()
+				}
+				wait, ok := parseRetryAfter(resp, c.clock.Now())
+				if ok && !fitsDeadline(ctx, wait) {
+					// The API will not serve the call before the deadline.
+					resp.Body = io.NopCloser(bytes.NewReader(respBody))
+					return resp, nil
+				}
+				lastErr, retryAfter = apiErr, wait
+				continue
+			}
+		}
+		if !stream {
+			resp.Body = io.NopCloser(bytes.NewReader(respBody))
+		}
+		return resp, nil
+	}
+}
+
+// recordBreaker reports an attempt to the circuit breaker. Attempts ended
+// by the caller's context say nothing about the API and are not counted.
+func (t *retryTransport) recordBreaker(ctx context.Context, resp *http.Response, err error) {
+	c := t.c
+	if ctx.Err() != nil {
+		c.breaker.release()
+		return
+	}
+	if c.breaker.record(err != nil || resp.StatusCode >= 500) {
+		c.stats.breakerTrip()
+		c.log(ctx, SubsystemHTTP, slog.LevelWarn, "Circuit breaker opened", "cooldown", c.breaker.cfg.Cooldown)
+	}
+}
+
+// attempt sends one request, applying the HTTP client's Timeout, and
+// buffers the response body. If the body cannot be read, the response is
+// returned along with the read error.
+func (t *retryTransport) attempt(req *http.Request) (*http.Response, []byte, error) {
+	cancel := context.CancelFunc(func() {})
+	if timeout := t.c.httpClient.Timeout; timeout > 0 {
+		var ctx context.Context
+		ctx, cancel = context.WithTimeout(req.Context(), timeout)
+		req = req.WithContext(ctx)
+	}
+	resp, err := t.next.RoundTrip(req)
+	if err != nil {
+		cancel()
+		return nil, nil, err
+	}
+	if streamed(req.Context(), resp) {
+		// The caller decodes the body as it arrives; the attempt timeout
+		// covers the read and ends when the body is closed.
+		resp.Body = &cancelOnClose{ReadCloser: resp.Body, cancel: cancel}
+		return resp, nil, nil
+	}
+	defer cancel()
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return resp, nil, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+	return resp, body, nil
+}
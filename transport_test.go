@@ -0,0 +1,83 @@
+package iptuapi
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) { return f(req) }
+
+func TestTransportLayering(t *testing.T) {
+	attempts := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		assert.Equal(t, "test_key", r.Header.Get("X-API-Key"))
+		if attempts == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		json.NewEncoder(w).Encode(CidadesResult{})
+	}))
+	defer server.Close()
+
+	var (
+		mu    sync.Mutex
+		order []string
+	)
+	wrapper := func(name string) TransportWrapper {
+		return func(next http.RoundTripper) http.RoundTripper {
+			return roundTripFunc(func(req *http.Request) (*http.Response, error) {
+				mu.Lock()
+				order = append(order, name)
+				mu.Unlock()
+				assert.Empty(t, req.Header.Get("X-API-Key"), "wrappers must not see the API key")
+				return next.RoundTrip(req)
+			})
+		}
+	}
+
+	client := NewClient("test_key",
+		WithBaseURL(server.URL),
+		WithRetry(&RetryConfig{MaxRetries: 2, InitialDelay: time.Millisecond, MaxDelay: time.Millisecond, BackoffFactor: 1, RetryableStatus: []int{503}}),
+		WithTransportWrapper(wrapper("outer")),
+		WithTransportWrapper(wrapper("inner")),
+	)
+
+	_, err := client.IPTUToolsCidades(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 2, attempts)
+	assert.Equal(t, []string{"outer", "inner"}, order, "wrappers run once per call, outside retries")
+}
+
+func TestTransportBaseSeesAuth(t *testing.T) {
+	var seen http.Header
+	base := roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		seen = req.Header.Clone()
+		return http.DefaultTransport.RoundTrip(req)
+	})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(CidadesResult{})
+	}))
+	defer server.Close()
+
+	client := NewClient("test_key", WithBaseURL(server.URL), WithHTTPClient(&http.Client{Transport: base}))
+
+	// The transport can be reused outside the SDK methods.
+	hc := &http.Client{Transport: client.Transport()}
+	resp, err := hc.Get(server.URL + "/iptu-tools/cidades")
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, "test_key", seen.Get("X-API-Key"))
+	assert.Contains(t, seen.Get("User-Agent"), "iptuapi-go/")
+	assert.Equal(t, int64(1), client.Stats().Requests["GET /iptu-tools/cidades"]["200"])
+}
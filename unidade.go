@@ -0,0 +1,211 @@
+package iptuapi
+
+import (
+	"strings"
+	"unicode"
+)
+
+// TipoUnidade is the kind of unit a cadastral record describes.
+type TipoUnidade string
+
+const (
+	UnidadeApartamento TipoUnidade = "apartamento"
+	UnidadeVagaGaragem TipoUnidade = "vaga_garagem"
+	UnidadeDeposito    TipoUnidade = "deposito"
+	UnidadeComercial   TipoUnidade = "comercial"
+	// UnidadeOutra is any other record, such as a house or a bare lot, or
+	// one with too little data to classify.
+	UnidadeOutra TipoUnidade = "outra"
+)
+
+// CriterioUnidade records which attribute decided a classification, from
+// the most to the least reliable.
+type CriterioUnidade string
+
+const (
+	CriterioTipoUso     CriterioUnidade = "tipo_uso"
+	CriterioComplemento CriterioUnidade = "complemento"
+	CriterioArea        CriterioUnidade = "area"
+)
+
+// ClassificacaoUnidade is the result of ClassificarUnidade.
+type ClassificacaoUnidade struct {
+	Tipo     TipoUnidade     `json:"tipo"`
+	Criterio CriterioUnidade `json:"criterio,omitempty"`
+}
+
+// UnidadeInfo holds the attributes a unit is classified by. Build it by
+// hand for records other than ConsultaEnderecoResult, e.g. comparables.
+type UnidadeInfo struct {
+	TipoUso        string
+	TipoPadrao     string
+	Complemento    string
+	AreaConstruida float64
+}
+
+// RegrasUnidade are the patterns ClassificarUnidade uses for a city. Terms
+// are matched as whole words, ignoring case and accents.
+type RegrasUnidade struct {
+	// Vaga, Deposito, Comercial and Residencial are terms of the tipo de
+	// uso and padrão descriptions of each kind, checked in that order,
+	// so "não residencial" must be listed under Comercial.
+	Vaga        []string
+	Deposito    []string
+	Comercial   []string
+	Residencial []string
+	// ComplementoVaga, ComplementoDeposito and ComplementoComercial are
+	// terms of the complemento, e.g. "Box 12", used when the descriptions
+	// do not decide.
+	ComplementoVaga      []string
+	ComplementoDeposito  []string
+	ComplementoComercial []string
+	// DepositoAreaMax and VagaAreaMax are the largest built areas, in m²,
+	// of a condominium unit without use description taken as a storage
+	// unit or a garage box. Studios can be as small, so the area alone
+	// never overrides a residential use.
+	DepositoAreaMax float64
+	VagaAreaMax     float64
+}
+
+var regrasUnidadeBase = RegrasUnidade{
+	Vaga:                 []string{"garagem", "garagens", "estacionamento", "vaga", "vagas", "box de garagem"},
+	Deposito:             []string{"deposito", "hobby box", "escaninho"},
+	Comercial:            []string{"nao residencial", "comercial", "comercio", "loja", "lojas", "escritorio", "consultorio", "servico", "servicos", "sala comercial"},
+	Residencial:          []string{"residencial", "residencia", "apartamento", "habitacional"},
+	ComplementoVaga:      []string{"vaga", "vg", "box", "garagem", "gar"},
+	ComplementoDeposito:  []string{"deposito", "dep", "hobby box", "escaninho"},
+	ComplementoComercial: []string{"loja", "lj", "sala", "sl", "conjunto", "cj"},
+	DepositoAreaMax:      6,
+	VagaAreaMax:          20,
+}
+
+// regrasUnidadePadrao holds the city-specific rules shipped with the SDK.
+// São Paulo's cadastre adds the share of common areas to garage units, so
+// they are larger there.
+var regrasUnidadePadrao = map[Cidade]func(r *RegrasUnidade){
+	CidadeSaoPaulo: func(r *RegrasUnidade) {
+		r.Residencial = append(r.Residencial, "residencial vertical", "residencial horizontal")
+		r.VagaAreaMax = 30
+	},
+	CidadeRioDeJaneiro: func(r *RegrasUnidade) {
+		r.ComplementoVaga = append(r.ComplementoVaga, "vaga garagem", "vgg")
+	},
+	CidadeBeloHorizonte: func(r *RegrasUnidade) {
+		r.Deposito = append(r.Deposito, "area privativa de deposito")
+	},
+}
+
+// DefaultRegrasUnidade returns a copy of the rules shipped for cidade. Use
+// it as a starting point for custom rules.
+func DefaultRegrasUnidade(cidade Cidade) RegrasUnidade {
+	r := regrasUnidadeBase
+	for _, terms := range []*[]string{&r.Vaga, &r.Deposito, &r.Comercial, &r.Residencial,
+		&r.ComplementoVaga, &r.ComplementoDeposito, &r.ComplementoComercial} {
+		*terms = append([]string(nil), *terms...)
+	}
+	if adjust, ok := regrasUnidadePadrao[cidadeOrDefault(cidade)]; ok {
+		adjust(&r)
+	}
+	return r
+}
+
+// ClassificarUnidade tags a property of cidade as an apartment, garage
+// box, storage or commercial unit, so comparables can be restricted to
+// units of the same kind: a few garage boxes are enough to distort an
+// average R$/m². It uses the city's default rules; see
+// RegrasUnidade.Classificar.
+func ClassificarUnidade(cidade Cidade, r *ConsultaEnderecoResult) ClassificacaoUnidade {
+	return DefaultRegrasUnidade(cidade).Classificar(unidadeInfo(r))
+}
+
+func unidadeInfo(r *ConsultaEnderecoResult) UnidadeInfo {
+	return UnidadeInfo{
+		TipoUso:        r.TipoUso,
+		TipoPadrao:     r.TipoPadrao,
+		Complemento:    r.Complemento,
+		AreaConstruida: r.AreaConstruida,
+	}
+}
+
+// FiltrarUnidades returns the results of cidade classified as one of
+// tipos, in their original order.
+func FiltrarUnidades(cidade Cidade, results []ConsultaEnderecoResult, tipos ...TipoUnidade) []ConsultaEnderecoResult {
+	regras := DefaultRegrasUnidade(cidade)
+	var out []ConsultaEnderecoResult
+	for i := range results {
+		c := regras.Classificar(unidadeInfo(&results[i]))
+		for _, t := range tipos {
+			if c.Tipo == t {
+				out = append(out, results[i])
+				break
+			}
+		}
+	}
+	return out
+}
+
+// Classificar classifies u. The tipo de uso and padrão descriptions are
+// tried first, then the complemento, then, for condominium units (those
+// with a complemento) without a use description, the built area.
+func (r RegrasUnidade) Classificar(u UnidadeInfo) ClassificacaoUnidade {
+	uso := unidadeTerms(u.TipoUso + " " + u.TipoPadrao)
+	switch {
+	case hasTerm(uso, r.Vaga):
+		return ClassificacaoUnidade{UnidadeVagaGaragem, CriterioTipoUso}
+	case hasTerm(uso, r.Deposito):
+		return ClassificacaoUnidade{UnidadeDeposito, CriterioTipoUso}
+	case hasTerm(uso, r.Comercial):
+		return ClassificacaoUnidade{UnidadeComercial, CriterioTipoUso}
+	}
+
+	// Storage before garage: "hobby box" also contains "box".
+	complemento := unidadeTerms(u.Complemento)
+	switch {
+	case hasTerm(complemento, r.ComplementoDeposito):
+		return ClassificacaoUnidade{UnidadeDeposito, CriterioComplemento}
+	case hasTerm(complemento, r.ComplementoVaga):
+		return ClassificacaoUnidade{UnidadeVagaGaragem, CriterioComplemento}
+	case hasTerm(complemento, r.ComplementoComercial):
+		return ClassificacaoUnidade{UnidadeComercial, CriterioComplemento}
+	}
+
+	residencial := hasTerm(uso, r.Residencial)
+	condominio := strings.TrimSpace(u.Complemento) != ""
+	if !residencial && condominio && u.AreaConstruida > 0 {
+		switch {
+		case u.AreaConstruida <= r.DepositoAreaMax:
+			return ClassificacaoUnidade{UnidadeDeposito, CriterioArea}
+		case u.AreaConstruida <= r.VagaAreaMax:
+			return ClassificacaoUnidade{UnidadeVagaGaragem, CriterioArea}
+		}
+	}
+	if condominio {
+		criterio := CriterioComplemento
+		if residencial {
+			criterio = CriterioTipoUso
+		}
+		return ClassificacaoUnidade{UnidadeApartamento, criterio}
+	}
+	if residencial && hasTerm(uso, []string{"apartamento", "vertical"}) {
+		return ClassificacaoUnidade{UnidadeApartamento, CriterioTipoUso}
+	}
+	return ClassificacaoUnidade{Tipo: UnidadeOutra}
+}
+
+// unidadeTerms lowercases s, folds accents and turns anything but letters
+// and digits into single spaces, padded so terms match as whole words.
+func unidadeTerms(s string) string {
+	fields := strings.FieldsFunc(strings.ToLower(FoldAccents(s)), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	return " " + strings.Join(fields, " ") + " "
+}
+
+func hasTerm(text string, terms []string) bool {
+	for _, t := range terms {
+		if strings.Contains(text, unidadeTerms(t)) {
+			return true
+		}
+	}
+	return false
+}
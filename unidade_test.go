@@ -0,0 +1,67 @@
+package iptuapi
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClassificarUnidade(t *testing.T) {
+	tests := []struct {
+		cidade Cidade
+		r      ConsultaEnderecoResult
+		want   ClassificacaoUnidade
+	}{
+		{CidadeSaoPaulo, ConsultaEnderecoResult{TipoUso: "Apartamento em condomínio", Complemento: "Apto 51", AreaConstruida: 85},
+			ClassificacaoUnidade{UnidadeApartamento, CriterioTipoUso}},
+		{CidadeSaoPaulo, ConsultaEnderecoResult{TipoUso: "Garagem (exceto em prédio de uso exclusivamente residencial)", AreaConstruida: 25},
+			ClassificacaoUnidade{UnidadeVagaGaragem, CriterioTipoUso}},
+		{CidadeSaoPaulo, ConsultaEnderecoResult{TipoUso: "Escritório/consultório em condomínio", Complemento: "Cj 42"},
+			ClassificacaoUnidade{UnidadeComercial, CriterioTipoUso}},
+		{CidadeRioDeJaneiro, ConsultaEnderecoResult{TipoUso: "Não residencial", AreaConstruida: 40},
+			ClassificacaoUnidade{UnidadeComercial, CriterioTipoUso}},
+		{CidadeSaoPaulo, ConsultaEnderecoResult{Complemento: "Hobby Box 3", AreaConstruida: 4},
+			ClassificacaoUnidade{UnidadeDeposito, CriterioComplemento}},
+		{CidadeSaoPaulo, ConsultaEnderecoResult{Complemento: "Box 12"},
+			ClassificacaoUnidade{UnidadeVagaGaragem, CriterioComplemento}},
+		{CidadeRioDeJaneiro, ConsultaEnderecoResult{Complemento: "Loja A"},
+			ClassificacaoUnidade{UnidadeComercial, CriterioComplemento}},
+		// Without a use description, small condominium units are taken by
+		// area, with São Paulo's larger garage threshold.
+		{CidadeSaoPaulo, ConsultaEnderecoResult{Complemento: "Unid. 7", AreaConstruida: 26},
+			ClassificacaoUnidade{UnidadeVagaGaragem, CriterioArea}},
+		{CidadeRioDeJaneiro, ConsultaEnderecoResult{Complemento: "Unid. 7", AreaConstruida: 26},
+			ClassificacaoUnidade{UnidadeApartamento, CriterioComplemento}},
+		{CidadeSaoPaulo, ConsultaEnderecoResult{Complemento: "Unid. 8", AreaConstruida: 5},
+			ClassificacaoUnidade{UnidadeDeposito, CriterioArea}},
+		// A residential use is never overridden by the area: a studio.
+		{CidadeSaoPaulo, ConsultaEnderecoResult{TipoUso: "Residencial", Complemento: "Apto 3", AreaConstruida: 14},
+			ClassificacaoUnidade{UnidadeApartamento, CriterioTipoUso}},
+		{CidadeSaoPaulo, ConsultaEnderecoResult{TipoUso: "Residência", AreaConstruida: 150},
+			ClassificacaoUnidade{Tipo: UnidadeOutra}},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, ClassificarUnidade(tt.cidade, &tt.r), "%+v", tt.r)
+	}
+}
+
+func TestDefaultRegrasUnidadeIsACopy(t *testing.T) {
+	r := DefaultRegrasUnidade(CidadeSaoPaulo)
+	r.Vaga[0] = "x"
+	r.Residencial = append(r.Residencial, "kitnet")
+	assert.Equal(t, "garagem", DefaultRegrasUnidade(CidadeSaoPaulo).Vaga[0])
+	assert.NotContains(t, DefaultRegrasUnidade(CidadeSaoPaulo).Residencial, "kitnet")
+	assert.Equal(t, 20.0, DefaultRegrasUnidade(CidadeCuritiba).VagaAreaMax)
+}
+
+func TestFiltrarUnidades(t *testing.T) {
+	results := []ConsultaEnderecoResult{
+		{SQL: "1", Complemento: "Apto 11", AreaConstruida: 70},
+		{SQL: "2", Complemento: "Vaga 3", AreaConstruida: 12},
+		{SQL: "3", Complemento: "Depósito 1", AreaConstruida: 3},
+		{SQL: "4", Complemento: "Apto 12", AreaConstruida: 72},
+	}
+	got := FiltrarUnidades(CidadeSaoPaulo, results, UnidadeApartamento)
+	assert.Equal(t, []string{"1", "4"}, []string{got[0].SQL, got[1].SQL})
+	assert.Len(t, FiltrarUnidades(CidadeSaoPaulo, results, UnidadeVagaGaragem, UnidadeDeposito), 2)
+}
@@ -0,0 +1,189 @@
+package iptuapi
+
+import (
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Codes of the FieldErrors of parameters rejected before sending a call.
+const (
+	CodigoObrigatorio     = "required"
+	CodigoFormato         = "invalid_format"
+	CodigoForaDoIntervalo = "out_of_range"
+	CodigoNaoSuportado    = "unsupported"
+)
+
+// WithSkipValidation sends the call without checking its parameters
+// locally, e.g. for a city added to the API after this SDK was released.
+// By default calls with invalid parameters fail with a *ValidationError
+// without spending a request.
+func WithSkipValidation() RequestOption {
+	return func(o *requestOptions) {
+		o.skipValidation = true
+	}
+}
+
+// validation collects the field errors of a set of parameters.
+type validation struct {
+	prefix string
+	errors []FieldError
+}
+
+func (v *validation) add(field, code, message string) {
+	v.errors = append(v.errors, FieldError{Field: v.prefix + field, Message: message, Code: code})
+}
+
+func (v *validation) required(field, value string) {
+	if strings.TrimSpace(value) == "" {
+		v.add(field, CodigoObrigatorio, "campo obrigatório")
+	}
+}
+
+func (v *validation) cidade(field string, cidade Cidade) {
+	if cidade != "" && !knownCidade(cidade) {
+		v.add(field, CodigoNaoSuportado, "cidade não suportada: "+string(cidade))
+	}
+}
+
+func (v *validation) positive(field string, value float64) {
+	if !(value > 0) || math.IsInf(value, 0) {
+		v.add(field, CodigoForaDoIntervalo, "deve ser maior que zero")
+	}
+}
+
+// err returns the errors as a *ValidationError, or nil if there are none.
+func (v *validation) err() error {
+	if len(v.errors) == 0 {
+		return nil
+	}
+	parts := make([]string, len(v.errors))
+	for i, fe := range v.errors {
+		parts[i] = fe.Field + ": " + fe.Message
+	}
+	return &ValidationError{APIError: &APIError{Message: strings.Join(parts, "; ")}, Errors: v.errors}
+}
+
+func knownCidade(cidade Cidade) bool {
+	for _, c := range capabilityCidades {
+		if c == cidade {
+			return true
+		}
+	}
+	return false
+}
+
+// Validate checks the parameters as ConsultaEndereco does before sending
+// them. It returns a *ValidationError listing every invalid field.
+func (p *ConsultaEnderecoParams) Validate() error {
+	var v validation
+	v.required("logradouro", SanitizeText(p.Logradouro))
+	v.cidade("cidade", p.Cidade)
+	return v.err()
+}
+
+// Validate checks the parameters as ValuationEstimate does before sending
+// them. It returns a *ValidationError listing every invalid field.
+func (p *ValuationParams) Validate() error {
+	var v validation
+	p.validate(&v)
+	return v.err()
+}
+
+func (p *ValuationParams) validate(v *validation) {
+	v.positive("area_construida", p.AreaConstruida)
+	if p.AreaTerreno < 0 || math.IsNaN(p.AreaTerreno) || math.IsInf(p.AreaTerreno, 0) {
+		v.add("area_terreno", CodigoForaDoIntervalo, "não pode ser negativa")
+	}
+	v.required("bairro", p.Bairro)
+	if p.AnoConstrucao != 0 && (p.AnoConstrucao < 1500 || p.AnoConstrucao > time.Now().Year()+5) {
+		v.add("ano_construcao", CodigoForaDoIntervalo, "ano de construção inválido")
+	}
+	v.cidade("cidade", p.Cidade)
+}
+
+// Validate checks the parameters as IPTUToolsSimulador does before sending
+// them. It returns a *ValidationError listing every invalid field.
+func (p *SimuladorParams) Validate() error {
+	var v validation
+	v.positive("valor_iptu", p.ValorIPTU)
+	if p.ValorVenal < 0 {
+		v.add("valor_venal", CodigoForaDoIntervalo, "não pode ser negativo")
+	}
+	v.cidade("cidade", Cidade(p.Cidade))
+	return v.err()
+}
+
+// Validate checks the parameters as ITBITransacoes does before sending
+// them. It returns a *ValidationError listing every invalid field.
+func (p *ITBIParams) Validate() error {
+	var v validation
+	if !p.DataInicio.IsZero() && !p.DataFim.IsZero() && p.DataFim.Before(p.DataInicio) {
+		v.add("data_fim", CodigoForaDoIntervalo, "data final anterior à inicial")
+	}
+	v.cidade("cidade", p.Cidade)
+	return v.err()
+}
+
+func validateSQL(sql string, cidade Cidade) error {
+	var v validation
+	v.required("sql", sql)
+	v.cidade("cidade", cidade)
+	return v.err()
+}
+
+func validateCEP(cep string, cidade Cidade) error {
+	var v validation
+	digits := cepDigits(cep)
+	if digits == "" {
+		v.add("cep", CodigoObrigatorio, "campo obrigatório")
+	} else if len(digits) != 8 || strings.Trim(digits, "0123456789") != "" {
+		v.add("cep", CodigoFormato, "o CEP deve ter 8 dígitos")
+	}
+	v.cidade("cidade", cidade)
+	return v.err()
+}
+
+func validateCoordenadas(latitude, longitude float64) error {
+	var v validation
+	if !(latitude >= -90 && latitude <= 90) {
+		v.add("latitude", CodigoForaDoIntervalo, "latitude fora do intervalo -90 a 90")
+	}
+	if !(longitude >= -180 && longitude <= 180) {
+		v.add("longitude", CodigoForaDoIntervalo, "longitude fora do intervalo -180 a 180")
+	}
+	return v.err()
+}
+
+func validateBatch(imoveis []ValuationParams) error {
+	var v validation
+	if len(imoveis) == 0 {
+		v.add("imoveis", CodigoObrigatorio, "lista vazia")
+	}
+	for i := range imoveis {
+		v.prefix = "imoveis[" + strconv.Itoa(i) + "]."
+		imoveis[i].validate(&v)
+	}
+	return v.err()
+}
+
+// check runs validate unless the call skips validation. With a fallback
+// provider, unsupported cities are left for it to serve.
+func (c *Client) check(opts []RequestOption, validate func() error) error {
+	if newRequestOptions(opts).skipValidation {
+		return nil
+	}
+	err := validate()
+	if err == nil || c.fallback == nil {
+		return err
+	}
+	ve := err.(*ValidationError)
+	var v validation
+	for _, fe := range ve.Errors {
+		if fe.Code != CodigoNaoSuportado {
+			v.errors = append(v.errors, fe)
+		}
+	}
+	return v.err()
+}
@@ -0,0 +1,109 @@
+package iptuapi
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func fields(t *testing.T, err error) []string {
+	t.Helper()
+	var ve *ValidationError
+	require.True(t, errors.As(err, &ve), "%v", err)
+	assert.Zero(t, ve.StatusCode)
+	var names []string
+	for _, fe := range ve.Errors {
+		names = append(names, fe.Field+" "+fe.Code)
+	}
+	return names
+}
+
+func TestValidateParams(t *testing.T) {
+	assert.NoError(t, (&ConsultaEnderecoParams{Logradouro: "Avenida Paulista", Cidade: CidadeSaoPaulo}).Validate())
+	assert.Equal(t, []string{"logradouro required", "cidade unsupported"},
+		fields(t, (&ConsultaEnderecoParams{Logradouro: " ", Cidade: "gotham"}).Validate()))
+
+	assert.NoError(t, (&ValuationParams{AreaConstruida: 80, Bairro: "Pinheiros"}).Validate())
+	assert.Equal(t, []string{"area_construida out_of_range", "area_terreno out_of_range", "bairro required", "ano_construcao out_of_range"},
+		fields(t, (&ValuationParams{AreaConstruida: -1, AreaTerreno: -5, AnoConstrucao: 3000}).Validate()))
+
+	assert.Equal(t, []string{"valor_iptu out_of_range"}, fields(t, (&SimuladorParams{}).Validate()))
+
+	jan, feb := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)
+	assert.NoError(t, (&ITBIParams{DataInicio: jan, DataFim: feb}).Validate())
+	assert.Equal(t, []string{"data_fim out_of_range"}, fields(t, (&ITBIParams{DataInicio: feb, DataFim: jan}).Validate()))
+
+	assert.NoError(t, validateCEP("01310-100", ""))
+	assert.Equal(t, []string{"cep invalid_format"}, fields(t, validateCEP("0131", "")))
+	assert.Equal(t, []string{"latitude out_of_range"}, fields(t, validateCoordenadas(-91, 0)))
+
+	err := validateBatch([]ValuationParams{{AreaConstruida: 80, Bairro: "Moema"}, {AreaConstruida: 80}})
+	assert.Equal(t, []string{"imoveis[1].bairro required"}, fields(t, err))
+	assert.EqualError(t, err, "iptuapi: imoveis[1].bairro: campo obrigatório")
+}
+
+func TestValidationSpendsNoRequest(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		w.Write([]byte(`{"detail":"cidade invalida"}`))
+	}))
+	defer server.Close()
+
+	client := NewClient("test_key", WithBaseURL(server.URL), WithRetry(&RetryConfig{MaxRetries: 0}))
+	ctx := context.Background()
+
+	_, err := client.ConsultaSQL(ctx, "000.000.0000-0", "gotham")
+	assert.True(t, IsValidation(err))
+	_, err = client.ConsultaCEP(ctx, "abc", "")
+	assert.True(t, IsValidation(err))
+	_, err = client.ValuationEstimate(ctx, &ValuationParams{Bairro: "Pinheiros"})
+	assert.True(t, IsValidation(err))
+	err = client.ITBITransacoesStream(ctx, &ITBIParams{Cidade: "gotham"}, func(ITBITransacao) error { return nil })
+	assert.True(t, IsValidation(err))
+	assert.Zero(t, calls.Load())
+
+	_, err = client.ConsultaSQL(ctx, "000.000.0000-0", "gotham", WithSkipValidation())
+	var ve *ValidationError
+	require.True(t, errors.As(err, &ve))
+	assert.Equal(t, http.StatusUnprocessableEntity, ve.StatusCode, "sent to the API")
+	assert.Equal(t, int32(1), calls.Load())
+}
+
+func TestValidationUsesCidadeOverride(t *testing.T) {
+	var cidades []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		cidades = append(cidades, r.URL.Query().Get("cidade"))
+		w.Write([]byte(`[]`))
+	}))
+	defer server.Close()
+
+	client := NewClient("test_key", WithBaseURL(server.URL), WithRetry(&RetryConfig{MaxRetries: 0}))
+	ctx := context.Background()
+
+	_, err := client.ConsultaCEP(ctx, "01310100", CidadeSaoPaulo, WithCidade("gotham"))
+	assert.True(t, IsValidation(err), "validated for the city it would be sent for")
+	err = client.ConsultaCEPStream(ctx, "01310100", CidadeSaoPaulo, func(ConsultaEnderecoResult) error { return nil }, WithCidade("gotham"))
+	assert.True(t, IsValidation(err))
+	_, err = client.ITBITransacoes(ctx, &ITBIParams{Cidade: CidadeSaoPaulo}, WithCidade("gotham"))
+	assert.True(t, IsValidation(err))
+	assert.Empty(t, cidades)
+
+	_, err = client.ConsultaCEP(ctx, "01310100", "gotham", WithCidade(CidadeBeloHorizonte))
+	require.NoError(t, err)
+	p := &ITBIParams{Cidade: "gotham"}
+	_, err = client.ITBITransacoes(ctx, p, WithCidade(CidadeBeloHorizonte))
+	require.NoError(t, err)
+	assert.Equal(t, Cidade("gotham"), p.Cidade, "the caller's params are not modified")
+	_, err = client.DadosIPTUHistorico(ctx, "000.000.0000-0", CidadeSaoPaulo, WithCidade(CidadeRecife))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"bh", "bh", "recife"}, cidades)
+}
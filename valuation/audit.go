@@ -0,0 +1,220 @@
+package valuation
+
+import (
+	"archive/zip"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"time"
+
+	iptuapi "github.com/raphaeltorquat0/iptuapi-go"
+)
+
+// AuditFormatVersion is the version of the audit bundle layout.
+const AuditFormatVersion = 1
+
+// ErrTampered is returned by Bundle.Verify when the signature or a file hash
+// does not match.
+var ErrTampered = errors.New("valuation: audit bundle signature mismatch")
+
+// RawResponse is an API response body captured verbatim.
+type RawResponse struct {
+	Name string
+	Body []byte
+}
+
+// BundleFile is one file in an audit bundle.
+type BundleFile struct {
+	Name string `json:"name"`
+	Data []byte `json:"data"`
+}
+
+// FileHash is the SHA-256 of a bundle file, listed in the manifest.
+type FileHash struct {
+	Name   string `json:"name"`
+	SHA256 string `json:"sha256"`
+}
+
+// Manifest describes an audit bundle. Signature is the hex HMAC-SHA256 of
+// the manifest's JSON encoding with Signature empty.
+type Manifest struct {
+	Versao     int        `json:"versao"`
+	CriadoEm   time.Time  `json:"criado_em"`
+	SDKVersion string     `json:"sdk_version"`
+	KeyID      string     `json:"key_id,omitempty"`
+	Arquivos   []FileHash `json:"arquivos"`
+	Signature  string     `json:"signature"`
+}
+
+// Bundle is a tamper-evident record of everything that went into a
+// valuation: inputs, result and raw API responses.
+type Bundle struct {
+	Manifest Manifest     `json:"manifest"`
+	Files    []BundleFile `json:"files"`
+}
+
+// AuditOptions configures AuditBundle.
+type AuditOptions struct {
+	// KeyID identifies the signing key, for rotation.
+	KeyID string
+	// Now overrides the creation time (default time.Now).
+	Now time.Time
+}
+
+// AuditBundle builds a bundle holding result and inputs as JSON plus every
+// raw response, signed with key. Store it with Bundle.WriteZIP or as JSON,
+// and check it later with Verify.
+func AuditBundle(result, inputs interface{}, rawResponses []RawResponse, key []byte, opts *AuditOptions) (*Bundle, error) {
+	if len(key) == 0 {
+		return nil, errors.New("valuation: audit bundle requires a signing key")
+	}
+	o := AuditOptions{}
+	if opts != nil {
+		o = *opts
+	}
+	if o.Now.IsZero() {
+		o.Now = time.Now()
+	}
+
+	b := &Bundle{Manifest: Manifest{
+		Versao:     AuditFormatVersion,
+		CriadoEm:   o.Now.UTC(),
+		SDKVersion: iptuapi.Version,
+		KeyID:      o.KeyID,
+	}}
+	for _, part := range []struct {
+		name string
+		v    interface{}
+	}{{"inputs.json", inputs}, {"result.json", result}} {
+		data, err := json.MarshalIndent(part.v, "", "  ")
+		if err != nil {
+			return nil, fmt.Errorf("valuation: encoding %s: %w", part.name, err)
+		}
+		b.Files = append(b.Files, BundleFile{Name: part.name, Data: data})
+	}
+	for i, r := range rawResponses {
+		name := path.Join("raw", fmt.Sprintf("%02d-%s", i+1, path.Base(r.Name)))
+		b.Files = append(b.Files, BundleFile{Name: name, Data: r.Body})
+	}
+
+	for _, f := range b.Files {
+		b.Manifest.Arquivos = append(b.Manifest.Arquivos, FileHash{Name: f.Name, SHA256: sha256Hex(f.Data)})
+	}
+	sig, err := b.Manifest.sign(key)
+	if err != nil {
+		return nil, err
+	}
+	b.Manifest.Signature = sig
+	return b, nil
+}
+
+// Verify checks the manifest signature and that every file matches its
+// listed hash, returning ErrTampered otherwise.
+func (b *Bundle) Verify(key []byte) error {
+	want, err := b.Manifest.sign(key)
+	if err != nil {
+		return err
+	}
+	if !hmac.Equal([]byte(want), []byte(b.Manifest.Signature)) {
+		return ErrTampered
+	}
+
+	if len(b.Files) != len(b.Manifest.Arquivos) {
+		return fmt.Errorf("%w: %d files, manifest lists %d", ErrTampered, len(b.Files), len(b.Manifest.Arquivos))
+	}
+	hashes := make(map[string]string, len(b.Manifest.Arquivos))
+	for _, fh := range b.Manifest.Arquivos {
+		hashes[fh.Name] = fh.SHA256
+	}
+	for _, f := range b.Files {
+		if hashes[f.Name] != sha256Hex(f.Data) {
+			return fmt.Errorf("%w: %s", ErrTampered, f.Name)
+		}
+	}
+	return nil
+}
+
+// File returns the named file's contents.
+func (b *Bundle) File(name string) ([]byte, bool) {
+	for _, f := range b.Files {
+		if f.Name == name {
+			return f.Data, true
+		}
+	}
+	return nil, false
+}
+
+// WriteZIP writes the bundle as a ZIP archive with manifest.json alongside
+// the files.
+func (b *Bundle) WriteZIP(w io.Writer) error {
+	zw := zip.NewWriter(w)
+	manifest, err := json.MarshalIndent(b.Manifest, "", "  ")
+	if err != nil {
+		return err
+	}
+	files := append([]BundleFile{{Name: "manifest.json", Data: manifest}}, b.Files...)
+	for _, f := range files {
+		fw, err := zw.CreateHeader(&zip.FileHeader{Name: f.Name, Method: zip.Deflate, Modified: b.Manifest.CriadoEm})
+		if err != nil {
+			return err
+		}
+		if _, err := fw.Write(f.Data); err != nil {
+			return err
+		}
+	}
+	return zw.Close()
+}
+
+// ReadZIP reads a bundle written by WriteZIP. Call Verify on the result.
+func ReadZIP(r io.ReaderAt, size int64) (*Bundle, error) {
+	zr, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil, err
+	}
+	b := &Bundle{}
+	foundManifest := false
+	for _, zf := range zr.File {
+		rc, err := zf.Open()
+		if err != nil {
+			return nil, err
+		}
+		data, err := io.ReadAll(rc)
+		rc.Close()
+		if err != nil {
+			return nil, err
+		}
+		if zf.Name == "manifest.json" {
+			if err := json.Unmarshal(data, &b.Manifest); err != nil {
+				return nil, fmt.Errorf("valuation: decoding manifest: %w", err)
+			}
+			foundManifest = true
+			continue
+		}
+		b.Files = append(b.Files, BundleFile{Name: zf.Name, Data: data})
+	}
+	if !foundManifest {
+		return nil, errors.New("valuation: audit bundle has no manifest.json")
+	}
+	return b, nil
+}
+
+func (m Manifest) sign(key []byte) (string, error) {
+	m.Signature = ""
+	data, err := json.Marshal(m)
+	if err != nil {
+		return "", err
+	}
+	mac := hmac.New(sha256.New, key)
+	mac.Write(data)
+	return hex.EncodeToString(mac.Sum(nil)), nil
+}
+
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
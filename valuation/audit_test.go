@@ -0,0 +1,38 @@
+package valuation
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	iptuapi "github.com/raphaeltorquat0/iptuapi-go"
+)
+
+func TestAuditBundle(t *testing.T) {
+	key := []byte("secret")
+	inputs := iptuapi.ValuationParams{AreaConstruida: 120, Bairro: "Moema"}
+	result := iptuapi.ValuationResult{ValorEstimado: 1500000}
+	raw := []RawResponse{{Name: "valuation-estimate.json", Body: []byte(`{"valor_estimado":1500000}`)}}
+
+	b, err := AuditBundle(result, inputs, raw, key, &AuditOptions{KeyID: "k1", Now: time.Date(2026, 5, 1, 12, 0, 0, 0, time.UTC)})
+	require.NoError(t, err)
+	require.NoError(t, b.Verify(key))
+	assert.Len(t, b.Manifest.Arquivos, 3)
+	assert.ErrorIs(t, b.Verify([]byte("other")), ErrTampered)
+
+	var buf bytes.Buffer
+	require.NoError(t, b.WriteZIP(&buf))
+	read, err := ReadZIP(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	require.NoError(t, err)
+	require.NoError(t, read.Verify(key))
+	body, ok := read.File("raw/01-valuation-estimate.json")
+	require.True(t, ok)
+	assert.Equal(t, raw[0].Body, body)
+
+	// Changing a file without re-signing is detected.
+	read.Files[1].Data = []byte(`{"valor_estimado":9}`)
+	assert.ErrorIs(t, read.Verify(key), ErrTampered)
+}
@@ -0,0 +1,166 @@
+package valuation
+
+import (
+	"errors"
+	"math"
+	"time"
+
+	iptuapi "github.com/raphaeltorquat0/iptuapi-go"
+)
+
+// Estimate is one value estimate fed to Blend.
+type Estimate struct {
+	Valor float64 `json:"valor"`
+	// Confianca is the estimate's confidence in [0, 1].
+	Confianca float64 `json:"confianca"`
+	// Data is when the estimate's underlying data was observed.
+	Data time.Time `json:"data"`
+	// N is the number of samples behind the estimate, when applicable.
+	N int `json:"n,omitempty"`
+}
+
+// FromAVM converts an API valuation into an Estimate. DataAvaliacao is
+// parsed as a date or RFC 3339 timestamp when present.
+func FromAVM(r *iptuapi.ValuationResult) Estimate {
+	e := Estimate{Valor: r.ValorEstimado, Confianca: r.Confianca, N: r.ComparaveisUtilizados}
+	for _, layout := range []string{time.RFC3339, "2006-01-02"} {
+		if t, err := time.Parse(layout, r.DataAvaliacao); err == nil {
+			e.Data = t
+			break
+		}
+	}
+	return e
+}
+
+// FromITBI estimates the value of a unit with builtArea m2 from ITBI
+// comparables: the median value per m2 of the non-outlier comparables times
+// the area. Confidence grows with the sample size as n/(n+5), and Data is
+// the most recent transaction date.
+func FromITBI(comps []Comparable, builtArea float64) Estimate {
+	var unit []float64
+	var latest time.Time
+	for _, c := range comps {
+		if c.Outlier || c.AreaConstruida <= 0 || c.ITBI == nil {
+			continue
+		}
+		unit = append(unit, c.Valor/c.AreaConstruida)
+		if t, err := time.Parse("2006-01-02", c.ITBI.DataTransacao); err == nil && t.After(latest) {
+			latest = t
+		}
+	}
+	if len(unit) == 0 {
+		return Estimate{}
+	}
+	n := float64(len(unit))
+	return Estimate{
+		Valor:     Median(unit) * builtArea,
+		Confianca: n / (n + 5),
+		Data:      latest,
+		N:         len(unit),
+	}
+}
+
+// BlendMethod selects how Blend weighs the AVM and ITBI estimates.
+type BlendMethod string
+
+const (
+	// BlendFixed uses Weights.AVM and Weights.ITBI as given.
+	BlendFixed BlendMethod = "fixed"
+	// BlendConfidence multiplies the fixed weights by each estimate's
+	// Confianca.
+	BlendConfidence BlendMethod = "confidence"
+	// BlendRecency multiplies the fixed weights by 0.5^(age/HalfLife), so
+	// older data counts for less.
+	BlendRecency BlendMethod = "recency"
+)
+
+// Weights is the blending policy.
+type Weights struct {
+	Method BlendMethod
+	// AVM and ITBI are the base weights (both zero means 1 and 1).
+	AVM  float64
+	ITBI float64
+	// HalfLife is the age at which recency halves a weight (default one year).
+	HalfLife time.Duration
+	// Now is the reference time for recency (default time.Now).
+	Now time.Time
+}
+
+// BlendResult is the blended value and the normalized weights applied.
+type BlendResult struct {
+	Valor    float64     `json:"valor"`
+	PesoAVM  float64     `json:"peso_avm"`
+	PesoITBI float64     `json:"peso_itbi"`
+	Method   BlendMethod `json:"method"`
+}
+
+// ErrNoEstimate is returned by Blend when neither estimate has a value.
+var ErrNoEstimate = errors.New("valuation: no estimate to blend")
+
+// Blend combines an AVM estimate and an ITBI estimate as
+// (wA*avm + wI*itbi) / (wA + wI), with weights derived from w.Method. An
+// estimate without a value gets zero weight.
+func Blend(avm, itbi Estimate, w Weights) (BlendResult, error) {
+	if w.Method == "" {
+		w.Method = BlendFixed
+	}
+	if w.AVM == 0 && w.ITBI == 0 {
+		w.AVM, w.ITBI = 1, 1
+	}
+	if w.HalfLife <= 0 {
+		w.HalfLife = 365 * 24 * time.Hour
+	}
+	if w.Now.IsZero() {
+		w.Now = time.Now()
+	}
+
+	wa, wi := w.AVM, w.ITBI
+	switch w.Method {
+	case BlendConfidence:
+		wa *= avm.Confianca
+		wi *= itbi.Confianca
+	case BlendRecency:
+		wa *= recencyFactor(avm.Data, w.Now, w.HalfLife)
+		wi *= recencyFactor(itbi.Data, w.Now, w.HalfLife)
+	case BlendFixed:
+	default:
+		return BlendResult{}, errors.New("valuation: unknown blend method " + string(w.Method))
+	}
+	if avm.Valor <= 0 {
+		wa = 0
+	}
+	if itbi.Valor <= 0 {
+		wi = 0
+	}
+
+	total := wa + wi
+	if total <= 0 {
+		// Weights cancelled out (e.g. zero confidence on both): fall back to
+		// whichever estimates exist, equally weighted.
+		if avm.Valor > 0 {
+			wa = 1
+		}
+		if itbi.Valor > 0 {
+			wi = 1
+		}
+		total = wa + wi
+		if total == 0 {
+			return BlendResult{}, ErrNoEstimate
+		}
+	}
+
+	return BlendResult{
+		Valor:    (wa*avm.Valor + wi*itbi.Valor) / total,
+		PesoAVM:  wa / total,
+		PesoITBI: wi / total,
+		Method:   w.Method,
+	}, nil
+}
+
+// recencyFactor is 1 for undated or future data.
+func recencyFactor(t, now time.Time, halfLife time.Duration) float64 {
+	if t.IsZero() || !t.Before(now) {
+		return 1
+	}
+	return math.Pow(0.5, float64(now.Sub(t))/float64(halfLife))
+}
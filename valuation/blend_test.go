@@ -0,0 +1,51 @@
+package valuation
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	iptuapi "github.com/raphaeltorquat0/iptuapi-go"
+)
+
+func TestBlend(t *testing.T) {
+	now := time.Date(2026, 6, 1, 0, 0, 0, 0, time.UTC)
+	avm := FromAVM(&iptuapi.ValuationResult{ValorEstimado: 1000000, Confianca: 0.8, DataAvaliacao: "2026-06-01"})
+	itbi := Estimate{Valor: 800000, Confianca: 0.2, Data: now.AddDate(-1, 0, 0)}
+
+	fixed, err := Blend(avm, itbi, Weights{AVM: 3, ITBI: 1})
+	require.NoError(t, err)
+	assert.InDelta(t, 950000, fixed.Valor, 0.01)
+	assert.InDelta(t, 0.75, fixed.PesoAVM, 1e-9)
+
+	conf, err := Blend(avm, itbi, Weights{Method: BlendConfidence})
+	require.NoError(t, err)
+	assert.InDelta(t, 960000, conf.Valor, 0.01)
+
+	rec, err := Blend(avm, itbi, Weights{Method: BlendRecency, Now: now, HalfLife: 365 * 24 * time.Hour})
+	require.NoError(t, err)
+	assert.InDelta(t, 2.0/3, rec.PesoAVM, 1e-9)
+
+	only, err := Blend(Estimate{}, itbi, Weights{Method: BlendConfidence})
+	require.NoError(t, err)
+	assert.Equal(t, 800000.0, only.Valor)
+
+	_, err = Blend(Estimate{}, Estimate{}, Weights{})
+	assert.ErrorIs(t, err, ErrNoEstimate)
+}
+
+func TestFromITBI(t *testing.T) {
+	comps := Dedup([]iptuapi.ITBITransacao{
+		{SQL: "1", ValorTransacao: 1000000, AreaConstruida: 100, DataTransacao: "2025-03-10"},
+		{SQL: "2", ValorTransacao: 1200000, AreaConstruida: 100, DataTransacao: "2025-08-01"},
+		{SQL: "3", ValorTransacao: 1100000, AreaConstruida: 100, DataTransacao: "2025-05-20"},
+	}, nil, nil)
+
+	e := FromITBI(comps, 80)
+	assert.InDelta(t, 880000, e.Valor, 0.01)
+	assert.Equal(t, 3, e.N)
+	assert.InDelta(t, 0.375, e.Confianca, 1e-9)
+	assert.Equal(t, time.Date(2025, 8, 1, 0, 0, 0, 0, time.UTC), e.Data)
+}
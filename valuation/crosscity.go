@@ -0,0 +1,81 @@
+package valuation
+
+import (
+	"errors"
+	"fmt"
+	"math"
+
+	iptuapi "github.com/raphaeltorquat0/iptuapi-go"
+)
+
+// ErrNoDeflator is returned when a city has no value for a deflator, or a
+// Normalizer has no deflators.
+var ErrNoDeflator = errors.New("valuation: missing deflator")
+
+// Deflator is a per-city index used to compare values across cities, such
+// as household income or the regional CUB (custo unitário básico de
+// construção, R$/m²). Only the ratio between cities matters, so any unit
+// can be used.
+type Deflator struct {
+	Name   string                     `json:"name"`
+	Values map[iptuapi.Cidade]float64 `json:"values"`
+	// Weight is the deflator's share when several are combined (default 1).
+	Weight float64 `json:"weight,omitempty"`
+}
+
+// Normalizer expresses valor/m² of any city in terms of a reference city,
+// so multi-city dashboards compare like for like. With several deflators,
+// the city factor is their weighted geometric mean.
+type Normalizer struct {
+	Reference iptuapi.Cidade `json:"reference"`
+	Deflators []Deflator     `json:"deflators"`
+}
+
+// Factor returns the multiplier that converts values of cidade into the
+// reference city's terms. It is 1 for the reference city.
+func (n *Normalizer) Factor(cidade iptuapi.Cidade) (float64, error) {
+	if len(n.Deflators) == 0 {
+		return 0, ErrNoDeflator
+	}
+	var logSum, weights float64
+	for _, d := range n.Deflators {
+		w := d.Weight
+		if w <= 0 {
+			w = 1
+		}
+		ref, city := d.Values[n.Reference], d.Values[cidade]
+		if ref <= 0 {
+			return 0, fmt.Errorf("%w: %s for reference %s", ErrNoDeflator, d.Name, n.Reference)
+		}
+		if city <= 0 {
+			return 0, fmt.Errorf("%w: %s for %s", ErrNoDeflator, d.Name, cidade)
+		}
+		logSum += w * math.Log(ref/city)
+		weights += w
+	}
+	return math.Exp(logSum / weights), nil
+}
+
+// Normalize converts a valor/m² observed in cidade into the reference
+// city's terms.
+func (n *Normalizer) Normalize(cidade iptuapi.Cidade, valorM2 float64) (float64, error) {
+	f, err := n.Factor(cidade)
+	if err != nil {
+		return 0, err
+	}
+	return valorM2 * f, nil
+}
+
+// NormalizeComparables returns the unit values (see UnitValue) of items
+// from cidade in the reference city's terms.
+func (n *Normalizer) NormalizeComparables(cidade iptuapi.Cidade, items []Comparable) ([]float64, error) {
+	f, err := n.Factor(cidade)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]float64, len(items))
+	for i, c := range items {
+		out[i] = UnitValue(c) * f
+	}
+	return out, nil
+}
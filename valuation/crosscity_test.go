@@ -0,0 +1,44 @@
+package valuation
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	iptuapi "github.com/raphaeltorquat0/iptuapi-go"
+)
+
+func TestNormalizer(t *testing.T) {
+	cub := Deflator{Name: "cub", Values: map[iptuapi.Cidade]float64{
+		iptuapi.CidadeSaoPaulo: 2000, iptuapi.CidadeRecife: 1600,
+	}}
+	renda := Deflator{Name: "renda", Values: map[iptuapi.Cidade]float64{
+		iptuapi.CidadeSaoPaulo: 4000, iptuapi.CidadeRecife: 1000,
+	}}
+
+	n := &Normalizer{Reference: iptuapi.CidadeSaoPaulo, Deflators: []Deflator{cub}}
+	v, err := n.Normalize(iptuapi.CidadeRecife, 8000)
+	require.NoError(t, err)
+	assert.InDelta(t, 10000, v, 1e-9)
+
+	f, err := n.Factor(iptuapi.CidadeSaoPaulo)
+	require.NoError(t, err)
+	assert.Equal(t, 1.0, f)
+
+	// Equal weights: sqrt(1.25 * 4).
+	n.Deflators = []Deflator{cub, renda}
+	f, err = n.Factor(iptuapi.CidadeRecife)
+	require.NoError(t, err)
+	assert.InDelta(t, 2.2360679, f, 1e-6)
+
+	values, err := n.NormalizeComparables(iptuapi.CidadeRecife, []Comparable{{Valor: 500000, AreaConstruida: 100}})
+	require.NoError(t, err)
+	assert.InDelta(t, 5000*2.2360679, values[0], 1e-3)
+
+	_, err = n.Factor(iptuapi.CidadeCuritiba)
+	assert.True(t, errors.Is(err, ErrNoDeflator))
+	_, err = (&Normalizer{}).Factor(iptuapi.CidadeRecife)
+	assert.True(t, errors.Is(err, ErrNoDeflator))
+}
@@ -0,0 +1,207 @@
+// Package valuation provides helpers for building valuation reports from
+// API data: de-duplicating comparables across sources and related analytics.
+package valuation
+
+import (
+	"math"
+	"sort"
+	"strings"
+
+	iptuapi "github.com/raphaeltorquat0/iptuapi-go"
+)
+
+// Fonte identifies where a comparable came from.
+type Fonte string
+
+const (
+	FonteITBI     Fonte = "itbi"
+	FonteCadastro Fonte = "cadastro"
+)
+
+// LinkMethod records how an ITBI record was linked to a cadastral record.
+type LinkMethod string
+
+const (
+	// LinkSQL means both records carry the same SQL.
+	LinkSQL LinkMethod = "sql"
+	// LinkHeuristic means the records were matched by address and area.
+	LinkHeuristic LinkMethod = "heuristica"
+)
+
+// Link pairs an ITBI record with a cadastral comparable referencing the same
+// unit. ITBI and Cadastro are indices into the slices given to LinkITBI.
+type Link struct {
+	ITBI     int        `json:"itbi"`
+	Cadastro int        `json:"cadastro"`
+	Method   LinkMethod `json:"method"`
+	Score    float64    `json:"score"`
+}
+
+// LinkOptions configures LinkITBI.
+type LinkOptions struct {
+	// Matcher compares street names (default iptuapi.LevenshteinMatcher).
+	Matcher iptuapi.Matcher
+	// MinScore is the minimum address score for a heuristic link
+	// (default 0.9).
+	MinScore float64
+	// AreaTolerance is the maximum relative difference in built area for a
+	// heuristic link (default 0.05). Records without area are not linked
+	// heuristically.
+	AreaTolerance float64
+}
+
+// LinkITBI links ITBI transactions to cadastral comparables that reference
+// the same unit: first exactly by SQL, then heuristically by address, number
+// and built area. Each record is linked at most once, best score first.
+func LinkITBI(itbi []iptuapi.ITBITransacao, cadastro []iptuapi.ComparavelItem, opts *LinkOptions) []Link {
+	o := LinkOptions{}
+	if opts != nil {
+		o = *opts
+	}
+	if o.Matcher == nil {
+		o.Matcher = iptuapi.LevenshteinMatcher
+	}
+	if o.MinScore <= 0 {
+		o.MinScore = 0.9
+	}
+	if o.AreaTolerance <= 0 {
+		o.AreaTolerance = 0.05
+	}
+
+	var candidates []Link
+	for i := range itbi {
+		for j := range cadastro {
+			if l, ok := linkPair(&itbi[i], &cadastro[j], &o); ok {
+				l.ITBI, l.Cadastro = i, j
+				candidates = append(candidates, l)
+			}
+		}
+	}
+	sort.SliceStable(candidates, func(a, b int) bool {
+		if candidates[a].Method != candidates[b].Method {
+			return candidates[a].Method == LinkSQL
+		}
+		return candidates[a].Score > candidates[b].Score
+	})
+
+	usedITBI := make(map[int]bool)
+	usedCadastro := make(map[int]bool)
+	var links []Link
+	for _, l := range candidates {
+		if usedITBI[l.ITBI] || usedCadastro[l.Cadastro] {
+			continue
+		}
+		usedITBI[l.ITBI], usedCadastro[l.Cadastro] = true, true
+		links = append(links, l)
+	}
+	sort.Slice(links, func(a, b int) bool { return links[a].ITBI < links[b].ITBI })
+	return links
+}
+
+func linkPair(t *iptuapi.ITBITransacao, c *iptuapi.ComparavelItem, o *LinkOptions) (Link, bool) {
+	if t.SQL != "" && c.SQL != "" {
+		if normalizeSQL(t.SQL) == normalizeSQL(c.SQL) {
+			return Link{Method: LinkSQL, Score: 1}, true
+		}
+		// Both carry a SQL and they differ: different units.
+		return Link{}, false
+	}
+
+	if t.AreaConstruida <= 0 || c.AreaConstruida <= 0 {
+		return Link{}, false
+	}
+	if math.Abs(t.AreaConstruida-c.AreaConstruida)/math.Max(t.AreaConstruida, c.AreaConstruida) > o.AreaTolerance {
+		return Link{}, false
+	}
+	if t.Bairro != "" && c.Bairro != "" && !iptuapi.SameBairro("", t.Bairro, c.Bairro) {
+		return Link{}, false
+	}
+
+	score := iptuapi.AddressScore(o.Matcher,
+		&iptuapi.ConsultaEnderecoParams{Logradouro: t.Logradouro, Numero: t.Numero},
+		&iptuapi.ConsultaEnderecoResult{Logradouro: c.Logradouro, Numero: c.Numero})
+	if score < o.MinScore {
+		return Link{}, false
+	}
+	return Link{Method: LinkHeuristic, Score: score}, true
+}
+
+// normalizeSQL keeps only the digits of a SQL so "000.000.0000-0" and
+// "00000000000" compare equal.
+func normalizeSQL(sql string) string {
+	return strings.Map(func(r rune) rune {
+		if r >= '0' && r <= '9' {
+			return r
+		}
+		return -1
+	}, sql)
+}
+
+// Comparable is one unit in a de-duplicated comparables report.
+type Comparable struct {
+	SQL            string  `json:"sql,omitempty"`
+	Logradouro     string  `json:"logradouro,omitempty"`
+	Numero         string  `json:"numero,omitempty"`
+	Bairro         string  `json:"bairro,omitempty"`
+	AreaTerreno    float64 `json:"area_terreno,omitempty"`
+	AreaConstruida float64 `json:"area_construida,omitempty"`
+	// Valor is the transaction value when an ITBI record exists, otherwise
+	// the cadastral valor venal.
+	Valor float64 `json:"valor"`
+	// Fontes lists every source that reported the unit.
+	Fontes []Fonte `json:"fontes"`
+	// Link is set when the unit was reported by both sources.
+	Link *Link `json:"link,omitempty"`
+	// Outlier is set by FlagOutliers when the unit was excluded from ranges.
+	Outlier       bool   `json:"outlier,omitempty"`
+	OutlierReason string `json:"outlier_reason,omitempty"`
+
+	ITBI     *iptuapi.ITBITransacao  `json:"itbi,omitempty"`
+	Cadastro *iptuapi.ComparavelItem `json:"cadastro,omitempty"`
+}
+
+// Dedup merges ITBI transactions and cadastral comparables into one list in
+// which a unit reported by both sources appears once.
+func Dedup(itbi []iptuapi.ITBITransacao, cadastro []iptuapi.ComparavelItem, opts *LinkOptions) []Comparable {
+	links := LinkITBI(itbi, cadastro, opts)
+	linkByITBI := make(map[int]Link, len(links))
+	linkedCadastro := make(map[int]bool, len(links))
+	for _, l := range links {
+		linkByITBI[l.ITBI] = l
+		linkedCadastro[l.Cadastro] = true
+	}
+
+	out := make([]Comparable, 0, len(itbi)+len(cadastro)-len(links))
+	for i := range itbi {
+		t := &itbi[i]
+		comp := Comparable{
+			SQL: t.SQL, Logradouro: t.Logradouro, Numero: t.Numero, Bairro: t.Bairro,
+			AreaTerreno: t.AreaTerreno, AreaConstruida: t.AreaConstruida,
+			Valor: t.ValorTransacao, Fontes: []Fonte{FonteITBI}, ITBI: t,
+		}
+		if l, ok := linkByITBI[i]; ok {
+			c := &cadastro[l.Cadastro]
+			comp.Link, comp.Cadastro = &l, c
+			comp.Fontes = append(comp.Fontes, FonteCadastro)
+			if comp.SQL == "" {
+				comp.SQL = c.SQL
+			}
+			if comp.AreaTerreno == 0 {
+				comp.AreaTerreno = c.AreaTerreno
+			}
+		}
+		out = append(out, comp)
+	}
+	for j := range cadastro {
+		if linkedCadastro[j] {
+			continue
+		}
+		c := &cadastro[j]
+		out = append(out, Comparable{
+			SQL: c.SQL, Logradouro: c.Logradouro, Numero: c.Numero, Bairro: c.Bairro,
+			AreaTerreno: c.AreaTerreno, AreaConstruida: c.AreaConstruida,
+			Valor: c.ValorVenalTotal, Fontes: []Fonte{FonteCadastro}, Cadastro: c,
+		})
+	}
+	return out
+}
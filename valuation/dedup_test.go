@@ -0,0 +1,36 @@
+package valuation
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	iptuapi "github.com/raphaeltorquat0/iptuapi-go"
+)
+
+func TestLinkITBI(t *testing.T) {
+	itbi := []iptuapi.ITBITransacao{
+		{SQL: "000.000.0001-1", Logradouro: "Rua Augusta", Numero: "100", AreaConstruida: 80, ValorTransacao: 900000},
+		{Logradouro: "R. Augusta", Numero: "200", Bairro: "Consolação", AreaConstruida: 101, ValorTransacao: 1000000},
+		{Logradouro: "Rua Augusta", Numero: "300", AreaConstruida: 60, ValorTransacao: 500000},
+	}
+	cadastro := []iptuapi.ComparavelItem{
+		{SQL: "00000000011", Logradouro: "Rua Augusta", Numero: "100", AreaConstruida: 80, ValorVenalTotal: 700000},
+		{SQL: "000.000.0002-2", Logradouro: "Rua Augusta", Numero: "200", Bairro: "consolação", AreaConstruida: 100, ValorVenalTotal: 800000},
+		{SQL: "000.000.0003-3", Logradouro: "Rua Augusta", Numero: "300", AreaConstruida: 90, ValorVenalTotal: 400000},
+	}
+
+	links := LinkITBI(itbi, cadastro, nil)
+	require.Len(t, links, 2)
+	assert.Equal(t, Link{ITBI: 0, Cadastro: 0, Method: LinkSQL, Score: 1}, links[0])
+	assert.Equal(t, LinkHeuristic, links[1].Method)
+	assert.Equal(t, 1, links[1].Cadastro)
+
+	comps := Dedup(itbi, cadastro, nil)
+	require.Len(t, comps, 4)
+	assert.Equal(t, []Fonte{FonteITBI, FonteCadastro}, comps[1].Fontes)
+	assert.Equal(t, "000.000.0002-2", comps[1].SQL)
+	assert.Equal(t, 1000000.0, comps[1].Valor)
+	assert.Equal(t, []Fonte{FonteCadastro}, comps[3].Fontes, "area mismatch keeps unit 300 separate")
+}
@@ -0,0 +1,195 @@
+package valuation
+
+import (
+	"fmt"
+	"math"
+	"sort"
+)
+
+// OutlierMethod selects the robust rule used to flag outliers.
+type OutlierMethod string
+
+const (
+	// OutlierIQR flags values outside [Q1 - K*IQR, Q3 + K*IQR] (default K 1.5).
+	OutlierIQR OutlierMethod = "iqr"
+	// OutlierMAD flags values whose modified z-score, 0.6745*|x-median|/MAD,
+	// exceeds K (default K 3.5).
+	OutlierMAD OutlierMethod = "mad"
+)
+
+// OutlierOptions configures outlier detection.
+type OutlierOptions struct {
+	Method OutlierMethod
+	// K is the rule's threshold; zero uses the method's default.
+	K float64
+	// Metric extracts the compared value (default: value per built m2, or
+	// the raw value when the area is unknown).
+	Metric func(Comparable) float64
+}
+
+// Median returns the median of values, or NaN for an empty slice.
+func Median(values []float64) float64 {
+	return Quantile(values, 0.5)
+}
+
+// Quantile returns the q-quantile (0 <= q <= 1) of values using linear
+// interpolation between order statistics, or NaN for an empty slice.
+func Quantile(values []float64, q float64) float64 {
+	if len(values) == 0 {
+		return math.NaN()
+	}
+	s := sortedCopy(values)
+	pos := q * float64(len(s)-1)
+	lo := int(math.Floor(pos))
+	hi := int(math.Ceil(pos))
+	return s[lo] + (s[hi]-s[lo])*(pos-float64(lo))
+}
+
+// MAD returns the median absolute deviation from the median.
+func MAD(values []float64) float64 {
+	m := Median(values)
+	dev := make([]float64, len(values))
+	for i, v := range values {
+		dev[i] = math.Abs(v - m)
+	}
+	return Median(dev)
+}
+
+// TrimmedMean returns the mean after dropping the lowest and highest trim
+// share of values (e.g. 0.1 drops 10% from each end).
+func TrimmedMean(values []float64, trim float64) float64 {
+	if len(values) == 0 {
+		return math.NaN()
+	}
+	s := sortedCopy(values)
+	k := int(math.Floor(trim * float64(len(s))))
+	if 2*k >= len(s) {
+		return Median(s)
+	}
+	sum := 0.0
+	for _, v := range s[k : len(s)-k] {
+		sum += v
+	}
+	return sum / float64(len(s)-2*k)
+}
+
+// OutlierBounds returns the accepted [lo, hi] range for values under opts.
+func OutlierBounds(values []float64, opts *OutlierOptions) (lo, hi float64) {
+	o := outlierDefaults(opts)
+	switch o.Method {
+	case OutlierMAD:
+		m, mad := Median(values), MAD(values)
+		if mad == 0 {
+			return m, m
+		}
+		spread := o.K * mad / 0.6745
+		return m - spread, m + spread
+	default:
+		q1, q3 := Quantile(values, 0.25), Quantile(values, 0.75)
+		iqr := q3 - q1
+		return q1 - o.K*iqr, q3 + o.K*iqr
+	}
+}
+
+// FlagOutliers marks outlier comparables in place, setting Outlier and
+// OutlierReason, and returns the comparables that were kept. Sets with fewer
+// than four values are left untouched: there is not enough data to call
+// anything an outlier.
+func FlagOutliers(comps []Comparable, opts *OutlierOptions) []Comparable {
+	o := outlierDefaults(opts)
+	if len(comps) < 4 {
+		return comps
+	}
+
+	values := make([]float64, len(comps))
+	for i := range comps {
+		values[i] = o.Metric(comps[i])
+	}
+	lo, hi := OutlierBounds(values, &o)
+
+	kept := make([]Comparable, 0, len(comps))
+	for i := range comps {
+		v := values[i]
+		switch {
+		case v < lo:
+			comps[i].Outlier = true
+			comps[i].OutlierReason = fmt.Sprintf("%s: %.2f below %.2f", o.Method, v, lo)
+		case v > hi:
+			comps[i].Outlier = true
+			comps[i].OutlierReason = fmt.Sprintf("%s: %.2f above %.2f", o.Method, v, hi)
+		default:
+			comps[i].Outlier = false
+			comps[i].OutlierReason = ""
+			kept = append(kept, comps[i])
+		}
+	}
+	return kept
+}
+
+// Summary describes the distribution of a comparables set after outliers
+// were excluded.
+type Summary struct {
+	N           int     `json:"n"`
+	Excluded    int     `json:"excluded"`
+	Min         float64 `json:"min"`
+	Max         float64 `json:"max"`
+	Median      float64 `json:"median"`
+	TrimmedMean float64 `json:"trimmed_mean"`
+}
+
+// Summarize flags outliers in comps and returns the range of the remaining
+// values under opts.Metric, with a 10% trimmed mean.
+func Summarize(comps []Comparable, opts *OutlierOptions) Summary {
+	o := outlierDefaults(opts)
+	kept := FlagOutliers(comps, &o)
+	s := Summary{N: len(kept), Excluded: len(comps) - len(kept)}
+	if len(kept) == 0 {
+		return s
+	}
+
+	values := make([]float64, len(kept))
+	for i := range kept {
+		values[i] = o.Metric(kept[i])
+	}
+	sorted := sortedCopy(values)
+	s.Min, s.Max = sorted[0], sorted[len(sorted)-1]
+	s.Median = Median(sorted)
+	s.TrimmedMean = TrimmedMean(sorted, 0.1)
+	return s
+}
+
+// UnitValue returns the value per built m2, or the raw value when the built
+// area is unknown.
+func UnitValue(c Comparable) float64 {
+	if c.AreaConstruida > 0 {
+		return c.Valor / c.AreaConstruida
+	}
+	return c.Valor
+}
+
+func outlierDefaults(opts *OutlierOptions) OutlierOptions {
+	o := OutlierOptions{}
+	if opts != nil {
+		o = *opts
+	}
+	if o.Method == "" {
+		o.Method = OutlierIQR
+	}
+	if o.K <= 0 {
+		if o.Method == OutlierMAD {
+			o.K = 3.5
+		} else {
+			o.K = 1.5
+		}
+	}
+	if o.Metric == nil {
+		o.Metric = UnitValue
+	}
+	return o
+}
+
+func sortedCopy(values []float64) []float64 {
+	s := append([]float64(nil), values...)
+	sort.Float64s(s)
+	return s
+}
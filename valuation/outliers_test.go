@@ -0,0 +1,43 @@
+package valuation
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRobustStatistics(t *testing.T) {
+	values := []float64{10, 12, 11, 13, 100}
+	assert.Equal(t, 12.0, Median(values))
+	assert.Equal(t, 1.0, MAD(values))
+	assert.Equal(t, 12.0, TrimmedMean(values, 0.2))
+	assert.True(t, math.IsNaN(Median(nil)))
+	assert.Equal(t, 11.0, Quantile(values, 0.25))
+}
+
+func TestFlagOutliers(t *testing.T) {
+	comps := []Comparable{
+		{SQL: "1", Valor: 1000000, AreaConstruida: 100},
+		{SQL: "2", Valor: 1050000, AreaConstruida: 100},
+		{SQL: "3", Valor: 980000, AreaConstruida: 100},
+		{SQL: "4", Valor: 1020000, AreaConstruida: 100},
+		// Related-party transfer far below market.
+		{SQL: "5", Valor: 150000, AreaConstruida: 100},
+	}
+
+	for _, method := range []OutlierMethod{OutlierIQR, OutlierMAD} {
+		c := append([]Comparable(nil), comps...)
+		kept := FlagOutliers(c, &OutlierOptions{Method: method})
+		assert.Len(t, kept, 4, method)
+		assert.True(t, c[4].Outlier, method)
+		assert.Contains(t, c[4].OutlierReason, "below", method)
+	}
+
+	s := Summarize(comps, nil)
+	assert.Equal(t, 4, s.N)
+	assert.Equal(t, 1, s.Excluded)
+	assert.Equal(t, 9800.0, s.Min)
+	assert.Equal(t, 10500.0, s.Max)
+	assert.Equal(t, 10100.0, s.Median)
+}